@@ -0,0 +1,166 @@
+package admin
+
+import (
+	"centralized-wallet/internal/utils"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchQuery     = 100
+)
+
+// SearchUsersHandler finds users by part of their email, handle or name, or by ID, given in the q query parameter
+func SearchUsersHandler(as AdminServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" || utf8.RuneCountInString(query) > maxSearchQuery {
+			utils.ErrorResponse(c, utils.ErrorInvalidSearchQuery, nil, "")
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchLimit)))
+		if err != nil || limit <= 0 || limit > maxSearchLimit {
+			utils.ErrorResponse(c, utils.ErrorInvalidLimit, nil, "")
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			utils.ErrorResponse(c, utils.ErrorInvalidOffset, nil, "")
+			return
+		}
+
+		users, err := as.SearchUsers(query, limit, offset)
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[SearchUsersHandler] Error searching users")
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgUsersRetrieved, gin.H{"users": users})
+	}
+}
+
+// WalletLookupHandler returns any wallet by its number, with its owner
+func WalletLookupHandler(as AdminServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w, owner, err := as.GetWallet(c.Param("wallet_number"))
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.RepoErrWalletNotFound):
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[WalletLookupHandler] Error getting wallet")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgWalletRetrieved, gin.H{"wallet": w, "owner": owner})
+	}
+}
+
+// WalletNumberParamMiddleware stands in for the WalletNumberMiddleware on admin routes: it puts the wallet number and
+// currency of the path in the context once the wallet is found, whoever owns it, so the wallet handlers can serve it read-only
+func WalletNumberParamMiddleware(as AdminServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w, _, err := as.GetWallet(c.Param("wallet_number"))
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.RepoErrWalletNotFound):
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[WalletNumberParamMiddleware] Error getting wallet")
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("wallet_number", w.WalletNumber)
+		c.Set("wallet_currency", w.Currency)
+		c.Next()
+	}
+}
+
+// SetUserStatusHandler enables or disables a user. Disabling refuses their tokens and ends their sessions.
+func SetUserStatusHandler(as AdminServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := parseTargetUserID(c)
+		if !ok {
+			return
+		}
+
+		var request struct {
+			Status string `json:"status" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+
+		if err := as.SetUserStatus(userID, request.Status); err != nil {
+			switch {
+			case errors.Is(err, utils.ServiceErrInvalidUserStatus):
+				utils.ErrorResponse(c, utils.ErrInvalidUserStatus, nil, "")
+			case errors.Is(err, utils.ErrUserNotFound):
+				utils.ErrorResponse(c, utils.ErrUserNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[SetUserStatusHandler] Error setting user status")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgUserStatusUpdated, gin.H{"user_id": userID, "status": request.Status})
+	}
+}
+
+// SetUserRoleHandler changes a user's role. Their tokens are refused until refreshed with the new role.
+func SetUserRoleHandler(as AdminServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := parseTargetUserID(c)
+		if !ok {
+			return
+		}
+
+		var request struct {
+			Role string `json:"role" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+
+		if err := as.SetUserRole(userID, request.Role); err != nil {
+			switch {
+			case errors.Is(err, utils.ServiceErrInvalidUserRole):
+				utils.ErrorResponse(c, utils.ErrInvalidUserRole, nil, "")
+			case errors.Is(err, utils.ErrUserNotFound):
+				utils.ErrorResponse(c, utils.ErrUserNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[SetUserRoleHandler] Error setting user role")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgUserRoleUpdated, gin.H{"user_id": userID, "role": request.Role})
+	}
+}
+
+// parseTargetUserID reads the ID of the user to change from the path, writing the error response when it is
+// invalid or is the acting admin's own, so an admin cannot lock themselves out
+func parseTargetUserID(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || userID <= 0 {
+		utils.ErrorResponse(c, utils.ErrInvalidPathUserID, nil, "")
+		return 0, false
+	}
+	if userID == c.GetInt("user_id") {
+		utils.ErrorResponse(c, utils.ErrCannotChangeOwnUser, nil, "")
+		return 0, false
+	}
+	return userID, true
+}
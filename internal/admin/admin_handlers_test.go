@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	mockAdmin "centralized-wallet/tests/mocks/admin"
+	"centralized-wallet/tests/testutils"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupAdminRouter serves the admin routes as admin 1, standing in for JWTMiddleware and RequireRole
+func setupAdminRouter() (*gin.Engine, *mockAdmin.MockAdminService) {
+	gin.SetMode(gin.TestMode)
+	adminService := new(mockAdmin.MockAdminService)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", 1)
+		c.Set("role", models.UserRoleAdmin)
+	})
+	router.GET("/admin/users", SearchUsersHandler(adminService))
+	router.GET("/admin/wallets/:wallet_number", WalletLookupHandler(adminService))
+	router.GET("/admin/wallets/:wallet_number/number", WalletNumberParamMiddleware(adminService), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"wallet_number": c.GetString("wallet_number"), "currency": c.GetString("wallet_currency")})
+	})
+	router.PUT("/admin/users/:id/status", SetUserStatusHandler(adminService))
+	router.PUT("/admin/users/:id/role", SetUserRoleHandler(adminService))
+	return router, adminService
+}
+
+func TestSearchUsersHandler(t *testing.T) {
+	t.Run("finds users", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+		handle := "jane"
+		adminService.On("SearchUsers", "jane", 20, 0).Return([]models.User{
+			{ID: 5, Email: "jane@test.com", Handle: &handle, Status: models.UserStatusActive, Role: models.UserRoleUser},
+		}, nil)
+
+		w := testutils.ExecuteRequest(router, "GET", "/admin/users?q=jane", nil, "")
+
+		testutils.AssertAPISuccessResponse(t, w, utils.MsgUsersRetrieved, gin.H{"users": []gin.H{
+			{"id": 5, "email": "jane@test.com", "handle": "jane", "status": "active", "role": "user"},
+		}})
+	})
+
+	cases := []struct {
+		name     string
+		url      string
+		expected *utils.AppError
+	}{
+		{"missing query", "/admin/users", utils.ErrorInvalidSearchQuery},
+		{"blank query", "/admin/users?q=%20%20", utils.ErrorInvalidSearchQuery},
+		{"limit too high", "/admin/users?q=jane&limit=101", utils.ErrorInvalidLimit},
+		{"negative offset", "/admin/users?q=jane&offset=-1", utils.ErrorInvalidOffset},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router, adminService := setupAdminRouter()
+
+			w := testutils.ExecuteRequest(router, "GET", tc.url, nil, "")
+
+			testutils.AssertAPIErrorResponse(t, w, tc.expected)
+			adminService.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWalletLookupHandler(t *testing.T) {
+	t.Run("wallet of another user", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+		balance, _ := money.Parse("12.50", "USD")
+		adminService.On("GetWallet", "WAL-2").Return(
+			&models.Wallet{ID: 7, UserID: 2, WalletNumber: "WAL-2", Name: "Main", IsDefault: true, Currency: "USD", Balance: balance, HeldBalance: money.Zero("USD")},
+			&models.User{ID: 2, Email: "david@example.com", Status: models.UserStatusActive, Role: models.UserRoleUser},
+			nil)
+
+		w := testutils.ExecuteRequest(router, "GET", "/admin/wallets/WAL-2", nil, "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"wallet_number":"WAL-2"`)
+		assert.Contains(t, w.Body.String(), `"owner":{"id":2,"email":"david@example.com","status":"active","role":"user"}`)
+	})
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+		adminService.On("GetWallet", "WAL-X").Return(nil, nil, utils.RepoErrWalletNotFound)
+
+		w := testutils.ExecuteRequest(router, "GET", "/admin/wallets/WAL-X", nil, "")
+		testutils.AssertAPIErrorResponse(t, w, utils.ErrWalletNotFound)
+	})
+}
+
+func TestWalletNumberParamMiddleware(t *testing.T) {
+	t.Run("sets the wallet number and currency of any user", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+		adminService.On("GetWallet", "WAL-2").Return(&models.Wallet{ID: 7, UserID: 2, WalletNumber: "WAL-2", Currency: "JPY"}, &models.User{ID: 2}, nil)
+
+		w := testutils.ExecuteRequest(router, "GET", "/admin/wallets/WAL-2/number", nil, "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"wallet_number":"WAL-2","currency":"JPY"}`, w.Body.String())
+	})
+
+	t.Run("unknown wallet stops the request", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+		adminService.On("GetWallet", "WAL-X").Return(nil, nil, utils.RepoErrWalletNotFound)
+
+		w := testutils.ExecuteRequest(router, "GET", "/admin/wallets/WAL-X/number", nil, "")
+		testutils.AssertAPIErrorResponse(t, w, utils.ErrWalletNotFound)
+	})
+}
+
+func TestSetUserStatusHandler(t *testing.T) {
+	t.Run("disables a user", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+		adminService.On("SetUserStatus", 2, models.UserStatusDisabled).Return(nil)
+
+		w := testutils.ExecuteRequest(router, "PUT", "/admin/users/2/status", map[string]interface{}{"status": "disabled"}, "")
+
+		testutils.AssertAPISuccessResponse(t, w, utils.MsgUserStatusUpdated, gin.H{"user_id": 2, "status": "disabled"})
+		adminService.AssertExpectations(t)
+	})
+
+	cases := []struct {
+		name     string
+		url      string
+		body     map[string]interface{}
+		err      error
+		expected *utils.AppError
+	}{
+		{"invalid user ID", "/admin/users/abc/status", map[string]interface{}{"status": "disabled"}, nil, utils.ErrInvalidPathUserID},
+		{"own account", "/admin/users/1/status", map[string]interface{}{"status": "disabled"}, nil, utils.ErrCannotChangeOwnUser},
+		{"missing status", "/admin/users/2/status", map[string]interface{}{}, nil, utils.ErrInvalidRequest},
+		{"unknown status", "/admin/users/2/status", map[string]interface{}{"status": "suspended"}, utils.ServiceErrInvalidUserStatus, utils.ErrInvalidUserStatus},
+		{"unknown user", "/admin/users/2/status", map[string]interface{}{"status": "disabled"}, utils.ErrUserNotFound, utils.ErrUserNotFound},
+		{"database error", "/admin/users/2/status", map[string]interface{}{"status": "disabled"}, errors.New("connection reset"), utils.ErrInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router, adminService := setupAdminRouter()
+			if tc.err != nil {
+				adminService.On("SetUserStatus", 2, tc.body["status"]).Return(tc.err)
+			}
+
+			w := testutils.ExecuteRequest(router, "PUT", tc.url, tc.body, "")
+
+			testutils.AssertAPIErrorResponse(t, w, tc.expected)
+			if tc.err == nil {
+				adminService.AssertNotCalled(t, "SetUserStatus", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSetUserRoleHandler(t *testing.T) {
+	t.Run("changes the role", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+		adminService.On("SetUserRole", 2, models.UserRoleSupport).Return(nil)
+
+		w := testutils.ExecuteRequest(router, "PUT", "/admin/users/2/role", map[string]interface{}{"role": "support"}, "")
+
+		testutils.AssertAPISuccessResponse(t, w, utils.MsgUserRoleUpdated, gin.H{"user_id": 2, "role": "support"})
+		adminService.AssertExpectations(t)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+		adminService.On("SetUserRole", 2, "root").Return(utils.ServiceErrInvalidUserRole)
+
+		w := testutils.ExecuteRequest(router, "PUT", "/admin/users/2/role", map[string]interface{}{"role": "root"}, "")
+		testutils.AssertAPIErrorResponse(t, w, utils.ErrInvalidUserRole)
+	})
+
+	t.Run("own account", func(t *testing.T) {
+		router, adminService := setupAdminRouter()
+
+		w := testutils.ExecuteRequest(router, "PUT", "/admin/users/1/role", map[string]interface{}{"role": "user"}, "")
+		testutils.AssertAPIErrorResponse(t, w, utils.ErrCannotChangeOwnUser)
+		adminService.AssertNotCalled(t, "SetUserRole", mock.Anything, mock.Anything)
+	})
+}
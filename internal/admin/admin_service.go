@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/user"
+	"centralized-wallet/internal/wallet"
+	"strings"
+)
+
+// AdminServiceInterface is what support, admin and auditor staff do through the admin API: look users and wallets
+// up across all users, and for admins, disable users and change roles
+type AdminServiceInterface interface {
+	SearchUsers(query string, limit, offset int) ([]models.User, error)
+	GetWallet(walletNumber string) (*models.Wallet, *models.User, error)
+	SetUserStatus(userID int, status string) error
+	SetUserRole(userID int, role string) error
+}
+
+// AdminService reads users and wallets without the ownership checks of the user-facing services. Status and role
+// changes go through the AccountService, which invalidates the user's tokens.
+type AdminService struct {
+	userRepo       user.UserRepositoryInterface
+	walletRepo     wallet.WalletRepositoryInterface
+	accountService user.AccountServiceInterface
+}
+
+// Ensure AdminService implements AdminServiceInterface
+var _ AdminServiceInterface = &AdminService{}
+
+// NewAdminService creates an AdminService
+func NewAdminService(userRepo user.UserRepositoryInterface, walletRepo wallet.WalletRepositoryInterface, accountService user.AccountServiceInterface) *AdminService {
+	return &AdminService{
+		userRepo:       userRepo,
+		walletRepo:     walletRepo,
+		accountService: accountService,
+	}
+}
+
+// SearchUsers finds users by part of their email, handle or name, or by ID. A leading "$" is dropped so a
+// $handle can be searched as written.
+func (s *AdminService) SearchUsers(query string, limit, offset int) ([]models.User, error) {
+	return s.userRepo.SearchUsers(strings.TrimPrefix(query, "$"), limit, offset)
+}
+
+// GetWallet returns any wallet by its number, with its owner. An unknown wallet is RepoErrWalletNotFound.
+func (s *AdminService) GetWallet(walletNumber string) (*models.Wallet, *models.User, error) {
+	w, err := s.walletRepo.FindByWalletNumber(walletNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	owner, err := s.userRepo.GetUserByID(w.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, owner, nil
+}
+
+// SetUserStatus enables or disables a user
+func (s *AdminService) SetUserStatus(userID int, status string) error {
+	return s.accountService.SetUserStatus(userID, status)
+}
+
+// SetUserRole changes a user's role
+func (s *AdminService) SetUserRole(userID int, role string) error {
+	return s.accountService.SetUserRole(userID, role)
+}
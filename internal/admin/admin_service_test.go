@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockUser "centralized-wallet/tests/mocks/user"
+	mockWallet "centralized-wallet/tests/mocks/wallet"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAdminServiceMock() (*AdminService, *mockUser.MockUserRepository, *mockWallet.MockWalletRepository, *mockUser.MockAccountService) {
+	userRepo := new(mockUser.MockUserRepository)
+	walletRepo := new(mockWallet.MockWalletRepository)
+	accountService := new(mockUser.MockAccountService)
+	return NewAdminService(userRepo, walletRepo, accountService), userRepo, walletRepo, accountService
+}
+
+func TestSearchUsers(t *testing.T) {
+	service, userRepo, _, _ := setupAdminServiceMock()
+	handle := "jane"
+	userRepo.On("SearchUsers", "jane", 20, 0).Return([]models.User{{ID: 5, Email: "jane@test.com", Handle: &handle}}, nil)
+
+	// A $handle is searched without its "$"
+	users, err := service.SearchUsers("$jane", 20, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, 5, users[0].ID)
+	userRepo.AssertExpectations(t)
+}
+
+func TestGetWallet(t *testing.T) {
+	t.Run("wallet of any user, with its owner", func(t *testing.T) {
+		service, userRepo, walletRepo, _ := setupAdminServiceMock()
+		walletRepo.On("FindByWalletNumber", "WAL-2").Return(&models.Wallet{ID: 7, UserID: 2, WalletNumber: "WAL-2"}, nil)
+		userRepo.On("GetUserByID", 2).Return(&models.User{ID: 2, Email: "david@example.com"}, nil)
+
+		w, owner, err := service.GetWallet("WAL-2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 7, w.ID)
+		assert.Equal(t, "david@example.com", owner.Email)
+	})
+
+	t.Run("unknown wallet", func(t *testing.T) {
+		service, userRepo, walletRepo, _ := setupAdminServiceMock()
+		walletRepo.On("FindByWalletNumber", "WAL-X").Return(nil, utils.RepoErrWalletNotFound)
+
+		_, _, err := service.GetWallet("WAL-X")
+
+		assert.Equal(t, utils.RepoErrWalletNotFound, err)
+		userRepo.AssertNotCalled(t, "GetUserByID", 2)
+	})
+}
+
+func TestSetUserStatusAndRole(t *testing.T) {
+	service, _, _, accountService := setupAdminServiceMock()
+	accountService.On("SetUserStatus", 2, models.UserStatusDisabled).Return(nil)
+	accountService.On("SetUserRole", 2, models.UserRoleSupport).Return(utils.ErrUserNotFound)
+
+	assert.NoError(t, service.SetUserStatus(2, models.UserStatusDisabled))
+	assert.Equal(t, utils.ErrUserNotFound, service.SetUserRole(2, models.UserRoleSupport))
+	accountService.AssertExpectations(t)
+}
@@ -0,0 +1,61 @@
+package analytics
+
+import (
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyticsHandler returns what moved in and out of one of the user's wallets over a period:
+// totals, counts per type, top counterparties and per-period buckets
+func AnalyticsHandler(as AnalyticsServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		from, err := wallet.ParseHistoryTime(c.Query("from"), false)
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+			return
+		}
+		to, err := wallet.ParseHistoryTime(c.Query("to"), true)
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+			return
+		}
+
+		interval := strings.ToLower(c.DefaultQuery("interval", DefaultInterval))
+		if !IsValidInterval(interval) {
+			utils.ErrorResponse(c, utils.ErrorInvalidInterval, nil, "")
+			return
+		}
+
+		top, err := strconv.Atoi(c.DefaultQuery("top", strconv.Itoa(DefaultTop)))
+		if err != nil || top <= 0 || top > MaxTop {
+			utils.ErrorResponse(c, utils.ErrorInvalidTop, nil, "")
+			return
+		}
+
+		analytics, err := as.GetAnalytics(userID.(int), c.Query("wallet_number"), from, to, interval, top)
+		if err != nil {
+			switch err {
+			case utils.ServiceErrInvalidAnalyticsPeriod:
+				utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[AnalyticsHandler] Error aggregating transactions")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgAnalyticsRetrieved, gin.H{"analytics": analytics})
+	}
+}
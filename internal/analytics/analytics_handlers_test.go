@@ -0,0 +1,132 @@
+package analytics
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAnalytics "centralized-wallet/tests/mocks/analytics"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	"centralized-wallet/tests/testutils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupAnalyticsHandlerRouter(analyticsService *mockAnalytics.MockAnalyticsService) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	token, _ := auth.GenerateJWT(testUserID)
+	blacklistService := new(mockAuth.MockBlacklistService)
+	blacklistService.On("IsTokenBlacklisted", token).Return(false, nil)
+	accountChecker := new(mockAuth.MockAccountChecker)
+	accountChecker.On("CurrentTokenVersion", testUserID).Return(1, nil)
+
+	walletRoutes := router.Group("/wallets")
+	walletRoutes.Use(auth.JWTMiddleware(blacklistService, new(mockAuth.MockSessionService), accountChecker))
+	{
+		walletRoutes.GET("/analytics", AnalyticsHandler(analyticsService))
+	}
+	return router, token
+}
+
+func TestAnalyticsHandler(t *testing.T) {
+	result := &models.WalletAnalytics{WalletNumber: testWalletNumber, Currency: "USD", Interval: IntervalWeek, TotalIn: usd("170.00"), TotalOut: usd("40.25"), Net: usd("129.75"), Count: 4}
+
+	testCases := []struct {
+		name                  string
+		url                   string
+		mockSetup             func(m *mockAnalytics.MockAnalyticsService)
+		expectedResponseError *utils.AppError
+	}{
+		{
+			name: "Defaults of the default wallet",
+			url:  "/wallets/analytics",
+			mockSetup: func(m *mockAnalytics.MockAnalyticsService) {
+				m.On("GetAnalytics", testUserID, "", (*time.Time)(nil), (*time.Time)(nil), DefaultInterval, DefaultTop).Return(result, nil)
+			},
+		},
+		{
+			name: "Weekly buckets of a named wallet",
+			url:  "/wallets/analytics?wallet_number=wallet123&from=2024-05-01&to=2024-05-31&interval=Week&top=3",
+			mockSetup: func(m *mockAnalytics.MockAnalyticsService) {
+				m.On("GetAnalytics", testUserID, testWalletNumber, &testFrom, &testTo, IntervalWeek, 3).Return(result, nil)
+			},
+		},
+		{
+			name:                  "Unknown interval",
+			url:                   "/wallets/analytics?interval=year",
+			mockSetup:             func(m *mockAnalytics.MockAnalyticsService) {},
+			expectedResponseError: utils.ErrorInvalidInterval,
+		},
+		{
+			name:                  "Top out of range",
+			url:                   "/wallets/analytics?top=50",
+			mockSetup:             func(m *mockAnalytics.MockAnalyticsService) {},
+			expectedResponseError: utils.ErrorInvalidTop,
+		},
+		{
+			name:                  "Invalid date",
+			url:                   "/wallets/analytics?from=May",
+			mockSetup:             func(m *mockAnalytics.MockAnalyticsService) {},
+			expectedResponseError: utils.ErrorInvalidDateRange,
+		},
+		{
+			name: "Period ending before it starts",
+			url:  "/wallets/analytics?from=2024-06-01T00:00:00Z&to=2024-05-01T00:00:00Z",
+			mockSetup: func(m *mockAnalytics.MockAnalyticsService) {
+				m.On("GetAnalytics", testUserID, "", &testTo, &testFrom, DefaultInterval, DefaultTop).Return(nil, utils.ServiceErrInvalidAnalyticsPeriod)
+			},
+			expectedResponseError: utils.ErrorInvalidDateRange,
+		},
+		{
+			name: "Wallet of another user",
+			url:  "/wallets/analytics?wallet_number=wallet456",
+			mockSetup: func(m *mockAnalytics.MockAnalyticsService) {
+				m.On("GetAnalytics", testUserID, "wallet456", mock.Anything, mock.Anything, DefaultInterval, DefaultTop).Return(nil, utils.RepoErrWalletNotFound)
+			},
+			expectedResponseError: utils.ErrWalletNotFound,
+		},
+		{
+			name: "Query failure",
+			url:  "/wallets/analytics",
+			mockSetup: func(m *mockAnalytics.MockAnalyticsService) {
+				m.On("GetAnalytics", testUserID, "", mock.Anything, mock.Anything, DefaultInterval, DefaultTop).Return(nil, errors.New("db error"))
+			},
+			expectedResponseError: utils.ErrInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			analyticsService := new(mockAnalytics.MockAnalyticsService)
+			router, token := setupAnalyticsHandlerRouter(analyticsService)
+			tc.mockSetup(analyticsService)
+
+			w := testutils.ExecuteRequest(router, http.MethodGet, tc.url, nil, token)
+
+			if tc.expectedResponseError != nil {
+				testutils.AssertAPIErrorResponse(t, w, tc.expectedResponseError)
+			} else {
+				assert.Equal(t, http.StatusOK, w.Code)
+				var response struct {
+					Message string `json:"message"`
+					Data    struct {
+						Analytics models.WalletAnalytics `json:"analytics"`
+					} `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, utils.MsgAnalyticsRetrieved, response.Message)
+				assert.Equal(t, usd("129.75"), response.Data.Analytics.Net)
+				assert.Equal(t, 4, response.Data.Analytics.Count)
+			}
+			analyticsService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package analytics
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"database/sql"
+	"time"
+)
+
+// AnalyticsRepositoryInterface defines the aggregate queries over a wallet's posted transactions.
+// Amounts are returned in the given currency, which must be the wallet's.
+type AnalyticsRepositoryInterface interface {
+	GetTypeTotals(walletNumber, currency string, from, to time.Time) ([]models.AnalyticsTypeTotal, error)
+	GetTopCounterparties(walletNumber, currency string, from, to time.Time, limit int) ([]models.AnalyticsCounterparty, error)
+	GetBuckets(walletNumber, currency string, from, to time.Time, interval string) ([]models.AnalyticsBucket, error)
+}
+
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+// Ensure AnalyticsRepository implements AnalyticsRepositoryInterface
+var _ AnalyticsRepositoryInterface = &AnalyticsRepository{}
+
+// NewAnalyticsRepository creates a new instance of AnalyticsRepository
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// flowsQuery lists the posted transactions of wallet $1 created in [$2, $3), with what each moved in and out
+// of the wallet and the wallet on the other side. Pending and failed transactions never moved money.
+const flowsQuery = `WITH flows AS (
+		SELECT t.transaction_type,
+			   t.created_at,
+			   CASE WHEN t.from_wallet_number = $1 THEN t.to_wallet_number ELSE t.from_wallet_number END AS counterparty,
+			   CASE WHEN t.to_wallet_number = $1 THEN COALESCE(t.to_amount, t.amount) ELSE 0 END AS amount_in,
+			   CASE WHEN t.from_wallet_number = $1 THEN t.amount ELSE 0 END AS amount_out
+		FROM transactions t
+		WHERE (t.from_wallet_number = $1 OR t.to_wallet_number = $1)
+		AND t.status IN ('` + transaction.StatusCompleted + `', '` + transaction.StatusReversed + `')
+		AND t.created_at >= $2 AND t.created_at < $3
+	)`
+
+// GetTypeTotals counts and sums the wallet's transactions per type, ordered by type
+func (repo *AnalyticsRepository) GetTypeTotals(walletNumber, currency string, from, to time.Time) ([]models.AnalyticsTypeTotal, error) {
+	query := flowsQuery + `
+		SELECT transaction_type, COUNT(*), SUM(amount_in)::BIGINT, SUM(amount_out)::BIGINT
+		FROM flows
+		GROUP BY transaction_type
+		ORDER BY transaction_type`
+
+	rows, err := repo.db.Query(query, walletNumber, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []models.AnalyticsTypeTotal{}
+	for rows.Next() {
+		var total models.AnalyticsTypeTotal
+		var in, out int64
+		if err := rows.Scan(&total.TransactionType, &total.Count, &in, &out); err != nil {
+			return nil, err
+		}
+		total.In = money.New(in, currency)
+		total.Out = money.New(out, currency)
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// GetTopCounterparties sums the wallet's transactions per other wallet and returns the limit largest by volume,
+// money in and out together. Deposits and withdrawals have no other wallet and are left out.
+func (repo *AnalyticsRepository) GetTopCounterparties(walletNumber, currency string, from, to time.Time, limit int) ([]models.AnalyticsCounterparty, error) {
+	query := flowsQuery + `
+		SELECT f.counterparty, u.email, COUNT(*), SUM(f.amount_in)::BIGINT, SUM(f.amount_out)::BIGINT
+		FROM flows f
+		LEFT JOIN wallets w ON w.wallet_number = f.counterparty
+		LEFT JOIN users u ON u.id = w.user_id
+		WHERE f.counterparty IS NOT NULL
+		GROUP BY f.counterparty, u.email
+		ORDER BY SUM(f.amount_in) + SUM(f.amount_out) DESC, f.counterparty
+		LIMIT $4`
+
+	rows, err := repo.db.Query(query, walletNumber, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counterparties := []models.AnalyticsCounterparty{}
+	for rows.Next() {
+		var counterparty models.AnalyticsCounterparty
+		var email sql.NullString
+		var in, out int64
+		if err := rows.Scan(&counterparty.WalletNumber, &email, &counterparty.Count, &in, &out); err != nil {
+			return nil, err
+		}
+		counterparty.Email = email.String
+		counterparty.In = money.New(in, currency)
+		counterparty.Out = money.New(out, currency)
+		counterparties = append(counterparties, counterparty)
+	}
+	return counterparties, rows.Err()
+}
+
+// GetBuckets sums the wallet's transactions per day, week or month, oldest first. Periods without transactions are left out.
+func (repo *AnalyticsRepository) GetBuckets(walletNumber, currency string, from, to time.Time, interval string) ([]models.AnalyticsBucket, error) {
+	query := flowsQuery + `
+		SELECT DATE_TRUNC($4, created_at) AS bucket, COUNT(*), SUM(amount_in)::BIGINT, SUM(amount_out)::BIGINT
+		FROM flows
+		GROUP BY bucket
+		ORDER BY bucket`
+
+	rows, err := repo.db.Query(query, walletNumber, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []models.AnalyticsBucket{}
+	for rows.Next() {
+		var bucket models.AnalyticsBucket
+		var in, out int64
+		if err := rows.Scan(&bucket.Start, &bucket.Count, &in, &out); err != nil {
+			return nil, err
+		}
+		bucket.Start = bucket.Start.UTC()
+		bucket.In = money.New(in, currency)
+		bucket.Out = money.New(out, currency)
+		bucket.Net = money.New(in-out, currency)
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
@@ -0,0 +1,162 @@
+package analytics
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/redis"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+
+	DefaultInterval   = IntervalDay
+	DefaultTop        = 5
+	MaxTop            = 20
+	DefaultPeriodDays = 30 // Period covered when from is omitted, ending with to
+)
+
+// IsValidInterval reports whether interval is a supported bucket size
+func IsValidInterval(interval string) bool {
+	switch interval {
+	case IntervalDay, IntervalWeek, IntervalMonth:
+		return true
+	}
+	return false
+}
+
+// AnalyticsServiceInterface aggregates what moved in and out of a wallet over a period
+type AnalyticsServiceInterface interface {
+	GetAnalytics(userID int, walletNumber string, from, to *time.Time, interval string, top int) (*models.WalletAnalytics, error)
+}
+
+type AnalyticsService struct {
+	analyticsRepo AnalyticsRepositoryInterface
+	walletRepo    wallet.WalletRepositoryInterface
+	redisService  redis.RedisServiceInterface
+	now           func() time.Time
+}
+
+// Ensure AnalyticsService implements AnalyticsServiceInterface
+var _ AnalyticsServiceInterface = &AnalyticsService{}
+
+// NewAnalyticsService creates an AnalyticsService. Results are cached in Redis when redis is not nil.
+func NewAnalyticsService(analyticsRepo AnalyticsRepositoryInterface, walletRepo wallet.WalletRepositoryInterface, redis redis.RedisServiceInterface) *AnalyticsService {
+	return &AnalyticsService{
+		analyticsRepo: analyticsRepo,
+		walletRepo:    walletRepo,
+		redisService:  redis,
+		now:           time.Now,
+	}
+}
+
+// GetAnalytics aggregates the posted transactions of one of the user's wallets, the default one when walletNumber is empty.
+// Without to the period ends with the current day (UTC), and without from it covers DefaultPeriodDays days, so
+// repeated requests share a cache entry. Entries are dropped by the transaction service whenever the wallet records a transaction.
+func (s *AnalyticsService) GetAnalytics(userID int, walletNumber string, from, to *time.Time, interval string, top int) (*models.WalletAnalytics, error) {
+	periodFrom, periodTo := s.period(from, to)
+	if !periodFrom.Before(periodTo) {
+		return nil, utils.ServiceErrInvalidAnalyticsPeriod
+	}
+
+	owned, err := wallet.FindOwnedWallet(s.walletRepo, userID, walletNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("user:%s:analytics:%s:%s:%s:%d", owned.WalletNumber,
+		periodFrom.Format(time.RFC3339Nano), periodTo.Format(time.RFC3339Nano), interval, top)
+
+	// Check Redis cache first if available
+	if s.redisService != nil {
+		cached, err := s.redisService.Get(context.Background(), cacheKey)
+		if err == nil && cached != "" {
+			var analytics models.WalletAnalytics
+			if err = json.Unmarshal([]byte(cached), &analytics); err == nil {
+				return &analytics, nil
+			}
+		}
+	}
+
+	analytics, err := s.aggregate(owned, periodFrom, periodTo, interval, top)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the analytics if redis available
+	if s.redisService != nil {
+		cacheData, err := json.Marshal(analytics)
+		if err == nil {
+			s.redisService.Set(context.Background(), cacheKey, cacheData, 10*time.Minute)
+		}
+	}
+
+	return analytics, nil
+}
+
+// aggregate runs the aggregate queries and sums the totals per type into the wallet's totals
+func (s *AnalyticsService) aggregate(owned *models.Wallet, from, to time.Time, interval string, top int) (*models.WalletAnalytics, error) {
+	byType, err := s.analyticsRepo.GetTypeTotals(owned.WalletNumber, owned.Currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+	counterparties, err := s.analyticsRepo.GetTopCounterparties(owned.WalletNumber, owned.Currency, from, to, top)
+	if err != nil {
+		return nil, err
+	}
+	buckets, err := s.analyticsRepo.GetBuckets(owned.WalletNumber, owned.Currency, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &models.WalletAnalytics{
+		WalletNumber:      owned.WalletNumber,
+		Currency:          owned.Currency,
+		From:              from,
+		To:                to,
+		Interval:          interval,
+		TotalIn:           money.Zero(owned.Currency),
+		TotalOut:          money.Zero(owned.Currency),
+		ByType:            byType,
+		TopCounterparties: counterparties,
+		Buckets:           buckets,
+	}
+	for _, total := range byType {
+		if analytics.TotalIn, err = analytics.TotalIn.Add(total.In); err != nil {
+			return nil, err
+		}
+		if analytics.TotalOut, err = analytics.TotalOut.Add(total.Out); err != nil {
+			return nil, err
+		}
+		analytics.Count += total.Count
+	}
+	if analytics.Net, err = analytics.TotalIn.Sub(analytics.TotalOut); err != nil {
+		return nil, err
+	}
+
+	return analytics, nil
+}
+
+// period fills in the bounds left out of the request, in UTC
+func (s *AnalyticsService) period(from, to *time.Time) (time.Time, time.Time) {
+	var periodTo time.Time
+	if to != nil {
+		periodTo = to.UTC()
+	} else {
+		today := s.now().UTC()
+		periodTo = time.Date(today.Year(), today.Month(), today.Day()+1, 0, 0, 0, 0, time.UTC)
+	}
+
+	periodFrom := periodTo.AddDate(0, 0, -DefaultPeriodDays)
+	if from != nil {
+		periodFrom = from.UTC()
+	}
+	return periodFrom, periodTo
+}
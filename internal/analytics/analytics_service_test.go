@@ -0,0 +1,149 @@
+package analytics
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	mockAnalytics "centralized-wallet/tests/mocks/analytics"
+	mockRedis "centralized-wallet/tests/mocks/redis"
+	mockWallet "centralized-wallet/tests/mocks/wallet"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testUserID       = 1
+	testWalletNumber = "wallet123"
+	testFrom         = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	testTo           = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	testCacheKey     = "user:wallet123:analytics:2024-05-01T00:00:00Z:2024-06-01T00:00:00Z:week:5"
+)
+
+func usd(value string) money.Money {
+	return money.MustParse(value, money.DefaultCurrency)
+}
+
+type analyticsServiceMocks struct {
+	analyticsRepo *mockAnalytics.MockAnalyticsRepository
+	walletRepo    *mockWallet.MockWalletRepository
+	redis         *mockRedis.MockRedisClient
+}
+
+func setupAnalyticsServiceMock() (*AnalyticsService, analyticsServiceMocks) {
+	mocks := analyticsServiceMocks{
+		analyticsRepo: new(mockAnalytics.MockAnalyticsRepository),
+		walletRepo:    new(mockWallet.MockWalletRepository),
+		redis:         new(mockRedis.MockRedisClient),
+	}
+	return NewAnalyticsService(mocks.analyticsRepo, mocks.walletRepo, mocks.redis), mocks
+}
+
+func testWallet() *models.Wallet {
+	return &models.Wallet{ID: 1, UserID: testUserID, WalletNumber: testWalletNumber, Currency: "USD"}
+}
+
+// expectAggregates sets up a month of wallet123: two deposits, a transfer out and a transfer in
+func expectAggregates(m *mockAnalytics.MockAnalyticsRepository) {
+	m.On("GetTypeTotals", testWalletNumber, "USD", testFrom, testTo).Return([]models.AnalyticsTypeTotal{
+		{TransactionType: "deposit", Count: 2, In: usd("150.00"), Out: usd("0.00")},
+		{TransactionType: "transfer", Count: 2, In: usd("20.00"), Out: usd("40.25")},
+	}, nil)
+	m.On("GetTopCounterparties", testWalletNumber, "USD", testFrom, testTo, DefaultTop).Return([]models.AnalyticsCounterparty{
+		{WalletNumber: "wallet456", Email: "bob@example.com", Count: 2, In: usd("20.00"), Out: usd("40.25")},
+	}, nil)
+	m.On("GetBuckets", testWalletNumber, "USD", testFrom, testTo, IntervalWeek).Return([]models.AnalyticsBucket{
+		{Start: time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC), Count: 3, In: usd("150.00"), Out: usd("40.25"), Net: usd("109.75")},
+		{Start: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), Count: 1, In: usd("20.00"), Out: usd("0.00"), Net: usd("20.00")},
+	}, nil)
+}
+
+func TestGetAnalyticsService(t *testing.T) {
+	t.Run("aggregates and caches the period", func(t *testing.T) {
+		service, mocks := setupAnalyticsServiceMock()
+		mocks.walletRepo.On("GetDefaultWallet", testUserID).Return(testWallet(), nil)
+		mocks.redis.On("Get", mock.Anything, testCacheKey).Return("", nil)
+		expectAggregates(mocks.analyticsRepo)
+		mocks.redis.On("Set", mock.Anything, testCacheKey, mock.Anything, 10*time.Minute).Return(nil)
+
+		analytics, err := service.GetAnalytics(testUserID, "", &testFrom, &testTo, IntervalWeek, DefaultTop)
+
+		assert.NoError(t, err)
+		assert.Equal(t, testWalletNumber, analytics.WalletNumber)
+		assert.Equal(t, usd("170.00"), analytics.TotalIn)
+		assert.Equal(t, usd("40.25"), analytics.TotalOut)
+		assert.Equal(t, usd("129.75"), analytics.Net)
+		assert.Equal(t, 4, analytics.Count)
+		assert.Len(t, analytics.ByType, 2)
+		assert.Len(t, analytics.TopCounterparties, 1)
+		assert.Len(t, analytics.Buckets, 2)
+		mocks.analyticsRepo.AssertExpectations(t)
+		mocks.redis.AssertExpectations(t)
+	})
+
+	t.Run("cached analytics skip the queries", func(t *testing.T) {
+		service, mocks := setupAnalyticsServiceMock()
+		mocks.walletRepo.On("GetDefaultWallet", testUserID).Return(testWallet(), nil)
+		cached, _ := json.Marshal(&models.WalletAnalytics{
+			WalletNumber: testWalletNumber,
+			Currency:     "USD",
+			TotalIn:      usd("170.00"),
+			TotalOut:     usd("40.25"),
+			Net:          usd("129.75"),
+			Buckets:      []models.AnalyticsBucket{{Count: 1, In: usd("20.00"), Out: usd("0.00"), Net: usd("20.00")}},
+		})
+		mocks.redis.On("Get", mock.Anything, testCacheKey).Return(string(cached), nil)
+
+		analytics, err := service.GetAnalytics(testUserID, "", &testFrom, &testTo, IntervalWeek, DefaultTop)
+
+		assert.NoError(t, err)
+		assert.Equal(t, usd("129.75"), analytics.Net)
+		assert.Equal(t, usd("20.00"), analytics.Buckets[0].In)
+		mocks.analyticsRepo.AssertNotCalled(t, "GetTypeTotals", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("period defaults to the last 30 days", func(t *testing.T) {
+		service, mocks := setupAnalyticsServiceMock()
+		service.now = func() time.Time { return time.Date(2024, 5, 31, 15, 0, 0, 0, time.UTC) }
+		mocks.walletRepo.On("GetDefaultWallet", testUserID).Return(testWallet(), nil)
+		mocks.redis.On("Get", mock.Anything, "user:wallet123:analytics:2024-05-02T00:00:00Z:2024-06-01T00:00:00Z:day:5").Return("", nil)
+		from := testTo.AddDate(0, 0, -DefaultPeriodDays)
+		mocks.analyticsRepo.On("GetTypeTotals", testWalletNumber, "USD", from, testTo).Return([]models.AnalyticsTypeTotal{}, nil)
+		mocks.analyticsRepo.On("GetTopCounterparties", testWalletNumber, "USD", from, testTo, DefaultTop).Return([]models.AnalyticsCounterparty{}, nil)
+		mocks.analyticsRepo.On("GetBuckets", testWalletNumber, "USD", from, testTo, IntervalDay).Return([]models.AnalyticsBucket{}, nil)
+		mocks.redis.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		analytics, err := service.GetAnalytics(testUserID, "", nil, nil, IntervalDay, DefaultTop)
+
+		assert.NoError(t, err)
+		assert.Equal(t, from, analytics.From)
+		assert.Equal(t, testTo, analytics.To)
+		assert.Equal(t, usd("0.00"), analytics.Net)
+		assert.Equal(t, 0, analytics.Count)
+	})
+
+	t.Run("period ending before it starts", func(t *testing.T) {
+		service, mocks := setupAnalyticsServiceMock()
+
+		analytics, err := service.GetAnalytics(testUserID, "", &testTo, &testFrom, IntervalDay, DefaultTop)
+
+		assert.ErrorIs(t, err, utils.ServiceErrInvalidAnalyticsPeriod)
+		assert.Nil(t, analytics)
+		mocks.walletRepo.AssertNotCalled(t, "GetDefaultWallet", mock.Anything)
+	})
+
+	t.Run("wallet of another user", func(t *testing.T) {
+		service, mocks := setupAnalyticsServiceMock()
+		other := testWallet()
+		other.UserID = 2
+		mocks.walletRepo.On("FindByWalletNumber", "wallet456").Return(other, nil)
+
+		analytics, err := service.GetAnalytics(testUserID, "wallet456", &testFrom, &testTo, IntervalDay, DefaultTop)
+
+		assert.ErrorIs(t, err, utils.RepoErrWalletNotFound)
+		assert.Nil(t, analytics)
+	})
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"net/http"
+
+	"centralized-wallet/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys tokens can be verified with, as a JSON Web Key Set. The set is served
+// as is, without the API envelope, so standard JWT libraries can read it.
+func JWKSHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ks, err := CurrentKeySet()
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[JWKSHandler] Error loading keys")
+			return
+		}
+
+		// Verifiers may cache the set, so keep it short: a newly added key must reach them before the tokens it signs
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, ks.JWKS())
+	}
+}
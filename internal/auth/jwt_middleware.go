@@ -9,7 +9,19 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func JWTMiddleware(blacklistService BlacklistServiceInterface) gin.HandlerFunc {
+// AccountCheckerInterface tells whether a user may use their tokens. It is implemented by the user package,
+// which owns users.
+type AccountCheckerInterface interface {
+	// CurrentTokenVersion returns the token version of an active user, ServiceErrUserDisabled for a disabled one
+	// and ErrUserNotFound for a deleted one
+	CurrentTokenVersion(userID int) (int, error)
+	// CurrentRole returns the role of a user, for the tokens issued to them
+	CurrentRole(userID int) (string, error)
+}
+
+// JWTMiddleware authenticates requests by their bearer token, refusing blacklisted tokens, tokens of users that
+// no longer exist or are disabled, tokens older than the user's token version and tokens of revoked sessions
+func JWTMiddleware(blacklistService BlacklistServiceInterface, sessionService SessionServiceInterface, accountChecker AccountCheckerInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
@@ -58,13 +70,52 @@ func JWTMiddleware(blacklistService BlacklistServiceInterface) gin.HandlerFunc {
 
 		// Add the user ID to the context
 		claims, ok := token.Claims.(jwt.MapClaims)
-		if ok && token.Valid {
-			c.Set("user_id", int(claims["user_id"].(float64)))
-		} else {
+		if !ok || !token.Valid {
 			utils.ErrorResponse(c, utils.ErrInvalidToken, nil, "")
 			c.Abort()
 			return
 		}
+		userID := int(claims["user_id"].(float64))
+		c.Set("user_id", userID)
+
+		// The user must still exist and be active, and the token must not predate a password change
+		version, err := accountChecker.CurrentTokenVersion(userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.ErrUserNotFound):
+				utils.ErrorResponse(c, utils.ErrInvalidToken, nil, "")
+			case errors.Is(err, utils.ServiceErrUserDisabled):
+				utils.ErrorResponse(c, utils.ErrUserDisabled, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[JWTMiddleware] Error checking user")
+			}
+			c.Abort()
+			return
+		}
+		if TokenVersionClaim(claims) != version {
+			utils.ErrorResponse(c, utils.ErrTokenRevoked, nil, "")
+			c.Abort()
+			return
+		}
+
+		// Tokens issued at login name their session, which may have been revoked since
+		if sessionID, ok := claims["sid"].(string); ok {
+			err := sessionService.CheckSession(sessionID)
+			if err == utils.ServiceErrSessionRevoked {
+				utils.ErrorResponse(c, utils.ErrSessionRevoked, nil, "")
+				c.Abort()
+				return
+			}
+			if err != nil {
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[JWTMiddleware] Error checking session")
+				c.Abort()
+				return
+			}
+			c.Set("session_id", sessionID)
+		}
+
+		// The role is current: changing it bumps the token version, refusing tokens of the previous role
+		c.Set("role", RoleClaim(claims))
 
 		// Continue to next handler
 		c.Next()
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"centralized-wallet/internal/utils"
 	mockAuth "centralized-wallet/tests/mocks/auth"
 
 	"github.com/gin-gonic/gin"
@@ -35,11 +36,21 @@ func generateInvalidToken() (string, error) {
 	return token.SignedString([]byte(secretKey))
 }
 
+// Helper function to generate the JWT token of a session, as issued at login
+func generateSessionToken() (string, error) {
+	return GenerateAccessToken(123, AccessTokenClaims{SessionID: "session-1"})
+}
+
+// Helper function to generate a JWT token issued before the user's last password change
+func generateOutdatedToken() (string, error) {
+	return GenerateAccessToken(123, AccessTokenClaims{TokenVersion: 1})
+}
+
 // Helper function to set up the router with JWT middleware
-func setupRouterWithJWT(mockBlacklistService *mockAuth.MockBlacklistService) *gin.Engine {
+func setupRouterWithJWT(mockBlacklistService *mockAuth.MockBlacklistService, mockSessionService *mockAuth.MockSessionService, mockAccountChecker *mockAuth.MockAccountChecker) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
-	router.Use(JWTMiddleware(mockBlacklistService))
+	router.Use(JWTMiddleware(mockBlacklistService, mockSessionService, mockAccountChecker))
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "Success"})
 	})
@@ -52,6 +63,8 @@ func TestJWTMiddleware(t *testing.T) {
 		name                 string
 		tokenGenerator       func() (string, error)
 		mockBlacklistService func(tokenString string, mockBlacklistService *mockAuth.MockBlacklistService)
+		mockSessionService   func(mockSessionService *mockAuth.MockSessionService)
+		mockAccountChecker   func(mockAccountChecker *mockAuth.MockAccountChecker)
 		expectedStatus       int
 		expectedResponseBody string
 	}{
@@ -108,6 +121,80 @@ func TestJWTMiddleware(t *testing.T) {
 			expectedStatus:       http.StatusOK,
 			expectedResponseBody: `{"message":"Success"}`,
 		},
+		{
+			name:           "Valid session token",
+			tokenGenerator: generateSessionToken,
+			mockBlacklistService: func(tokenString string, mockBlacklistService *mockAuth.MockBlacklistService) {
+				mockBlacklistService.On("IsTokenBlacklisted", tokenString).Return(false, nil)
+			},
+			mockSessionService: func(mockSessionService *mockAuth.MockSessionService) {
+				mockSessionService.On("CheckSession", "session-1").Return(nil)
+			},
+			expectedStatus:       http.StatusOK,
+			expectedResponseBody: `{"message":"Success"}`,
+		},
+		{
+			name:           "Session revoked",
+			tokenGenerator: generateSessionToken,
+			mockBlacklistService: func(tokenString string, mockBlacklistService *mockAuth.MockBlacklistService) {
+				mockBlacklistService.On("IsTokenBlacklisted", tokenString).Return(false, nil)
+			},
+			mockSessionService: func(mockSessionService *mockAuth.MockSessionService) {
+				mockSessionService.On("CheckSession", "session-1").Return(utils.ServiceErrSessionRevoked)
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedResponseBody: `{"status":"error","message":"Session has been revoked, log in again"}`,
+		},
+		{
+			name:           "User disabled",
+			tokenGenerator: generateSessionToken,
+			mockBlacklistService: func(tokenString string, mockBlacklistService *mockAuth.MockBlacklistService) {
+				mockBlacklistService.On("IsTokenBlacklisted", tokenString).Return(false, nil)
+			},
+			mockAccountChecker: func(mockAccountChecker *mockAuth.MockAccountChecker) {
+				mockAccountChecker.On("CurrentTokenVersion", 123).Return(0, utils.ServiceErrUserDisabled)
+			},
+			expectedStatus:       http.StatusForbidden,
+			expectedResponseBody: `{"status":"error","message":"Account has been disabled"}`,
+		},
+		{
+			name:           "User no longer exists",
+			tokenGenerator: generateValidToken,
+			mockBlacklistService: func(tokenString string, mockBlacklistService *mockAuth.MockBlacklistService) {
+				mockBlacklistService.On("IsTokenBlacklisted", tokenString).Return(false, nil)
+			},
+			mockAccountChecker: func(mockAccountChecker *mockAuth.MockAccountChecker) {
+				mockAccountChecker.On("CurrentTokenVersion", 123).Return(0, utils.ErrUserNotFound)
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedResponseBody: `{"status":"error","message":"Invalid token"}`,
+		},
+		{
+			name:           "Token issued before a password change",
+			tokenGenerator: generateOutdatedToken,
+			mockBlacklistService: func(tokenString string, mockBlacklistService *mockAuth.MockBlacklistService) {
+				mockBlacklistService.On("IsTokenBlacklisted", tokenString).Return(false, nil)
+			},
+			mockAccountChecker: func(mockAccountChecker *mockAuth.MockAccountChecker) {
+				mockAccountChecker.On("CurrentTokenVersion", 123).Return(2, nil)
+			},
+			expectedStatus:       http.StatusUnauthorized,
+			expectedResponseBody: `{"status":"error","message":"Token has been revoked, log in again"}`,
+		},
+		{
+			name: "Token of the current version",
+			tokenGenerator: func() (string, error) {
+				return GenerateAccessToken(123, AccessTokenClaims{TokenVersion: 2})
+			},
+			mockBlacklistService: func(tokenString string, mockBlacklistService *mockAuth.MockBlacklistService) {
+				mockBlacklistService.On("IsTokenBlacklisted", tokenString).Return(false, nil)
+			},
+			mockAccountChecker: func(mockAccountChecker *mockAuth.MockAccountChecker) {
+				mockAccountChecker.On("CurrentTokenVersion", 123).Return(2, nil)
+			},
+			expectedStatus:       http.StatusOK,
+			expectedResponseBody: `{"message":"Success"}`,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -120,8 +207,22 @@ func TestJWTMiddleware(t *testing.T) {
 			mockBlacklistService := new(mockAuth.MockBlacklistService)
 			tt.mockBlacklistService(tokenString, mockBlacklistService)
 
+			// Tokens without a session never reach the session service
+			mockSessionService := new(mockAuth.MockSessionService)
+			if tt.mockSessionService != nil {
+				tt.mockSessionService(mockSessionService)
+			}
+
+			// Unless the test case says otherwise, the user is active and has never changed their password
+			mockAccountChecker := new(mockAuth.MockAccountChecker)
+			if tt.mockAccountChecker != nil {
+				tt.mockAccountChecker(mockAccountChecker)
+			} else {
+				mockAccountChecker.On("CurrentTokenVersion", 123).Return(1, nil)
+			}
+
 			// Set up router and execute the request
-			router := setupRouterWithJWT(mockBlacklistService)
+			router := setupRouterWithJWT(mockBlacklistService, mockSessionService, mockAccountChecker)
 			req, _ := http.NewRequest("GET", "/test", nil)
 			if tokenString != "" {
 				req.Header.Set("Authorization", "Bearer "+tokenString)
@@ -135,6 +236,10 @@ func TestJWTMiddleware(t *testing.T) {
 
 			// Validate the mock expectations
 			mockBlacklistService.AssertExpectations(t)
+			mockSessionService.AssertExpectations(t)
+			if tt.mockAccountChecker != nil {
+				mockAccountChecker.AssertExpectations(t)
+			}
 		})
 	}
 }
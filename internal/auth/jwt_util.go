@@ -1,44 +1,86 @@
 package auth
 
 import (
-	"github.com/golang-jwt/jwt/v5"
-	// "log"
-	"os"
 	"time"
+
+	"centralized-wallet/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
-// var jwtSecret []byte
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// AccessTokenTTL is how long an access token is valid for. Clients renew it with their refresh token.
+const AccessTokenTTL = 15 * time.Minute
+
+// AccessTokenClaims are the claims an access token carries besides its user and expiry
+type AccessTokenClaims struct {
+	SessionID    string // The sid claim, checked against revoked sessions
+	TokenVersion int    // The ver claim, checked against the user's current token version
+	Role         string // The role claim, checked by RequireRole
+}
 
-// GenerateJWT generates a new JWT token for a user
+// GenerateJWT generates a new JWT token for a user, signed with the active key of the current KeySet
 func GenerateJWT(userID int, expiration ...time.Duration) (string, error) {
-	// Set default expiration time to 72 hours if not provided
-	expirationTime := time.Hour * 72
+	// Set default expiration time to AccessTokenTTL if not provided
+	expirationTime := AccessTokenTTL
 	if len(expiration) > 0 {
 		expirationTime = expiration[0] // Use the provided expiration time
 	}
 
+	return signAccessToken(userID, AccessTokenClaims{}, expirationTime)
+}
+
+// GenerateAccessToken generates an access token of a session, valid for AccessTokenTTL
+func GenerateAccessToken(userID int, extra AccessTokenClaims) (string, error) {
+	return signAccessToken(userID, extra, AccessTokenTTL)
+}
+
+func signAccessToken(userID int, extra AccessTokenClaims, expiration time.Duration) (string, error) {
 	// Set token claims
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(expirationTime).Unix(), // Token expiration
+		"exp":     time.Now().Add(expiration).Unix(), // Token expiration
+	}
+	if extra.SessionID != "" {
+		claims["sid"] = extra.SessionID
+	}
+	if extra.TokenVersion != 0 {
+		claims["ver"] = extra.TokenVersion
+	}
+	if extra.Role != "" {
+		claims["role"] = extra.Role
 	}
 
-	// Create the token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign the token with the secret key
-	return token.SignedString(jwtSecret)
+	ks, err := CurrentKeySet()
+	if err != nil {
+		return "", err
+	}
+	return ks.Sign(claims)
 }
 
+// ValidateJWT verifies a token with the key of the current KeySet named by its kid header
 func ValidateJWT(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Ensure the token's signing method is HMAC (HS256)
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return jwtSecret, nil
-	})
+	ks, err := CurrentKeySet()
+	if err != nil {
+		return nil, err
+	}
+	return ks.Parse(tokenString)
+}
+
+// TokenVersionClaim returns the ver claim of a token. Tokens issued before token versions existed have none and
+// count as the first version.
+func TokenVersionClaim(claims jwt.MapClaims) int {
+	if version, ok := claims["ver"].(float64); ok {
+		return int(version)
+	}
+	return 1
+}
+
+// RoleClaim returns the role claim of a token. Tokens issued before roles existed have none and are of a user.
+func RoleClaim(claims jwt.MapClaims) string {
+	if role, ok := claims["role"].(string); ok && role != "" {
+		return role
+	}
+	return models.UserRoleUser
 }
 
 // A helper function to check the token's validity and claims.
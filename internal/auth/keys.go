@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA key accepted for RS256
+const minRSAKeyBits = 2048
+
+var (
+	ErrNoSigningKey    = errors.New("no private key to sign tokens with")
+	ErrUnknownKeyID    = errors.New("token signed with an unknown key")
+	ErrKeysDirRequired = errors.New("JWT_KEYS_DIR must be set unless APP_ENV is local or test")
+)
+
+// ephemeralKeyEnvs are the values of APP_ENV in which the server may start without JWT_KEYS_DIR
+var ephemeralKeyEnvs = map[string]bool{"local": true, "test": true}
+
+// SigningKey is one key of a KeySet. Retired keys may be kept as public keys only, to verify the tokens they signed.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod // RS256 or EdDSA, from the key type
+	PrivateKey crypto.Signer     // Nil for a verification-only key
+	PublicKey  crypto.PublicKey
+}
+
+// KeySet holds the keys tokens are verified with, by kid, and the active key new tokens are signed with.
+// Rotating keys means adding a new key and making it active; tokens signed by the previous key stay valid
+// for as long as that key is in the set.
+type KeySet struct {
+	keys   map[string]*SigningKey
+	active *SigningKey
+}
+
+// LoadKeySet loads the PEM keys of a directory, one key per file named <kid>.pem. Private keys (PKCS#8, or PKCS#1
+// for RSA) can sign and verify; public keys (PKIX) only verify. The active key is activeKID, or when empty the
+// private key whose kid sorts last, so naming keys by creation date makes the newest one active.
+func LoadKeySet(dir, activeKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	ks := &KeySet{keys: make(map[string]*SigningKey, len(paths))}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := parseKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", path, err)
+		}
+		ks.keys[kid] = key
+		if key.PrivateKey != nil && activeKID == "" {
+			ks.active = key
+		}
+	}
+
+	if activeKID != "" {
+		ks.active = ks.keys[activeKID]
+	}
+	if ks.active == nil || ks.active.PrivateKey == nil {
+		return nil, ErrNoSigningKey
+	}
+	return ks, nil
+}
+
+// GenerateKeySet creates a KeySet with a single new Ed25519 key. Tokens it signs do not survive a restart.
+func GenerateKeySet() (*KeySet, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key := &SigningKey{ID: "ephemeral", Method: jwt.SigningMethodEdDSA, PrivateKey: private, PublicKey: public}
+	return &KeySet{keys: map[string]*SigningKey{key.ID: key}, active: key}, nil
+}
+
+// ActiveKeyID returns the kid of the key new tokens are signed with
+func (ks *KeySet) ActiveKeyID() string {
+	return ks.active.ID
+}
+
+// Sign signs the claims with the active key, naming it in the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.PrivateKey)
+}
+
+// Parse verifies a token with the key named by its kid header. The algorithm must be the one of that key,
+// so a token cannot pick a weaker verification than its key calls for.
+func (ks *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+}
+
+// JWK is the public part of a key, as published in a JSON Web Key Set (RFC 7517, RFC 8037 for Ed25519)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`   // RSA modulus
+	E         string `json:"e,omitempty"`   // RSA public exponent
+	Curve     string `json:"crv,omitempty"` // Ed25519
+	X         string `json:"x,omitempty"`   // Ed25519 public key
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, sorted by kid, for other services to verify tokens with
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch public := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+// parseKey reads an RSA or Ed25519 key, private or public, from a PEM block
+func parseKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+	}
+
+	if public, ok := key.PublicKey.(*rsa.PublicKey); ok && public.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA key of %d bits, at least %d required", public.N.BitLen(), minRSAKeyBits)
+	}
+	return key, nil
+}
+
+var (
+	currentKeySet  atomic.Pointer[KeySet]
+	ephemeralKeys  sync.Once
+	errEphemeralKS error
+)
+
+// KeySetFromEnv returns the keys the server signs tokens with: those of JWT_KEYS_DIR, the active one being
+// JWT_ACTIVE_KID when set. Without JWT_KEYS_DIR it fails with ErrKeysDirRequired, so a deployment never signs
+// tokens with a key that dies with the process, unless APP_ENV is local or test, where an ephemeral key is used.
+// JWT_SECRET is no longer read; a deployment still setting it is warned, since it does not protect any token.
+func KeySetFromEnv(getenv func(string) string) (*KeySet, error) {
+	if getenv("JWT_SECRET") != "" {
+		log.Println("[auth] WARNING: JWT_SECRET is set but ignored. Tokens are signed with the keys of JWT_KEYS_DIR; remove JWT_SECRET from the environment")
+	}
+
+	if keysDir := getenv("JWT_KEYS_DIR"); keysDir != "" {
+		ks, err := LoadKeySet(keysDir, getenv("JWT_ACTIVE_KID"))
+		if err != nil {
+			return nil, fmt.Errorf("keys of %s: %w", keysDir, err)
+		}
+		return ks, nil
+	}
+
+	appEnv := getenv("APP_ENV")
+	if !ephemeralKeyEnvs[appEnv] {
+		return nil, ErrKeysDirRequired
+	}
+	log.Printf("[auth] WARNING: JWT_KEYS_DIR is not set, signing with an ephemeral key (APP_ENV=%s); tokens do not survive a restart", appEnv)
+	return GenerateKeySet()
+}
+
+// UseKeySet makes GenerateJWT and ValidateJWT use the given keys. Swapping the set rotates keys without a restart.
+func UseKeySet(ks *KeySet) {
+	currentKeySet.Store(ks)
+}
+
+// CurrentKeySet returns the keys in use. Without UseKeySet, an ephemeral key is generated on first use so unit
+// tests work without configuration. The server always calls UseKeySet at startup, with the keys of KeySetFromEnv.
+func CurrentKeySet() (*KeySet, error) {
+	if ks := currentKeySet.Load(); ks != nil {
+		return ks, nil
+	}
+
+	ephemeralKeys.Do(func() {
+		var ks *KeySet
+		ks, errEphemeralKS = GenerateKeySet()
+		if errEphemeralKS == nil {
+			log.Println("[auth] No JWT keys loaded, signing with an ephemeral key")
+			currentKeySet.CompareAndSwap(nil, ks)
+		}
+	})
+	if errEphemeralKS != nil {
+		return nil, errEphemeralKS
+	}
+	return currentKeySet.Load(), nil
+}
+
+// StartKeyReloading reloads the key directory periodically, so a key added to it, or a key removed once the tokens
+// it signed have expired, takes effect without a restart. A directory that fails to load keeps the current keys.
+func StartKeyReloading(dir, activeKID string, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ks, err := LoadKeySet(dir, activeKID)
+			if err != nil {
+				log.Printf("Warning: Failed to reload JWT keys: %v", err)
+				continue
+			}
+			UseKeySet(ks)
+		}
+	}()
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeEd25519Key writes a new Ed25519 private key to <dir>/<kid>.pem and returns its public key
+func writeEd25519Key(t *testing.T, dir, kid string) ed25519.PublicKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	assert.NoError(t, err)
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+	return public
+}
+
+// writeRSAKey writes a new PKCS#1 RSA private key of the given size to <dir>/<kid>.pem
+func writeRSAKey(t *testing.T, dir, kid string, bits int) *rsa.PrivateKey {
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	assert.NoError(t, err)
+	writePEM(t, dir, kid, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private))
+	return private
+}
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600))
+}
+
+// useKeySet makes the KeySet current for the test, restoring the previous one afterwards
+func useKeySet(t *testing.T, ks *KeySet) {
+	previous, err := CurrentKeySet()
+	assert.NoError(t, err)
+	UseKeySet(ks)
+	t.Cleanup(func() { UseKeySet(previous) })
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "2024-01", 2048)
+	writeEd25519Key(t, dir, "2024-02")
+
+	t.Run("newest private key is active", func(t *testing.T) {
+		ks, err := LoadKeySet(dir, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-02", ks.ActiveKeyID())
+	})
+
+	t.Run("active key by kid", func(t *testing.T) {
+		ks, err := LoadKeySet(dir, "2024-01")
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-01", ks.ActiveKeyID())
+
+		token, err := ks.Sign(jwt.MapClaims{"user_id": 1})
+		assert.NoError(t, err)
+		parsed, err := ks.Parse(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "RS256", parsed.Method.Alg())
+		assert.Equal(t, "2024-01", parsed.Header["kid"])
+	})
+
+	t.Run("unknown active kid", func(t *testing.T) {
+		_, err := LoadKeySet(dir, "2023-12")
+		assert.ErrorIs(t, err, ErrNoSigningKey)
+	})
+
+	t.Run("public key cannot be active", func(t *testing.T) {
+		publicDir := t.TempDir()
+		public := writeEd25519Key(t, t.TempDir(), "unused")
+		der, err := x509.MarshalPKIXPublicKey(public)
+		assert.NoError(t, err)
+		writePEM(t, publicDir, "2024-03", "PUBLIC KEY", der)
+
+		_, err = LoadKeySet(publicDir, "")
+		assert.ErrorIs(t, err, ErrNoSigningKey)
+	})
+
+	t.Run("RSA key too small", func(t *testing.T) {
+		weakDir := t.TempDir()
+		writeRSAKey(t, weakDir, "weak", 1024)
+
+		_, err := LoadKeySet(weakDir, "")
+		assert.ErrorContains(t, err, "at least 2048 required")
+	})
+}
+
+func TestKeySetFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "2024-01")
+	writeEd25519Key(t, dir, "2024-02")
+
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	t.Run("keys of JWT_KEYS_DIR", func(t *testing.T) {
+		ks, err := KeySetFromEnv(env(map[string]string{"APP_ENV": "production", "JWT_KEYS_DIR": dir, "JWT_ACTIVE_KID": "2024-01"}))
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-01", ks.ActiveKeyID())
+	})
+
+	t.Run("JWT_KEYS_DIR without a private key", func(t *testing.T) {
+		_, err := KeySetFromEnv(env(map[string]string{"APP_ENV": "local", "JWT_KEYS_DIR": t.TempDir()}))
+		assert.ErrorIs(t, err, ErrNoSigningKey)
+	})
+
+	t.Run("ephemeral key in local and test runs", func(t *testing.T) {
+		for _, appEnv := range []string{"local", "test"} {
+			ks, err := KeySetFromEnv(env(map[string]string{"APP_ENV": appEnv}))
+			assert.NoError(t, err)
+			assert.Equal(t, "ephemeral", ks.ActiveKeyID())
+		}
+	})
+
+	t.Run("JWT_KEYS_DIR required anywhere else", func(t *testing.T) {
+		for _, appEnv := range []string{"", "production", "staging"} {
+			_, err := KeySetFromEnv(env(map[string]string{"APP_ENV": appEnv}))
+			assert.ErrorIs(t, err, ErrKeysDirRequired)
+		}
+	})
+
+	t.Run("JWT_SECRET does not replace JWT_KEYS_DIR", func(t *testing.T) {
+		_, err := KeySetFromEnv(env(map[string]string{"APP_ENV": "production", "JWT_SECRET": "secret"}))
+		assert.ErrorIs(t, err, ErrKeysDirRequired)
+	})
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "2024-01")
+	ks, err := LoadKeySet(dir, "")
+	assert.NoError(t, err)
+	useKeySet(t, ks)
+
+	oldToken, err := GenerateJWT(1)
+	assert.NoError(t, err)
+
+	// Rotate: a new key is added and becomes active, the previous one stays to verify what it signed
+	writeRSAKey(t, dir, "2024-02", 2048)
+	rotated, err := LoadKeySet(dir, "")
+	assert.NoError(t, err)
+	UseKeySet(rotated)
+
+	newToken, err := GenerateJWT(1)
+	assert.NoError(t, err)
+
+	for _, tokenString := range []string{oldToken, newToken} {
+		token, err := ValidateJWT(tokenString)
+		assert.NoError(t, err)
+		assert.NoError(t, CheckTokenClaims(token))
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-02", parsed.Header["kid"])
+
+	// Once the previous key is removed, the tokens it signed are refused
+	assert.NoError(t, os.Remove(filepath.Join(dir, "2024-01.pem")))
+	retired, err := LoadKeySet(dir, "")
+	assert.NoError(t, err)
+	UseKeySet(retired)
+
+	_, err = ValidateJWT(oldToken)
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestParseRefusesMismatchedKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, dir, "rsa", 2048)
+	writeEd25519Key(t, dir, "ed")
+	ks, err := LoadKeySet(dir, "ed")
+	assert.NoError(t, err)
+
+	claims := jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Hour).Unix()}
+
+	t.Run("unknown kid", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "missing"
+		signed, err := token.SignedString(rsaKey)
+		assert.NoError(t, err)
+
+		_, err = ks.Parse(signed)
+		assert.ErrorIs(t, err, ErrUnknownKeyID)
+	})
+
+	t.Run("algorithm of another key", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "ed"
+		signed, err := token.SignedString(rsaKey)
+		assert.NoError(t, err)
+
+		_, err = ks.Parse(signed)
+		assert.ErrorIs(t, err, jwt.ErrSignatureInvalid)
+	})
+
+	t.Run("HMAC is refused", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = "ed"
+		signed, err := token.SignedString([]byte("secret"))
+		assert.NoError(t, err)
+
+		_, err = ks.Parse(signed)
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+}
+
+func TestJWKSHandler(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, dir, "2024-01", 2048)
+	edKey := writeEd25519Key(t, dir, "2024-02")
+	ks, err := LoadKeySet(dir, "")
+	assert.NoError(t, err)
+	useKeySet(t, ks)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", JWKSHandler())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+
+	var set JWKS
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	assert.Len(t, set.Keys, 2)
+
+	assert.Equal(t, "2024-01", set.Keys[0].KeyID)
+	assert.Equal(t, "RSA", set.Keys[0].KeyType)
+	assert.Equal(t, "RS256", set.Keys[0].Algorithm)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()), set.Keys[0].N)
+
+	assert.Equal(t, JWK{
+		KeyType:   "OKP",
+		KeyID:     "2024-02",
+		Use:       "sig",
+		Algorithm: "EdDSA",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(edKey),
+	}, set.Keys[1])
+
+	// No private key material is published
+	assert.NotContains(t, w.Body.String(), `"d"`)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+	"time"
+)
+
+// RefreshTokenRepositoryInterface defines the methods for persisting hashed refresh tokens
+type RefreshTokenRepositoryInterface interface {
+	CreateRefreshToken(token *models.RefreshToken) error
+	GetRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error)
+	RevokeRefreshToken(tokenHash string, now time.Time) (*models.RefreshToken, error)
+}
+
+type RefreshTokenRepository struct {
+	db *sql.DB
+}
+
+// Ensure RefreshTokenRepository implements RefreshTokenRepositoryInterface
+var _ RefreshTokenRepositoryInterface = &RefreshTokenRepository{}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// CreateRefreshToken inserts a new refresh token and sets its generated ID
+func (repo *RefreshTokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	return repo.db.QueryRow(query, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt).Scan(&token.ID)
+}
+
+// GetRefreshTokenByHash fetches a refresh token, revoked or not, by the hash of its value
+func (repo *RefreshTokenRepository) GetRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	query := `SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, created_at
+			  FROM refresh_tokens WHERE token_hash = $1`
+
+	return scanRefreshToken(repo.db.QueryRow(query, tokenHash))
+}
+
+// RevokeRefreshToken revokes an active, unexpired token and returns it. The state is checked in the update
+// itself, so when the same token is presented twice concurrently only one call gets it back; the other gets
+// RepoErrRefreshTokenNotFound, as for an unknown, revoked or expired token.
+func (repo *RefreshTokenRepository) RevokeRefreshToken(tokenHash string, now time.Time) (*models.RefreshToken, error) {
+	query := `UPDATE refresh_tokens SET revoked_at = $2
+			  WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > $2
+			  RETURNING id, user_id, family_id, token_hash, expires_at, revoked_at, created_at`
+
+	return scanRefreshToken(repo.db.QueryRow(query, tokenHash, now))
+}
+
+func scanRefreshToken(row *sql.Row) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := row.Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &token.RevokedAt, &token.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.RepoErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
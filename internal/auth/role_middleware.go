@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"centralized-wallet/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets requests through only when the role of their token, set by JWTMiddleware, is one of roles.
+// It must run after JWTMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorResponse(c, utils.ErrInsufficientRole, nil, "")
+		c.Abort()
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	"centralized-wallet/tests/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupRoleRouter serves a route open to support and admin staff behind the JWT middleware
+func setupRoleRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	blacklistService := new(mockAuth.MockBlacklistService)
+	blacklistService.On("IsTokenBlacklisted", mock.Anything).Return(false, nil)
+	accountChecker := new(mockAuth.MockAccountChecker)
+	accountChecker.On("CurrentTokenVersion", 1).Return(1, nil)
+
+	router := gin.New()
+	router.Use(JWTMiddleware(blacklistService, new(mockAuth.MockSessionService), accountChecker))
+	router.GET("/admin", RequireRole(models.UserRoleSupport, models.UserRoleAdmin), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "Success"})
+	})
+	return router
+}
+
+func TestRequireRole(t *testing.T) {
+	testCases := []struct {
+		name          string
+		role          string
+		expectedError *utils.AppError
+	}{
+		{name: "allowed role", role: models.UserRoleSupport},
+		{name: "another allowed role", role: models.UserRoleAdmin},
+		{name: "role not allowed", role: models.UserRoleAuditor, expectedError: utils.ErrInsufficientRole},
+		{name: "user", role: models.UserRoleUser, expectedError: utils.ErrInsufficientRole},
+		{name: "token without a role is of a user", expectedError: utils.ErrInsufficientRole},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupRoleRouter()
+			token, err := GenerateAccessToken(1, AccessTokenClaims{Role: tc.role})
+			assert.NoError(t, err)
+
+			w := testutils.ExecuteRequest(router, "GET", "/admin", nil, token)
+
+			if tc.expectedError != nil {
+				testutils.AssertAPIErrorResponse(t, w, tc.expectedError)
+			} else {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.JSONEq(t, `{"message":"Success"}`, w.Body.String())
+			}
+		})
+	}
+}
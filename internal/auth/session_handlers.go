@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"centralized-wallet/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessionsHandler lists where the user is logged in, marking the session the request comes from
+func ListSessionsHandler(ss SessionServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		sessions, err := ss.ListSessions(userID.(int))
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[ListSessionsHandler] Error listing sessions")
+			return
+		}
+
+		currentID := c.GetString("session_id")
+		for _, session := range sessions {
+			session.Current = session.ID == currentID
+		}
+
+		utils.SuccessResponse(c, utils.MsgSessionsRetrieved, gin.H{"sessions": sessions})
+	}
+}
+
+// RevokeSessionHandler logs one of the user's sessions out, for instance on a lost or stolen device
+func RevokeSessionHandler(ss SessionServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		err := ss.RevokeSession(userID.(int), c.Param("id"))
+		if err != nil {
+			switch err {
+			case utils.RepoErrSessionNotFound:
+				utils.ErrorResponse(c, utils.ErrSessionNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[RevokeSessionHandler] Error revoking session")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgSessionRevoked, nil)
+	}
+}
+
+// RevokeAllSessionsHandler logs the user out everywhere, including the session the request comes from
+func RevokeAllSessionsHandler(ss SessionServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		revoked, err := ss.RevokeAllSessions(userID.(int))
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[RevokeAllSessionsHandler] Error revoking sessions")
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgAllSessionsRevoked, gin.H{"revoked": revoked})
+	}
+}
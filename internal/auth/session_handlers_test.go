@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	"centralized-wallet/tests/testutils"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupSessionRouter serves the session routes behind the JWT middleware, and returns a token of session "current"
+func setupSessionRouter(t *testing.T) (*gin.Engine, *mockAuth.MockSessionService, string) {
+	gin.SetMode(gin.TestMode)
+	token, err := GenerateAccessToken(1, AccessTokenClaims{SessionID: "current"})
+	assert.NoError(t, err)
+
+	blacklistService := new(mockAuth.MockBlacklistService)
+	blacklistService.On("IsTokenBlacklisted", token).Return(false, nil)
+	sessionService := new(mockAuth.MockSessionService)
+	sessionService.On("CheckSession", "current").Return(nil)
+	accountChecker := new(mockAuth.MockAccountChecker)
+	accountChecker.On("CurrentTokenVersion", 1).Return(1, nil)
+
+	router := gin.New()
+	router.Use(JWTMiddleware(blacklistService, sessionService, accountChecker))
+	router.GET("/sessions", ListSessionsHandler(sessionService))
+	router.DELETE("/sessions/:id", RevokeSessionHandler(sessionService))
+	router.DELETE("/sessions", RevokeAllSessionsHandler(sessionService))
+	return router, sessionService, token
+}
+
+func TestListSessionsHandler(t *testing.T) {
+	router, sessionService, token := setupSessionRouter(t)
+	seenAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sessionService.On("ListSessions", 1).Return([]*models.Session{
+		{ID: "current", UserID: 1, UserAgent: "Mozilla/5.0", IPAddress: "203.0.113.7", CreatedAt: seenAt, LastSeenAt: seenAt},
+		{ID: "phone", UserID: 1, UserAgent: "WalletApp/2.1 (iOS)", IPAddress: "198.51.100.4", CreatedAt: seenAt, LastSeenAt: seenAt},
+	}, nil)
+
+	w := testutils.ExecuteRequest(router, "GET", "/sessions", nil, token)
+
+	testutils.AssertAPISuccessResponse(t, w, utils.MsgSessionsRetrieved, gin.H{"sessions": []gin.H{
+		{"id": "current", "user_agent": "Mozilla/5.0", "ip_address": "203.0.113.7", "created_at": seenAt, "last_seen_at": seenAt, "current": true},
+		{"id": "phone", "user_agent": "WalletApp/2.1 (iOS)", "ip_address": "198.51.100.4", "created_at": seenAt, "last_seen_at": seenAt, "current": false},
+	}})
+}
+
+func TestRevokeSessionHandler(t *testing.T) {
+	testCases := []struct {
+		name          string
+		revokeError   error
+		expectedError *utils.AppError
+	}{
+		{name: "revokes the session"},
+		{name: "unknown or another user's session", revokeError: utils.RepoErrSessionNotFound, expectedError: utils.ErrSessionNotFound},
+		{name: "database error", revokeError: errors.New("connection reset"), expectedError: utils.ErrInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router, sessionService, token := setupSessionRouter(t)
+			sessionService.On("RevokeSession", 1, "phone").Return(tc.revokeError)
+
+			w := testutils.ExecuteRequest(router, "DELETE", "/sessions/phone", nil, token)
+
+			if tc.expectedError != nil {
+				testutils.AssertAPIErrorResponse(t, w, tc.expectedError)
+			} else {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.JSONEq(t, `{"status":"success","message":"Session revoked successfully"}`, w.Body.String())
+			}
+			sessionService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeAllSessionsHandler(t *testing.T) {
+	router, sessionService, token := setupSessionRouter(t)
+	sessionService.On("RevokeAllSessions", 1).Return(3, nil)
+
+	w := testutils.ExecuteRequest(router, "DELETE", "/sessions", nil, token)
+
+	testutils.AssertAPISuccessResponse(t, w, utils.MsgAllSessionsRevoked, gin.H{"revoked": 3})
+	sessionService.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything)
+}
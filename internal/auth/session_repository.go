@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+	"time"
+)
+
+// SessionRepositoryInterface defines the methods for persisting login sessions
+type SessionRepositoryInterface interface {
+	CreateSession(session *models.Session) error
+	ListActiveSessions(userID int, now time.Time) ([]*models.Session, error)
+	TouchSession(sessionID string, now time.Time) error
+	RevokeSession(userID int, sessionID string, now time.Time) error
+	RevokeUserSessions(userID int, now time.Time) ([]string, error)
+}
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// Ensure SessionRepository implements SessionRepositoryInterface
+var _ SessionRepositoryInterface = &SessionRepository{}
+
+// NewSessionRepository creates a new instance of SessionRepository
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// CreateSession inserts a new session
+func (repo *SessionRepository) CreateSession(session *models.Session) error {
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at)
+			  VALUES ($1, $2, $3, $4, $5, $5)`
+
+	_, err := repo.db.Exec(query, session.ID, session.UserID, session.UserAgent, session.IPAddress, session.CreatedAt)
+	return err
+}
+
+// ListActiveSessions lists the user's sessions that are not revoked and still hold a usable refresh token,
+// most recently seen first
+func (repo *SessionRepository) ListActiveSessions(userID int, now time.Time) ([]*models.Session, error) {
+	query := `SELECT s.id, s.user_id, s.user_agent, s.ip_address, s.created_at, s.last_seen_at, s.revoked_at
+			  FROM sessions s
+			  WHERE s.user_id = $1 AND s.revoked_at IS NULL
+			    AND EXISTS (SELECT 1 FROM refresh_tokens rt
+			                WHERE rt.family_id = s.id AND rt.revoked_at IS NULL AND rt.expires_at > $2)
+			  ORDER BY s.last_seen_at DESC, s.id`
+
+	rows, err := repo.db.Query(query, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		var session models.Session
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress, &session.CreatedAt, &session.LastSeenAt, &session.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, rows.Err()
+}
+
+// TouchSession records activity on a session that is not revoked, returning RepoErrSessionNotFound otherwise
+func (repo *SessionRepository) TouchSession(sessionID string, now time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := repo.db.Exec(query, sessionID, now)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.RepoErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeSession revokes one of the user's sessions and its refresh tokens in a single statement, returning
+// RepoErrSessionNotFound when the user has no such active session
+func (repo *SessionRepository) RevokeSession(userID int, sessionID string, now time.Time) error {
+	query := `WITH revoked AS (
+				  UPDATE sessions SET revoked_at = $3
+				  WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+				  RETURNING id
+			  ), tokens AS (
+				  UPDATE refresh_tokens SET revoked_at = $3
+				  WHERE family_id IN (SELECT id FROM revoked) AND revoked_at IS NULL
+			  )
+			  SELECT id FROM revoked`
+
+	var id string
+	err := repo.db.QueryRow(query, sessionID, userID, now).Scan(&id)
+	if err == sql.ErrNoRows {
+		return utils.RepoErrSessionNotFound
+	}
+	return err
+}
+
+// RevokeUserSessions revokes every active session of the user and their refresh tokens, returning the
+// revoked session IDs
+func (repo *SessionRepository) RevokeUserSessions(userID int, now time.Time) ([]string, error) {
+	query := `WITH revoked AS (
+				  UPDATE sessions SET revoked_at = $2
+				  WHERE user_id = $1 AND revoked_at IS NULL
+				  RETURNING id
+			  ), tokens AS (
+				  UPDATE refresh_tokens SET revoked_at = $2
+				  WHERE family_id IN (SELECT id FROM revoked) AND revoked_at IS NULL
+			  )
+			  SELECT id FROM revoked ORDER BY id`
+
+	rows, err := repo.db.Query(query, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/redis"
+	"centralized-wallet/internal/utils"
+	"context"
+	"time"
+)
+
+const (
+	// SessionCacheTTL is how long the state of a session is cached in Redis. It bounds how often its
+	// last-seen time is written, and how long a revocation can take to be seen if Redis misses it.
+	SessionCacheTTL = time.Minute
+
+	maxUserAgentLength = 512
+
+	sessionActive  = "active"
+	sessionRevoked = "revoked"
+)
+
+// SessionServiceInterface tracks logins per device and lets users revoke them
+type SessionServiceInterface interface {
+	StartSession(userID int, userAgent, ipAddress string) (*models.Session, error)
+	ListSessions(userID int) ([]*models.Session, error)
+	CheckSession(sessionID string) error
+	RevokeSession(userID int, sessionID string) error
+	RevokeAllSessions(userID int) (int, error)
+}
+
+// SessionService keeps sessions in Postgres and caches whether they are revoked in Redis, so the JWT middleware
+// does not query the database on every request
+type SessionService struct {
+	repo         SessionRepositoryInterface
+	redisService redis.RedisServiceInterface
+	now          func() time.Time
+}
+
+// Ensure SessionService implements SessionServiceInterface
+var _ SessionServiceInterface = &SessionService{}
+
+// NewSessionService creates a SessionService
+func NewSessionService(repo SessionRepositoryInterface, redis redis.RedisServiceInterface) *SessionService {
+	return &SessionService{
+		repo:         repo,
+		redisService: redis,
+		now:          time.Now,
+	}
+}
+
+// StartSession records a login from the given device
+func (s *SessionService) StartSession(userID int, userAgent, ipAddress string) (*models.Session, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	now := s.now()
+	session := &models.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := s.repo.CreateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ListSessions returns the user's active sessions, most recently seen first
+func (s *SessionService) ListSessions(userID int) ([]*models.Session, error) {
+	return s.repo.ListActiveSessions(userID, s.now())
+}
+
+// CheckSession returns ServiceErrSessionRevoked when the session has been revoked. When the state is not
+// cached, the database is asked and the session's last-seen time updated in the same query.
+func (s *SessionService) CheckSession(sessionID string) error {
+	ctx := context.Background()
+	if state, err := s.redisService.Get(ctx, sessionCacheKey(sessionID)); err == nil {
+		if state == sessionRevoked {
+			return utils.ServiceErrSessionRevoked
+		}
+		return nil
+	}
+
+	state := sessionActive
+	err := s.repo.TouchSession(sessionID, s.now())
+	if err == utils.RepoErrSessionNotFound {
+		state = sessionRevoked
+	} else if err != nil {
+		return err
+	}
+
+	// Caching is an optimization; a failure only means the next request asks the database again
+	s.redisService.Set(ctx, sessionCacheKey(sessionID), state, SessionCacheTTL)
+
+	if state == sessionRevoked {
+		return utils.ServiceErrSessionRevoked
+	}
+	return nil
+}
+
+// RevokeSession ends one of the user's sessions: its refresh tokens are revoked and its access tokens refused
+func (s *SessionService) RevokeSession(userID int, sessionID string) error {
+	if err := s.repo.RevokeSession(userID, sessionID, s.now()); err != nil {
+		return err
+	}
+	s.cacheRevoked(sessionID)
+	return nil
+}
+
+// RevokeAllSessions ends every session of the user, logging them out everywhere, and returns how many ended
+func (s *SessionService) RevokeAllSessions(userID int) (int, error) {
+	ids, err := s.repo.RevokeUserSessions(userID, s.now())
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		s.cacheRevoked(id)
+	}
+	return len(ids), nil
+}
+
+// cacheRevoked overwrites a cached active state, so the revocation applies to the next request rather than
+// once the cached state expires
+func (s *SessionService) cacheRevoked(sessionID string) {
+	s.redisService.Set(context.Background(), sessionCacheKey(sessionID), sessionRevoked, SessionCacheTTL)
+}
+
+func sessionCacheKey(sessionID string) string {
+	return "session:" + sessionID
+}
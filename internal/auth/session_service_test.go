@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	mockRedis "centralized-wallet/tests/mocks/redis"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupSessionServiceMock() (*SessionService, *mockAuth.MockSessionRepository, *mockRedis.MockRedisClient) {
+	repo := new(mockAuth.MockSessionRepository)
+	redisClient := new(mockRedis.MockRedisClient)
+	service := NewSessionService(repo, redisClient)
+	service.now = func() time.Time { return testTokenNow }
+	return service, repo, redisClient
+}
+
+func TestStartSession(t *testing.T) {
+	service, repo, _ := setupSessionServiceMock()
+	var stored *models.Session
+	repo.On("CreateSession", mock.AnythingOfType("*models.Session")).
+		Run(func(args mock.Arguments) { stored = args.Get(0).(*models.Session) }).
+		Return(nil)
+
+	userAgent := strings.Repeat("a", maxUserAgentLength+10)
+	session, err := service.StartSession(1, userAgent, "203.0.113.7")
+
+	assert.NoError(t, err)
+	assert.Same(t, stored, session)
+	assert.NotEmpty(t, session.ID)
+	assert.Equal(t, 1, session.UserID)
+	assert.Len(t, session.UserAgent, maxUserAgentLength)
+	assert.Equal(t, "203.0.113.7", session.IPAddress)
+	assert.True(t, session.LastSeenAt.Equal(testTokenNow))
+}
+
+func TestCheckSession(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockSetup     func(repo *mockAuth.MockSessionRepository, redisClient *mockRedis.MockRedisClient)
+		expectedError error
+	}{
+		{
+			name: "active session cached",
+			mockSetup: func(repo *mockAuth.MockSessionRepository, redisClient *mockRedis.MockRedisClient) {
+				redisClient.On("Get", mock.Anything, "session:session-1").Return(sessionActive, nil)
+			},
+		},
+		{
+			name: "revoked session cached",
+			mockSetup: func(repo *mockAuth.MockSessionRepository, redisClient *mockRedis.MockRedisClient) {
+				redisClient.On("Get", mock.Anything, "session:session-1").Return(sessionRevoked, nil)
+			},
+			expectedError: utils.ServiceErrSessionRevoked,
+		},
+		{
+			name: "cache miss touches the session and caches it",
+			mockSetup: func(repo *mockAuth.MockSessionRepository, redisClient *mockRedis.MockRedisClient) {
+				redisClient.On("Get", mock.Anything, "session:session-1").Return("", redis.Nil)
+				repo.On("TouchSession", "session-1", testTokenNow).Return(nil)
+				redisClient.On("Set", mock.Anything, "session:session-1", sessionActive, SessionCacheTTL).Return(nil)
+			},
+		},
+		{
+			name: "cache miss on a revoked session",
+			mockSetup: func(repo *mockAuth.MockSessionRepository, redisClient *mockRedis.MockRedisClient) {
+				redisClient.On("Get", mock.Anything, "session:session-1").Return("", redis.Nil)
+				repo.On("TouchSession", "session-1", testTokenNow).Return(utils.RepoErrSessionNotFound)
+				redisClient.On("Set", mock.Anything, "session:session-1", sessionRevoked, SessionCacheTTL).Return(nil)
+			},
+			expectedError: utils.ServiceErrSessionRevoked,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, repo, redisClient := setupSessionServiceMock()
+			tc.mockSetup(repo, redisClient)
+
+			err := service.CheckSession("session-1")
+
+			assert.Equal(t, tc.expectedError, err)
+			repo.AssertExpectations(t)
+			redisClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Run("revokes and caches the revocation", func(t *testing.T) {
+		service, repo, redisClient := setupSessionServiceMock()
+		repo.On("RevokeSession", 1, "session-1", testTokenNow).Return(nil)
+		redisClient.On("Set", mock.Anything, "session:session-1", sessionRevoked, SessionCacheTTL).Return(nil)
+
+		assert.NoError(t, service.RevokeSession(1, "session-1"))
+		repo.AssertExpectations(t)
+		redisClient.AssertExpectations(t)
+	})
+
+	t.Run("session of another user", func(t *testing.T) {
+		service, repo, redisClient := setupSessionServiceMock()
+		repo.On("RevokeSession", 2, "session-1", testTokenNow).Return(utils.RepoErrSessionNotFound)
+
+		assert.Equal(t, utils.RepoErrSessionNotFound, service.RevokeSession(2, "session-1"))
+		redisClient.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	service, repo, redisClient := setupSessionServiceMock()
+	repo.On("RevokeUserSessions", 1, testTokenNow).Return([]string{"session-1", "session-2"}, nil)
+	redisClient.On("Set", mock.Anything, "session:session-1", sessionRevoked, SessionCacheTTL).Return(nil)
+	redisClient.On("Set", mock.Anything, "session:session-2", sessionRevoked, SessionCacheTTL).Return(nil)
+
+	revoked, err := service.RevokeAllSessions(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+	repo.AssertExpectations(t)
+	redisClient.AssertExpectations(t)
+}
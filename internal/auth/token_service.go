@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged for new tokens. Each refresh issues a new one.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// TokenServiceInterface issues access and refresh tokens and rotates refresh tokens
+type TokenServiceInterface interface {
+	IssueTokens(userID int, userAgent, ipAddress string) (*models.TokenPair, error)
+	RefreshTokens(refreshToken string) (*models.TokenPair, error)
+}
+
+// TokenService pairs short-lived access tokens with refresh tokens stored hashed in Postgres. Each login is a
+// session; its refresh tokens form a family named by the session ID, which its access tokens carry as sid.
+type TokenService struct {
+	repo     RefreshTokenRepositoryInterface
+	sessions SessionServiceInterface
+	accounts AccountCheckerInterface
+	now      func() time.Time
+}
+
+// Ensure TokenService implements TokenServiceInterface
+var _ TokenServiceInterface = &TokenService{}
+
+// NewTokenService creates a TokenService
+func NewTokenService(repo RefreshTokenRepositoryInterface, sessions SessionServiceInterface, accounts AccountCheckerInterface) *TokenService {
+	return &TokenService{
+		repo:     repo,
+		sessions: sessions,
+		accounts: accounts,
+		now:      time.Now,
+	}
+}
+
+// IssueTokens starts a session, and the refresh token family that goes with it, for a user who just logged in.
+// Disabled users get ServiceErrUserDisabled.
+func (s *TokenService) IssueTokens(userID int, userAgent, ipAddress string) (*models.TokenPair, error) {
+	claims, err := s.accountClaims(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessions.StartSession(userID, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	claims.SessionID = session.ID
+	return s.issue(userID, claims)
+}
+
+// RefreshTokens exchanges a refresh token for a new access token and the next refresh token of its family,
+// carrying the user's current token version and role.
+// The presented token is revoked; presenting it again revokes the whole session, since only a copy of the
+// token could be used after its rotation.
+func (s *TokenService) RefreshTokens(refreshToken string) (*models.TokenPair, error) {
+	tokenHash := HashRefreshToken(refreshToken)
+
+	current, err := s.repo.RevokeRefreshToken(tokenHash, s.now())
+	if err == utils.RepoErrRefreshTokenNotFound {
+		return nil, s.refusal(tokenHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.accountClaims(current.UserID)
+	if err != nil {
+		return nil, err
+	}
+	claims.SessionID = current.FamilyID
+	return s.issue(current.UserID, claims)
+}
+
+// accountClaims returns the claims of the user's current token version and role. Disabled users get
+// ServiceErrUserDisabled.
+func (s *TokenService) accountClaims(userID int) (AccessTokenClaims, error) {
+	version, err := s.accounts.CurrentTokenVersion(userID)
+	if err != nil {
+		return AccessTokenClaims{}, err
+	}
+	role, err := s.accounts.CurrentRole(userID)
+	if err != nil {
+		return AccessTokenClaims{}, err
+	}
+	return AccessTokenClaims{TokenVersion: version, Role: role}, nil
+}
+
+// refusal explains why a refresh token could not be revoked for rotation, revoking its session if it was reused
+func (s *TokenService) refusal(tokenHash string) error {
+	token, err := s.repo.GetRefreshTokenByHash(tokenHash)
+	if err == utils.RepoErrRefreshTokenNotFound {
+		return utils.ServiceErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if token.RevokedAt != nil {
+		// The session may have been revoked already, by the user or by an earlier reuse
+		err := s.sessions.RevokeSession(token.UserID, token.FamilyID)
+		if err != nil && err != utils.RepoErrSessionNotFound {
+			return err
+		}
+		return utils.ServiceErrRefreshTokenReused
+	}
+	return utils.ServiceErrRefreshTokenExpired
+}
+
+// issue stores a new refresh token in the family named by the claims' session, and signs an access token with the
+// claims to go with it
+func (s *TokenService) issue(userID int, claims AccessTokenClaims) (*models.TokenPair, error) {
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	err = s.repo.CreateRefreshToken(&models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  claims.SessionID,
+		TokenHash: HashRefreshToken(refreshToken),
+		ExpiresAt: now.Add(RefreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := GenerateAccessToken(userID, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// HashRefreshToken returns the hex SHA-256 of a refresh token, the form it is stored and looked up in
+func HashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns n random bytes encoded for use in URLs and JSON
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
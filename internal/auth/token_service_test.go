@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testTokenNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func setupTokenServiceMock() (*TokenService, *mockAuth.MockRefreshTokenRepository, *mockAuth.MockSessionService, *mockAuth.MockAccountChecker) {
+	repo := new(mockAuth.MockRefreshTokenRepository)
+	sessions := new(mockAuth.MockSessionService)
+	accounts := new(mockAuth.MockAccountChecker)
+	service := NewTokenService(repo, sessions, accounts)
+	service.now = func() time.Time { return testTokenNow }
+	return service, repo, sessions, accounts
+}
+
+func storedRefreshToken(revokedAt *time.Time) *models.RefreshToken {
+	return &models.RefreshToken{
+		ID:        3,
+		UserID:    1,
+		FamilyID:  "family-1",
+		TokenHash: HashRefreshToken("old-token"),
+		ExpiresAt: testTokenNow.Add(time.Hour),
+		RevokedAt: revokedAt,
+	}
+}
+
+func TestIssueTokens(t *testing.T) {
+	service, repo, sessions, accounts := setupTokenServiceMock()
+	accounts.On("CurrentTokenVersion", 1).Return(3, nil)
+	accounts.On("CurrentRole", 1).Return(models.UserRoleSupport, nil)
+	sessions.On("StartSession", 1, "Mozilla/5.0", "203.0.113.7").Return(&models.Session{ID: "family-1", UserID: 1}, nil)
+	var stored *models.RefreshToken
+	repo.On("CreateRefreshToken", mock.AnythingOfType("*models.RefreshToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(0).(*models.RefreshToken) }).
+		Return(nil)
+
+	tokens, err := service.IssueTokens(1, "Mozilla/5.0", "203.0.113.7")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 900, tokens.ExpiresIn)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	// Only the hash of the refresh token is stored
+	assert.Equal(t, HashRefreshToken(tokens.RefreshToken), stored.TokenHash)
+	assert.NotEqual(t, tokens.RefreshToken, stored.TokenHash)
+	assert.Equal(t, 1, stored.UserID)
+	// The refresh token family is the session
+	assert.Equal(t, "family-1", stored.FamilyID)
+	assert.True(t, stored.ExpiresAt.Equal(testTokenNow.Add(RefreshTokenTTL)))
+
+	token, err := ValidateJWT(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.True(t, token.Valid)
+	assert.Equal(t, "family-1", token.Claims.(jwt.MapClaims)["sid"])
+	assert.Equal(t, 3, TokenVersionClaim(token.Claims.(jwt.MapClaims)))
+	assert.Equal(t, models.UserRoleSupport, RoleClaim(token.Claims.(jwt.MapClaims)))
+}
+
+func TestIssueTokens_UserDisabled(t *testing.T) {
+	service, repo, sessions, accounts := setupTokenServiceMock()
+	accounts.On("CurrentTokenVersion", 1).Return(0, utils.ServiceErrUserDisabled)
+
+	tokens, err := service.IssueTokens(1, "Mozilla/5.0", "203.0.113.7")
+
+	assert.ErrorIs(t, err, utils.ServiceErrUserDisabled)
+	assert.Nil(t, tokens)
+	sessions.AssertNotCalled(t, "StartSession", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything)
+}
+
+func TestRefreshTokens(t *testing.T) {
+	revokedAt := testTokenNow.Add(-time.Minute)
+	oldHash := HashRefreshToken("old-token")
+
+	testCases := []struct {
+		name          string
+		mockSetup     func(repo *mockAuth.MockRefreshTokenRepository, sessions *mockAuth.MockSessionService, accounts *mockAuth.MockAccountChecker)
+		expectedError error
+	}{
+		{
+			name: "rotates the token within its family",
+			mockSetup: func(repo *mockAuth.MockRefreshTokenRepository, sessions *mockAuth.MockSessionService, accounts *mockAuth.MockAccountChecker) {
+				repo.On("RevokeRefreshToken", oldHash, testTokenNow).Return(storedRefreshToken(&testTokenNow), nil)
+				accounts.On("CurrentTokenVersion", 1).Return(1, nil)
+				accounts.On("CurrentRole", 1).Return(models.UserRoleUser, nil)
+				repo.On("CreateRefreshToken", mock.MatchedBy(func(token *models.RefreshToken) bool {
+					return token.UserID == 1 && token.FamilyID == "family-1" && token.TokenHash != oldHash
+				})).Return(nil)
+			},
+		},
+		{
+			name: "user disabled since the token was issued",
+			mockSetup: func(repo *mockAuth.MockRefreshTokenRepository, sessions *mockAuth.MockSessionService, accounts *mockAuth.MockAccountChecker) {
+				repo.On("RevokeRefreshToken", oldHash, testTokenNow).Return(storedRefreshToken(&testTokenNow), nil)
+				accounts.On("CurrentTokenVersion", 1).Return(0, utils.ServiceErrUserDisabled)
+			},
+			expectedError: utils.ServiceErrUserDisabled,
+		},
+		{
+			name: "unknown token",
+			mockSetup: func(repo *mockAuth.MockRefreshTokenRepository, sessions *mockAuth.MockSessionService, accounts *mockAuth.MockAccountChecker) {
+				repo.On("RevokeRefreshToken", oldHash, testTokenNow).Return(nil, utils.RepoErrRefreshTokenNotFound)
+				repo.On("GetRefreshTokenByHash", oldHash).Return(nil, utils.RepoErrRefreshTokenNotFound)
+			},
+			expectedError: utils.ServiceErrInvalidRefreshToken,
+		},
+		{
+			name: "expired token",
+			mockSetup: func(repo *mockAuth.MockRefreshTokenRepository, sessions *mockAuth.MockSessionService, accounts *mockAuth.MockAccountChecker) {
+				expired := storedRefreshToken(nil)
+				expired.ExpiresAt = testTokenNow.Add(-time.Hour)
+				repo.On("RevokeRefreshToken", oldHash, testTokenNow).Return(nil, utils.RepoErrRefreshTokenNotFound)
+				repo.On("GetRefreshTokenByHash", oldHash).Return(expired, nil)
+			},
+			expectedError: utils.ServiceErrRefreshTokenExpired,
+		},
+		{
+			name: "reused token revokes the session",
+			mockSetup: func(repo *mockAuth.MockRefreshTokenRepository, sessions *mockAuth.MockSessionService, accounts *mockAuth.MockAccountChecker) {
+				repo.On("RevokeRefreshToken", oldHash, testTokenNow).Return(nil, utils.RepoErrRefreshTokenNotFound)
+				repo.On("GetRefreshTokenByHash", oldHash).Return(storedRefreshToken(&revokedAt), nil)
+				sessions.On("RevokeSession", 1, "family-1").Return(nil)
+			},
+			expectedError: utils.ServiceErrRefreshTokenReused,
+		},
+		{
+			name: "reused token of a revoked session",
+			mockSetup: func(repo *mockAuth.MockRefreshTokenRepository, sessions *mockAuth.MockSessionService, accounts *mockAuth.MockAccountChecker) {
+				repo.On("RevokeRefreshToken", oldHash, testTokenNow).Return(nil, utils.RepoErrRefreshTokenNotFound)
+				repo.On("GetRefreshTokenByHash", oldHash).Return(storedRefreshToken(&revokedAt), nil)
+				sessions.On("RevokeSession", 1, "family-1").Return(utils.RepoErrSessionNotFound)
+			},
+			expectedError: utils.ServiceErrRefreshTokenReused,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, repo, sessions, accounts := setupTokenServiceMock()
+			tc.mockSetup(repo, sessions, accounts)
+
+			tokens, err := service.RefreshTokens("old-token")
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, tokens)
+				repo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEqual(t, "old-token", tokens.RefreshToken)
+				assert.NotEmpty(t, tokens.AccessToken)
+			}
+			repo.AssertExpectations(t)
+			sessions.AssertExpectations(t)
+			accounts.AssertExpectations(t)
+		})
+	}
+}
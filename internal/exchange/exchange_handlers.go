@@ -0,0 +1,104 @@
+package exchange
+
+import (
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateQuoteHandler prices a conversion from one of the authenticated user's wallets into a wallet of another currency.
+// from_wallet_number is optional and defaults to the user's default wallet. The amount is what the source wallet pays.
+func CreateQuoteHandler(es ExchangeServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		var request struct {
+			FromWalletNumber string      `json:"from_wallet_number"`
+			ToWalletNumber   string      `json:"to_wallet_number" binding:"required"`
+			Amount           json.Number `json:"amount" binding:"required"`
+			Currency         string      `json:"currency"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+
+		amount, ok := wallet.ParseAmount(c, request.Amount, request.Currency)
+		if !ok {
+			return
+		}
+
+		quote, err := es.CreateQuote(userID.(int), request.FromWalletNumber, request.ToWalletNumber, amount)
+		if err != nil {
+			switch err {
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			case utils.ServiceErrQuoteSameCurrency:
+				utils.ErrorResponse(c, utils.ErrQuoteSameCurrency, nil, "")
+			case utils.ServiceErrCurrencyMismatch:
+				utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+			case utils.ServiceErrNoConversionPath:
+				utils.ErrorResponse(c, utils.ErrNoConversionPath, nil, "")
+			case utils.ServiceErrAmountTooSmallToConvert:
+				utils.ErrorResponse(c, utils.ErrAmountTooSmallToConvert, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[CreateQuoteHandler] Error creating quote")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgQuoteCreated, gin.H{"quote": quote})
+	}
+}
+
+// ExecuteQuoteHandler converts the quoted amount at the quoted rate, as long as the quote has not expired
+func ExecuteQuoteHandler(es ExchangeServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		quoteID, err := strconv.Atoi(c.Param("id"))
+		if err != nil || quoteID <= 0 {
+			utils.ErrorResponse(c, utils.ErrInvalidQuoteID, nil, "")
+			return
+		}
+
+		quote, updatedWallet, err := es.ExecuteQuote(userID.(int), quoteID)
+		if err != nil {
+			switch err {
+			case utils.RepoErrQuoteNotFound:
+				utils.ErrorResponse(c, utils.ErrQuoteNotFound, nil, "")
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			case utils.ServiceErrQuoteAlreadyExecuted:
+				utils.ErrorResponse(c, utils.ErrQuoteAlreadyExecuted, nil, "")
+			case utils.ServiceErrQuoteExpired:
+				utils.ErrorResponse(c, utils.ErrQuoteExpired, nil, "")
+			case utils.RepoErrInsufficientFunds:
+				utils.ErrorResponse(c, utils.ErrorInsufficientFunds, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[ExecuteQuoteHandler] Error executing quote")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgQuoteExecuted, gin.H{
+			"quote":             quote,
+			"balance":           updatedWallet.Balance,
+			"available_balance": updatedWallet.AvailableBalance(),
+			"updated_at":        updatedWallet.UpdatedAt,
+		})
+	}
+}
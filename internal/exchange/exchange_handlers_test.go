@@ -0,0 +1,177 @@
+package exchange
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	mockExchange "centralized-wallet/tests/mocks/exchange"
+	"centralized-wallet/tests/testutils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupExchangeHandlerRouter(exchangeService *mockExchange.MockExchangeService) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	token, _ := auth.GenerateJWT(testUserID)
+	blacklistService := new(mockAuth.MockBlacklistService)
+	blacklistService.On("IsTokenBlacklisted", token).Return(false, nil)
+	accountChecker := new(mockAuth.MockAccountChecker)
+	accountChecker.On("CurrentTokenVersion", testUserID).Return(1, nil)
+
+	walletRoutes := router.Group("/wallets")
+	walletRoutes.Use(auth.JWTMiddleware(blacklistService, new(mockAuth.MockSessionService), accountChecker))
+	{
+		walletRoutes.POST("/fx/quotes", CreateQuoteHandler(exchangeService))
+		walletRoutes.POST("/fx/quotes/:id/execute", ExecuteQuoteHandler(exchangeService))
+	}
+	return router, token
+}
+
+func TestCreateQuoteHandler(t *testing.T) {
+	quote := openQuote()
+
+	testCases := []testutils.BaseHandlerTestCase{
+		{
+			Name:     "Quote from the default wallet",
+			TestType: "success",
+			Body:     map[string]interface{}{"to_wallet_number": testToWalletNumber, "amount": 50.0},
+			MockSetup: func() {
+				mockExchangeService.On("CreateQuote", testUserID, "", testToWalletNumber, usd("50.00")).Return(quote, nil)
+			},
+			ExpectedMessage: utils.MsgQuoteCreated,
+			ExpectedEntity:  gin.H{"quote": quote},
+		},
+		{
+			Name:     "Quote from a named wallet",
+			TestType: "success",
+			Body:     map[string]interface{}{"from_wallet_number": testFromWalletNumber, "to_wallet_number": testToWalletNumber, "amount": 50.0},
+			MockSetup: func() {
+				mockExchangeService.On("CreateQuote", testUserID, testFromWalletNumber, testToWalletNumber, usd("50.00")).Return(quote, nil)
+			},
+			ExpectedMessage: utils.MsgQuoteCreated,
+			ExpectedEntity:  gin.H{"quote": quote},
+		},
+		{
+			Name:                  "Missing destination wallet",
+			TestType:              "error",
+			Body:                  map[string]interface{}{"amount": 50.0},
+			MockSetup:             func() {},
+			ExpectedResponseError: utils.ErrInvalidRequest,
+		},
+		{
+			Name:     "Wallets in the same currency",
+			TestType: "error",
+			Body:     map[string]interface{}{"to_wallet_number": testToWalletNumber, "amount": 50.0},
+			MockSetup: func() {
+				mockExchangeService.On("CreateQuote", testUserID, "", testToWalletNumber, usd("50.00")).Return(nil, utils.ServiceErrQuoteSameCurrency)
+			},
+			ExpectedResponseError: utils.ErrQuoteSameCurrency,
+		},
+		{
+			Name:     "No rate for the pair",
+			TestType: "error",
+			Body:     map[string]interface{}{"to_wallet_number": testToWalletNumber, "amount": 50.0},
+			MockSetup: func() {
+				mockExchangeService.On("CreateQuote", testUserID, "", testToWalletNumber, usd("50.00")).Return(nil, utils.ServiceErrNoConversionPath)
+			},
+			ExpectedResponseError: utils.ErrNoConversionPath,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			exchangeHandlerTestFlow(t, tc, http.MethodPost, "/wallets/fx/quotes")
+		})
+	}
+}
+
+func TestExecuteQuoteHandler(t *testing.T) {
+	executed := openQuote()
+	executed.Status = StatusExecuted
+	wallet := &models.Wallet{Currency: "USD", Balance: usd("50.00"), HeldBalance: usd("5.00"), UpdatedAt: testNow}
+
+	testCases := []testutils.BaseHandlerTestCase{
+		{
+			Name:     "Execute an open quote",
+			TestType: "success",
+			URL:      "/wallets/fx/quotes/5/execute",
+			MockSetup: func() {
+				mockExchangeService.On("ExecuteQuote", testUserID, 5).Return(executed, wallet, nil)
+			},
+			ExpectedMessage: utils.MsgQuoteExecuted,
+			ExpectedEntity: gin.H{
+				"quote":             executed,
+				"balance":           50.0,
+				"available_balance": 45.0,
+				"updated_at":        testNow.Format(time.RFC3339Nano),
+			},
+		},
+		{
+			Name:                  "Invalid quote ID",
+			TestType:              "error",
+			URL:                   "/wallets/fx/quotes/abc/execute",
+			MockSetup:             func() {},
+			ExpectedResponseError: utils.ErrInvalidQuoteID,
+		},
+		{
+			Name:     "Quote expired",
+			TestType: "error",
+			URL:      "/wallets/fx/quotes/5/execute",
+			MockSetup: func() {
+				mockExchangeService.On("ExecuteQuote", testUserID, 5).Return(nil, nil, utils.ServiceErrQuoteExpired)
+			},
+			ExpectedResponseError: utils.ErrQuoteExpired,
+		},
+		{
+			Name:     "Quote already executed",
+			TestType: "error",
+			URL:      "/wallets/fx/quotes/5/execute",
+			MockSetup: func() {
+				mockExchangeService.On("ExecuteQuote", testUserID, 5).Return(nil, nil, utils.ServiceErrQuoteAlreadyExecuted)
+			},
+			ExpectedResponseError: utils.ErrQuoteAlreadyExecuted,
+		},
+		{
+			Name:     "Quote not found",
+			TestType: "error",
+			URL:      "/wallets/fx/quotes/5/execute",
+			MockSetup: func() {
+				mockExchangeService.On("ExecuteQuote", testUserID, 5).Return(nil, nil, utils.RepoErrQuoteNotFound)
+			},
+			ExpectedResponseError: utils.ErrQuoteNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			exchangeHandlerTestFlow(t, tc, http.MethodPost, tc.URL)
+		})
+	}
+}
+
+var mockExchangeService *mockExchange.MockExchangeService
+
+func exchangeHandlerTestFlow(t *testing.T, tc testutils.BaseHandlerTestCase, method, url string) {
+	mockExchangeService = new(mockExchange.MockExchangeService)
+	router, token := setupExchangeHandlerRouter(mockExchangeService)
+	tc.MockSetup()
+
+	var body interface{}
+	if tc.Body != nil {
+		body = tc.Body
+	}
+	w := testutils.ExecuteRequest(router, method, url, body, token)
+
+	if tc.TestType == "success" {
+		testutils.AssertAPISuccessResponse(t, w, tc.ExpectedMessage, tc.ExpectedEntity)
+	} else {
+		testutils.AssertAPIErrorResponse(t, w, tc.ExpectedResponseError)
+	}
+	mockExchangeService.AssertExpectations(t)
+}
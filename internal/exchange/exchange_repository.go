@@ -0,0 +1,127 @@
+package exchange
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+)
+
+// ExchangeRepositoryInterface defines the methods for persisting FX quotes
+type ExchangeRepositoryInterface interface {
+	Begin() (*sql.Tx, error)
+	Commit(tx *sql.Tx) error
+	Rollback(tx *sql.Tx) error
+	CreateQuote(quote *models.FXQuote) error
+	LockQuoteByID(tx *sql.Tx, quoteID int) (*models.FXQuote, error)
+	UpdateQuote(tx *sql.Tx, quote *models.FXQuote) error
+}
+
+type ExchangeRepository struct {
+	db *sql.DB
+}
+
+// Ensure ExchangeRepository implements ExchangeRepositoryInterface
+var _ ExchangeRepositoryInterface = &ExchangeRepository{}
+
+// NewExchangeRepository creates a new instance of ExchangeRepository
+func NewExchangeRepository(db *sql.DB) *ExchangeRepository {
+	return &ExchangeRepository{db: db}
+}
+
+// Begin a transaction
+func (repo *ExchangeRepository) Begin() (*sql.Tx, error) {
+	return repo.db.Begin()
+}
+
+// commit tx
+func (repo *ExchangeRepository) Commit(tx *sql.Tx) error {
+	return tx.Commit()
+}
+
+// rollback tx
+func (repo *ExchangeRepository) Rollback(tx *sql.Tx) error {
+	return tx.Rollback()
+}
+
+// CreateQuote inserts a new quote and sets its generated ID
+func (repo *ExchangeRepository) CreateQuote(quote *models.FXQuote) error {
+	query := `INSERT INTO fx_quotes (user_id, from_wallet_number, to_wallet_number, source_amount, source_currency,
+			  target_amount, target_currency, spread_amount, mid_rate, applied_rate, status, expires_at, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`
+
+	return repo.db.QueryRow(
+		query,
+		quote.UserID,
+		quote.FromWalletNumber,
+		quote.ToWalletNumber,
+		quote.SourceAmount,
+		quote.SourceCurrency,
+		quote.TargetAmount,
+		quote.TargetCurrency,
+		quote.SpreadAmount,
+		quote.MidRate,
+		quote.AppliedRate,
+		quote.Status,
+		quote.ExpiresAt,
+		quote.CreatedAt,
+	).Scan(&quote.ID)
+}
+
+// LockQuoteByID fetches a quote and locks its row until the DB transaction ends
+func (repo *ExchangeRepository) LockQuoteByID(tx *sql.Tx, quoteID int) (*models.FXQuote, error) {
+	query := `SELECT id, user_id, from_wallet_number, to_wallet_number, source_amount, source_currency,
+					 target_amount, target_currency, spread_amount, mid_rate, applied_rate, status,
+					 transaction_id, expires_at, created_at, executed_at
+			  FROM fx_quotes WHERE id = $1 FOR UPDATE`
+
+	var quote models.FXQuote
+	err := tx.QueryRow(query, quoteID).Scan(
+		&quote.ID,
+		&quote.UserID,
+		&quote.FromWalletNumber,
+		&quote.ToWalletNumber,
+		&quote.SourceAmount,
+		&quote.SourceCurrency,
+		&quote.TargetAmount,
+		&quote.TargetCurrency,
+		&quote.SpreadAmount,
+		&quote.MidRate,
+		&quote.AppliedRate,
+		&quote.Status,
+		&quote.TransactionID,
+		&quote.ExpiresAt,
+		&quote.CreatedAt,
+		&quote.ExecutedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.RepoErrQuoteNotFound
+		}
+		return nil, err
+	}
+	quote.SourceAmount = money.New(quote.SourceAmount.Amount, quote.SourceCurrency)
+	quote.SpreadAmount = money.New(quote.SpreadAmount.Amount, quote.SourceCurrency)
+	quote.TargetAmount = money.New(quote.TargetAmount.Amount, quote.TargetCurrency)
+	return &quote, nil
+}
+
+// UpdateQuote saves the outcome of executing a quote
+func (repo *ExchangeRepository) UpdateQuote(tx *sql.Tx, quote *models.FXQuote) error {
+	query := `UPDATE fx_quotes SET status = $1, transaction_id = $2, executed_at = $3
+			  WHERE id = $4`
+
+	result, err := tx.Exec(query, quote.Status, quote.TransactionID, quote.ExecutedAt, quote.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.RepoErrQuoteNotFound
+	}
+	return nil
+}
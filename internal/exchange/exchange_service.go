@@ -0,0 +1,172 @@
+package exchange
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"database/sql"
+	"time"
+)
+
+const (
+	StatusOpen     = "open"
+	StatusExecuted = "executed"
+
+	// QuoteTTL is how long a quoted rate can be executed for
+	QuoteTTL = 30 * time.Second
+)
+
+// ExchangeServiceInterface prices conversions between wallets of different currencies and executes them.
+// A quote locks the rate and spread; executing it within QuoteTTL moves the money at exactly that price.
+type ExchangeServiceInterface interface {
+	CreateQuote(userID int, fromWalletNumber, toWalletNumber string, amount money.Money) (*models.FXQuote, error)
+	ExecuteQuote(userID, quoteID int) (*models.FXQuote, *models.Wallet, error)
+}
+
+type ExchangeService struct {
+	exchangeRepo  ExchangeRepositoryInterface
+	walletRepo    wallet.WalletRepositoryInterface
+	ledgerService ledger.LedgerServiceInterface
+	converter     fx.ConverterInterface
+	now           func() time.Time
+}
+
+// Ensure ExchangeService implements ExchangeServiceInterface
+var _ ExchangeServiceInterface = &ExchangeService{}
+
+// NewExchangeService creates an ExchangeService. Executed quotes are posted through the ledger like cross-currency transfers.
+func NewExchangeService(exchangeRepo ExchangeRepositoryInterface, walletRepo wallet.WalletRepositoryInterface, ledgerService ledger.LedgerServiceInterface, converter fx.ConverterInterface) *ExchangeService {
+	return &ExchangeService{
+		exchangeRepo:  exchangeRepo,
+		walletRepo:    walletRepo,
+		ledgerService: ledgerService,
+		converter:     converter,
+		now:           time.Now,
+	}
+}
+
+// CreateQuote prices amount, debited from one of the user's wallets, in the currency of toWalletNumber.
+// An empty fromWalletNumber uses the user's default wallet. No funds are reserved until the quote is executed.
+func (s *ExchangeService) CreateQuote(userID int, fromWalletNumber, toWalletNumber string, amount money.Money) (*models.FXQuote, error) {
+	fromWallet, err := wallet.FindOwnedWallet(s.walletRepo, userID, fromWalletNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	toWallet, err := s.walletRepo.FindByWalletNumber(toWalletNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if toWallet.Currency == fromWallet.Currency {
+		return nil, utils.ServiceErrQuoteSameCurrency
+	}
+	if err := wallet.EnsureWalletCurrency(fromWallet, amount); err != nil {
+		return nil, err
+	}
+
+	conversion, err := wallet.PriceConversion(s.converter, amount, toWallet.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	quote := &models.FXQuote{
+		UserID:           userID,
+		FromWalletNumber: fromWallet.WalletNumber,
+		ToWalletNumber:   toWallet.WalletNumber,
+		SourceAmount:     conversion.Source,
+		SourceCurrency:   conversion.Source.Currency,
+		TargetAmount:     conversion.Target,
+		TargetCurrency:   conversion.Target.Currency,
+		SpreadAmount:     conversion.Spread,
+		MidRate:          conversion.MidRate,
+		AppliedRate:      conversion.AppliedRate,
+		Status:           StatusOpen,
+		ExpiresAt:        now.Add(QuoteTTL),
+		CreatedAt:        now,
+	}
+	if err := s.exchangeRepo.CreateQuote(quote); err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+// ExecuteQuote moves the quoted amounts between the wallets at the quoted rate and closes the quote.
+// It returns the executed quote and the user's updated source wallet.
+func (s *ExchangeService) ExecuteQuote(userID, quoteID int) (*models.FXQuote, *models.Wallet, error) {
+	tx, err := s.exchangeRepo.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer s.rollBackTxWhenErr(tx, &err)
+
+	// Lock the quote first so concurrent executions of the same quote run one after another
+	quote, err := s.exchangeRepo.LockQuoteByID(tx, quoteID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Quotes of other users are reported as not found so quote IDs cannot be probed
+	if quote.UserID != userID {
+		err = utils.RepoErrQuoteNotFound
+		return nil, nil, err
+	}
+	if quote.Status != StatusOpen {
+		err = utils.ServiceErrQuoteAlreadyExecuted
+		return nil, nil, err
+	}
+	if !quote.ExpiresAt.After(s.now()) {
+		err = utils.ServiceErrQuoteExpired
+		return nil, nil, err
+	}
+
+	fromWallet, err := s.walletRepo.FindByWalletNumber(quote.FromWalletNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	toWallet, err := s.walletRepo.FindByWalletNumber(quote.ToWalletNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lockedWallets, err := wallet.LockWalletsInOrder(s.walletRepo, tx, fromWallet.ID, toWallet.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = wallet.EnsureSufficientFunds(lockedWallets[fromWallet.ID].AvailableBalance(), quote.SourceAmount); err != nil {
+		return nil, nil, err
+	}
+
+	exchange, updatedWallet, _, err := s.ledgerService.Exchange(tx, quote.FromWalletNumber, quote.ToWalletNumber, quote.Conversion(), models.TransactionNote{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := s.now()
+	quote.Status = StatusExecuted
+	quote.TransactionID = &exchange.ID
+	quote.ExecutedAt = &now
+	if err = s.exchangeRepo.UpdateQuote(tx, quote); err != nil {
+		return nil, nil, err
+	}
+
+	err = s.exchangeRepo.Commit(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return quote, updatedWallet, nil
+}
+
+func (s *ExchangeService) rollBackTxWhenErr(tx *sql.Tx, err *error) {
+	if err != nil {
+		s.exchangeRepo.Rollback(tx)
+	}
+}
@@ -0,0 +1,266 @@
+package exchange
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	mockExchange "centralized-wallet/tests/mocks/exchange"
+	mockFx "centralized-wallet/tests/mocks/fx"
+	mockLedger "centralized-wallet/tests/mocks/ledger"
+	mockWallet "centralized-wallet/tests/mocks/wallet"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testUserID           = 1
+	testOtherUserID      = 2
+	testFromWalletNumber = "wallet123"
+	testToWalletNumber   = "wallet456"
+	testNow              = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func usd(value string) money.Money {
+	return money.MustParse(value, money.DefaultCurrency)
+}
+
+func eur(value string) money.Money {
+	return money.MustParse(value, "EUR")
+}
+
+type exchangeServiceMocks struct {
+	exchangeRepo  *mockExchange.MockExchangeRepository
+	walletRepo    *mockWallet.MockWalletRepository
+	ledgerService *mockLedger.MockLedgerService
+	converter     *mockFx.MockConverter
+}
+
+func setupExchangeServiceMock() (*ExchangeService, exchangeServiceMocks) {
+	mocks := exchangeServiceMocks{
+		exchangeRepo:  new(mockExchange.MockExchangeRepository),
+		walletRepo:    new(mockWallet.MockWalletRepository),
+		ledgerService: new(mockLedger.MockLedgerService),
+		converter:     new(mockFx.MockConverter),
+	}
+	service := NewExchangeService(mocks.exchangeRepo, mocks.walletRepo, mocks.ledgerService, mocks.converter)
+	service.now = func() time.Time { return testNow }
+	return service, mocks
+}
+
+func usdWallet() *models.Wallet {
+	return &models.Wallet{ID: 1, UserID: testUserID, WalletNumber: testFromWalletNumber, Currency: "USD", Balance: usd("100.00"), HeldBalance: usd("30.00")}
+}
+
+func eurWallet() *models.Wallet {
+	return &models.Wallet{ID: 2, UserID: testOtherUserID, WalletNumber: testToWalletNumber, Currency: "EUR", Balance: eur("10.00")}
+}
+
+func testConversion() *fx.Conversion {
+	return &fx.Conversion{
+		Source:      usd("50.00"),
+		Target:      eur("45.77"),
+		Spread:      usd("0.25"),
+		MidRate:     fx.MustParseRate("0.92"),
+		AppliedRate: fx.MustParseRate("0.9154"),
+	}
+}
+
+func openQuote() *models.FXQuote {
+	conversion := testConversion()
+	return &models.FXQuote{
+		ID:               5,
+		UserID:           testUserID,
+		FromWalletNumber: testFromWalletNumber,
+		ToWalletNumber:   testToWalletNumber,
+		SourceAmount:     conversion.Source,
+		SourceCurrency:   "USD",
+		TargetAmount:     conversion.Target,
+		TargetCurrency:   "EUR",
+		SpreadAmount:     conversion.Spread,
+		MidRate:          conversion.MidRate,
+		AppliedRate:      conversion.AppliedRate,
+		Status:           StatusOpen,
+		ExpiresAt:        testNow.Add(10 * time.Second),
+		CreatedAt:        testNow.Add(-20 * time.Second),
+	}
+}
+
+func TestCreateQuoteService(t *testing.T) {
+	testCases := []struct {
+		name          string
+		amount        money.Money
+		mockSetup     func(m exchangeServiceMocks)
+		expectedError error
+	}{
+		{
+			name:   "prices the conversion and stores an open quote",
+			amount: usd("50.00"),
+			mockSetup: func(m exchangeServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(eurWallet(), nil)
+				m.converter.On("Convert", usd("50.00"), "EUR").Return(testConversion(), nil)
+				m.exchangeRepo.On("CreateQuote", mock.MatchedBy(func(q *models.FXQuote) bool {
+					return q.UserID == testUserID && q.Status == StatusOpen && q.ExpiresAt.Equal(testNow.Add(QuoteTTL))
+				})).Return(nil)
+			},
+		},
+		{
+			name:   "wallets in the same currency",
+			amount: usd("50.00"),
+			mockSetup: func(m exchangeServiceMocks) {
+				dollarWallet := eurWallet()
+				dollarWallet.Currency = "USD"
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(dollarWallet, nil)
+			},
+			expectedError: utils.ServiceErrQuoteSameCurrency,
+		},
+		{
+			name:   "amount not in the source wallet currency",
+			amount: eur("50.00"),
+			mockSetup: func(m exchangeServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(eurWallet(), nil)
+			},
+			expectedError: utils.ServiceErrCurrencyMismatch,
+		},
+		{
+			name:   "no rate for the pair",
+			amount: usd("50.00"),
+			mockSetup: func(m exchangeServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(eurWallet(), nil)
+				m.converter.On("Convert", usd("50.00"), "EUR").Return(nil, fx.ErrRateNotFound)
+			},
+			expectedError: utils.ServiceErrNoConversionPath,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, m := setupExchangeServiceMock()
+			tc.mockSetup(m)
+
+			quote, err := service.CreateQuote(testUserID, "", testToWalletNumber, tc.amount)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, quote)
+				m.exchangeRepo.AssertNotCalled(t, "CreateQuote", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, usd("50.00"), quote.SourceAmount)
+				assert.Equal(t, eur("45.77"), quote.TargetAmount)
+				assert.Equal(t, usd("0.25"), quote.SpreadAmount)
+			}
+			m.exchangeRepo.AssertExpectations(t)
+			m.walletRepo.AssertExpectations(t)
+			m.converter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExecuteQuoteService(t *testing.T) {
+	// expectLockedQuote mocks opening the DB transaction and locking the quote
+	expectLockedQuote := func(m exchangeServiceMocks, quote *models.FXQuote) {
+		m.exchangeRepo.On("Begin").Return(nil, nil)
+		m.exchangeRepo.On("LockQuoteByID", mock.AnythingOfType("*sql.Tx"), 5).Return(quote, nil)
+		m.exchangeRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+	}
+
+	// expectLockedWallets mocks locking both wallets of the quote
+	expectLockedWallets := func(m exchangeServiceMocks, from *models.Wallet) {
+		m.walletRepo.On("FindByWalletNumber", testFromWalletNumber).Return(usdWallet(), nil)
+		m.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(eurWallet(), nil)
+		m.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 1).Return(from, nil)
+		m.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 2).Return(eurWallet(), nil)
+	}
+
+	testCases := []struct {
+		name          string
+		userID        int
+		mockSetup     func(m exchangeServiceMocks)
+		expectedError error
+	}{
+		{
+			name:   "moves the quoted amounts and closes the quote",
+			userID: testUserID,
+			mockSetup: func(m exchangeServiceMocks) {
+				expectLockedQuote(m, openQuote())
+				expectLockedWallets(m, usdWallet())
+				m.ledgerService.On("Exchange", mock.AnythingOfType("*sql.Tx"), testFromWalletNumber, testToWalletNumber, testConversion(), models.TransactionNote{}).
+					Return(&models.Transaction{ID: 42}, usdWallet(), eurWallet(), nil)
+				m.exchangeRepo.On("UpdateQuote", mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(q *models.FXQuote) bool {
+					return q.Status == StatusExecuted && *q.TransactionID == 42 && q.ExecutedAt.Equal(testNow)
+				})).Return(nil)
+				m.exchangeRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+			},
+		},
+		{
+			name:   "quote of another user",
+			userID: testOtherUserID,
+			mockSetup: func(m exchangeServiceMocks) {
+				expectLockedQuote(m, openQuote())
+			},
+			expectedError: utils.RepoErrQuoteNotFound,
+		},
+		{
+			name:   "quote already executed",
+			userID: testUserID,
+			mockSetup: func(m exchangeServiceMocks) {
+				executed := openQuote()
+				executed.Status = StatusExecuted
+				expectLockedQuote(m, executed)
+			},
+			expectedError: utils.ServiceErrQuoteAlreadyExecuted,
+		},
+		{
+			name:   "quote expired",
+			userID: testUserID,
+			mockSetup: func(m exchangeServiceMocks) {
+				expired := openQuote()
+				expired.ExpiresAt = testNow
+				expectLockedQuote(m, expired)
+			},
+			expectedError: utils.ServiceErrQuoteExpired,
+		},
+		{
+			name:   "funds on hold are not available",
+			userID: testUserID,
+			mockSetup: func(m exchangeServiceMocks) {
+				expectLockedQuote(m, openQuote())
+				lowFunds := usdWallet()
+				lowFunds.HeldBalance = usd("60.00")
+				expectLockedWallets(m, lowFunds)
+			},
+			expectedError: utils.RepoErrInsufficientFunds,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, m := setupExchangeServiceMock()
+			tc.mockSetup(m)
+
+			quote, wallet, err := service.ExecuteQuote(tc.userID, 5)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, quote)
+				m.ledgerService.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				m.exchangeRepo.AssertNotCalled(t, "Commit", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, StatusExecuted, quote.Status)
+				assert.Equal(t, testFromWalletNumber, wallet.WalletNumber)
+			}
+			m.exchangeRepo.AssertExpectations(t)
+			m.walletRepo.AssertExpectations(t)
+			m.ledgerService.AssertExpectations(t)
+		})
+	}
+}
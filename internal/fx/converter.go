@@ -0,0 +1,115 @@
+package fx
+
+import (
+	"centralized-wallet/internal/money"
+	"errors"
+	"math/big"
+)
+
+// DefaultSpreadBps is the margin taken on conversions, in basis points of the source amount (50 = 0.5%)
+const DefaultSpreadBps = 50
+
+var (
+	ErrSameCurrency   = errors.New("source and target currency are the same")
+	ErrAmountTooSmall = errors.New("amount is too small to convert")
+)
+
+// Conversion is the priced exchange of an amount into another currency
+type Conversion struct {
+	Source      money.Money // Debited from the source wallet, spread included
+	Target      money.Money // Credited to the destination wallet
+	Spread      money.Money // Platform revenue, in the source currency
+	MidRate     Rate        // Rate given by the provider
+	AppliedRate Rate        // Target per unit of source once the spread is taken
+}
+
+// ConverterInterface prices conversions between currencies
+type ConverterInterface interface {
+	Convert(source money.Money, toCurrency string) (*Conversion, error)
+}
+
+// Converter prices conversions at the provider's mid rate minus a fixed spread
+type Converter struct {
+	rates     RateProvider
+	spreadBps int64
+}
+
+// Ensure Converter implements ConverterInterface
+var _ ConverterInterface = &Converter{}
+
+// NewConverter creates a Converter taking spreadBps of every converted amount
+func NewConverter(rates RateProvider, spreadBps int64) *Converter {
+	return &Converter{rates: rates, spreadBps: spreadBps}
+}
+
+// Convert prices source in toCurrency. The spread is taken from the source amount, rounded half up,
+// and the rest is converted at the mid rate rounded down, so the platform never pays out more than it receives.
+func (c *Converter) Convert(source money.Money, toCurrency string) (*Conversion, error) {
+	toCurrency = money.NormalizeCurrency(toCurrency)
+	if source.Currency == toCurrency {
+		return nil, ErrSameCurrency
+	}
+
+	sourceExp, err := money.Exponent(source.Currency)
+	if err != nil {
+		return nil, err
+	}
+	targetExp, err := money.Exponent(toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	mid, err := c.rates.GetRate(source.Currency, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	spread := mulDivHalfUp(source.Amount, c.spreadBps, 10_000)
+	net := source.Amount - spread
+
+	// target = net * rate * 10^targetExp / (10^8 * 10^sourceExp), rounded down
+	rateUnit := new(big.Int).Mul(big.NewInt(rateScale), pow10(sourceExp))
+	target, err := mulDiv(big.NewInt(net), new(big.Int).Mul(big.NewInt(mid.Units), pow10(targetExp)), rateUnit)
+	if err != nil {
+		return nil, err
+	}
+	if target <= 0 {
+		return nil, ErrAmountTooSmall
+	}
+
+	applied, err := mulDiv(big.NewInt(target), rateUnit, new(big.Int).Mul(big.NewInt(source.Amount), pow10(targetExp)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conversion{
+		Source:      source,
+		Target:      money.New(target, toCurrency),
+		Spread:      money.New(spread, source.Currency),
+		MidRate:     mid,
+		AppliedRate: Rate{Units: applied},
+	}, nil
+}
+
+// mulDiv returns a * b / c rounded down. Every operand is a big.Int, so nothing overflows on the way
+// and money.ErrAmountOverflow is returned when the result does not fit in an int64.
+func mulDiv(a, b, c *big.Int) (int64, error) {
+	result := new(big.Int).Mul(a, b)
+	result.Quo(result, c)
+	if !result.IsInt64() {
+		return 0, money.ErrAmountOverflow
+	}
+	return result.Int64(), nil
+}
+
+// mulDivHalfUp returns a * b / c rounded half up, for positive operands
+func mulDivHalfUp(a, b, c int64) int64 {
+	result := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	result.Mul(result, big.NewInt(2)).Add(result, big.NewInt(c))
+	return result.Quo(result, big.NewInt(2*c)).Int64()
+}
+
+// pow10 returns 10^n as a big.Int
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,131 @@
+package fx
+
+import (
+	"centralized-wallet/internal/money"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticRates is a RateProvider backed by a fixed map of "FROM/TO" pairs
+type staticRates map[string]Rate
+
+func (s staticRates) GetRate(from, to string) (Rate, error) {
+	rate, ok := s[from+"/"+to]
+	if !ok {
+		return Rate{}, ErrRateNotFound
+	}
+	return rate, nil
+}
+
+func TestParseRate(t *testing.T) {
+	assert.Equal(t, Rate{Units: 92_000_000}, MustParseRate("0.92"))
+	assert.Equal(t, Rate{Units: 15_012_345_678}, MustParseRate("150.123456789"))
+	assert.Equal(t, "0.92", MustParseRate("0.920").String())
+	assert.Equal(t, "150", MustParseRate("150").String())
+
+	for _, invalid := range []string{"", "0", "-1", "1e3", "abc", ".5"} {
+		_, err := ParseRate(invalid)
+		assert.ErrorIs(t, err, ErrInvalidRate, invalid)
+	}
+}
+
+func TestRateInvertAndMul(t *testing.T) {
+	assert.Equal(t, "0.5", MustParseRate("2").Invert().String())
+	assert.Equal(t, "0.00666666", MustParseRate("150").Invert().String())
+	assert.Equal(t, "138", MustParseRate("0.92").Mul(MustParseRate("150")).String())
+}
+
+func TestConvert(t *testing.T) {
+	converter := NewConverter(staticRates{
+		"USD/EUR": MustParseRate("0.92"),
+		"USD/JPY": MustParseRate("150"),
+		"JPY/BHD": MustParseRate("0.0025"),
+		"JPY/USD": MustParseRate("0.0066"),
+	}, DefaultSpreadBps)
+
+	testCases := []struct {
+		name           string
+		source         money.Money
+		to             string
+		expectedTarget money.Money
+		expectedSpread money.Money
+		expectedRate   string
+		expectedError  error
+	}{
+		{
+			name:           "two-decimal currencies",
+			source:         money.MustParse("100.00", "USD"),
+			to:             "EUR",
+			expectedTarget: money.MustParse("91.54", "EUR"), // 99.50 * 0.92 = 91.54
+			expectedSpread: money.MustParse("0.50", "USD"),
+			expectedRate:   "0.9154",
+		},
+		{
+			name:           "into a zero-decimal currency rounds down",
+			source:         money.MustParse("10.01", "USD"),
+			to:             "jpy",
+			expectedTarget: money.MustParse("1494", "JPY"), // 9.96 * 150 = 1494
+			expectedSpread: money.MustParse("0.05", "USD"),
+			expectedRate:   "149.25074925",
+		},
+		{
+			name:           "from a zero-decimal into a three-decimal currency",
+			source:         money.MustParse("1000", "JPY"),
+			to:             "BHD",
+			expectedTarget: money.MustParse("2.487", "BHD"), // 995 * 0.0025 = 2.4875
+			expectedSpread: money.MustParse("5", "JPY"),
+			expectedRate:   "0.002487",
+		},
+		{
+			name:          "same currency",
+			source:        money.MustParse("1.00", "USD"),
+			to:            "USD",
+			expectedError: ErrSameCurrency,
+		},
+		{
+			name:          "no rate for the pair",
+			source:        money.MustParse("1.00", "EUR"),
+			to:            "GBP",
+			expectedError: ErrRateNotFound,
+		},
+		{
+			name:          "amount too small",
+			source:        money.MustParse("1", "JPY"),
+			to:            "USD",
+			expectedError: ErrAmountTooSmall,
+		},
+		{
+			name:           "largest amount into a currency with more decimals",
+			source:         money.New(math.MaxInt64, "JPY"),
+			to:             "USD",
+			expectedTarget: money.New(6_056_988_416_602_531_272, "USD"),
+			expectedSpread: money.New(46_116_860_184_273_879, "JPY"),
+			expectedRate:   "0.00656699",
+		},
+		{
+			name:          "target amount overflows",
+			source:        money.New(math.MaxInt64, "USD"),
+			to:            "JPY",
+			expectedError: money.ErrAmountOverflow,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conversion, err := converter.Convert(tc.source, tc.to)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, conversion)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.source, conversion.Source)
+			assert.Equal(t, tc.expectedTarget, conversion.Target)
+			assert.Equal(t, tc.expectedSpread, conversion.Spread)
+			assert.Equal(t, tc.expectedRate, conversion.AppliedRate.String())
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package fx
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RateDecimals is the precision exchange rates are kept at, matching the NUMERIC(20,8) columns
+const RateDecimals = 8
+
+// rateScale is 10^RateDecimals
+const rateScale = 100_000_000
+
+var (
+	ErrInvalidRate  = errors.New("invalid exchange rate")
+	ErrRateNotFound = errors.New("no exchange rate for the currency pair")
+)
+
+// Rate is an exchange rate in units of the quote currency per unit of the base currency,
+// kept as an integer number of 10^-8 steps so conversions are exact
+type Rate struct {
+	Units int64
+}
+
+// ParseRate converts a decimal string such as "0.92" into a Rate.
+// Decimals beyond RateDecimals are truncated.
+func ParseRate(value string) (Rate, error) {
+	value = strings.TrimSpace(value)
+	intPart, fracPart, _ := strings.Cut(value, ".")
+	if intPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Rate{}, ErrInvalidRate
+	}
+	if len(fracPart) > RateDecimals {
+		fracPart = fracPart[:RateDecimals]
+	}
+	fracPart += strings.Repeat("0", RateDecimals-len(fracPart))
+
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil || units <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{Units: units}, nil
+}
+
+// MustParseRate is like ParseRate but panics on error. Intended for seeds and tests.
+func MustParseRate(value string) Rate {
+	r, err := ParseRate(value)
+	if err != nil {
+		panic(fmt.Sprintf("fx: cannot parse rate %q: %v", value, err))
+	}
+	return r
+}
+
+// Invert returns the rate of the opposite direction, truncated to RateDecimals
+func (r Rate) Invert() Rate {
+	if r.Units <= 0 {
+		return Rate{}
+	}
+	return Rate{Units: rateScale * rateScale / r.Units}
+}
+
+// Mul chains two rates, e.g. EUR->USD and USD->JPY into EUR->JPY, truncated to RateDecimals
+func (r Rate) Mul(other Rate) Rate {
+	product := new(big.Int).Mul(big.NewInt(r.Units), big.NewInt(other.Units))
+	product.Quo(product, big.NewInt(rateScale))
+	return Rate{Units: product.Int64()}
+}
+
+// String renders the rate with trailing zeros removed, e.g. "0.92"
+func (r Rate) String() string {
+	s := fmt.Sprintf("%d.%0*d", r.Units/rateScale, RateDecimals, r.Units%rateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON encodes the rate as a JSON number
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or numeric string
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseRate(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC rate columns
+func (r *Rate) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case float64:
+		text = strconv.FormatFloat(v, 'f', RateDecimals, 64)
+	default:
+		return fmt.Errorf("fx: cannot scan %T into Rate", src)
+	}
+
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, storing the rate as its decimal text
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package fx
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// PivotCurrency is used to cross two currencies that have no direct rate between them
+const PivotCurrency = "USD"
+
+// RateProvider returns the mid-market rate to convert one currency into another
+type RateProvider interface {
+	GetRate(from, to string) (Rate, error)
+}
+
+// DBRateProvider reads rates from the fx_rates table, so rates can be maintained without a
+// network dependency and the service runs offline
+type DBRateProvider struct {
+	db *sql.DB
+}
+
+// Ensure DBRateProvider implements RateProvider
+var _ RateProvider = &DBRateProvider{}
+
+// NewDBRateProvider creates a new instance of DBRateProvider
+func NewDBRateProvider(db *sql.DB) *DBRateProvider {
+	return &DBRateProvider{db: db}
+}
+
+// GetRate looks up the direct pair first, then the inverse pair, then crosses both currencies through PivotCurrency
+func (p *DBRateProvider) GetRate(from, to string) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return Rate{Units: rateScale}, nil
+	}
+
+	rate, err := p.pairRate(from, to)
+	if err != ErrRateNotFound || from == PivotCurrency || to == PivotCurrency {
+		return rate, err
+	}
+
+	toPivot, err := p.pairRate(from, PivotCurrency)
+	if err != nil {
+		return Rate{}, err
+	}
+	fromPivot, err := p.pairRate(PivotCurrency, to)
+	if err != nil {
+		return Rate{}, err
+	}
+	return toPivot.Mul(fromPivot), nil
+}
+
+// pairRate returns the stored rate of the pair, inverting the opposite pair when only that one is stored
+func (p *DBRateProvider) pairRate(from, to string) (Rate, error) {
+	var rate Rate
+	err := p.db.QueryRow("SELECT rate FROM fx_rates WHERE base_currency = $1 AND quote_currency = $2", from, to).Scan(&rate)
+	if err == nil {
+		return rate, nil
+	}
+	if err != sql.ErrNoRows {
+		return Rate{}, err
+	}
+
+	err = p.db.QueryRow("SELECT rate FROM fx_rates WHERE base_currency = $1 AND quote_currency = $2", to, from).Scan(&rate)
+	if err == sql.ErrNoRows {
+		return Rate{}, ErrRateNotFound
+	}
+	if err != nil {
+		return Rate{}, err
+	}
+	return rate.Invert(), nil
+}
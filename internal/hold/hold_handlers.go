@@ -0,0 +1,188 @@
+package hold
+
+import (
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateHoldHandler reserves funds on one of the authenticated user's wallets for another wallet.
+// wallet_number is optional and defaults to the user's default wallet.
+// expires_in is optional and given in seconds; without it the hold lasts DefaultHoldTTL.
+func CreateHoldHandler(hs HoldServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		var request struct {
+			WalletNumber   string      `json:"wallet_number"`
+			ToWalletNumber string      `json:"to_wallet_number" binding:"required"`
+			Amount         json.Number `json:"amount" binding:"required"`
+			Currency       string      `json:"currency"`
+			ExpiresIn      *int64      `json:"expires_in"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+
+		amount, ok := wallet.ParseAmount(c, request.Amount, request.Currency)
+		if !ok {
+			return
+		}
+
+		var ttl time.Duration
+		if request.ExpiresIn != nil {
+			if *request.ExpiresIn <= 0 || *request.ExpiresIn > int64(MaxHoldTTL/time.Second) {
+				utils.ErrorResponse(c, utils.ErrInvalidHoldExpiry, nil, "")
+				return
+			}
+			ttl = time.Duration(*request.ExpiresIn) * time.Second
+		}
+
+		hold, err := hs.CreateHold(userID.(int), request.WalletNumber, request.ToWalletNumber, amount, ttl)
+		if err != nil {
+			switch err {
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			case utils.RepoErrInsufficientFunds:
+				utils.ErrorResponse(c, utils.ErrorInsufficientFunds, nil, "")
+			case utils.ServiceErrHoldOnOwnWallet:
+				utils.ErrorResponse(c, utils.ErrHoldOnOwnWallet, nil, "")
+			case utils.ServiceErrInvalidHoldExpiry:
+				utils.ErrorResponse(c, utils.ErrInvalidHoldExpiry, nil, "")
+			case utils.ServiceErrCurrencyMismatch:
+				utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+			case utils.ServiceErrNoConversionPath:
+				utils.ErrorResponse(c, utils.ErrNoConversionPath, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[CreateHoldHandler] Error creating hold")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgHoldCreated, gin.H{"hold": hold})
+	}
+}
+
+// CaptureHoldHandler transfers held funds to the authenticated user's wallet.
+// The amount is optional; without it the whole hold is captured. Any remainder is released.
+func CaptureHoldHandler(hs HoldServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		holdID, ok := parseHoldID(c)
+		if !ok {
+			return
+		}
+
+		// An empty body is allowed and means a full capture
+		var request struct {
+			Amount   json.Number `json:"amount"`
+			Currency string      `json:"currency"`
+		}
+		if c.Request.Body != nil && c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+				utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+				return
+			}
+		}
+
+		var amount *money.Money
+		if request.Amount != "" {
+			parsed, ok := wallet.ParseAmount(c, request.Amount, request.Currency)
+			if !ok {
+				return
+			}
+			amount = &parsed
+		}
+
+		hold, updatedWallet, err := hs.CaptureHold(userID.(int), holdID, amount)
+		if err != nil {
+			respondHoldError(c, err, "[CaptureHoldHandler] Error capturing hold")
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgHoldCaptured, gin.H{
+			"hold":              hold,
+			"balance":           updatedWallet.Balance,
+			"available_balance": updatedWallet.AvailableBalance(),
+			"updated_at":        updatedWallet.UpdatedAt,
+		})
+	}
+}
+
+// ReleaseHoldHandler cancels a hold made for the authenticated user's wallet, giving the funds back to the payer
+func ReleaseHoldHandler(hs HoldServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		holdID, ok := parseHoldID(c)
+		if !ok {
+			return
+		}
+
+		hold, err := hs.ReleaseHold(userID.(int), holdID)
+		if err != nil {
+			respondHoldError(c, err, "[ReleaseHoldHandler] Error releasing hold")
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgHoldReleased, gin.H{"hold": hold})
+	}
+}
+
+// parseHoldID reads the hold ID from the path, writing the error response when it is invalid
+func parseHoldID(c *gin.Context) (int, bool) {
+	holdID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || holdID <= 0 {
+		utils.ErrorResponse(c, utils.ErrInvalidHoldID, nil, "")
+		return 0, false
+	}
+	return holdID, true
+}
+
+// respondHoldError maps the errors shared by capture and release to their responses
+func respondHoldError(c *gin.Context, err error, logMessage string) {
+	switch err {
+	case utils.RepoErrWalletNotFound:
+		utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+	case utils.RepoErrHoldNotFound:
+		utils.ErrorResponse(c, utils.ErrHoldNotFound, nil, "")
+	case utils.ServiceErrHoldActionNotAllowed:
+		utils.ErrorResponse(c, utils.ErrHoldActionNotAllowed, nil, "")
+	case utils.ServiceErrHoldNotActive:
+		utils.ErrorResponse(c, utils.ErrHoldNotActive, nil, "")
+	case utils.ServiceErrHoldExpired:
+		utils.ErrorResponse(c, utils.ErrHoldExpired, nil, "")
+	case utils.ServiceErrCaptureExceedsHold:
+		utils.ErrorResponse(c, utils.ErrCaptureExceedsHold, nil, "")
+	case utils.ServiceErrCurrencyMismatch:
+		utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+	case utils.RepoErrInsufficientFunds:
+		utils.ErrorResponse(c, utils.ErrorInsufficientFunds, nil, "")
+	default:
+		utils.ErrorResponse(c, utils.ErrInternalServerError, err, logMessage)
+	}
+}
@@ -0,0 +1,237 @@
+package hold
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	mockHold "centralized-wallet/tests/mocks/hold"
+	"centralized-wallet/tests/testutils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupHoldHandlerRouter(holdService *mockHold.MockHoldService) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	token, _ := auth.GenerateJWT(testPayeeUserID)
+	blacklistService := new(mockAuth.MockBlacklistService)
+	blacklistService.On("IsTokenBlacklisted", token).Return(false, nil)
+	accountChecker := new(mockAuth.MockAccountChecker)
+	accountChecker.On("CurrentTokenVersion", testPayeeUserID).Return(1, nil)
+
+	walletRoutes := router.Group("/wallets")
+	walletRoutes.Use(auth.JWTMiddleware(blacklistService, new(mockAuth.MockSessionService), accountChecker))
+	{
+		walletRoutes.POST("/holds", CreateHoldHandler(holdService))
+		walletRoutes.POST("/holds/:id/capture", CaptureHoldHandler(holdService))
+		walletRoutes.POST("/holds/:id/release", ReleaseHoldHandler(holdService))
+	}
+	return router, token
+}
+
+func TestCreateHoldHandler(t *testing.T) {
+	hold := activeHold()
+
+	testCases := []testutils.BaseHandlerTestCase{
+		{
+			Name:     "Hold with default expiry",
+			TestType: "success",
+			Body:     map[string]interface{}{"to_wallet_number": testPayerWalletNumber, "amount": 50.0},
+			MockSetup: func() {
+				mockHoldService.On("CreateHold", testPayeeUserID, "", testPayerWalletNumber, usd("50.00"), time.Duration(0)).Return(hold, nil)
+			},
+			ExpectedMessage: utils.MsgHoldCreated,
+			ExpectedEntity:  gin.H{"hold": hold},
+		},
+		{
+			Name:     "Hold with explicit expiry",
+			TestType: "success",
+			Body:     map[string]interface{}{"to_wallet_number": testPayerWalletNumber, "amount": 50.0, "expires_in": 3600},
+			MockSetup: func() {
+				mockHoldService.On("CreateHold", testPayeeUserID, "", testPayerWalletNumber, usd("50.00"), time.Hour).Return(hold, nil)
+			},
+			ExpectedMessage: utils.MsgHoldCreated,
+			ExpectedEntity:  gin.H{"hold": hold},
+		},
+		{
+			Name:     "Hold from a named wallet",
+			TestType: "success",
+			Body:     map[string]interface{}{"wallet_number": testPayeeWalletNumber, "to_wallet_number": testPayerWalletNumber, "amount": 50.0},
+			MockSetup: func() {
+				mockHoldService.On("CreateHold", testPayeeUserID, testPayeeWalletNumber, testPayerWalletNumber, usd("50.00"), time.Duration(0)).Return(hold, nil)
+			},
+			ExpectedMessage: utils.MsgHoldCreated,
+			ExpectedEntity:  gin.H{"hold": hold},
+		},
+		{
+			Name:                  "Expiry out of range",
+			TestType:              "error",
+			Body:                  map[string]interface{}{"to_wallet_number": testPayerWalletNumber, "amount": 50.0, "expires_in": 0},
+			MockSetup:             func() {},
+			ExpectedResponseError: utils.ErrInvalidHoldExpiry,
+		},
+		{
+			Name:     "Insufficient available funds",
+			TestType: "error",
+			Body:     map[string]interface{}{"to_wallet_number": testPayerWalletNumber, "amount": 50.0},
+			MockSetup: func() {
+				mockHoldService.On("CreateHold", testPayeeUserID, "", testPayerWalletNumber, usd("50.00"), time.Duration(0)).Return(nil, utils.RepoErrInsufficientFunds)
+			},
+			ExpectedResponseError: utils.ErrorInsufficientFunds,
+		},
+		{
+			Name:     "Hold for own wallet",
+			TestType: "error",
+			Body:     map[string]interface{}{"to_wallet_number": testPayerWalletNumber, "amount": 50.0},
+			MockSetup: func() {
+				mockHoldService.On("CreateHold", testPayeeUserID, "", testPayerWalletNumber, usd("50.00"), time.Duration(0)).Return(nil, utils.ServiceErrHoldOnOwnWallet)
+			},
+			ExpectedResponseError: utils.ErrHoldOnOwnWallet,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			holdHandlerTestFlow(t, tc, http.MethodPost, "/wallets/holds")
+		})
+	}
+}
+
+func TestCaptureHoldHandler(t *testing.T) {
+	partial := usd("20.00")
+	captured := activeHold()
+	captured.Status = StatusCaptured
+	captured.CapturedAmount = partial
+	wallet := &models.Wallet{Balance: usd("30.00"), HeldBalance: usd("5.00"), UpdatedAt: testNow}
+
+	testCases := []testutils.BaseHandlerTestCase{
+		{
+			Name:     "Full capture without a body",
+			TestType: "success",
+			URL:      "/wallets/holds/9/capture",
+			MockSetup: func() {
+				mockHoldService.On("CaptureHold", testPayeeUserID, 9, (*money.Money)(nil)).Return(captured, wallet, nil)
+			},
+			ExpectedMessage: utils.MsgHoldCaptured,
+			ExpectedEntity: gin.H{
+				"hold":              captured,
+				"balance":           30.0,
+				"available_balance": 25.0,
+				"updated_at":        testNow.Format(time.RFC3339Nano),
+			},
+		},
+		{
+			Name:     "Partial capture",
+			TestType: "success",
+			URL:      "/wallets/holds/9/capture",
+			Body:     map[string]interface{}{"amount": 20.0},
+			MockSetup: func() {
+				mockHoldService.On("CaptureHold", testPayeeUserID, 9, &partial).Return(captured, wallet, nil)
+			},
+			ExpectedMessage: utils.MsgHoldCaptured,
+			ExpectedEntity: gin.H{
+				"hold":              captured,
+				"balance":           30.0,
+				"available_balance": 25.0,
+				"updated_at":        testNow.Format(time.RFC3339Nano),
+			},
+		},
+		{
+			Name:                  "Invalid hold ID",
+			TestType:              "error",
+			URL:                   "/wallets/holds/abc/capture",
+			MockSetup:             func() {},
+			ExpectedResponseError: utils.ErrInvalidHoldID,
+		},
+		{
+			Name:     "Hold expired",
+			TestType: "error",
+			URL:      "/wallets/holds/9/capture",
+			MockSetup: func() {
+				mockHoldService.On("CaptureHold", testPayeeUserID, 9, (*money.Money)(nil)).Return(nil, nil, utils.ServiceErrHoldExpired)
+			},
+			ExpectedResponseError: utils.ErrHoldExpired,
+		},
+		{
+			Name:     "Payer tries to capture",
+			TestType: "error",
+			URL:      "/wallets/holds/9/capture",
+			MockSetup: func() {
+				mockHoldService.On("CaptureHold", testPayeeUserID, 9, (*money.Money)(nil)).Return(nil, nil, utils.ServiceErrHoldActionNotAllowed)
+			},
+			ExpectedResponseError: utils.ErrHoldActionNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			holdHandlerTestFlow(t, tc, http.MethodPost, tc.URL)
+		})
+	}
+}
+
+func TestReleaseHoldHandler(t *testing.T) {
+	released := activeHold()
+	released.Status = StatusReleased
+
+	testCases := []testutils.BaseHandlerTestCase{
+		{
+			Name:     "Release",
+			TestType: "success",
+			MockSetup: func() {
+				mockHoldService.On("ReleaseHold", testPayeeUserID, 9).Return(released, nil)
+			},
+			ExpectedMessage: utils.MsgHoldReleased,
+			ExpectedEntity:  gin.H{"hold": released},
+		},
+		{
+			Name:     "Hold already captured",
+			TestType: "error",
+			MockSetup: func() {
+				mockHoldService.On("ReleaseHold", testPayeeUserID, 9).Return(nil, utils.ServiceErrHoldNotActive)
+			},
+			ExpectedResponseError: utils.ErrHoldNotActive,
+		},
+		{
+			Name:     "Hold not found",
+			TestType: "error",
+			MockSetup: func() {
+				mockHoldService.On("ReleaseHold", testPayeeUserID, 9).Return(nil, utils.RepoErrHoldNotFound)
+			},
+			ExpectedResponseError: utils.ErrHoldNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			holdHandlerTestFlow(t, tc, http.MethodPost, "/wallets/holds/9/release")
+		})
+	}
+}
+
+var mockHoldService *mockHold.MockHoldService
+
+func holdHandlerTestFlow(t *testing.T, tc testutils.BaseHandlerTestCase, method, url string) {
+	mockHoldService = new(mockHold.MockHoldService)
+	router, token := setupHoldHandlerRouter(mockHoldService)
+	tc.MockSetup()
+
+	var body interface{}
+	if tc.Body != nil {
+		body = tc.Body
+	}
+	w := testutils.ExecuteRequest(router, method, url, body, token)
+
+	if tc.TestType == "success" {
+		testutils.AssertAPISuccessResponse(t, w, tc.ExpectedMessage, tc.ExpectedEntity)
+	} else {
+		testutils.AssertAPIErrorResponse(t, w, tc.ExpectedResponseError)
+	}
+	mockHoldService.AssertExpectations(t)
+}
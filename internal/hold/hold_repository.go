@@ -0,0 +1,161 @@
+package hold
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+	"time"
+)
+
+// HoldRepositoryInterface defines the methods for persisting holds and the funds they reserve
+type HoldRepositoryInterface interface {
+	Begin() (*sql.Tx, error)
+	Commit(tx *sql.Tx) error
+	Rollback(tx *sql.Tx) error
+	CreateHold(tx *sql.Tx, hold *models.Hold) error
+	LockHoldByID(tx *sql.Tx, holdID int) (*models.Hold, error)
+	UpdateHold(tx *sql.Tx, hold *models.Hold) error
+	AdjustHeldBalance(tx *sql.Tx, walletNumber string, delta money.Money) error
+	FindExpiredHoldIDs(before time.Time) ([]int, error)
+}
+
+type HoldRepository struct {
+	db *sql.DB
+}
+
+// Ensure HoldRepository implements HoldRepositoryInterface
+var _ HoldRepositoryInterface = &HoldRepository{}
+
+// NewHoldRepository creates a new instance of HoldRepository
+func NewHoldRepository(db *sql.DB) *HoldRepository {
+	return &HoldRepository{db: db}
+}
+
+// Begin a transaction
+func (repo *HoldRepository) Begin() (*sql.Tx, error) {
+	return repo.db.Begin()
+}
+
+// commit tx
+func (repo *HoldRepository) Commit(tx *sql.Tx) error {
+	return tx.Commit()
+}
+
+// rollback tx
+func (repo *HoldRepository) Rollback(tx *sql.Tx) error {
+	return tx.Rollback()
+}
+
+// CreateHold inserts a new hold and sets its generated ID
+func (repo *HoldRepository) CreateHold(tx *sql.Tx, hold *models.Hold) error {
+	query := `INSERT INTO holds (wallet_number, to_wallet_number, amount, captured_amount, currency, status, expires_at, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	return tx.QueryRow(
+		query,
+		hold.WalletNumber,
+		hold.ToWalletNumber,
+		hold.Amount,
+		hold.CapturedAmount,
+		hold.Currency,
+		hold.Status,
+		hold.ExpiresAt,
+		hold.CreatedAt,
+	).Scan(&hold.ID)
+}
+
+// LockHoldByID fetches a hold and locks its row until the DB transaction ends
+func (repo *HoldRepository) LockHoldByID(tx *sql.Tx, holdID int) (*models.Hold, error) {
+	query := `SELECT id, wallet_number, to_wallet_number, amount, captured_amount, currency, status,
+					 expires_at, created_at, captured_at, released_at
+			  FROM holds WHERE id = $1 FOR UPDATE`
+
+	var hold models.Hold
+	err := tx.QueryRow(query, holdID).Scan(
+		&hold.ID,
+		&hold.WalletNumber,
+		&hold.ToWalletNumber,
+		&hold.Amount,
+		&hold.CapturedAmount,
+		&hold.Currency,
+		&hold.Status,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.CapturedAt,
+		&hold.ReleasedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.RepoErrHoldNotFound
+		}
+		return nil, err
+	}
+	hold.Amount = money.New(hold.Amount.Amount, hold.Currency)
+	hold.CapturedAmount = money.New(hold.CapturedAmount.Amount, hold.Currency)
+	return &hold, nil
+}
+
+// UpdateHold saves the outcome of a capture, release or expiry
+func (repo *HoldRepository) UpdateHold(tx *sql.Tx, hold *models.Hold) error {
+	query := `UPDATE holds SET captured_amount = $1, status = $2, captured_at = $3, released_at = $4
+			  WHERE id = $5`
+
+	result, err := tx.Exec(query, hold.CapturedAmount, hold.Status, hold.CapturedAt, hold.ReleasedAt, hold.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.RepoErrHoldNotFound
+	}
+	return nil
+}
+
+// AdjustHeldBalance moves the funds reserved on a wallet by a signed amount.
+// The reserved funds can never go below zero or above the wallet balance.
+func (repo *HoldRepository) AdjustHeldBalance(tx *sql.Tx, walletNumber string, delta money.Money) error {
+	query := `UPDATE wallets SET held_balance = held_balance + $1, updated_at = NOW()
+			  WHERE wallet_number = $2 AND held_balance + $1 >= 0 AND held_balance + $1 <= balance`
+
+	result, err := tx.Exec(query, delta, walletNumber)
+	if err != nil {
+		if ledger.IsCheckViolation(err) {
+			return utils.RepoErrInsufficientFunds
+		}
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.RepoErrInsufficientFunds
+	}
+	return nil
+}
+
+// FindExpiredHoldIDs returns the active holds whose expiry is at or before the given time
+func (repo *HoldRepository) FindExpiredHoldIDs(before time.Time) ([]int, error) {
+	rows, err := repo.db.Query("SELECT id FROM holds WHERE status = 'active' AND expires_at <= $1 ORDER BY id", before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,342 @@
+package hold
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"database/sql"
+	"log"
+	"time"
+)
+
+const (
+	StatusActive   = "active"
+	StatusCaptured = "captured"
+	StatusReleased = "released"
+	StatusExpired  = "expired"
+
+	// DefaultHoldTTL is how long a hold reserves funds when the client does not pick an expiry
+	DefaultHoldTTL = 7 * 24 * time.Hour
+	// MaxHoldTTL is the longest a hold can reserve funds for
+	MaxHoldTTL = 30 * 24 * time.Hour
+)
+
+// HoldServiceInterface reserves funds on the payer's wallet and later captures them into a transfer or gives them back.
+// Only the wallet the funds are held for can capture or release a hold.
+type HoldServiceInterface interface {
+	CreateHold(userID int, walletNumber, toWalletNumber string, amount money.Money, ttl time.Duration) (*models.Hold, error)
+	CaptureHold(userID, holdID int, amount *money.Money) (*models.Hold, *models.Wallet, error)
+	ReleaseHold(userID, holdID int) (*models.Hold, error)
+	ExpireHolds() (int, error)
+}
+
+type HoldService struct {
+	holdRepo      HoldRepositoryInterface
+	walletRepo    wallet.WalletRepositoryInterface
+	ledgerService ledger.LedgerServiceInterface
+	now           func() time.Time
+}
+
+// Ensure HoldService implements HoldServiceInterface
+var _ HoldServiceInterface = &HoldService{}
+
+// NewHoldService creates a HoldService. Captured funds move through the ledger like any other transfer.
+func NewHoldService(holdRepo HoldRepositoryInterface, walletRepo wallet.WalletRepositoryInterface, ledgerService ledger.LedgerServiceInterface) *HoldService {
+	return &HoldService{
+		holdRepo:      holdRepo,
+		walletRepo:    walletRepo,
+		ledgerService: ledgerService,
+		now:           time.Now,
+	}
+}
+
+// CreateHold reserves amount of the available balance of one of the user's wallets for toWalletNumber until the hold expires.
+// An empty walletNumber uses the user's default wallet and a ttl of zero uses DefaultHoldTTL.
+func (s *HoldService) CreateHold(userID int, walletNumber, toWalletNumber string, amount money.Money, ttl time.Duration) (*models.Hold, error) {
+	if ttl <= 0 {
+		ttl = DefaultHoldTTL
+	}
+	if ttl > MaxHoldTTL {
+		return nil, utils.ServiceErrInvalidHoldExpiry
+	}
+
+	payerWallet, err := wallet.FindOwnedWallet(s.walletRepo, userID, walletNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	payeeWallet, err := s.walletRepo.FindByWalletNumber(toWalletNumber)
+	if err != nil {
+		return nil, err
+	}
+	if payeeWallet.ID == payerWallet.ID {
+		return nil, utils.ServiceErrHoldOnOwnWallet
+	}
+
+	// Captured funds move as a plain transfer, so both wallets must share a currency
+	if payeeWallet.Currency != payerWallet.Currency {
+		return nil, utils.ServiceErrNoConversionPath
+	}
+	if err := wallet.EnsureWalletCurrency(payerWallet, amount); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.holdRepo.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.rollBackTxWhenErr(tx, &err)
+
+	// Lock the payer's wallet so the funds check and the reservation happen atomically
+	lockedWallets, err := wallet.LockWalletsInOrder(s.walletRepo, tx, payerWallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = wallet.EnsureSufficientFunds(lockedWallets[payerWallet.ID].AvailableBalance(), amount); err != nil {
+		return nil, err
+	}
+
+	if err = s.holdRepo.AdjustHeldBalance(tx, payerWallet.WalletNumber, amount); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	hold := &models.Hold{
+		WalletNumber:   payerWallet.WalletNumber,
+		ToWalletNumber: payeeWallet.WalletNumber,
+		Amount:         amount,
+		CapturedAmount: money.Zero(amount.Currency),
+		Currency:       amount.Currency,
+		Status:         StatusActive,
+		ExpiresAt:      now.Add(ttl),
+		CreatedAt:      now,
+	}
+	if err = s.holdRepo.CreateHold(tx, hold); err != nil {
+		return nil, err
+	}
+
+	err = s.holdRepo.Commit(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// CaptureHold transfers amount of the held funds to the user's wallet and releases the rest.
+// A nil amount captures the whole hold. It returns the captured hold and the user's updated wallet.
+func (s *HoldService) CaptureHold(userID, holdID int, amount *money.Money) (*models.Hold, *models.Wallet, error) {
+	tx, err := s.holdRepo.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer s.rollBackTxWhenErr(tx, &err)
+
+	hold, payeeWallet, err := s.lockActiveHold(tx, holdID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	capture := hold.Amount
+	if amount != nil {
+		capture = *amount
+	}
+	if capture.Currency != hold.Amount.Currency {
+		err = utils.ServiceErrCurrencyMismatch
+		return nil, nil, err
+	}
+	if cmp, cmpErr := capture.Cmp(hold.Amount); cmpErr != nil || cmp > 0 || !capture.IsPositive() {
+		err = utils.ServiceErrCaptureExceedsHold
+		return nil, nil, err
+	}
+
+	payerWallet, err := s.walletRepo.FindByWalletNumber(hold.WalletNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err = wallet.LockWalletsInOrder(s.walletRepo, tx, payerWallet.ID, payeeWallet.ID); err != nil {
+		return nil, nil, err
+	}
+
+	// Give back the whole reservation first so the ledger can debit the captured part
+	if err = s.holdRepo.AdjustHeldBalance(tx, hold.WalletNumber, hold.Amount.Neg()); err != nil {
+		return nil, nil, err
+	}
+
+	_, _, updatedWallet, err := s.ledgerService.Transfer(tx, hold.WalletNumber, hold.ToWalletNumber, capture, models.TransactionNote{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := s.now()
+	hold.Status = StatusCaptured
+	hold.CapturedAmount = capture
+	hold.CapturedAt = &now
+	if err = s.holdRepo.UpdateHold(tx, hold); err != nil {
+		return nil, nil, err
+	}
+
+	err = s.holdRepo.Commit(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hold, updatedWallet, nil
+}
+
+// ReleaseHold cancels an active hold held for one of the user's wallets and makes the funds available to the payer again
+func (s *HoldService) ReleaseHold(userID, holdID int) (*models.Hold, error) {
+	tx, err := s.holdRepo.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.rollBackTxWhenErr(tx, &err)
+
+	hold, _, err := s.lockActiveHold(tx, holdID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.release(tx, hold, StatusReleased); err != nil {
+		return nil, err
+	}
+
+	err = s.holdRepo.Commit(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// ExpireHolds releases every active hold past its expiry and returns how many were expired.
+// Each hold is expired in its own transaction so one failure does not block the others.
+func (s *HoldService) ExpireHolds() (int, error) {
+	ids, err := s.holdRepo.FindExpiredHoldIDs(s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		ok, err := s.expireHold(id)
+		if err != nil {
+			log.Printf("Warning: Failed to expire hold %d: %v", id, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// StartExpiryRelease expires past-due holds periodically in the background
+func (s *HoldService) StartExpiryRelease(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.ExpireHolds(); err != nil {
+				log.Printf("Warning: Failed to expire holds: %v", err)
+			}
+		}
+	}()
+}
+
+// expireHold releases a single hold if it is still active and past its expiry
+func (s *HoldService) expireHold(holdID int) (bool, error) {
+	tx, err := s.holdRepo.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer s.rollBackTxWhenErr(tx, &err)
+
+	hold, err := s.holdRepo.LockHoldByID(tx, holdID)
+	if err != nil {
+		return false, err
+	}
+
+	// The hold may have been captured or released since it was listed
+	if hold.Status != StatusActive || hold.ExpiresAt.After(s.now()) {
+		return false, nil
+	}
+
+	if err = s.release(tx, hold, StatusExpired); err != nil {
+		return false, err
+	}
+
+	err = s.holdRepo.Commit(tx)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// lockActiveHold locks a hold held for one of the user's wallets and checks it can still be captured or released.
+// It also returns the user's wallet the hold is for.
+func (s *HoldService) lockActiveHold(tx *sql.Tx, holdID, userID int) (*models.Hold, *models.Wallet, error) {
+	hold, err := s.holdRepo.LockHoldByID(tx, holdID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Users only see holds involving their own wallets, and only the payee decides what happens to one
+	payeeWallet, err := s.ownedWallet(userID, hold.ToWalletNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	if payeeWallet == nil {
+		payerWallet, err := s.ownedWallet(userID, hold.WalletNumber)
+		if err != nil {
+			return nil, nil, err
+		}
+		if payerWallet != nil {
+			return nil, nil, utils.ServiceErrHoldActionNotAllowed
+		}
+		return nil, nil, utils.RepoErrHoldNotFound
+	}
+
+	if hold.Status != StatusActive {
+		return nil, nil, utils.ServiceErrHoldNotActive
+	}
+	if !hold.ExpiresAt.After(s.now()) {
+		return nil, nil, utils.ServiceErrHoldExpired
+	}
+	return hold, payeeWallet, nil
+}
+
+// ownedWallet returns the wallet with the given number when it belongs to the user, or nil when it does not
+func (s *HoldService) ownedWallet(userID int, walletNumber string) (*models.Wallet, error) {
+	owned, err := wallet.FindOwnedWallet(s.walletRepo, userID, walletNumber)
+	if err == utils.RepoErrWalletNotFound {
+		return nil, nil
+	}
+	return owned, err
+}
+
+// release gives the held funds back to the payer and closes the hold with the given status
+func (s *HoldService) release(tx *sql.Tx, hold *models.Hold, status string) error {
+	if err := s.holdRepo.AdjustHeldBalance(tx, hold.WalletNumber, hold.Amount.Neg()); err != nil {
+		return err
+	}
+
+	now := s.now()
+	hold.Status = status
+	hold.ReleasedAt = &now
+	return s.holdRepo.UpdateHold(tx, hold)
+}
+
+func (s *HoldService) rollBackTxWhenErr(tx *sql.Tx, err *error) {
+	if err != nil {
+		s.holdRepo.Rollback(tx)
+	}
+}
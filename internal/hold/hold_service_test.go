@@ -0,0 +1,343 @@
+package hold
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	mockHold "centralized-wallet/tests/mocks/hold"
+	mockLedger "centralized-wallet/tests/mocks/ledger"
+	mockWallet "centralized-wallet/tests/mocks/wallet"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testPayerUserID       = 1
+	testPayeeUserID       = 2
+	testPayerWalletNumber = "wallet123"
+	testPayeeWalletNumber = "wallet456"
+	testNow               = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func usd(value string) money.Money {
+	return money.MustParse(value, money.DefaultCurrency)
+}
+
+type holdServiceMocks struct {
+	holdRepo      *mockHold.MockHoldRepository
+	walletRepo    *mockWallet.MockWalletRepository
+	ledgerService *mockLedger.MockLedgerService
+}
+
+func setupHoldServiceMock() (*HoldService, holdServiceMocks) {
+	mocks := holdServiceMocks{
+		holdRepo:      new(mockHold.MockHoldRepository),
+		walletRepo:    new(mockWallet.MockWalletRepository),
+		ledgerService: new(mockLedger.MockLedgerService),
+	}
+	service := NewHoldService(mocks.holdRepo, mocks.walletRepo, mocks.ledgerService)
+	service.now = func() time.Time { return testNow }
+	return service, mocks
+}
+
+func payerWallet() *models.Wallet {
+	return &models.Wallet{ID: 1, UserID: testPayerUserID, WalletNumber: testPayerWalletNumber, Currency: money.DefaultCurrency, Balance: usd("100.00"), HeldBalance: usd("30.00")}
+}
+
+func payeeWallet() *models.Wallet {
+	return &models.Wallet{ID: 2, UserID: testPayeeUserID, WalletNumber: testPayeeWalletNumber, Currency: money.DefaultCurrency, Balance: usd("10.00")}
+}
+
+func activeHold() *models.Hold {
+	return &models.Hold{
+		ID:             9,
+		WalletNumber:   testPayerWalletNumber,
+		ToWalletNumber: testPayeeWalletNumber,
+		Amount:         usd("50.00"),
+		CapturedAmount: usd("0.00"),
+		Status:         StatusActive,
+		ExpiresAt:      testNow.Add(time.Hour),
+		CreatedAt:      testNow.Add(-time.Hour),
+	}
+}
+
+func TestCreateHoldService(t *testing.T) {
+	testCases := []struct {
+		name          string
+		walletNumber  string
+		amount        money.Money
+		ttl           time.Duration
+		mockSetup     func(m holdServiceMocks)
+		expectedError error
+	}{
+		{
+			name:   "reserves available funds",
+			amount: usd("50.00"),
+			mockSetup: func(m holdServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testPayerUserID).Return(payerWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testPayeeWalletNumber).Return(payeeWallet(), nil)
+				m.holdRepo.On("Begin").Return(nil, nil)
+				m.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 1).Return(payerWallet(), nil)
+				m.holdRepo.On("AdjustHeldBalance", mock.AnythingOfType("*sql.Tx"), testPayerWalletNumber, usd("50.00")).Return(nil)
+				m.holdRepo.On("CreateHold", mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(h *models.Hold) bool {
+					return h.Status == StatusActive && h.ExpiresAt.Equal(testNow.Add(DefaultHoldTTL))
+				})).Return(nil)
+				m.holdRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+				m.holdRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+			},
+		},
+		{
+			name:   "funds already on hold are not available",
+			amount: usd("70.01"),
+			mockSetup: func(m holdServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testPayerUserID).Return(payerWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testPayeeWalletNumber).Return(payeeWallet(), nil)
+				m.holdRepo.On("Begin").Return(nil, nil)
+				m.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 1).Return(payerWallet(), nil)
+				m.holdRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+			},
+			expectedError: utils.RepoErrInsufficientFunds,
+		},
+		{
+			name:   "hold for own wallet",
+			amount: usd("10.00"),
+			mockSetup: func(m holdServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testPayerUserID).Return(payerWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testPayeeWalletNumber).Return(payerWallet(), nil)
+			},
+			expectedError: utils.ServiceErrHoldOnOwnWallet,
+		},
+		{
+			name:   "payee wallet in another currency",
+			amount: usd("10.00"),
+			mockSetup: func(m holdServiceMocks) {
+				euroWallet := payeeWallet()
+				euroWallet.Currency = "EUR"
+				m.walletRepo.On("GetDefaultWallet", testPayerUserID).Return(payerWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testPayeeWalletNumber).Return(euroWallet, nil)
+			},
+			expectedError: utils.ServiceErrNoConversionPath,
+		},
+		{
+			name:         "source wallet of another user",
+			walletNumber: testPayeeWalletNumber,
+			amount:       usd("10.00"),
+			mockSetup: func(m holdServiceMocks) {
+				m.walletRepo.On("FindByWalletNumber", testPayeeWalletNumber).Return(payeeWallet(), nil)
+			},
+			expectedError: utils.RepoErrWalletNotFound,
+		},
+		{
+			name:          "expiry too far away",
+			amount:        usd("10.00"),
+			ttl:           MaxHoldTTL + time.Second,
+			mockSetup:     func(m holdServiceMocks) {},
+			expectedError: utils.ServiceErrInvalidHoldExpiry,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, m := setupHoldServiceMock()
+			tc.mockSetup(m)
+
+			hold, err := service.CreateHold(testPayerUserID, tc.walletNumber, testPayeeWalletNumber, tc.amount, tc.ttl)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, hold)
+				m.holdRepo.AssertNotCalled(t, "CreateHold", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.amount, hold.Amount)
+			}
+			m.holdRepo.AssertExpectations(t)
+			m.walletRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCaptureHoldService(t *testing.T) {
+	partial := usd("20.00")
+	tooMuch := usd("50.01")
+
+	// expectLockedHold mocks the payee looking up and locking the hold
+	expectLockedHold := func(m holdServiceMocks, hold *models.Hold) {
+		m.holdRepo.On("Begin").Return(nil, nil)
+		m.holdRepo.On("LockHoldByID", mock.AnythingOfType("*sql.Tx"), 9).Return(hold, nil)
+		m.walletRepo.On("FindByWalletNumber", testPayeeWalletNumber).Return(payeeWallet(), nil)
+		m.holdRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+	}
+
+	// expectCapture mocks releasing the reservation and transferring the captured amount
+	expectCapture := func(m holdServiceMocks, captured money.Money) {
+		m.walletRepo.On("FindByWalletNumber", testPayerWalletNumber).Return(payerWallet(), nil)
+		m.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 1).Return(payerWallet(), nil)
+		m.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 2).Return(payeeWallet(), nil)
+		m.holdRepo.On("AdjustHeldBalance", mock.AnythingOfType("*sql.Tx"), testPayerWalletNumber, usd("-50.00")).Return(nil)
+		m.ledgerService.On("Transfer", mock.AnythingOfType("*sql.Tx"), testPayerWalletNumber, testPayeeWalletNumber, captured, models.TransactionNote{}).
+			Return(&models.Transaction{ID: 9}, payerWallet(), payeeWallet(), nil)
+		m.holdRepo.On("UpdateHold", mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(h *models.Hold) bool {
+			return h.Status == StatusCaptured && h.CapturedAmount == captured
+		})).Return(nil)
+		m.holdRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+	}
+
+	testCases := []struct {
+		name          string
+		userID        int
+		amount        *money.Money
+		mockSetup     func(m holdServiceMocks)
+		expectedError error
+	}{
+		{
+			name:   "full capture",
+			userID: testPayeeUserID,
+			mockSetup: func(m holdServiceMocks) {
+				expectLockedHold(m, activeHold())
+				expectCapture(m, usd("50.00"))
+			},
+		},
+		{
+			name:   "partial capture releases the rest",
+			userID: testPayeeUserID,
+			amount: &partial,
+			mockSetup: func(m holdServiceMocks) {
+				expectLockedHold(m, activeHold())
+				expectCapture(m, partial)
+			},
+		},
+		{
+			name:   "capture more than held",
+			userID: testPayeeUserID,
+			amount: &tooMuch,
+			mockSetup: func(m holdServiceMocks) {
+				expectLockedHold(m, activeHold())
+			},
+			expectedError: utils.ServiceErrCaptureExceedsHold,
+		},
+		{
+			name:   "hold already released",
+			userID: testPayeeUserID,
+			mockSetup: func(m holdServiceMocks) {
+				hold := activeHold()
+				hold.Status = StatusReleased
+				expectLockedHold(m, hold)
+			},
+			expectedError: utils.ServiceErrHoldNotActive,
+		},
+		{
+			name:   "hold past its expiry",
+			userID: testPayeeUserID,
+			mockSetup: func(m holdServiceMocks) {
+				hold := activeHold()
+				hold.ExpiresAt = testNow
+				expectLockedHold(m, hold)
+			},
+			expectedError: utils.ServiceErrHoldExpired,
+		},
+		{
+			name:   "payer cannot capture",
+			userID: testPayerUserID,
+			mockSetup: func(m holdServiceMocks) {
+				m.holdRepo.On("Begin").Return(nil, nil)
+				m.holdRepo.On("LockHoldByID", mock.AnythingOfType("*sql.Tx"), 9).Return(activeHold(), nil)
+				m.walletRepo.On("FindByWalletNumber", testPayeeWalletNumber).Return(payeeWallet(), nil)
+				m.walletRepo.On("FindByWalletNumber", testPayerWalletNumber).Return(payerWallet(), nil)
+				m.holdRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+			},
+			expectedError: utils.ServiceErrHoldActionNotAllowed,
+		},
+		{
+			name:   "hold of another user",
+			userID: testPayeeUserID,
+			mockSetup: func(m holdServiceMocks) {
+				hold := activeHold()
+				hold.ToWalletNumber = "other-wallet"
+				expectLockedHold(m, hold)
+				m.walletRepo.On("FindByWalletNumber", "other-wallet").Return(&models.Wallet{ID: 3, UserID: 3, WalletNumber: "other-wallet"}, nil)
+				m.walletRepo.On("FindByWalletNumber", testPayerWalletNumber).Return(payerWallet(), nil)
+			},
+			expectedError: utils.RepoErrHoldNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, m := setupHoldServiceMock()
+			tc.mockSetup(m)
+
+			hold, wallet, err := service.CaptureHold(tc.userID, 9, tc.amount)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, hold)
+				assert.Nil(t, wallet)
+				m.ledgerService.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, StatusCaptured, hold.Status)
+				assert.Equal(t, testNow, *hold.CapturedAt)
+				assert.Equal(t, testPayeeWalletNumber, wallet.WalletNumber)
+			}
+			m.holdRepo.AssertExpectations(t)
+			m.ledgerService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReleaseHoldService(t *testing.T) {
+	service, m := setupHoldServiceMock()
+
+	m.holdRepo.On("Begin").Return(nil, nil)
+	m.walletRepo.On("FindByWalletNumber", testPayeeWalletNumber).Return(payeeWallet(), nil)
+	m.holdRepo.On("LockHoldByID", mock.AnythingOfType("*sql.Tx"), 9).Return(activeHold(), nil)
+	m.holdRepo.On("AdjustHeldBalance", mock.AnythingOfType("*sql.Tx"), testPayerWalletNumber, usd("-50.00")).Return(nil)
+	m.holdRepo.On("UpdateHold", mock.AnythingOfType("*sql.Tx"), mock.AnythingOfType("*models.Hold")).Return(nil)
+	m.holdRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+	m.holdRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+
+	hold, err := service.ReleaseHold(testPayeeUserID, 9)
+
+	assert.NoError(t, err)
+	assert.Equal(t, StatusReleased, hold.Status)
+	assert.Equal(t, testNow, *hold.ReleasedAt)
+	m.holdRepo.AssertExpectations(t)
+}
+
+func TestExpireHoldsService(t *testing.T) {
+	service, m := setupHoldServiceMock()
+
+	captured := activeHold()
+	captured.ID = 10
+	captured.Status = StatusCaptured
+
+	m.holdRepo.On("FindExpiredHoldIDs", testNow).Return([]int{9, 10, 11}, nil)
+	m.holdRepo.On("Begin").Return(nil, nil)
+	m.holdRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+
+	// Hold 9 is still active and past due
+	expired := activeHold()
+	expired.ExpiresAt = testNow.Add(-time.Minute)
+	m.holdRepo.On("LockHoldByID", mock.AnythingOfType("*sql.Tx"), 9).Return(expired, nil)
+	m.holdRepo.On("AdjustHeldBalance", mock.AnythingOfType("*sql.Tx"), testPayerWalletNumber, usd("-50.00")).Return(nil).Once()
+	m.holdRepo.On("UpdateHold", mock.AnythingOfType("*sql.Tx"), expired).Return(nil)
+	m.holdRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+
+	// Hold 10 was captured after it was listed
+	m.holdRepo.On("LockHoldByID", mock.AnythingOfType("*sql.Tx"), 10).Return(captured, nil)
+
+	// Hold 11 fails and must not stop the others
+	m.holdRepo.On("LockHoldByID", mock.AnythingOfType("*sql.Tx"), 11).Return(nil, errors.New("db error"))
+
+	count, err := service.ExpireHolds()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, StatusExpired, expired.Status)
+	m.holdRepo.AssertExpectations(t)
+}
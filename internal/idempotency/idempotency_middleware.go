@@ -0,0 +1,192 @@
+package idempotency
+
+import (
+	"bytes"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header clients use to make retries safe
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set on responses that were replayed from a stored key
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+
+	// claimContextKey is where the middleware leaves the claim of the request for its handler
+	claimContextKey = "idempotency_claim"
+)
+
+// heldClaim is the claim of the request being handled and whether its handler took it
+type heldClaim struct {
+	claim models.IdempotencyClaim
+	taken bool
+}
+
+// responseRecorder captures the response body so it can be stored against the key
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware replays the stored response when a request is retried with the same Idempotency-Key.
+// Requests without the header are processed as usual. Must run after the JWT middleware.
+// A key whose request did not finish is held until it expires.
+func IdempotencyMiddleware(service IdempotencyServiceInterface) gin.HandlerFunc {
+	return idempotencyMiddleware(service, false)
+}
+
+// CommitTrackingIdempotencyMiddleware is IdempotencyMiddleware for routes whose handlers pass TakeClaim's claim
+// to the DB transaction moving the money, which marks the key committed.
+// A retry may then reclaim a key whose lease has passed without that mark.
+func CommitTrackingIdempotencyMiddleware(service IdempotencyServiceInterface) gin.HandlerFunc {
+	return idempotencyMiddleware(service, true)
+}
+
+// TakeClaim returns the claim of the request's Idempotency-Key, or nil when it was sent without one.
+// The handler hands it to the DB transaction moving the money, so the key is marked committed along with it.
+func TakeClaim(c *gin.Context) *models.IdempotencyClaim {
+	value, exists := c.Get(claimContextKey)
+	if !exists {
+		return nil
+	}
+
+	held := value.(*heldClaim)
+	held.taken = true
+	claim := held.claim
+	return &claim
+}
+
+func idempotencyMiddleware(service IdempotencyServiceInterface, reclaimable bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(HeaderIdempotencyKey))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			utils.ErrorResponse(c, utils.ErrInvalidIdempotencyKey, nil, "")
+			c.Abort()
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			c.Abort()
+			return
+		}
+
+		// Read the body for hashing and put it back for the handler
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := hashRequest(c.Request.Method, c.Request.URL.Path, body)
+
+		record, err := service.Begin(userID.(int), key, requestHash, reclaimable)
+		if err != nil {
+			switch err {
+			case utils.ServiceErrIdempotencyKeyReused:
+				utils.ErrorResponse(c, utils.ErrIdempotencyKeyReused, nil, "")
+			case utils.ServiceErrIdempotencyRequestInFlight:
+				utils.ErrorResponse(c, utils.ErrIdempotencyRequestInFlight, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[IdempotencyMiddleware] Error reserving idempotency key")
+			}
+			c.Abort()
+			return
+		}
+
+		if record.IsCompleted() {
+			c.Header(HeaderIdempotentReplayed, "true")
+			c.Data(*record.ResponseStatus, "application/json; charset=utf-8", record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		held := &heldClaim{claim: record.Claim()}
+		c.Set(claimContextKey, held)
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		// A panicking handler may have committed before it failed. Its key is only released when the handler
+		// took the claim, since the release then skips a key its DB transaction marked committed.
+		finished := false
+		defer func() {
+			if finished || !held.taken {
+				return
+			}
+			release(service, held.claim)
+		}()
+
+		c.Next()
+		finished = true
+
+		// Server errors are not stored so the client can retry with the same key
+		statusCode := recorder.Status()
+		if statusCode >= http.StatusInternalServerError {
+			release(service, held.claim)
+			return
+		}
+
+		// The request has taken effect, so the key is kept even if storing its response fails.
+		// It then stays pending and retries are refused until it expires.
+		if err := service.Complete(held.claim, requestHash, statusCode, recorder.body.Bytes()); err != nil {
+			log.Printf("Warning: Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+func release(service IdempotencyServiceInterface, claim models.IdempotencyClaim) {
+	if err := service.Release(claim); err != nil {
+		log.Printf("Warning: Failed to release idempotency key: %v", err)
+	}
+}
+
+// hashRequest fingerprints a request by method, path and body.
+// JSON bodies are canonicalized first so formatting and key order do not count as a different request.
+func hashRequest(method, path string, body []byte) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(method))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(path))
+	hasher.Write([]byte{0})
+	hasher.Write(canonicalJSON(body))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func canonicalJSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var payload interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		return body
+	}
+	canonical, err := json.Marshal(payload)
+	if err != nil {
+		return body
+	}
+	return canonical
+}
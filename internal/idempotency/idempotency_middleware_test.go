@@ -0,0 +1,254 @@
+package idempotency
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockIdempotency "centralized-wallet/tests/mocks/idempotency"
+	"centralized-wallet/tests/testutils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	middlewareUserID = 1
+	middlewareKey    = "retry-key-1"
+	depositBody      = `{"amount": 50}`
+	depositResponse  = `{"status":"success","message":"Deposit successful"}`
+)
+
+func setupRouterForIdempotencyTest(service IdempotencyServiceInterface, handlerStatus int, handlerCalls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	// Simulate setting user_id in the context via the JWT middleware
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", middlewareUserID)
+		c.Next()
+	})
+
+	router.POST("/wallets/deposit", IdempotencyMiddleware(service), func(c *gin.Context) {
+		*handlerCalls++
+		c.Data(handlerStatus, "application/json; charset=utf-8", []byte(depositResponse))
+	})
+
+	return router
+}
+
+func executeIdempotentRequest(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, "/wallets/deposit", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func pendingKey() *models.IdempotencyKey {
+	return &models.IdempotencyKey{
+		UserID:      middlewareUserID,
+		Key:         middlewareKey,
+		LockedUntil: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	requestHash := hashRequest(http.MethodPost, "/wallets/deposit", []byte(depositBody))
+	storedStatus := http.StatusOK
+	claim := pendingKey().Claim()
+
+	testCases := []struct {
+		name                  string
+		key                   string
+		handlerStatus         int
+		mockSetup             func(service *mockIdempotency.MockIdempotencyService)
+		expectedStatus        int
+		expectedHandlerCalls  int
+		expectedErrorResponse *utils.AppError
+		expectedReplayed      bool
+	}{
+		{
+			name:                 "No key processes the request normally",
+			handlerStatus:        http.StatusOK,
+			mockSetup:            func(service *mockIdempotency.MockIdempotencyService) {},
+			expectedStatus:       http.StatusOK,
+			expectedHandlerCalls: 1,
+		},
+		{
+			name:          "First request stores the response",
+			key:           middlewareKey,
+			handlerStatus: http.StatusOK,
+			mockSetup: func(service *mockIdempotency.MockIdempotencyService) {
+				service.On("Begin", middlewareUserID, middlewareKey, requestHash, false).Return(pendingKey(), nil)
+				service.On("Complete", claim, requestHash, http.StatusOK, []byte(depositResponse)).Return(nil)
+			},
+			expectedStatus:       http.StatusOK,
+			expectedHandlerCalls: 1,
+		},
+		{
+			name: "Retry replays the stored response",
+			key:  middlewareKey,
+			mockSetup: func(service *mockIdempotency.MockIdempotencyService) {
+				service.On("Begin", middlewareUserID, middlewareKey, requestHash, false).Return(&models.IdempotencyKey{
+					ResponseStatus: &storedStatus,
+					ResponseBody:   []byte(depositResponse),
+				}, nil)
+			},
+			expectedStatus:       http.StatusOK,
+			expectedHandlerCalls: 0,
+			expectedReplayed:     true,
+		},
+		{
+			name:          "Server error releases the key",
+			key:           middlewareKey,
+			handlerStatus: http.StatusInternalServerError,
+			mockSetup: func(service *mockIdempotency.MockIdempotencyService) {
+				service.On("Begin", middlewareUserID, middlewareKey, requestHash, false).Return(pendingKey(), nil)
+				service.On("Release", claim).Return(nil)
+			},
+			expectedStatus:       http.StatusInternalServerError,
+			expectedHandlerCalls: 1,
+		},
+		{
+			name: "Key reused with a different body",
+			key:  middlewareKey,
+			mockSetup: func(service *mockIdempotency.MockIdempotencyService) {
+				service.On("Begin", middlewareUserID, middlewareKey, requestHash, false).Return(nil, utils.ServiceErrIdempotencyKeyReused)
+			},
+			expectedStatus:        http.StatusConflict,
+			expectedErrorResponse: utils.ErrIdempotencyKeyReused,
+		},
+		{
+			name: "Original request still in flight",
+			key:  middlewareKey,
+			mockSetup: func(service *mockIdempotency.MockIdempotencyService) {
+				service.On("Begin", middlewareUserID, middlewareKey, requestHash, false).Return(nil, utils.ServiceErrIdempotencyRequestInFlight)
+			},
+			expectedStatus:        http.StatusConflict,
+			expectedErrorResponse: utils.ErrIdempotencyRequestInFlight,
+		},
+		{
+			name:                  "Key too long",
+			key:                   strings.Repeat("k", maxKeyLength+1),
+			mockSetup:             func(service *mockIdempotency.MockIdempotencyService) {},
+			expectedStatus:        http.StatusBadRequest,
+			expectedErrorResponse: utils.ErrInvalidIdempotencyKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service := new(mockIdempotency.MockIdempotencyService)
+			tc.mockSetup(service)
+
+			handlerCalls := 0
+			router := setupRouterForIdempotencyTest(service, tc.handlerStatus, &handlerCalls)
+			w := executeIdempotentRequest(router, tc.key, depositBody)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedHandlerCalls, handlerCalls)
+			if tc.expectedErrorResponse != nil {
+				testutils.AssertAPIErrorResponse(t, w, tc.expectedErrorResponse)
+			}
+			if tc.expectedReplayed {
+				assert.Equal(t, "true", w.Header().Get(HeaderIdempotentReplayed))
+				assert.JSONEq(t, depositResponse, w.Body.String())
+			}
+			service.AssertExpectations(t)
+		})
+	}
+}
+
+func setupRouterForPanicTest(middleware gin.HandlerFunc, takeClaim bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery(), func(c *gin.Context) {
+		c.Set("user_id", middlewareUserID)
+		c.Next()
+	})
+	router.POST("/wallets/deposit", middleware, func(c *gin.Context) {
+		if takeClaim {
+			TakeClaim(c)
+		}
+		panic("handler failed")
+	})
+	return router
+}
+
+func TestIdempotencyMiddlewareHoldsKeyOnPanic(t *testing.T) {
+	requestHash := hashRequest(http.MethodPost, "/wallets/deposit", []byte(depositBody))
+
+	service := new(mockIdempotency.MockIdempotencyService)
+	service.On("Begin", middlewareUserID, middlewareKey, requestHash, false).Return(pendingKey(), nil)
+
+	router := setupRouterForPanicTest(IdempotencyMiddleware(service), false)
+	w := executeIdempotentRequest(router, middlewareKey, depositBody)
+
+	// The handler may have committed before panicking, so the key stays pending until it expires
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	service.AssertExpectations(t)
+	service.AssertNotCalled(t, "Release", mock.Anything)
+	service.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCommitTrackingIdempotencyMiddlewareReleasesTakenKeyOnPanic(t *testing.T) {
+	requestHash := hashRequest(http.MethodPost, "/wallets/deposit", []byte(depositBody))
+
+	service := new(mockIdempotency.MockIdempotencyService)
+	service.On("Begin", middlewareUserID, middlewareKey, requestHash, true).Return(pendingKey(), nil)
+	service.On("Release", pendingKey().Claim()).Return(nil)
+
+	router := setupRouterForPanicTest(CommitTrackingIdempotencyMiddleware(service), true)
+	w := executeIdempotentRequest(router, middlewareKey, depositBody)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	service.AssertExpectations(t)
+	service.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTakeClaim(t *testing.T) {
+	requestHash := hashRequest(http.MethodPost, "/wallets/deposit", []byte(depositBody))
+
+	service := new(mockIdempotency.MockIdempotencyService)
+	service.On("Begin", middlewareUserID, middlewareKey, requestHash, true).Return(pendingKey(), nil)
+	service.On("Complete", pendingKey().Claim(), requestHash, http.StatusOK, []byte(depositResponse)).Return(nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", middlewareUserID)
+		c.Next()
+	})
+
+	var claims []*models.IdempotencyClaim
+	router.POST("/wallets/deposit", CommitTrackingIdempotencyMiddleware(service), func(c *gin.Context) {
+		claims = append(claims, TakeClaim(c))
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(depositResponse))
+	})
+
+	executeIdempotentRequest(router, middlewareKey, depositBody)
+	executeIdempotentRequest(router, "", depositBody)
+
+	expected := pendingKey().Claim()
+	assert.Equal(t, []*models.IdempotencyClaim{&expected, nil}, claims)
+	service.AssertExpectations(t)
+}
+
+func TestHashRequestIgnoresJSONFormatting(t *testing.T) {
+	compact := hashRequest(http.MethodPost, "/wallets/transfer", []byte(`{"amount":10,"to_wallet_number":"wallet456"}`))
+	reordered := hashRequest(http.MethodPost, "/wallets/transfer", []byte("{\n  \"to_wallet_number\": \"wallet456\",\n  \"amount\": 10\n}"))
+	differentAmount := hashRequest(http.MethodPost, "/wallets/transfer", []byte(`{"amount":11,"to_wallet_number":"wallet456"}`))
+	differentPath := hashRequest(http.MethodPost, "/wallets/withdraw", []byte(`{"amount":10,"to_wallet_number":"wallet456"}`))
+
+	assert.Equal(t, compact, reordered)
+	assert.NotEqual(t, compact, differentAmount)
+	assert.NotEqual(t, compact, differentPath)
+}
@@ -0,0 +1,147 @@
+package idempotency
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+	"time"
+)
+
+// IdempotencyRepositoryInterface defines the methods for persisting idempotency keys
+type IdempotencyRepositoryInterface interface {
+	Reserve(record *models.IdempotencyKey) (bool, error)
+	FindByKey(userID int, key string) (*models.IdempotencyKey, error)
+	Reclaim(claim models.IdempotencyClaim, newLockedUntil time.Time) (*models.IdempotencyClaim, error)
+	SaveResponse(claim models.IdempotencyClaim, statusCode int, body []byte) error
+	Release(claim models.IdempotencyClaim) error
+	Delete(userID int, key string) error
+	DeleteExpired() (int64, error)
+}
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+// Ensure IdempotencyRepository implements IdempotencyRepositoryInterface
+var _ IdempotencyRepositoryInterface = &IdempotencyRepository{}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve inserts a pending key for the user. It returns false when the key already exists.
+// The lease is read back as stored, so the record can be used as the claim of the request.
+func (repo *IdempotencyRepository) Reserve(record *models.IdempotencyKey) (bool, error) {
+	query := `INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at, locked_until)
+			  VALUES ($1, $2, $3, $4, $5, $6)
+			  ON CONFLICT (user_id, idempotency_key) DO NOTHING
+			  RETURNING locked_until`
+
+	err := repo.db.QueryRow(query, record.UserID, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt, record.LockedUntil).
+		Scan(&record.LockedUntil)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FindByKey fetches the stored key for the user
+func (repo *IdempotencyRepository) FindByKey(userID int, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	var statusCode sql.NullInt64
+
+	query := `SELECT id, user_id, idempotency_key, request_hash, response_status, response_body, created_at, expires_at, locked_until, committed_at
+			  FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`
+	err := repo.db.QueryRow(query, userID, key).Scan(
+		&record.ID,
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&statusCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+		&record.LockedUntil,
+		&record.CommittedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.RepoErrIdempotencyKeyNotFound
+		}
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		status := int(statusCode.Int64)
+		record.ResponseStatus = &status
+	}
+	return &record, nil
+}
+
+// Reclaim takes over a pending key whose lease has passed and returns the claim of the new lease.
+// The key is only taken over while it holds the lease that was read and was not marked committed,
+// so it returns nil when another request reclaimed, committed or completed it first.
+func (repo *IdempotencyRepository) Reclaim(claim models.IdempotencyClaim, newLockedUntil time.Time) (*models.IdempotencyClaim, error) {
+	query := `UPDATE idempotency_keys SET locked_until = $1
+			  WHERE user_id = $2 AND idempotency_key = $3 AND locked_until = $4
+			  AND response_status IS NULL AND committed_at IS NULL
+			  RETURNING locked_until`
+
+	reclaimed := models.IdempotencyClaim{UserID: claim.UserID, Key: claim.Key}
+	err := repo.db.QueryRow(query, newLockedUntil, claim.UserID, claim.Key, claim.LockedUntil).Scan(&reclaimed.LockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reclaimed, nil
+}
+
+// SaveResponse stores the response of the original request so retries can replay it.
+// It returns RepoErrIdempotencyClaimLost when the key is no longer held by the claim.
+func (repo *IdempotencyRepository) SaveResponse(claim models.IdempotencyClaim, statusCode int, body []byte) error {
+	query := `UPDATE idempotency_keys SET response_status = $1, response_body = $2
+			  WHERE user_id = $3 AND idempotency_key = $4 AND locked_until = $5 AND response_status IS NULL`
+	result, err := repo.db.Exec(query, statusCode, body, claim.UserID, claim.Key, claim.LockedUntil)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.RepoErrIdempotencyClaimLost
+	}
+	return nil
+}
+
+// Release removes a key still held by the claim, unless the request holding it committed
+func (repo *IdempotencyRepository) Release(claim models.IdempotencyClaim) error {
+	query := `DELETE FROM idempotency_keys
+			  WHERE user_id = $1 AND idempotency_key = $2 AND locked_until = $3
+			  AND response_status IS NULL AND committed_at IS NULL`
+	_, err := repo.db.Exec(query, claim.UserID, claim.Key, claim.LockedUntil)
+	return err
+}
+
+// Delete removes a key whatever its state, e.g. once it has expired
+func (repo *IdempotencyRepository) Delete(userID int, key string) error {
+	_, err := repo.db.Exec("DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2", userID, key)
+	return err
+}
+
+// DeleteExpired purges keys whose TTL has passed and returns how many were removed
+func (repo *IdempotencyRepository) DeleteExpired() (int64, error) {
+	result, err := repo.db.Exec("DELETE FROM idempotency_keys WHERE expires_at <= NOW()")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
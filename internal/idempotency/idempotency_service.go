@@ -0,0 +1,216 @@
+package idempotency
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/redis"
+	"centralized-wallet/internal/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultKeyTTL is how long a stored response can be replayed for the same key
+	DefaultKeyTTL = 24 * time.Hour
+	// DefaultLeaseDuration is how long a request holds its key before a retry may reclaim it.
+	// Only keys of routes that mark them committed are reclaimed, and only while unmarked.
+	DefaultLeaseDuration = time.Minute
+)
+
+type IdempotencyServiceInterface interface {
+	Begin(userID int, key, requestHash string, reclaimable bool) (*models.IdempotencyKey, error)
+	Complete(claim models.IdempotencyClaim, requestHash string, statusCode int, body []byte) error
+	Release(claim models.IdempotencyClaim) error
+	PurgeExpired() (int64, error)
+}
+
+type IdempotencyService struct {
+	repo         IdempotencyRepositoryInterface
+	redisService redis.RedisServiceInterface
+	ttl          time.Duration
+	lease        time.Duration
+	now          func() time.Time
+}
+
+// Ensure IdempotencyService implements IdempotencyServiceInterface
+var _ IdempotencyServiceInterface = &IdempotencyService{}
+
+// NewIdempotencyService creates an IdempotencyService. The redis service is optional and only fronts Postgres.
+func NewIdempotencyService(repo IdempotencyRepositoryInterface, redis redis.RedisServiceInterface) *IdempotencyService {
+	return &IdempotencyService{
+		repo:         repo,
+		redisService: redis,
+		ttl:          DefaultKeyTTL,
+		lease:        DefaultLeaseDuration,
+		now:          time.Now,
+	}
+}
+
+// Begin claims the key for a new request.
+// It returns the pending key when the caller should process the request, or the stored record when its response
+// should be replayed. A pending key is refused until it expires, since its request may already have moved the money.
+// When reclaimable is set, the route marks its key committed in the DB transaction moving the money, so a pending
+// key whose lease has passed and that is not marked is reclaimed: the request holding it did not commit.
+func (s *IdempotencyService) Begin(userID int, key, requestHash string, reclaimable bool) (*models.IdempotencyKey, error) {
+	if cached := s.getCached(userID, key); cached != nil {
+		return s.checkExisting(cached, requestHash)
+	}
+
+	// A second attempt covers a key that expired or was released between the insert and the lookup
+	for attempt := 0; attempt < 2; attempt++ {
+		now := s.now()
+		record := &models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.ttl),
+			LockedUntil: now.Add(s.lease),
+		}
+		reserved, err := s.repo.Reserve(record)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return record, nil
+		}
+
+		existing, err := s.repo.FindByKey(userID, key)
+		if err == utils.RepoErrIdempotencyKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !existing.ExpiresAt.After(now) {
+			if err := s.repo.Delete(userID, key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if existing.IsCompleted() {
+			s.cache(existing)
+		} else if reclaimable && s.canReclaim(existing, requestHash, now) {
+			claim, err := s.repo.Reclaim(existing.Claim(), now.Add(s.lease))
+			if err != nil {
+				return nil, err
+			}
+			if claim != nil {
+				existing.LockedUntil = claim.LockedUntil
+				return existing, nil
+			}
+			continue
+		}
+		return s.checkExisting(existing, requestHash)
+	}
+
+	return nil, utils.ServiceErrIdempotencyRequestInFlight
+}
+
+// Complete stores the response of the original request against the key it holds
+func (s *IdempotencyService) Complete(claim models.IdempotencyClaim, requestHash string, statusCode int, body []byte) error {
+	if err := s.repo.SaveResponse(claim, statusCode, body); err != nil {
+		return err
+	}
+
+	now := s.now()
+	s.cache(&models.IdempotencyKey{
+		UserID:         claim.UserID,
+		Key:            claim.Key,
+		RequestHash:    requestHash,
+		ResponseStatus: &statusCode,
+		ResponseBody:   body,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.ttl),
+	})
+	return nil
+}
+
+// Release drops a reservation so the client can retry a request that failed on our side.
+// A key marked committed is kept, since its request moved the money.
+func (s *IdempotencyService) Release(claim models.IdempotencyClaim) error {
+	return s.repo.Release(claim)
+}
+
+// PurgeExpired deletes keys that can no longer be replayed
+func (s *IdempotencyService) PurgeExpired() (int64, error) {
+	return s.repo.DeleteExpired()
+}
+
+// StartExpiryCleanup purges expired keys periodically in the background
+func (s *IdempotencyService) StartExpiryCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.PurgeExpired(); err != nil {
+				log.Printf("Warning: Failed to purge expired idempotency keys: %v", err)
+			}
+		}
+	}()
+}
+
+// canReclaim reports whether a pending key was left by an earlier attempt at the same request that did not commit
+func (s *IdempotencyService) canReclaim(existing *models.IdempotencyKey, requestHash string, now time.Time) bool {
+	return existing.RequestHash == requestHash && existing.CommittedAt == nil && !existing.LockedUntil.After(now)
+}
+
+// checkExisting decides whether a stored key can be replayed for the incoming request
+func (s *IdempotencyService) checkExisting(existing *models.IdempotencyKey, requestHash string) (*models.IdempotencyKey, error) {
+	if existing.RequestHash != requestHash {
+		return nil, utils.ServiceErrIdempotencyKeyReused
+	}
+	if !existing.IsCompleted() {
+		return nil, utils.ServiceErrIdempotencyRequestInFlight
+	}
+	return existing, nil
+}
+
+func cacheKey(userID int, key string) string {
+	return fmt.Sprintf("user:%d:idempotency:%s", userID, key)
+}
+
+// getCached returns a completed, unexpired record from Redis, or nil on a miss
+func (s *IdempotencyService) getCached(userID int, key string) *models.IdempotencyKey {
+	if s.redisService == nil {
+		return nil
+	}
+
+	cached, err := s.redisService.Get(context.Background(), cacheKey(userID, key))
+	if err != nil || cached == "" {
+		return nil
+	}
+
+	var record models.IdempotencyKey
+	if err := json.Unmarshal([]byte(cached), &record); err != nil {
+		return nil
+	}
+	if !record.IsCompleted() || !record.ExpiresAt.After(s.now()) {
+		return nil
+	}
+	return &record
+}
+
+// cache stores a completed record in Redis until the key expires
+func (s *IdempotencyService) cache(record *models.IdempotencyKey) {
+	if s.redisService == nil {
+		return
+	}
+
+	ttl := record.ExpiresAt.Sub(s.now())
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	if err := s.redisService.Set(context.Background(), cacheKey(record.UserID, record.Key), data, ttl); err != nil {
+		log.Printf("Warning: Failed to cache idempotency key in Redis: %v", err)
+	}
+}
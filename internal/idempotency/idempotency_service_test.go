@@ -0,0 +1,249 @@
+package idempotency_test
+
+import (
+	"centralized-wallet/internal/idempotency"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockIdempotency "centralized-wallet/tests/mocks/idempotency"
+	mockRedis "centralized-wallet/tests/mocks/redis"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testUserID       = 1
+	testKey          = "3f1c9a52-key"
+	testHash         = "hash-of-original-request"
+	testCacheKey     = "user:1:idempotency:3f1c9a52-key"
+	testStoredBody   = `{"status":"success","message":"Deposit successful"}`
+	otherHash        = "hash-of-different-request"
+	testStoredStatus = http.StatusOK
+)
+
+func completedRecord(hash string, expiresAt time.Time) *models.IdempotencyKey {
+	status := testStoredStatus
+	return &models.IdempotencyKey{
+		UserID:         testUserID,
+		Key:            testKey,
+		RequestHash:    hash,
+		ResponseStatus: &status,
+		ResponseBody:   []byte(testStoredBody),
+		CreatedAt:      expiresAt.Add(-idempotency.DefaultKeyTTL),
+		ExpiresAt:      expiresAt,
+	}
+}
+
+func pendingRecord(hash string, expiresAt, lockedUntil time.Time) *models.IdempotencyKey {
+	return &models.IdempotencyKey{
+		UserID:      testUserID,
+		Key:         testKey,
+		RequestHash: hash,
+		CreatedAt:   expiresAt.Add(-idempotency.DefaultKeyTTL),
+		ExpiresAt:   expiresAt,
+		LockedUntil: lockedUntil,
+	}
+}
+
+func committedRecord(hash string, expiresAt, lockedUntil time.Time) *models.IdempotencyKey {
+	record := pendingRecord(hash, expiresAt, lockedUntil)
+	committedAt := lockedUntil.Add(-time.Second)
+	record.CommittedAt = &committedAt
+	return record
+}
+
+func TestBeginService(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	lapsedClaim := models.IdempotencyClaim{UserID: testUserID, Key: testKey, LockedUntil: past}
+
+	testCases := []struct {
+		name             string
+		reclaimable      bool
+		mockSetup        func(repo *mockIdempotency.MockIdempotencyRepository)
+		expectedReplayed bool
+		expectedError    error
+	}{
+		{
+			name: "New key is reserved",
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(true, nil)
+			},
+		},
+		{
+			name: "Completed key with same request is replayed",
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(completedRecord(testHash, future), nil)
+			},
+			expectedReplayed: true,
+		},
+		{
+			name: "Key reused with a different request",
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(completedRecord(otherHash, future), nil)
+			},
+			expectedError: utils.ServiceErrIdempotencyKeyReused,
+		},
+		{
+			name: "Original request still in flight",
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(pendingRecord(testHash, future, future), nil)
+			},
+			expectedError: utils.ServiceErrIdempotencyRequestInFlight,
+		},
+		{
+			name: "Pending key with a lapsed lease is held when the route does not track commits",
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(pendingRecord(testHash, future, past), nil)
+			},
+			expectedError: utils.ServiceErrIdempotencyRequestInFlight,
+		},
+		{
+			name:        "Pending key with a lapsed lease is reclaimed",
+			reclaimable: true,
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(pendingRecord(testHash, future, past), nil)
+				repo.On("Reclaim", lapsedClaim, mock.AnythingOfType("time.Time")).
+					Return(&models.IdempotencyClaim{UserID: testUserID, Key: testKey, LockedUntil: future}, nil)
+			},
+		},
+		{
+			name:        "Committed key is not reclaimed once its lease lapses",
+			reclaimable: true,
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(committedRecord(testHash, future, past), nil)
+			},
+			expectedError: utils.ServiceErrIdempotencyRequestInFlight,
+		},
+		{
+			name:        "Lapsed lease reclaimed by another request first",
+			reclaimable: true,
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(pendingRecord(testHash, future, past), nil).Once()
+				repo.On("Reclaim", lapsedClaim, mock.AnythingOfType("time.Time")).Return(nil, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(pendingRecord(testHash, future, future), nil).Once()
+			},
+			expectedError: utils.ServiceErrIdempotencyRequestInFlight,
+		},
+		{
+			name:        "Lapsed lease is not reclaimed for a different request",
+			reclaimable: true,
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil)
+				repo.On("FindByKey", testUserID, testKey).Return(pendingRecord(otherHash, future, past), nil)
+			},
+			expectedError: utils.ServiceErrIdempotencyKeyReused,
+		},
+		{
+			name: "Expired key is replaced",
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, nil).Once()
+				repo.On("FindByKey", testUserID, testKey).Return(completedRecord(otherHash, past), nil)
+				repo.On("Delete", testUserID, testKey).Return(nil)
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(true, nil).Once()
+			},
+		},
+		{
+			name: "Database error",
+			mockSetup: func(repo *mockIdempotency.MockIdempotencyRepository) {
+				repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(false, errors.New("db error"))
+			},
+			expectedError: errors.New("db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockIdempotency.MockIdempotencyRepository)
+			tc.mockSetup(repo)
+
+			service := idempotency.NewIdempotencyService(repo, nil)
+			record, err := service.Begin(testUserID, testKey, testHash, tc.reclaimable)
+
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+				assert.Nil(t, record)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedReplayed, record.IsCompleted())
+				if !tc.expectedReplayed {
+					assert.True(t, record.LockedUntil.After(time.Now()), "the request should hold a live lease")
+				}
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBeginServiceUsesRedisCache(t *testing.T) {
+	repo := new(mockIdempotency.MockIdempotencyRepository)
+	redisMock := new(mockRedis.MockRedisClient)
+
+	cached, _ := json.Marshal(completedRecord(testHash, time.Now().Add(time.Hour)))
+	redisMock.On("Get", mock.Anything, testCacheKey).Return(string(cached), nil)
+
+	service := idempotency.NewIdempotencyService(repo, redisMock)
+	record, err := service.Begin(testUserID, testKey, testHash, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testStoredBody, string(record.ResponseBody))
+	repo.AssertNotCalled(t, "Reserve", mock.Anything)
+}
+
+func TestCompleteService(t *testing.T) {
+	repo := new(mockIdempotency.MockIdempotencyRepository)
+	redisMock := new(mockRedis.MockRedisClient)
+	claim := models.IdempotencyClaim{UserID: testUserID, Key: testKey, LockedUntil: time.Now().Add(time.Minute)}
+
+	repo.On("SaveResponse", claim, http.StatusOK, []byte(testStoredBody)).Return(nil)
+	redisMock.On("Set", mock.Anything, testCacheKey, mock.Anything, mock.AnythingOfType("time.Duration")).Return(nil)
+
+	service := idempotency.NewIdempotencyService(repo, redisMock)
+	err := service.Complete(claim, testHash, http.StatusOK, []byte(testStoredBody))
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	redisMock.AssertExpectations(t)
+}
+
+func TestCompleteServiceWithAStaleClaim(t *testing.T) {
+	repo := new(mockIdempotency.MockIdempotencyRepository)
+	redisMock := new(mockRedis.MockRedisClient)
+	claim := models.IdempotencyClaim{UserID: testUserID, Key: testKey, LockedUntil: time.Now().Add(-time.Minute)}
+
+	repo.On("SaveResponse", claim, http.StatusConflict, []byte(testStoredBody)).Return(utils.RepoErrIdempotencyClaimLost)
+
+	service := idempotency.NewIdempotencyService(repo, redisMock)
+	err := service.Complete(claim, testHash, http.StatusConflict, []byte(testStoredBody))
+
+	assert.Equal(t, utils.RepoErrIdempotencyClaimLost, err)
+	redisMock.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBeginServiceFallsBackToDatabaseOnCacheMiss(t *testing.T) {
+	repo := new(mockIdempotency.MockIdempotencyRepository)
+	redisMock := new(mockRedis.MockRedisClient)
+
+	redisMock.On("Get", mock.Anything, testCacheKey).Return("", redis.Nil)
+	repo.On("Reserve", mock.AnythingOfType("*models.IdempotencyKey")).Return(true, nil)
+
+	service := idempotency.NewIdempotencyService(repo, redisMock)
+	record, err := service.Begin(testUserID, testKey, testHash, false)
+
+	assert.NoError(t, err)
+	assert.False(t, record.IsCompleted())
+	repo.AssertExpectations(t)
+}
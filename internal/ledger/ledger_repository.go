@@ -0,0 +1,153 @@
+package ledger
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgCheckViolation is the Postgres error code raised when a CHECK constraint fails
+const pgCheckViolation = "23514"
+
+// LedgerRepositoryInterface defines the methods for ledger persistence
+type LedgerRepositoryInterface interface {
+	EnsureAccount(tx *sql.Tx, account *models.LedgerAccount) error
+	CreateJournalEntry(tx *sql.Tx, entry *models.JournalEntry) error
+	CreatePosting(tx *sql.Tx, posting *models.Posting) error
+	ApplyWalletDelta(tx *sql.Tx, walletNumber string, delta money.Money) (*models.Wallet, error)
+	GetPostedBalance(code string, currency string) (money.Money, error)
+	GetPostedBalanceAsOf(code string, currency string, asOf time.Time) (money.Money, error)
+	GetWalletBalance(walletNumber string) (money.Money, error)
+}
+
+type LedgerRepository struct {
+	db *sql.DB
+}
+
+// Ensure LedgerRepository implements LedgerRepositoryInterface
+var _ LedgerRepositoryInterface = &LedgerRepository{}
+
+// NewLedgerRepository creates a new instance of LedgerRepository
+func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// EnsureAccount creates the account if it does not exist yet and sets its ID
+func (repo *LedgerRepository) EnsureAccount(tx *sql.Tx, account *models.LedgerAccount) error {
+	// The no-op update makes RETURNING yield the existing row on conflict
+	query := `INSERT INTO ledger_accounts (code, account_type, wallet_number, currency)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (code, currency) DO UPDATE SET code = EXCLUDED.code
+			  RETURNING id, created_at`
+	return tx.QueryRow(query, account.Code, account.AccountType, account.WalletNumber, account.Currency).
+		Scan(&account.ID, &account.CreatedAt)
+}
+
+// CreateJournalEntry inserts the entry header and sets its ID
+func (repo *LedgerRepository) CreateJournalEntry(tx *sql.Tx, entry *models.JournalEntry) error {
+	query := `INSERT INTO journal_entries (transaction_id, entry_type, created_at)
+			  VALUES ($1, $2, $3) RETURNING id`
+	return tx.QueryRow(query, entry.TransactionID, entry.EntryType, entry.CreatedAt).Scan(&entry.ID)
+}
+
+// CreatePosting inserts a single debit or credit line and sets its ID
+func (repo *LedgerRepository) CreatePosting(tx *sql.Tx, posting *models.Posting) error {
+	query := `INSERT INTO postings (journal_entry_id, account_id, direction, amount, currency, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	return tx.QueryRow(
+		query,
+		posting.JournalEntryID,
+		posting.AccountID,
+		posting.Direction,
+		posting.Amount,
+		posting.Amount.Currency,
+		posting.CreatedAt,
+	).Scan(&posting.ID)
+}
+
+// ApplyWalletDelta moves the materialized wallet balance by a signed amount.
+// A debit that would take the balance below the funds reserved by holds is refused.
+func (repo *LedgerRepository) ApplyWalletDelta(tx *sql.Tx, walletNumber string, delta money.Money) (*models.Wallet, error) {
+	query := `UPDATE wallets SET balance = balance + $1, updated_at = NOW()
+			  WHERE wallet_number = $2 AND balance + $1 >= held_balance
+			  RETURNING id, user_id, wallet_number, name, is_default, currency, balance, held_balance, created_at, updated_at`
+
+	var wallet models.Wallet
+	err := tx.QueryRow(query, delta, walletNumber).Scan(
+		&wallet.ID,
+		&wallet.UserID,
+		&wallet.WalletNumber,
+		&wallet.Name,
+		&wallet.IsDefault,
+		&wallet.Currency,
+		&wallet.Balance,
+		&wallet.HeldBalance,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows || IsCheckViolation(err) {
+			return nil, utils.RepoErrInsufficientFunds
+		}
+		return nil, err
+	}
+	if wallet.Currency != delta.Currency {
+		return nil, utils.RepoErrCurrencyMismatch
+	}
+	wallet.Balance = money.New(wallet.Balance.Amount, wallet.Currency)
+	wallet.HeldBalance = money.New(wallet.HeldBalance.Amount, wallet.Currency)
+	return &wallet, nil
+}
+
+// GetPostedBalance sums credits minus debits for an account, which is the balance of a wallet account
+func (repo *LedgerRepository) GetPostedBalance(code string, currency string) (money.Money, error) {
+	query := `SELECT COALESCE(SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END), 0)::BIGINT
+			  FROM postings p
+			  JOIN ledger_accounts la ON la.id = p.account_id
+			  WHERE la.code = $1 AND la.currency = $2`
+
+	balance := money.Zero(currency)
+	if err := repo.db.QueryRow(query, code, currency).Scan(&balance); err != nil {
+		return money.Money{}, err
+	}
+	return balance, nil
+}
+
+// GetPostedBalanceAsOf sums credits minus debits for an account over the postings made up to and including asOf
+func (repo *LedgerRepository) GetPostedBalanceAsOf(code string, currency string, asOf time.Time) (money.Money, error) {
+	query := `SELECT COALESCE(SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END), 0)::BIGINT
+			  FROM postings p
+			  JOIN ledger_accounts la ON la.id = p.account_id
+			  WHERE la.code = $1 AND la.currency = $2 AND p.created_at <= $3`
+
+	balance := money.Zero(currency)
+	if err := repo.db.QueryRow(query, code, currency, asOf).Scan(&balance); err != nil {
+		return money.Money{}, err
+	}
+	return balance, nil
+}
+
+// GetWalletBalance returns the materialized balance stored on the wallet row
+func (repo *LedgerRepository) GetWalletBalance(walletNumber string) (money.Money, error) {
+	var balance money.Money
+	var currency string
+	err := repo.db.QueryRow("SELECT balance, currency FROM wallets WHERE wallet_number = $1", walletNumber).Scan(&balance, &currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return money.Money{}, utils.RepoErrWalletNotFound
+		}
+		return money.Money{}, err
+	}
+	return money.New(balance.Amount, currency), nil
+}
+
+// IsCheckViolation reports whether the error comes from a failed CHECK constraint (e.g. balance >= 0)
+func IsCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgCheckViolation
+}
@@ -0,0 +1,312 @@
+package ledger
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+	"time"
+)
+
+const (
+	Debit  = "debit"
+	Credit = "credit"
+
+	AccountTypeWallet = "wallet"
+	AccountTypeSystem = "system"
+
+	// System accounts the wallets post against
+	AccountExternalCash    = "system:external_cash"    // Money entering or leaving the platform
+	AccountFees            = "system:fees"             // Fee revenue
+	AccountOpeningBalances = "system:opening_balances" // Balances carried over from before the ledger existed
+	AccountFXPosition      = "system:fx_position"      // Currency bought and sold by the platform on conversions, one account per currency
+	AccountFXSpread        = "system:fx_spread"        // Spread revenue earned on conversions
+)
+
+// LedgerServiceInterface is the only way wallet balances change.
+// Every operation records the user-facing transaction, with the client's note, and a balanced journal entry in the caller's DB transaction.
+type LedgerServiceInterface interface {
+	Deposit(tx *sql.Tx, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error)
+	Withdraw(tx *sql.Tx, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error)
+	Transfer(tx *sql.Tx, fromWalletNumber, toWalletNumber string, amount money.Money, note models.TransactionNote) (*models.Transaction, *models.Wallet, *models.Wallet, error)
+	Reverse(tx *sql.Tx, original *models.Transaction, amount money.Money) (*models.Transaction, *models.Wallet, error)
+	Exchange(tx *sql.Tx, fromWalletNumber, toWalletNumber string, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, *models.Wallet, *models.Wallet, error)
+	VerifyWalletBalance(walletNumber string) error
+	GetBalanceAsOf(walletNumber, currency string, asOf time.Time) (money.Money, error)
+}
+
+type LedgerService struct {
+	repo               LedgerRepositoryInterface
+	transactionService transaction.TransactionServiceInterface
+}
+
+// Ensure LedgerService implements LedgerServiceInterface
+var _ LedgerServiceInterface = &LedgerService{}
+
+// NewLedgerService creates a new LedgerService
+func NewLedgerService(repo LedgerRepositoryInterface, transactionService transaction.TransactionServiceInterface) *LedgerService {
+	return &LedgerService{repo: repo, transactionService: transactionService}
+}
+
+// line is one side of a journal entry before its account is resolved
+type line struct {
+	account   models.LedgerAccount
+	direction string
+	amount    money.Money
+}
+
+// WalletAccountCode returns the ledger account code of a wallet
+func WalletAccountCode(walletNumber string) string {
+	return "wallet:" + walletNumber
+}
+
+func walletAccount(walletNumber, currency string) models.LedgerAccount {
+	return models.LedgerAccount{
+		Code:         WalletAccountCode(walletNumber),
+		AccountType:  AccountTypeWallet,
+		WalletNumber: &walletNumber,
+		Currency:     currency,
+	}
+}
+
+func systemAccount(code, currency string) models.LedgerAccount {
+	return models.LedgerAccount{Code: code, AccountType: AccountTypeSystem, Currency: currency}
+}
+
+// Deposit debits external cash and credits the wallet
+func (ls *LedgerService) Deposit(tx *sql.Tx, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	txn, err := ls.transactionService.RecordTransaction(tx, nil, &walletNumber, "deposit", amount, note)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets, err := ls.post(tx, txn, []line{
+		{account: systemAccount(AccountExternalCash, amount.Currency), direction: Debit, amount: amount},
+		{account: walletAccount(walletNumber, amount.Currency), direction: Credit, amount: amount},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wallets[walletNumber], nil
+}
+
+// Withdraw debits the wallet and credits external cash
+func (ls *LedgerService) Withdraw(tx *sql.Tx, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	txn, err := ls.transactionService.RecordTransaction(tx, &walletNumber, nil, "withdraw", amount, note)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets, err := ls.post(tx, txn, []line{
+		{account: walletAccount(walletNumber, amount.Currency), direction: Debit, amount: amount},
+		{account: systemAccount(AccountExternalCash, amount.Currency), direction: Credit, amount: amount},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wallets[walletNumber], nil
+}
+
+// Transfer debits the sender's wallet and credits the recipient's wallet, returning the transfer transaction
+// and both updated wallets
+func (ls *LedgerService) Transfer(tx *sql.Tx, fromWalletNumber, toWalletNumber string, amount money.Money, note models.TransactionNote) (*models.Transaction, *models.Wallet, *models.Wallet, error) {
+	txn, err := ls.transactionService.RecordTransaction(tx, &fromWalletNumber, &toWalletNumber, "transfer", amount, note)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	wallets, err := ls.post(tx, txn, []line{
+		{account: walletAccount(fromWalletNumber, amount.Currency), direction: Debit, amount: amount},
+		{account: walletAccount(toWalletNumber, amount.Currency), direction: Credit, amount: amount},
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return txn, wallets[fromWalletNumber], wallets[toWalletNumber], nil
+}
+
+// Reverse moves amount of the original transaction back: it debits the wallet that received the funds and credits
+// the wallet or external cash they came from. It returns the reversal and the debited wallet.
+func (ls *LedgerService) Reverse(tx *sql.Tx, original *models.Transaction, amount money.Money) (*models.Transaction, *models.Wallet, error) {
+	reversal, err := ls.transactionService.RecordReversal(tx, original, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	source := systemAccount(AccountExternalCash, amount.Currency)
+	if original.FromWalletNumber != nil {
+		source = walletAccount(*original.FromWalletNumber, amount.Currency)
+	}
+
+	wallets, err := ls.post(tx, reversal, []line{
+		{account: walletAccount(*original.ToWalletNumber, amount.Currency), direction: Debit, amount: amount},
+		{account: source, direction: Credit, amount: amount},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return reversal, wallets[*original.ToWalletNumber], nil
+}
+
+// Exchange debits the source wallet in its currency and credits the destination wallet in the other one.
+// The platform's FX position takes the converted amount on each side and the spread is booked as revenue,
+// so the entry balances per currency. It returns the exchange transaction and both updated wallets.
+func (ls *LedgerService) Exchange(tx *sql.Tx, fromWalletNumber, toWalletNumber string, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, *models.Wallet, *models.Wallet, error) {
+	txn, err := ls.transactionService.RecordExchange(tx, fromWalletNumber, toWalletNumber, conversion, note)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	source, target, spread := conversion.Source, conversion.Target, conversion.Spread
+	converted, err := source.Sub(spread)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	lines := []line{
+		{account: walletAccount(fromWalletNumber, source.Currency), direction: Debit, amount: source},
+		{account: systemAccount(AccountFXPosition, source.Currency), direction: Credit, amount: converted},
+		{account: systemAccount(AccountFXPosition, target.Currency), direction: Debit, amount: target},
+		{account: walletAccount(toWalletNumber, target.Currency), direction: Credit, amount: target},
+	}
+	if spread.IsPositive() {
+		lines = append(lines, line{account: systemAccount(AccountFXSpread, spread.Currency), direction: Credit, amount: spread})
+	}
+
+	wallets, err := ls.post(tx, txn, lines)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return txn, wallets[fromWalletNumber], wallets[toWalletNumber], nil
+}
+
+// VerifyWalletBalance checks that the balance stored on the wallet equals the sum of its postings
+func (ls *LedgerService) VerifyWalletBalance(walletNumber string) error {
+	stored, err := ls.repo.GetWalletBalance(walletNumber)
+	if err != nil {
+		return err
+	}
+
+	posted, err := ls.repo.GetPostedBalance(WalletAccountCode(walletNumber), stored.Currency)
+	if err != nil {
+		return err
+	}
+
+	if cmp, err := stored.Cmp(posted); err != nil || cmp != 0 {
+		return utils.ServiceErrLedgerBalanceMismatch
+	}
+	return nil
+}
+
+// GetBalanceAsOf returns the wallet's balance at a point in time, from its ledger postings up to and including asOf
+func (ls *LedgerService) GetBalanceAsOf(walletNumber, currency string, asOf time.Time) (money.Money, error) {
+	return ls.repo.GetPostedBalanceAsOf(WalletAccountCode(walletNumber), currency, asOf)
+}
+
+// post writes a balanced journal entry for the transaction, stamped with its time, applies it to the affected wallet balances,
+// stores the resulting balances on the transaction and marks it completed. It returns the updated wallets keyed by wallet number.
+func (ls *LedgerService) post(tx *sql.Tx, txn *models.Transaction, lines []line) (map[string]*models.Wallet, error) {
+	if err := validateBalanced(lines); err != nil {
+		return nil, err
+	}
+
+	// The entry and its postings take the transaction's time, so a statement period reading balances from the
+	// postings and lines from the transactions puts both on the same side of its bounds
+	postedAt := txn.CreatedAt
+	entry := &models.JournalEntry{
+		TransactionID: &txn.ID,
+		EntryType:     txn.TransactionType,
+		CreatedAt:     postedAt,
+	}
+	if err := ls.repo.CreateJournalEntry(tx, entry); err != nil {
+		return nil, err
+	}
+
+	wallets := make(map[string]*models.Wallet)
+	for _, l := range lines {
+		account := l.account
+		if err := ls.repo.EnsureAccount(tx, &account); err != nil {
+			return nil, err
+		}
+
+		posting := models.Posting{
+			JournalEntryID: entry.ID,
+			AccountID:      account.ID,
+			Direction:      l.direction,
+			Amount:         l.amount,
+			CreatedAt:      postedAt,
+		}
+		if err := ls.repo.CreatePosting(tx, &posting); err != nil {
+			return nil, err
+		}
+		entry.Postings = append(entry.Postings, posting)
+
+		if account.AccountType != AccountTypeWallet {
+			continue
+		}
+
+		// Wallets are liabilities of the platform: credits raise the balance, debits lower it
+		delta := l.amount
+		if l.direction == Debit {
+			delta = l.amount.Neg()
+		}
+		wallet, err := ls.repo.ApplyWalletDelta(tx, *account.WalletNumber, delta)
+		if err != nil {
+			return nil, err
+		}
+		wallets[*account.WalletNumber] = wallet
+	}
+
+	// Record the resulting balances in the same DB transaction as the wallet updates
+	var fromBalance, toBalance *money.Money
+	if txn.FromWalletNumber != nil {
+		if wallet, ok := wallets[*txn.FromWalletNumber]; ok {
+			fromBalance = &wallet.Balance
+		}
+	}
+	if txn.ToWalletNumber != nil {
+		if wallet, ok := wallets[*txn.ToWalletNumber]; ok {
+			toBalance = &wallet.Balance
+		}
+	}
+	if err := ls.transactionService.SetBalancesAfter(tx, txn, fromBalance, toBalance); err != nil {
+		return nil, err
+	}
+
+	if err := ls.transactionService.UpdateStatus(tx, txn, transaction.StatusCompleted); err != nil {
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// validateBalanced ensures every line is positive and debits equal credits in each currency
+func validateBalanced(lines []line) error {
+	if len(lines) < 2 {
+		return utils.ServiceErrUnbalancedEntry
+	}
+
+	totals := make(map[string]int64)
+	for _, l := range lines {
+		if !l.amount.IsPositive() || l.amount.Currency != l.account.Currency {
+			return utils.ServiceErrUnbalancedEntry
+		}
+		switch l.direction {
+		case Debit:
+			totals[l.amount.Currency] += l.amount.Amount
+		case Credit:
+			totals[l.amount.Currency] -= l.amount.Amount
+		default:
+			return utils.ServiceErrUnbalancedEntry
+		}
+	}
+
+	for _, total := range totals {
+		if total != 0 {
+			return utils.ServiceErrUnbalancedEntry
+		}
+	}
+	return nil
+}
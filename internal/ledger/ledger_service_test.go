@@ -0,0 +1,368 @@
+package ledger
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	mockLedger "centralized-wallet/tests/mocks/ledger"
+	mockTransaction "centralized-wallet/tests/mocks/transaction"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testFromWalletNumber = "wallet123"
+	testToWalletNumber   = "wallet456"
+	testAmount           = money.MustParse("50.00", money.DefaultCurrency)
+)
+
+func setupLedgerServiceMock() (*LedgerService, *mockLedger.MockLedgerRepository, *mockTransaction.MockTransactionService) {
+	repo := new(mockLedger.MockLedgerRepository)
+	transactionService := new(mockTransaction.MockTransactionService)
+	return NewLedgerService(repo, transactionService), repo, transactionService
+}
+
+// expectPosting mocks resolving an account and inserting a posting against it
+func expectPosting(repo *mockLedger.MockLedgerRepository, code string, accountID int, direction string) {
+	repo.On("EnsureAccount", mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(a *models.LedgerAccount) bool {
+		return a.Code == code
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.LedgerAccount).ID = accountID
+	}).Return(nil).Once()
+
+	repo.On("CreatePosting", mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(p *models.Posting) bool {
+		return p.AccountID == accountID && p.Direction == direction && p.Amount == testAmount
+	})).Return(nil).Once()
+}
+
+func TestDepositPostsAgainstExternalCash(t *testing.T) {
+	ls, repo, transactionService := setupLedgerServiceMock()
+	tx := new(sql.Tx)
+	// Recorded right before a statement period ends: the postings must not land after it
+	createdAt := time.Date(2024, 5, 31, 23, 59, 59, 999999000, time.UTC)
+
+	transactionService.On("RecordTransaction", tx, (*string)(nil), &testToWalletNumber, "deposit", testAmount, models.TransactionNote{}).
+		Return(&models.Transaction{ID: 7, ToWalletNumber: &testToWalletNumber, TransactionType: "deposit", CreatedAt: createdAt}, nil)
+	repo.On("CreateJournalEntry", tx, mock.MatchedBy(func(e *models.JournalEntry) bool {
+		return *e.TransactionID == 7 && e.EntryType == "deposit" && e.CreatedAt.Equal(createdAt)
+	})).Return(nil)
+	expectPosting(repo, AccountExternalCash, 1, Debit)
+	expectPosting(repo, WalletAccountCode(testToWalletNumber), 2, Credit)
+
+	updated := &models.Wallet{WalletNumber: testToWalletNumber, Balance: money.MustParse("250.00", money.DefaultCurrency)}
+	repo.On("ApplyWalletDelta", tx, testToWalletNumber, testAmount).Return(updated, nil)
+	// The deposit only has a destination side, left with the updated balance
+	transactionService.On("SetBalancesAfter", tx, mock.AnythingOfType("*models.Transaction"), (*money.Money)(nil), &updated.Balance).Return(nil)
+	transactionService.On("UpdateStatus", tx, mock.MatchedBy(func(txn *models.Transaction) bool {
+		return txn.ID == 7
+	}), transaction.StatusCompleted).Return(nil)
+
+	wallet, err := ls.Deposit(tx, testToWalletNumber, testAmount, models.TransactionNote{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, updated, wallet)
+	for _, call := range repo.Calls {
+		if call.Method == "CreatePosting" {
+			assert.Equal(t, createdAt, call.Arguments.Get(1).(*models.Posting).CreatedAt)
+		}
+	}
+	repo.AssertExpectations(t)
+	transactionService.AssertExpectations(t)
+}
+
+func TestTransferDebitsSenderAndCreditsRecipient(t *testing.T) {
+	ls, repo, transactionService := setupLedgerServiceMock()
+	tx := new(sql.Tx)
+
+	transactionService.On("RecordTransaction", tx, &testFromWalletNumber, &testToWalletNumber, "transfer", testAmount, models.TransactionNote{}).
+		Return(&models.Transaction{ID: 8, TransactionType: "transfer"}, nil)
+	repo.On("CreateJournalEntry", tx, mock.AnythingOfType("*models.JournalEntry")).Return(nil)
+	expectPosting(repo, WalletAccountCode(testFromWalletNumber), 1, Debit)
+	expectPosting(repo, WalletAccountCode(testToWalletNumber), 2, Credit)
+
+	fromWallet := &models.Wallet{WalletNumber: testFromWalletNumber}
+	toWallet := &models.Wallet{WalletNumber: testToWalletNumber}
+	repo.On("ApplyWalletDelta", tx, testFromWalletNumber, testAmount.Neg()).Return(fromWallet, nil)
+	repo.On("ApplyWalletDelta", tx, testToWalletNumber, testAmount).Return(toWallet, nil)
+	transactionService.On("SetBalancesAfter", tx, mock.AnythingOfType("*models.Transaction"), mock.Anything, mock.Anything).Return(nil)
+	transactionService.On("UpdateStatus", tx, mock.AnythingOfType("*models.Transaction"), transaction.StatusCompleted).Return(nil)
+
+	gotTxn, gotFrom, gotTo, err := ls.Transfer(tx, testFromWalletNumber, testToWalletNumber, testAmount, models.TransactionNote{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 8, gotTxn.ID)
+	assert.Equal(t, fromWallet, gotFrom)
+	assert.Equal(t, toWallet, gotTo)
+	repo.AssertExpectations(t)
+	transactionService.AssertExpectations(t)
+}
+
+func TestReverseTransferDebitsRecipientAndCreditsSender(t *testing.T) {
+	ls, repo, transactionService := setupLedgerServiceMock()
+	tx := new(sql.Tx)
+
+	original := &models.Transaction{
+		ID:               8,
+		FromWalletNumber: &testFromWalletNumber,
+		ToWalletNumber:   &testToWalletNumber,
+		TransactionType:  "transfer",
+		Amount:           testAmount,
+		Status:           transaction.StatusCompleted,
+	}
+	reversal := &models.Transaction{ID: 11, TransactionType: "reversal", ReversesTransactionID: &original.ID}
+
+	transactionService.On("RecordReversal", tx, original, testAmount).Return(reversal, nil)
+	repo.On("CreateJournalEntry", tx, mock.MatchedBy(func(e *models.JournalEntry) bool {
+		return *e.TransactionID == 11 && e.EntryType == "reversal"
+	})).Return(nil)
+	expectPosting(repo, WalletAccountCode(testToWalletNumber), 1, Debit)
+	expectPosting(repo, WalletAccountCode(testFromWalletNumber), 2, Credit)
+
+	recipient := &models.Wallet{WalletNumber: testToWalletNumber}
+	repo.On("ApplyWalletDelta", tx, testToWalletNumber, testAmount.Neg()).Return(recipient, nil)
+	repo.On("ApplyWalletDelta", tx, testFromWalletNumber, testAmount).Return(&models.Wallet{WalletNumber: testFromWalletNumber}, nil)
+	transactionService.On("SetBalancesAfter", tx, mock.AnythingOfType("*models.Transaction"), mock.Anything, mock.Anything).Return(nil)
+	transactionService.On("UpdateStatus", tx, reversal, transaction.StatusCompleted).Return(nil)
+
+	gotReversal, wallet, err := ls.Reverse(tx, original, testAmount)
+
+	assert.NoError(t, err)
+	assert.Equal(t, reversal, gotReversal)
+	assert.Equal(t, recipient, wallet)
+	repo.AssertExpectations(t)
+	transactionService.AssertExpectations(t)
+}
+
+func TestReverseDepositReturnsMoneyToExternalCash(t *testing.T) {
+	ls, repo, transactionService := setupLedgerServiceMock()
+	tx := new(sql.Tx)
+
+	original := &models.Transaction{ID: 7, ToWalletNumber: &testToWalletNumber, TransactionType: "deposit", Amount: testAmount}
+	reversal := &models.Transaction{ID: 12, TransactionType: "reversal"}
+
+	transactionService.On("RecordReversal", tx, original, testAmount).Return(reversal, nil)
+	repo.On("CreateJournalEntry", tx, mock.AnythingOfType("*models.JournalEntry")).Return(nil)
+	expectPosting(repo, WalletAccountCode(testToWalletNumber), 1, Debit)
+	expectPosting(repo, AccountExternalCash, 2, Credit)
+	repo.On("ApplyWalletDelta", tx, testToWalletNumber, testAmount.Neg()).Return(&models.Wallet{}, nil)
+	transactionService.On("SetBalancesAfter", tx, mock.AnythingOfType("*models.Transaction"), mock.Anything, mock.Anything).Return(nil)
+	transactionService.On("UpdateStatus", tx, reversal, transaction.StatusCompleted).Return(nil)
+
+	_, _, err := ls.Reverse(tx, original, testAmount)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestExchangePostsThroughFXPosition(t *testing.T) {
+	ls, repo, transactionService := setupLedgerServiceMock()
+	tx := new(sql.Tx)
+
+	conversion := &fx.Conversion{
+		Source:      money.MustParse("100.00", "USD"),
+		Target:      money.MustParse("91.54", "EUR"),
+		Spread:      money.MustParse("0.50", "USD"),
+		MidRate:     fx.MustParseRate("0.92"),
+		AppliedRate: fx.MustParseRate("0.9154"),
+	}
+	exchange := &models.Transaction{ID: 12, TransactionType: "exchange"}
+
+	transactionService.On("RecordExchange", tx, testFromWalletNumber, testToWalletNumber, conversion, models.TransactionNote{}).Return(exchange, nil)
+	repo.On("CreateJournalEntry", tx, mock.AnythingOfType("*models.JournalEntry")).Return(nil)
+
+	expected := []struct {
+		code      string
+		direction string
+		amount    money.Money
+	}{
+		{WalletAccountCode(testFromWalletNumber), Debit, money.MustParse("100.00", "USD")},
+		{AccountFXPosition, Credit, money.MustParse("99.50", "USD")},
+		{AccountFXPosition, Debit, money.MustParse("91.54", "EUR")},
+		{WalletAccountCode(testToWalletNumber), Credit, money.MustParse("91.54", "EUR")},
+		{AccountFXSpread, Credit, money.MustParse("0.50", "USD")},
+	}
+	for i, e := range expected {
+		accountID := i + 1
+		e := e
+		repo.On("EnsureAccount", tx, mock.MatchedBy(func(a *models.LedgerAccount) bool {
+			return a.Code == e.code && a.Currency == e.amount.Currency
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.LedgerAccount).ID = accountID
+		}).Return(nil).Once()
+		repo.On("CreatePosting", tx, mock.MatchedBy(func(p *models.Posting) bool {
+			return p.AccountID == accountID && p.Direction == e.direction && p.Amount == e.amount
+		})).Return(nil).Once()
+	}
+
+	fromWallet := &models.Wallet{WalletNumber: testFromWalletNumber}
+	toWallet := &models.Wallet{WalletNumber: testToWalletNumber}
+	repo.On("ApplyWalletDelta", tx, testFromWalletNumber, money.MustParse("-100.00", "USD")).Return(fromWallet, nil)
+	repo.On("ApplyWalletDelta", tx, testToWalletNumber, money.MustParse("91.54", "EUR")).Return(toWallet, nil)
+	transactionService.On("SetBalancesAfter", tx, mock.AnythingOfType("*models.Transaction"), mock.Anything, mock.Anything).Return(nil)
+	transactionService.On("UpdateStatus", tx, exchange, transaction.StatusCompleted).Return(nil)
+
+	gotExchange, gotFrom, gotTo, err := ls.Exchange(tx, testFromWalletNumber, testToWalletNumber, conversion, models.TransactionNote{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, exchange, gotExchange)
+	assert.Equal(t, fromWallet, gotFrom)
+	assert.Equal(t, toWallet, gotTo)
+	repo.AssertExpectations(t)
+	transactionService.AssertExpectations(t)
+}
+
+func TestWithdrawInsufficientFunds(t *testing.T) {
+	ls, repo, transactionService := setupLedgerServiceMock()
+	tx := new(sql.Tx)
+
+	transactionService.On("RecordTransaction", tx, &testFromWalletNumber, (*string)(nil), "withdraw", testAmount, models.TransactionNote{}).
+		Return(&models.Transaction{ID: 9, TransactionType: "withdraw"}, nil)
+	repo.On("CreateJournalEntry", tx, mock.AnythingOfType("*models.JournalEntry")).Return(nil)
+	expectPosting(repo, WalletAccountCode(testFromWalletNumber), 1, Debit)
+	repo.On("ApplyWalletDelta", tx, testFromWalletNumber, testAmount.Neg()).Return(nil, utils.RepoErrInsufficientFunds)
+
+	wallet, err := ls.Withdraw(tx, testFromWalletNumber, testAmount, models.TransactionNote{})
+
+	assert.ErrorIs(t, err, utils.RepoErrInsufficientFunds)
+	assert.Nil(t, wallet)
+	repo.AssertNotCalled(t, "EnsureAccount", tx, mock.MatchedBy(func(a *models.LedgerAccount) bool {
+		return a.Code == AccountExternalCash
+	}))
+	transactionService.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecordTransactionErrorStopsPosting(t *testing.T) {
+	ls, repo, transactionService := setupLedgerServiceMock()
+	tx := new(sql.Tx)
+
+	transactionService.On("RecordTransaction", tx, (*string)(nil), &testToWalletNumber, "deposit", testAmount, models.TransactionNote{}).
+		Return(nil, utils.ErrDatabaseError)
+
+	wallet, err := ls.Deposit(tx, testToWalletNumber, testAmount, models.TransactionNote{})
+
+	assert.ErrorIs(t, err, utils.ErrDatabaseError)
+	assert.Nil(t, wallet)
+	repo.AssertNotCalled(t, "CreateJournalEntry", mock.Anything, mock.Anything)
+}
+
+func TestStatusTransitionErrorFailsPosting(t *testing.T) {
+	ls, repo, transactionService := setupLedgerServiceMock()
+	tx := new(sql.Tx)
+
+	transactionService.On("RecordTransaction", tx, (*string)(nil), &testToWalletNumber, "deposit", testAmount, models.TransactionNote{}).
+		Return(&models.Transaction{ID: 10, TransactionType: "deposit"}, nil)
+	repo.On("CreateJournalEntry", tx, mock.AnythingOfType("*models.JournalEntry")).Return(nil)
+	expectPosting(repo, AccountExternalCash, 1, Debit)
+	expectPosting(repo, WalletAccountCode(testToWalletNumber), 2, Credit)
+	repo.On("ApplyWalletDelta", tx, testToWalletNumber, testAmount).Return(&models.Wallet{}, nil)
+	transactionService.On("SetBalancesAfter", tx, mock.AnythingOfType("*models.Transaction"), mock.Anything, mock.Anything).Return(nil)
+	transactionService.On("UpdateStatus", tx, mock.AnythingOfType("*models.Transaction"), transaction.StatusCompleted).
+		Return(utils.ServiceErrInvalidStatusTransition)
+
+	wallet, err := ls.Deposit(tx, testToWalletNumber, testAmount, models.TransactionNote{})
+
+	assert.ErrorIs(t, err, utils.ServiceErrInvalidStatusTransition)
+	assert.Nil(t, wallet)
+}
+
+func TestValidateBalanced(t *testing.T) {
+	usd := func(v string) money.Money { return money.MustParse(v, money.DefaultCurrency) }
+	cash := systemAccount(AccountExternalCash, money.DefaultCurrency)
+	wallet := walletAccount(testToWalletNumber, money.DefaultCurrency)
+
+	testCases := []struct {
+		name          string
+		lines         []line
+		expectedError error
+	}{
+		{
+			name: "balanced entry",
+			lines: []line{
+				{account: cash, direction: Debit, amount: usd("10.00")},
+				{account: wallet, direction: Credit, amount: usd("10.00")},
+			},
+		},
+		{
+			name: "debits exceed credits",
+			lines: []line{
+				{account: cash, direction: Debit, amount: usd("10.00")},
+				{account: wallet, direction: Credit, amount: usd("9.99")},
+			},
+			expectedError: utils.ServiceErrUnbalancedEntry,
+		},
+		{
+			name: "single line",
+			lines: []line{
+				{account: cash, direction: Debit, amount: usd("10.00")},
+			},
+			expectedError: utils.ServiceErrUnbalancedEntry,
+		},
+		{
+			name: "non-positive amount",
+			lines: []line{
+				{account: cash, direction: Debit, amount: usd("0.00")},
+				{account: wallet, direction: Credit, amount: usd("0.00")},
+			},
+			expectedError: utils.ServiceErrUnbalancedEntry,
+		},
+		{
+			name: "unknown direction",
+			lines: []line{
+				{account: cash, direction: "sideways", amount: usd("10.00")},
+				{account: wallet, direction: Credit, amount: usd("10.00")},
+			},
+			expectedError: utils.ServiceErrUnbalancedEntry,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedError, validateBalanced(tc.lines))
+		})
+	}
+}
+
+func TestVerifyWalletBalance(t *testing.T) {
+	stored := money.MustParse("100.00", money.DefaultCurrency)
+
+	testCases := []struct {
+		name          string
+		posted        money.Money
+		postedErr     error
+		expectedError error
+	}{
+		{name: "balances agree", posted: stored},
+		{name: "balances disagree", posted: money.MustParse("99.99", money.DefaultCurrency), expectedError: utils.ServiceErrLedgerBalanceMismatch},
+		{name: "database error", posted: money.Money{}, postedErr: errors.New("db error"), expectedError: errors.New("db error")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ls, repo, _ := setupLedgerServiceMock()
+			repo.On("GetWalletBalance", testFromWalletNumber).Return(stored, nil)
+			repo.On("GetPostedBalance", WalletAccountCode(testFromWalletNumber), money.DefaultCurrency).Return(tc.posted, tc.postedErr)
+
+			err := ls.VerifyWalletBalance(testFromWalletNumber)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestGetBalanceAsOf(t *testing.T) {
+	asOf := time.Date(2024, 5, 1, 23, 59, 59, 0, time.UTC)
+	ls, repo, _ := setupLedgerServiceMock()
+	repo.On("GetPostedBalanceAsOf", WalletAccountCode(testFromWalletNumber), money.DefaultCurrency, asOf).Return(testAmount, nil)
+
+	balance, err := ls.GetBalanceAsOf(testFromWalletNumber, money.DefaultCurrency, asOf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testAmount, balance)
+	repo.AssertExpectations(t)
+}
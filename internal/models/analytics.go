@@ -0,0 +1,90 @@
+package models
+
+import (
+	"centralized-wallet/internal/money"
+	"encoding/json"
+	"time"
+)
+
+// WalletAnalytics aggregates the posted transactions of a wallet over [From, To). Every amount is in the wallet's
+// currency: incoming exchanges count with the amount credited, outgoing ones with the amount debited.
+type WalletAnalytics struct {
+	WalletNumber      string                  `json:"wallet_number"`
+	Currency          string                  `json:"currency"`
+	From              time.Time               `json:"from"` // Inclusive
+	To                time.Time               `json:"to"`   // Exclusive
+	Interval          string                  `json:"interval"`
+	TotalIn           money.Money             `json:"total_in"`
+	TotalOut          money.Money             `json:"total_out"`
+	Net               money.Money             `json:"net"` // TotalIn minus TotalOut
+	Count             int                     `json:"count"`
+	ByType            []AnalyticsTypeTotal    `json:"by_type"`
+	TopCounterparties []AnalyticsCounterparty `json:"top_counterparties"`
+	Buckets           []AnalyticsBucket       `json:"buckets"`
+}
+
+// AnalyticsTypeTotal sums the transactions of one type
+type AnalyticsTypeTotal struct {
+	TransactionType string      `json:"transaction_type"`
+	Count           int         `json:"count"`
+	In              money.Money `json:"in"`
+	Out             money.Money `json:"out"`
+}
+
+// AnalyticsCounterparty sums the transactions exchanged with one other wallet
+type AnalyticsCounterparty struct {
+	WalletNumber string      `json:"wallet_number"`
+	Email        string      `json:"email,omitempty"`
+	Count        int         `json:"count"`
+	In           money.Money `json:"in"`
+	Out          money.Money `json:"out"`
+}
+
+// AnalyticsBucket sums the transactions of one day, week (starting on Monday) or month, in UTC
+type AnalyticsBucket struct {
+	Start time.Time   `json:"start"`
+	Count int         `json:"count"`
+	In    money.Money `json:"in"`
+	Out   money.Money `json:"out"`
+	Net   money.Money `json:"net"`
+}
+
+// UnmarshalJSON decodes the amounts in the wallet's currency, so cached analytics of zero- or three-decimal
+// wallets keep their precision. The rows are pre-filled with zero amounts, which json then decodes into in place.
+func (a *WalletAnalytics) UnmarshalJSON(data []byte) error {
+	type plain WalletAnalytics
+
+	var probe struct {
+		Currency          string            `json:"currency"`
+		ByType            []json.RawMessage `json:"by_type"`
+		TopCounterparties []json.RawMessage `json:"top_counterparties"`
+		Buckets           []json.RawMessage `json:"buckets"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+
+	zero := money.Zero(probe.Currency)
+	decoded := plain{
+		TotalIn:           zero,
+		TotalOut:          zero,
+		Net:               zero,
+		ByType:            make([]AnalyticsTypeTotal, len(probe.ByType)),
+		TopCounterparties: make([]AnalyticsCounterparty, len(probe.TopCounterparties)),
+		Buckets:           make([]AnalyticsBucket, len(probe.Buckets)),
+	}
+	for i := range decoded.ByType {
+		decoded.ByType[i] = AnalyticsTypeTotal{In: zero, Out: zero}
+	}
+	for i := range decoded.TopCounterparties {
+		decoded.TopCounterparties[i] = AnalyticsCounterparty{In: zero, Out: zero}
+	}
+	for i := range decoded.Buckets {
+		decoded.Buckets[i] = AnalyticsBucket{In: zero, Out: zero, Net: zero}
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*a = WalletAnalytics(decoded)
+	return nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/money"
+	"time"
+)
+
+// FXQuote locks the price of a conversion between two wallets of different currencies until it expires
+type FXQuote struct {
+	ID               int         `db:"id" json:"id"`
+	UserID           int         `db:"user_id" json:"-"`
+	FromWalletNumber string      `db:"from_wallet_number" json:"from_wallet_number"`
+	ToWalletNumber   string      `db:"to_wallet_number" json:"to_wallet_number"`
+	SourceAmount     money.Money `db:"source_amount" json:"source_amount"` // Debited from the source wallet, spread included
+	SourceCurrency   string      `db:"source_currency" json:"source_currency"`
+	TargetAmount     money.Money `db:"target_amount" json:"target_amount"` // Credited to the destination wallet
+	TargetCurrency   string      `db:"target_currency" json:"target_currency"`
+	SpreadAmount     money.Money `db:"spread_amount" json:"spread_amount"` // Kept by the platform, in the source currency
+	MidRate          fx.Rate     `db:"mid_rate" json:"mid_rate"`
+	AppliedRate      fx.Rate     `db:"applied_rate" json:"applied_rate"`
+	Status           string      `db:"status" json:"status"`
+	TransactionID    *int        `db:"transaction_id" json:"transaction_id"` // Set once the quote is executed
+	ExpiresAt        time.Time   `db:"expires_at" json:"expires_at"`
+	CreatedAt        time.Time   `db:"created_at" json:"created_at"`
+	ExecutedAt       *time.Time  `db:"executed_at" json:"executed_at"`
+}
+
+// Conversion returns the priced conversion the quote locked
+func (q *FXQuote) Conversion() *fx.Conversion {
+	return &fx.Conversion{
+		Source:      q.SourceAmount,
+		Target:      q.TargetAmount,
+		Spread:      q.SpreadAmount,
+		MidRate:     q.MidRate,
+		AppliedRate: q.AppliedRate,
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"centralized-wallet/internal/money"
+	"time"
+)
+
+// Hold reserves part of a wallet's balance for a later transfer to another wallet
+type Hold struct {
+	ID             int         `db:"id" json:"id"`
+	WalletNumber   string      `db:"wallet_number" json:"wallet_number"`       // Wallet whose funds are reserved
+	ToWalletNumber string      `db:"to_wallet_number" json:"to_wallet_number"` // Wallet that receives the funds on capture
+	Amount         money.Money `db:"amount" json:"amount"`
+	CapturedAmount money.Money `db:"captured_amount" json:"captured_amount"`
+	Currency       string      `db:"currency" json:"currency"` // Currency of the payer's wallet
+	Status         string      `db:"status" json:"status"`
+	ExpiresAt      time.Time   `db:"expires_at" json:"expires_at"`
+	CreatedAt      time.Time   `db:"created_at" json:"created_at"`
+	CapturedAt     *time.Time  `db:"captured_at" json:"captured_at"`
+	ReleasedAt     *time.Time  `db:"released_at" json:"released_at"` // Set when the hold is released or expires
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// IdempotencyKey stores the outcome of a mutating request so client retries can be replayed safely
+type IdempotencyKey struct {
+	ID             int        `db:"id" json:"id"`
+	UserID         int        `db:"user_id" json:"user_id"`
+	Key            string     `db:"idempotency_key" json:"idempotency_key"`
+	RequestHash    string     `db:"request_hash" json:"request_hash"`       // SHA-256 of method, path and body
+	ResponseStatus *int       `db:"response_status" json:"response_status"` // Nullable until the original request completes
+	ResponseBody   []byte     `db:"response_body" json:"response_body"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	LockedUntil    time.Time  `db:"locked_until" json:"locked_until"` // Lease of the request holding the key, which also identifies that request
+	CommittedAt    *time.Time `db:"committed_at" json:"committed_at"` // Set in the DB transaction that moved the money of the request
+}
+
+// IdempotencyClaim identifies the request holding a key by the lease it was given.
+// A claim is stale once another request reclaimed the key, so it can no longer mark, complete or release it.
+type IdempotencyClaim struct {
+	UserID      int
+	Key         string
+	LockedUntil time.Time
+}
+
+// Claim returns the claim of the request holding the key
+func (k *IdempotencyKey) Claim() IdempotencyClaim {
+	return IdempotencyClaim{UserID: k.UserID, Key: k.Key, LockedUntil: k.LockedUntil}
+}
+
+// IsCompleted reports whether the original response has been stored
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.ResponseStatus != nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"centralized-wallet/internal/money"
+	"time"
+)
+
+// LedgerAccount is an account in the double-entry ledger, either a user wallet or a system account
+type LedgerAccount struct {
+	ID           int       `db:"id" json:"id"`
+	Code         string    `db:"code" json:"code"`                   // e.g. "wallet:WAL-1-..." or "system:external_cash"
+	AccountType  string    `db:"account_type" json:"account_type"`   // "wallet" or "system"
+	WalletNumber *string   `db:"wallet_number" json:"wallet_number"` // Only set for wallet accounts
+	Currency     string    `db:"currency" json:"currency"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// JournalEntry groups the postings of one business event. Its debits always equal its credits.
+type JournalEntry struct {
+	ID            int       `db:"id" json:"id"`
+	TransactionID *int      `db:"transaction_id" json:"transaction_id"` // The user-facing transaction this entry backs
+	EntryType     string    `db:"entry_type" json:"entry_type"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	Postings      []Posting `json:"postings"`
+}
+
+// Posting is a single debit or credit against a ledger account
+type Posting struct {
+	ID             int         `db:"id" json:"id"`
+	JournalEntryID int         `db:"journal_entry_id" json:"journal_entry_id"`
+	AccountID      int         `db:"account_id" json:"account_id"`
+	Direction      string      `db:"direction" json:"direction"` // "debit" or "credit"
+	Amount         money.Money `db:"amount" json:"amount"`       // Always positive, in minor units
+	CreatedAt      time.Time   `db:"created_at" json:"created_at"`
+}
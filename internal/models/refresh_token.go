@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RefreshToken is a stored refresh token. Only its hash is kept; the token itself is shown once, when issued.
+type RefreshToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	FamilyID  string     `db:"family_id"` // Shared by the tokens rotated from one login
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// TokenPair is what a login or a refresh returns: a short-lived access token and the refresh token to renew it
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Lifetime of the access token, in seconds
+}
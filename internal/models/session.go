@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Session is one login on one device, from the login until it is revoked or its refresh tokens expire
+type Session struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     int        `db:"user_id" json:"-"`
+	UserAgent  string     `db:"user_agent" json:"user_agent"`
+	IPAddress  string     `db:"ip_address" json:"ip_address"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastSeenAt time.Time  `db:"last_seen_at" json:"last_seen_at"` // Updated at most once per minute
+	RevokedAt  *time.Time `db:"revoked_at" json:"-"`
+	Current    bool       `db:"-" json:"current"` // Whether the listing was requested from this session
+}
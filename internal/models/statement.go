@@ -0,0 +1,58 @@
+package models
+
+import (
+	"centralized-wallet/internal/money"
+	"time"
+)
+
+// Statement is the header of a wallet statement over [From, To): the wallet and its balances at both ends of the period
+type Statement struct {
+	WalletNumber   string
+	Name           string
+	Currency       string
+	From           time.Time   // Inclusive
+	To             time.Time   // Exclusive
+	OpeningBalance money.Money // Balance right before From
+	ClosingBalance money.Money // Balance right before To
+}
+
+// StatementLine is one posted transaction of a statement, seen from the statement's wallet
+type StatementLine struct {
+	ID                 int          `json:"id"`
+	Date               time.Time    `json:"date"`
+	TransactionType    string       `json:"transaction_type"`
+	Status             string       `json:"status"`
+	Direction          string       `json:"direction"`
+	CounterpartyWallet string       `json:"counterparty_wallet_number,omitempty"`
+	CounterpartyEmail  string       `json:"counterparty_email,omitempty"`
+	Amount             money.Money  `json:"amount"` // Signed: positive when credited to the wallet, negative when debited
+	Currency           string       `json:"currency"`
+	BalanceAfter       *money.Money `json:"balance_after,omitempty"`
+	Fee                *money.Money `json:"fee,omitempty"` // Part of the amount kept by the platform, e.g. the spread of an outgoing exchange
+}
+
+// StatementTotal sums the posted transactions of one type over a statement's period
+type StatementTotal struct {
+	TransactionType string      `json:"transaction_type"`
+	Count           int         `json:"count"`
+	Credits         money.Money `json:"credits"` // Sum of the amounts credited to the wallet
+	Debits          money.Money `json:"debits"`  // Sum of the amounts debited from the wallet, as a positive amount
+}
+
+// MonthlyStatement is a statement document issued for one wallet and one calendar month.
+// The rendered documents are only loaded when one is downloaded.
+type MonthlyStatement struct {
+	ID               int         `db:"id" json:"-"`
+	StatementNumber  string      `db:"statement_number" json:"statement_number"`
+	WalletNumber     string      `db:"wallet_number" json:"wallet_number"`
+	PeriodStart      time.Time   `db:"period_start" json:"period_start"` // Inclusive
+	PeriodEnd        time.Time   `db:"period_end" json:"period_end"`     // Exclusive
+	Currency         string      `db:"currency" json:"currency"`
+	OpeningBalance   money.Money `db:"opening_balance" json:"opening_balance"`
+	ClosingBalance   money.Money `db:"closing_balance" json:"closing_balance"`
+	TotalFees        money.Money `db:"total_fees" json:"total_fees"`
+	TransactionCount int         `db:"transaction_count" json:"transaction_count"`
+	CreatedAt        time.Time   `db:"created_at" json:"created_at"`
+	HTML             []byte      `db:"html" json:"-"`
+	PDF              []byte      `db:"pdf" json:"-"`
+}
@@ -1,29 +1,234 @@
 package models
 
-import "time"
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/money"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Transaction represents a financial transaction
 type Transaction struct {
-	ID               int       `db:"id" json:"id"`
-	FromWalletNumber *string   `db:"from_wallet_number" json:"from_wallet_number"` // Nullable field, so it's a pointer
-	ToWalletNumber   *string   `db:"to_wallet_number" json:"to_wallet_number"`     // Nullable field, so it's a pointer
-	TransactionType  string    `db:"transaction_type" json:"transaction_type"`
-	Amount           float64   `db:"amount" json:"amount"`
-	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	ID                    int          `db:"id" json:"id"`
+	FromWalletNumber      *string      `db:"from_wallet_number" json:"from_wallet_number"` // Nullable field, so it's a pointer
+	ToWalletNumber        *string      `db:"to_wallet_number" json:"to_wallet_number"`     // Nullable field, so it's a pointer
+	TransactionType       string       `db:"transaction_type" json:"transaction_type"`
+	Amount                money.Money  `db:"amount" json:"amount"`
+	Currency              string       `db:"currency" json:"currency"` // ISO 4217 code of the amount
+	Status                string       `db:"status" json:"status"`
+	ReversesTransactionID *int         `db:"reverses_transaction_id" json:"reverses_transaction_id"` // Set on reversals, points at the original transaction
+	ToAmount              *money.Money `db:"to_amount" json:"to_amount,omitempty"`                   // Set on exchanges: the amount credited, in the destination currency
+	ToCurrency            *string      `db:"to_currency" json:"to_currency,omitempty"`               // Set on exchanges: ISO 4217 code of ToAmount
+	FXRate                *fx.Rate     `db:"fx_rate" json:"fx_rate,omitempty"`                       // Set on exchanges: destination units per source unit, spread included
+	FXSpread              *money.Money `db:"fx_spread" json:"fx_spread,omitempty"`                   // Set on exchanges: the part of Amount kept by the platform
+	FromBalanceAfter      *money.Money `db:"from_balance_after" json:"from_balance_after,omitempty"` // Balance of the source wallet once posted
+	ToBalanceAfter        *money.Money `db:"to_balance_after" json:"to_balance_after,omitempty"`     // Balance of the destination wallet once posted
+	Memo                  *string      `db:"memo" json:"memo,omitempty"`                             // Free text shown to both parties
+	ExternalReference     *string      `db:"external_reference" json:"external_reference,omitempty"` // The client's own ID for the transaction
+	Metadata              Metadata     `db:"metadata" json:"metadata,omitempty"`                     // Free-form key/values from the client
+	CreatedAt             time.Time    `db:"created_at" json:"created_at"`
+	CompletedAt           *time.Time   `db:"completed_at" json:"completed_at"` // Set when the transaction reaches completed
+	FailedAt              *time.Time   `db:"failed_at" json:"failed_at"`       // Set when the transaction reaches failed
+	ReversedAt            *time.Time   `db:"reversed_at" json:"reversed_at"`   // Set when the transaction reaches reversed
+}
+
+// TransactionNote is what the client attaches to a deposit, withdrawal or transfer. Every field is optional.
+type TransactionNote struct {
+	Memo              string
+	ExternalReference string
+	Metadata          Metadata
+	Idempotency       *IdempotencyClaim // Key of the request, marked committed in the DB transaction recording it
+}
+
+// Apply copies the fields of the note that are set onto the transaction
+func (n TransactionNote) Apply(transaction *Transaction) {
+	if n.Memo != "" {
+		memo := n.Memo
+		transaction.Memo = &memo
+	}
+	if n.ExternalReference != "" {
+		reference := n.ExternalReference
+		transaction.ExternalReference = &reference
+	}
+	if len(n.Metadata) > 0 {
+		transaction.Metadata = n.Metadata
+	}
+}
+
+// Metadata is a JSON object stored in a JSONB column, NULL when empty
+type Metadata map[string]interface{}
+
+// Value implements driver.Valuer
+func (m Metadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan implements sql.Scanner
+func (m *Metadata) Scan(src interface{}) error {
+	var encoded []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		encoded = v
+	case string:
+		encoded = []byte(v)
+	default:
+		return fmt.Errorf("models: cannot scan %T into Metadata", src)
+	}
+	return json.Unmarshal(encoded, m)
 }
 
 type TransactionWithEmails struct {
 	Transaction         // Embedding the existing Transaction struct
 	FromEmail   *string `json:"from_email"`
 	ToEmail     *string `json:"to_email"`
+	FromUserID  *int    `json:"-"` // Owner of the source wallet, used for ownership checks
+	ToUserID    *int    `json:"-"` // Owner of the destination wallet
 }
 
 type FormattedTransaction struct {
-	TransactionType  string  `json:"transaction_type"`
-	Amount           float64 `json:"amount"`
-	Direction        string  `json:"direction"`
-	FromWalletNumber string  `json:"from_wallet_number,omitempty"`
-	FromEmail        string  `json:"from_email,omitempty"`
-	ToWalletNumber   string  `json:"to_wallet_number,omitempty"`
-	ToEmail          string  `json:"to_email,omitempty"`
+	ID                    int          `json:"id"`
+	TransactionType       string       `json:"transaction_type"`
+	Amount                money.Money  `json:"amount"`
+	Currency              string       `json:"currency"`
+	Status                string       `json:"status"`
+	Direction             string       `json:"direction"`
+	FromWalletNumber      string       `json:"from_wallet_number,omitempty"`
+	FromEmail             string       `json:"from_email,omitempty"`
+	ToWalletNumber        string       `json:"to_wallet_number,omitempty"`
+	ToEmail               string       `json:"to_email,omitempty"`
+	ReversesTransactionID *int         `json:"reverses_transaction_id,omitempty"`
+	ToAmount              *money.Money `json:"to_amount,omitempty"`
+	ToCurrency            string       `json:"to_currency,omitempty"`
+	FXRate                *fx.Rate     `json:"fx_rate,omitempty"`
+	BalanceAfter          *money.Money `json:"balance_after,omitempty"` // Balance of the wallet once the transaction was posted
+	Memo                  string       `json:"memo,omitempty"`
+	ExternalReference     string       `json:"external_reference,omitempty"`
+	Metadata              Metadata     `json:"metadata,omitempty"`
+}
+
+// UnmarshalJSON decodes the amounts in the currency of their leg, so cached histories
+// of zero- or three-decimal wallets keep their precision
+func (ft *FormattedTransaction) UnmarshalJSON(data []byte) error {
+	type plain FormattedTransaction
+
+	var probe struct {
+		Currency     string          `json:"currency"`
+		ToCurrency   string          `json:"to_currency"`
+		Direction    string          `json:"direction"`
+		BalanceAfter json.RawMessage `json:"balance_after"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+
+	decoded := plain{Amount: money.Zero(probe.Currency)}
+	balanceCurrency := probe.Currency
+	if probe.ToCurrency != "" {
+		toAmount := money.Zero(probe.ToCurrency)
+		decoded.ToAmount = &toAmount
+		if probe.Direction == "incoming" {
+			balanceCurrency = probe.ToCurrency
+		}
+	}
+	if len(probe.BalanceAfter) > 0 {
+		balanceAfter := money.Zero(balanceCurrency)
+		decoded.BalanceAfter = &balanceAfter
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*ft = FormattedTransaction(decoded)
+	return nil
+}
+
+// TransactionDetail is the full record of one transaction as seen by one of its parties, e.g. for a receipt.
+// Emails are masked and BalanceAfter is the balance of the viewer's wallet once the transaction was posted.
+type TransactionDetail struct {
+	ID                    int          `json:"id"`
+	TransactionType       string       `json:"transaction_type"`
+	Status                string       `json:"status"`
+	Direction             string       `json:"direction"`
+	Amount                money.Money  `json:"amount"`
+	Currency              string       `json:"currency"`
+	FromWalletNumber      string       `json:"from_wallet_number,omitempty"`
+	FromEmail             string       `json:"from_email,omitempty"`
+	ToWalletNumber        string       `json:"to_wallet_number,omitempty"`
+	ToEmail               string       `json:"to_email,omitempty"`
+	ReversesTransactionID *int         `json:"reverses_transaction_id,omitempty"`
+	ToAmount              *money.Money `json:"to_amount,omitempty"`
+	ToCurrency            string       `json:"to_currency,omitempty"`
+	FXRate                *fx.Rate     `json:"fx_rate,omitempty"`
+	FXSpread              *money.Money `json:"fx_spread,omitempty"`
+	CreatedAt             time.Time    `json:"created_at"`
+	CompletedAt           *time.Time   `json:"completed_at,omitempty"`
+	FailedAt              *time.Time   `json:"failed_at,omitempty"`
+	ReversedAt            *time.Time   `json:"reversed_at,omitempty"`
+	WalletNumber          string       `json:"wallet_number"`           // The viewer's wallet in the transaction
+	BalanceAfter          *money.Money `json:"balance_after,omitempty"` // Unset while nothing has been posted, e.g. pending or failed
+	Memo                  string       `json:"memo,omitempty"`
+	ExternalReference     string       `json:"external_reference,omitempty"`
+	Metadata              Metadata     `json:"metadata,omitempty"`
+}
+
+// TransactionPage is one page of a cursor-paginated history. A cursor is empty when there is no page in that direction.
+type TransactionPage struct {
+	Transactions []FormattedTransaction `json:"transactions"`
+	NextCursor   string                 `json:"next_cursor"`
+	PrevCursor   string                 `json:"prev_cursor"`
+}
+
+// TransactionFilter narrows the transaction history. Empty fields do not filter.
+// Direction, amounts and counterparty are relative to the wallet whose history is read.
+type TransactionFilter struct {
+	Status            string
+	Type              string
+	Direction         string       // incoming or outgoing
+	CreatedFrom       *time.Time   // Inclusive
+	CreatedTo         *time.Time   // Exclusive
+	MinAmount         *money.Money // Inclusive, compared with the amount that moved in or out of the wallet
+	MaxAmount         *money.Money // Inclusive
+	Counterparty      string       // Wallet number or email of the other side
+	ExternalReference string       // Reference the client gave when making the transaction
+}
+
+// CacheKey renders every filter field, so cached history pages of different filters never mix
+func (f TransactionFilter) CacheKey() string {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	formatAmount := func(m *money.Money) string {
+		if m == nil {
+			return ""
+		}
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	return strings.Join([]string{
+		"status=" + f.Status,
+		"type=" + f.Type,
+		"direction=" + f.Direction,
+		"from=" + formatTime(f.CreatedFrom),
+		"to=" + formatTime(f.CreatedTo),
+		"min=" + formatAmount(f.MinAmount),
+		"max=" + formatAmount(f.MaxAmount),
+		"counterparty=" + f.Counterparty,
+		"reference=" + f.ExternalReference,
+	}, ":")
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"centralized-wallet/internal/money"
+	"time"
+)
+
+// TransferIntent is a previewed transfer that moves no money until the payer confirms it before it expires
+type TransferIntent struct {
+	ID                int         `db:"id" json:"id"`
+	UserID            int         `db:"user_id" json:"-"`
+	FromWalletNumber  string      `db:"from_wallet_number" json:"from_wallet_number"`
+	ToWalletNumber    string      `db:"to_wallet_number" json:"-"` // The payer only sees the masked recipient
+	Recipient         Recipient   `json:"recipient"`
+	Amount            money.Money `db:"amount" json:"amount"` // Debited from the source wallet, fee included
+	Currency          string      `db:"currency" json:"currency"`
+	Fee               money.Money `db:"fee" json:"fee"`                         // Kept by the platform, in the source currency
+	CreditedAmount    money.Money `db:"credited_amount" json:"credited_amount"` // Credited to the recipient, in Recipient.Currency
+	BalanceAfter      money.Money `db:"balance_after" json:"balance_after"`     // Available balance of the source wallet once confirmed
+	Memo              *string     `db:"memo" json:"memo,omitempty"`
+	ExternalReference *string     `db:"external_reference" json:"external_reference,omitempty"`
+	Metadata          Metadata    `db:"metadata" json:"metadata,omitempty"`
+	Status            string      `db:"status" json:"status"`
+	TransactionID     *int        `db:"transaction_id" json:"transaction_id,omitempty"` // Set once the intent is confirmed
+	ExpiresAt         time.Time   `db:"expires_at" json:"expires_at"`
+	CreatedAt         time.Time   `db:"created_at" json:"created_at"`
+	ConfirmedAt       *time.Time  `db:"confirmed_at" json:"confirmed_at,omitempty"`
+}
+
+// Note returns the memo, external reference and metadata the confirmed transfer is recorded with
+func (i *TransferIntent) Note() TransactionNote {
+	note := TransactionNote{Metadata: i.Metadata}
+	if i.Memo != nil {
+		note.Memo = *i.Memo
+	}
+	if i.ExternalReference != nil {
+		note.ExternalReference = *i.ExternalReference
+	}
+	return note
+}
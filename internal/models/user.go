@@ -1,9 +1,37 @@
 package models
 
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled" // Cannot log in; outstanding tokens are refused
+)
+
+const (
+	UserRoleUser    = "user"
+	UserRoleSupport = "support" // Reads users, wallets and transactions through the admin API
+	UserRoleAdmin   = "admin"   // Support access, and disables users and changes roles
+	UserRoleAuditor = "auditor" // Reads users, wallets and transactions through the admin API
+)
+
 type User struct {
-	ID        int    `db:"id" json:"id"`
-	Email     string `db:"email" json:"email"`
-	Password  string `db:"password" json:"-"`                      // Excluded from JSON responses for security
-	CreatedAt string `db:"created_at" json:"created_at,omitempty"` // `omitempty` avoids sending empty values
-	UpdatedAt string `db:"updated_at" json:"updated_at,omitempty"`
+	ID           int     `db:"id" json:"id"`
+	Email        string  `db:"email" json:"email"`
+	Password     string  `db:"password" json:"-"`              // Excluded from JSON responses for security
+	Handle       *string `db:"handle" json:"handle,omitempty"` // Unique, lowercase, without the leading "$"
+	Name         *string `db:"name" json:"name,omitempty"`     // Display name, shown masked to payers
+	Status       string  `db:"status" json:"status,omitempty"`
+	Role         string  `db:"role" json:"role,omitempty"`
+	TokenVersion int     `db:"token_version" json:"-"`                 // Carried by access tokens; bumped to invalidate them all
+	CreatedAt    string  `db:"created_at" json:"created_at,omitempty"` // `omitempty` avoids sending empty values
+	UpdatedAt    string  `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// Recipient is the user a transfer addressed by email or $handle goes to, as previewed to the payer.
+// The wallet number and user ID are kept server side; the payer only sees masked details.
+type Recipient struct {
+	UserID       int    `json:"-"`
+	WalletNumber string `json:"-"`
+	Handle       string `json:"handle,omitempty"`
+	Name         string `json:"name,omitempty"` // Masked, e.g. "J*** D***"
+	Email        string `json:"email"`          // Masked, e.g. "j***@example.com"
+	Currency     string `json:"currency"`       // Currency of the wallet that receives the transfer
 }
@@ -1,12 +1,24 @@
 package models
 
-import "time"
+import (
+	"centralized-wallet/internal/money"
+	"time"
+)
 
 type Wallet struct {
-	ID           int       `db:"id" json:"id"`           // Wallet ID
-	UserID       int       `db:"user_id" json:"user_id"` // Foreign key to the user
-	WalletNumber string    `db:"wallet_number" json:"wallet_number"`
-	Balance      float64   `db:"balance" json:"balance"`       // The balance in the wallet
-	CreatedAt    time.Time `db:"created_at" json:"created_at"` // Timestamp when the wallet was created
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"` // Timestamp when the wallet was last updated
+	ID           int         `db:"id" json:"id"`           // Wallet ID
+	UserID       int         `db:"user_id" json:"user_id"` // Foreign key to the user
+	WalletNumber string      `db:"wallet_number" json:"wallet_number"`
+	Name         string      `db:"name" json:"name"`                 // Name chosen by the user, unique per user
+	IsDefault    bool        `db:"is_default" json:"is_default"`     // Wallet used when a request names no wallet
+	Currency     string      `db:"currency" json:"currency"`         // ISO 4217 code of the balance, fixed at creation
+	Balance      money.Money `db:"balance" json:"balance"`           // The balance in the wallet
+	HeldBalance  money.Money `db:"held_balance" json:"held_balance"` // Part of the balance reserved by active holds
+	CreatedAt    time.Time   `db:"created_at" json:"created_at"`     // Timestamp when the wallet was created
+	UpdatedAt    time.Time   `db:"updated_at" json:"updated_at"`     // Timestamp when the wallet was last updated
+}
+
+// AvailableBalance is the part of the balance that can still be withdrawn or transferred
+func (w *Wallet) AvailableBalance() money.Money {
+	return money.New(w.Balance.Amount-w.HeldBalance.Amount, w.Balance.Currency)
 }
@@ -0,0 +1,60 @@
+package money
+
+import "strings"
+
+// DefaultCurrency is used whenever an amount is not tied to an explicit currency
+const DefaultCurrency = "USD"
+
+// currencyExponents maps an ISO 4217 code to the number of decimals of its minor unit
+var currencyExponents = map[string]int{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"CAD": 2,
+	"AUD": 2,
+	"SGD": 2,
+	"THB": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+// Exponent returns the number of minor-unit decimals allowed for the currency
+func Exponent(currency string) (int, error) {
+	exp, ok := currencyExponents[normalizeCurrency(currency)]
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	return exp, nil
+}
+
+// IsSupported reports whether the currency is known to the money package
+func IsSupported(currency string) bool {
+	_, err := Exponent(currency)
+	return err == nil
+}
+
+// NormalizeCurrency upper-cases the code and maps an empty one to DefaultCurrency
+func NormalizeCurrency(currency string) string {
+	return normalizeCurrency(currency)
+}
+
+func normalizeCurrency(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+// pow10 returns 10^n for the small exponents used by currencies
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
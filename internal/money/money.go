@@ -0,0 +1,301 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid monetary amount")
+	ErrTooManyDecimals  = errors.New("amount has more decimals than the currency allows")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrAmountOverflow   = errors.New("monetary amount overflows")
+)
+
+// Money is an exact monetary amount stored as an integer number of minor units (e.g. cents)
+type Money struct {
+	Amount   int64  // Amount in minor units of the currency
+	Currency string // ISO 4217 currency code
+}
+
+// New creates a Money value from an amount already expressed in minor units
+func New(minorUnits int64, currency string) Money {
+	return Money{Amount: minorUnits, Currency: normalizeCurrency(currency)}
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// Parse converts a decimal string such as "12.34" into Money.
+// It rejects amounts with more significant decimals than the currency allows.
+func Parse(value string, currency string) (Money, error) {
+	currency = normalizeCurrency(currency)
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	negative, intPart, fracPart, err := splitDecimal(value)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if len(fracPart) > exp {
+		// Trailing zeros beyond the currency precision do not change the amount
+		if strings.Trim(fracPart[exp:], "0") != "" {
+			return Money{}, ErrTooManyDecimals
+		}
+		fracPart = fracPart[:exp]
+	}
+
+	minor, err := toMinorUnits(intPart, fracPart, exp)
+	if err != nil {
+		return Money{}, err
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// MustParse is like Parse but panics on error. Intended for constants, seeds and tests.
+func MustParse(value string, currency string) Money {
+	m, err := Parse(value, currency)
+	if err != nil {
+		panic(fmt.Sprintf("money: cannot parse %q as %s: %v", value, currency, err))
+	}
+	return m
+}
+
+// FromFloat converts a float64 into Money, rounding half away from zero to the currency precision.
+// The float is first rendered as its shortest decimal representation so the result is deterministic.
+func FromFloat(value float64, currency string) (Money, error) {
+	currency = normalizeCurrency(currency)
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	negative, intPart, fracPart, err := splitDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		return Money{}, err
+	}
+
+	roundUp := false
+	if len(fracPart) > exp {
+		roundUp = fracPart[exp] >= '5'
+		fracPart = fracPart[:exp]
+	}
+
+	minor, err := toMinorUnits(intPart, fracPart, exp)
+	if err != nil {
+		return Money{}, err
+	}
+	if roundUp {
+		if minor == math.MaxInt64 {
+			return Money{}, ErrAmountOverflow
+		}
+		minor++
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// Add returns m + other. Both amounts must share the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if !m.SameCurrency(other) {
+		return Money{}, ErrCurrencyMismatch
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.currency()}, nil
+}
+
+// Sub returns m - other. Both amounts must share the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+	return m.Add(other.Neg())
+}
+
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.currency()}
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or 1
+func (m Money) Cmp(other Money) (int, error) {
+	if !m.SameCurrency(other) {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// SameCurrency reports whether both amounts are expressed in the same currency
+func (m Money) SameCurrency(other Money) bool {
+	return m.currency() == other.currency()
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// String renders the amount as a plain decimal string with the currency precision, e.g. "12.30"
+func (m Money) String() string {
+	exp, err := Exponent(m.currency())
+	if err != nil {
+		exp = 0
+	}
+
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-(m.Amount + 1)) + 1 // avoids overflow for math.MinInt64
+	}
+
+	if exp == 0 {
+		return sign + strconv.FormatUint(abs, 10)
+	}
+
+	unit := uint64(pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/unit, exp, abs%unit)
+}
+
+// MarshalJSON encodes the amount as a JSON number so API responses keep their numeric shape
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number (or numeric string) using the receiver's currency,
+// falling back to DefaultCurrency when none is set.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		return nil
+	}
+	raw = strings.Trim(raw, `"`)
+
+	parsed, err := Parse(raw, m.currency())
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for BIGINT minor-unit columns
+func (m *Money) Scan(src interface{}) error {
+	var minor int64
+	switch v := src.(type) {
+	case nil:
+		minor = 0
+	case int64:
+		minor = v
+	case int32:
+		minor = int64(v)
+	case []byte:
+		parsed, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q: %w", v, err)
+		}
+		minor = parsed
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q: %w", v, err)
+		}
+		minor = parsed
+	default:
+		return fmt.Errorf("money: cannot scan type %T", src)
+	}
+
+	m.Amount = minor
+	m.Currency = m.currency()
+	return nil
+}
+
+// Value implements driver.Valuer, storing the amount in minor units
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+func (m Money) currency() string {
+	return normalizeCurrency(m.Currency)
+}
+
+// splitDecimal validates a plain decimal string and splits it into sign, integer and fractional digits
+func splitDecimal(value string) (negative bool, intPart string, fracPart string, err error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return false, "", "", ErrInvalidAmount
+	}
+
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return false, "", "", ErrInvalidAmount
+	}
+	if hasDot && fracPart == "" {
+		return false, "", "", ErrInvalidAmount
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return false, "", "", ErrInvalidAmount
+	}
+
+	return negative, intPart, fracPart, nil
+}
+
+// toMinorUnits combines integer and fractional digits into an integer amount of minor units
+func toMinorUnits(intPart, fracPart string, exp int) (int64, error) {
+	digits := intPart + fracPart + strings.Repeat("0", exp-len(fracPart))
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return 0, nil
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, ErrAmountOverflow
+	}
+	return minor, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
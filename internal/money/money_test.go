@@ -0,0 +1,130 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		currency      string
+		expected      Money
+		expectedError error
+	}{
+		{name: "whole amount", input: "100", currency: "USD", expected: Money{Amount: 10000, Currency: "USD"}},
+		{name: "two decimals", input: "12.34", currency: "USD", expected: Money{Amount: 1234, Currency: "USD"}},
+		{name: "one decimal", input: "0.5", currency: "USD", expected: Money{Amount: 50, Currency: "USD"}},
+		{name: "trailing zeros beyond precision", input: "1.500", currency: "USD", expected: Money{Amount: 150, Currency: "USD"}},
+		{name: "negative amount", input: "-3.21", currency: "USD", expected: Money{Amount: -321, Currency: "USD"}},
+		{name: "lower case currency", input: "1", currency: "usd", expected: Money{Amount: 100, Currency: "USD"}},
+		{name: "default currency", input: "1", currency: "", expected: Money{Amount: 100, Currency: DefaultCurrency}},
+		{name: "too many decimals", input: "0.001", currency: "USD", expectedError: ErrTooManyDecimals},
+		{name: "not a number", input: "abc", currency: "USD", expectedError: ErrInvalidAmount},
+		{name: "exponent notation", input: "1e3", currency: "USD", expectedError: ErrInvalidAmount},
+		{name: "dangling dot", input: "1.", currency: "USD", expectedError: ErrInvalidAmount},
+		{name: "empty", input: "", currency: "USD", expectedError: ErrInvalidAmount},
+		{name: "zero-decimal currency", input: "1500", currency: "JPY", expected: Money{Amount: 1500, Currency: "JPY"}},
+		{name: "decimals on zero-decimal currency", input: "1.5", currency: "JPY", expectedError: ErrTooManyDecimals},
+		{name: "three-decimal currency", input: "1.234", currency: "BHD", expected: Money{Amount: 1234, Currency: "BHD"}},
+		{name: "too many decimals for three-decimal currency", input: "1.2345", currency: "BHD", expectedError: ErrTooManyDecimals},
+		{name: "unknown currency", input: "1", currency: "XYZ", expectedError: ErrUnknownCurrency},
+		{name: "overflow", input: "999999999999999999999", currency: "USD", expectedError: ErrAmountOverflow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := Parse(tc.input, tc.currency)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, m)
+		})
+	}
+}
+
+func TestFromFloatRoundsHalfAwayFromZero(t *testing.T) {
+	testCases := []struct {
+		input    float64
+		expected int64
+	}{
+		{input: 0.1 + 0.2, expected: 30},
+		{input: 1.005, expected: 101},
+		{input: 2.675, expected: 268},
+		{input: -1.005, expected: -101},
+		{input: 10, expected: 1000},
+	}
+
+	for _, tc := range testCases {
+		m, err := FromFloat(tc.input, "USD")
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, m.Amount, "input %v", tc.input)
+	}
+
+	_, err := FromFloat(math.NaN(), "USD")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestArithmetic(t *testing.T) {
+	a := MustParse("0.10", "USD")
+	b := MustParse("0.20", "USD")
+
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse("0.30", "USD"), sum)
+
+	diff, err := a.Sub(b)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-10), diff.Amount)
+
+	cmp, err := a.Cmp(b)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, cmp)
+
+	_, err = a.Add(Money{Amount: math.MaxInt64, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "12.30", New(1230, "USD").String())
+	assert.Equal(t, "-0.05", New(-5, "USD").String())
+	assert.Equal(t, "0.00", Zero("USD").String())
+	assert.Equal(t, "1500", New(1500, "JPY").String())
+	assert.Equal(t, "1.005", New(1005, "BHD").String())
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	payload := struct {
+		Amount Money `json:"amount"`
+	}{Amount: MustParse("42.10", "USD")}
+
+	data, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":42.10}`, string(data))
+
+	var decoded struct {
+		Amount Money `json:"amount"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, payload.Amount, decoded.Amount)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":1.234}`), &decoded), ErrTooManyDecimals)
+}
+
+func TestScanAndValue(t *testing.T) {
+	var m Money
+	assert.NoError(t, m.Scan(int64(1999)))
+	assert.Equal(t, Money{Amount: 1999, Currency: DefaultCurrency}, m)
+
+	value, err := m.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1999), value)
+
+	assert.Error(t, m.Scan(1.5))
+}
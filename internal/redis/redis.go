@@ -20,6 +20,7 @@ type RedisServiceInterface interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	DeleteKeysByPattern(ctx context.Context, pattern string) error
+	Increment(ctx context.Context, key string, expiration time.Duration) (int64, error)
 }
 
 var (
@@ -106,6 +107,22 @@ func (r *RedisService) Set(ctx context.Context, key string, value interface{}, e
 	return r.Client.Set(ctx, key, value, expiration).Err()
 }
 
+// Increment adds one to the counter at key and returns the new count. The expiration is set when the
+// counter has none, so the counter covers a fixed window starting with its first increment. Both commands
+// run in one MULTI/EXEC, so a counter can never be left without an expiration.
+func (r *RedisService) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	var count *redis.IntCmd
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, expiration)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
 // Utility function to calculate pool utilization as a percentage.
 func calculatePoolUtilization(poolStats *redis.PoolStats) float64 {
 	if poolStats.TotalConns == 0 {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"log"
 	"testing"
+	"time"
 )
 
 var redisService *RedisService
@@ -53,3 +54,44 @@ func TestHealth(t *testing.T) {
 		t.Fatalf("expected redis_version to be present, got %v", stats["redis_version"])
 	}
 }
+
+// TestIncrement checks the counter starts at one, keeps counting and expires with its first increment.
+func TestIncrement(t *testing.T) {
+	srv := NewRedisService()
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		count, err := srv.Increment(ctx, "test:increment", time.Minute)
+		if err != nil {
+			t.Fatalf("Increment() returned an error: %v", err)
+		}
+		if count != want {
+			t.Fatalf("expected count %d, got %d", want, count)
+		}
+	}
+
+	ttl, err := srv.Client.TTL(ctx, "test:increment").Result()
+	if err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected the counter to expire within a minute, got %v (%v)", ttl, err)
+	}
+}
+
+// TestIncrementExpiresCounterWithoutTTL checks a counter left without an expiration is given one on its next increment.
+func TestIncrementExpiresCounterWithoutTTL(t *testing.T) {
+	srv := NewRedisService()
+	ctx := context.Background()
+
+	if err := srv.Client.Set(ctx, "test:increment:stuck", 11, 0).Err(); err != nil {
+		t.Fatalf("Set() returned an error: %v", err)
+	}
+
+	count, err := srv.Increment(ctx, "test:increment:stuck", time.Minute)
+	if err != nil || count != 12 {
+		t.Fatalf("expected count 12, got %d (%v)", count, err)
+	}
+
+	ttl, err := srv.Client.TTL(ctx, "test:increment:stuck").Result()
+	if err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected the counter to expire within a minute, got %v (%v)", ttl, err)
+	}
+}
@@ -6,19 +6,28 @@ import (
 	"fmt"
 )
 
+// SeedTransactions inserts a transaction as is. Transactions without a status are seeded as completed.
 func SeedTransactions(db *sql.DB, transaction *models.Transaction) error {
+	status := transaction.Status
+	if status == "" {
+		status = "completed"
+	}
+
 	_, err := db.Exec(`
 		INSERT INTO transactions (
 			transaction_type,
 			from_wallet_number,
 			to_wallet_number,
 			amount,
-			created_at
-		) VALUES ($1, $2, $3, $4, $5)`,
+			status,
+			created_at,
+			completed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $5 = 'completed' THEN $6::TIMESTAMP END)`,
 		transaction.TransactionType,
 		transaction.FromWalletNumber,
 		transaction.ToWalletNumber,
 		transaction.Amount,
+		status,
 		transaction.CreatedAt, // Make sure to add the created_at field
 	)
 
@@ -2,27 +2,79 @@ package seed
 
 import (
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"database/sql"
 	"fmt"
 )
 
+// SeedWallets inserts a wallet and backs any starting balance with an opening-balance journal entry,
+// so the wallet balance always matches its ledger postings. A user's first seeded wallet becomes the default.
 func SeedWallets(db *sql.DB, wallet *models.Wallet) error {
-	_, err := db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin wallet seed: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
 		INSERT INTO wallets (
 			user_id,
 			wallet_number,
+			name,
+			is_default,
 			balance
-		) VALUES ($1, $2, $3)`,
+		) VALUES ($1, $2, $3, NOT EXISTS (SELECT 1 FROM wallets WHERE user_id = $1), $4)`,
 		wallet.UserID,
 		wallet.WalletNumber,
+		walletName(wallet),
 		wallet.Balance,
 	)
-
 	if err != nil {
 		return fmt.Errorf("failed to insert wallet: %v", err)
 	}
 
-	return nil
+	if wallet.Balance.IsPositive() {
+		if err := seedOpeningBalance(tx, wallet); err != nil {
+			return fmt.Errorf("failed to seed opening balance: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// walletName falls back to the name new wallets get when none is chosen
+func walletName(wallet *models.Wallet) string {
+	if wallet.Name == "" {
+		return "Main"
+	}
+	return wallet.Name
+}
+
+// seedOpeningBalance credits the wallet account and debits the opening balances system account
+func seedOpeningBalance(tx *sql.Tx, wallet *models.Wallet) error {
+	currency := wallet.Balance.Currency
+	accountQuery := `INSERT INTO ledger_accounts (code, account_type, wallet_number, currency)
+					 VALUES ($1, $2, $3, $4)
+					 ON CONFLICT (code, currency) DO UPDATE SET code = EXCLUDED.code
+					 RETURNING id`
+
+	var walletAccountID, openingAccountID, entryID int
+	if err := tx.QueryRow(accountQuery, "wallet:"+wallet.WalletNumber, "wallet", wallet.WalletNumber, currency).Scan(&walletAccountID); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(accountQuery, "system:opening_balances", "system", nil, currency).Scan(&openingAccountID); err != nil {
+		return err
+	}
+	if err := tx.QueryRow("INSERT INTO journal_entries (entry_type) VALUES ('opening_balance') RETURNING id").Scan(&entryID); err != nil {
+		return err
+	}
+
+	postingQuery := `INSERT INTO postings (journal_entry_id, account_id, direction, amount, currency) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.Exec(postingQuery, entryID, walletAccountID, "credit", wallet.Balance, currency); err != nil {
+		return err
+	}
+	_, err := tx.Exec(postingQuery, entryID, openingAccountID, "debit", wallet.Balance, currency)
+	return err
 }
 
 func GenerateSampleWallets() []models.Wallet {
@@ -33,19 +85,19 @@ func GenerateSampleWallets() []models.Wallet {
 			ID:           1,
 			UserID:       1, // Belongs to Alice
 			WalletNumber: "wallet123",
-			Balance:      100.00, // Balance of 100
+			Balance:      money.MustParse("100.00", money.DefaultCurrency), // Balance of 100
 		},
 		{
 			ID:           2,
 			UserID:       2, // Belongs to Bob
 			WalletNumber: "wallet456",
-			Balance:      200.00, // Balance of 200
+			Balance:      money.MustParse("200.00", money.DefaultCurrency), // Balance of 200
 		},
 		{
 			ID:           3,
 			UserID:       3, // Belongs to Charlie
 			WalletNumber: "wallet789",
-			Balance:      300.00, // Balance of 300
+			Balance:      money.MustParse("300.00", money.DefaultCurrency), // Balance of 300
 		},
 	}
 }
@@ -3,9 +3,17 @@ package server
 import (
 	"net/http"
 
+	"centralized-wallet/internal/admin"
+	"centralized-wallet/internal/analytics"
 	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/exchange"
+	"centralized-wallet/internal/hold"
+	"centralized-wallet/internal/idempotency"
 	"centralized-wallet/internal/logging"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/statement"
 	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/transfer"
 	"centralized-wallet/internal/user"
 	"centralized-wallet/internal/wallet"
 
@@ -22,9 +30,12 @@ func (s *Server) RegisterRoutes() http.Handler {
 	r.GET("/db-health", s.dbHealthHandler)
 	r.GET("/redis-health", s.redisHealthHandler)
 
+	r.GET("/.well-known/jwks.json", auth.JWKSHandler()) // Public keys to verify access tokens with
+
 	// Register all routes
 	s.registerUserRoutes(r, s.userService)
 	s.registerWalletRoutes(r, s.walletService, s.transactionService)
+	s.registerAdminRoutes(r, s.transactionService)
 
 	return r
 }
@@ -42,24 +53,73 @@ func (s *Server) redisHealthHandler(c *gin.Context) {
 // registerUserRoutes registers all routes related to users
 func (s *Server) registerUserRoutes(r *gin.Engine, userService *user.UserService) {
 	r.POST("/register", user.RegistrationHandler(userService))
-	r.POST("/login", user.LoginHandler(userService))
+	r.POST("/login", user.LoginHandler(userService, s.tokenService))
+	r.POST("/token/refresh", user.RefreshTokenHandler(s.tokenService)) // Rotate a refresh token
+
+	r.Use(auth.JWTMiddleware(s.blackListService, s.sessionService, s.accountService)) // Apply JWT middleware to all user routes
+	r.POST("/logout", user.LogoutHandler(s.blackListService, s.sessionService))
+	r.PUT("/profile", user.UpdateProfileHandler(userService))
+	r.PUT("/password", user.ChangePasswordHandler(s.accountService)) // Change the password, invalidating every token
 
-	r.Use(auth.JWTMiddleware(s.blackListService)) // Apply JWT middleware to all user routes
-	r.POST("/logout", user.LogoutHandler(s.blackListService))
+	r.GET("/sessions", auth.ListSessionsHandler(s.sessionService))         // Where the user is logged in
+	r.DELETE("/sessions/:id", auth.RevokeSessionHandler(s.sessionService)) // Log one device out
+	r.DELETE("/sessions", auth.RevokeAllSessionsHandler(s.sessionService)) // Log out everywhere
 }
 
 // registerWalletRoutes registers all routes related to wallets and transactions
 func (s *Server) registerWalletRoutes(r *gin.Engine, walletService *wallet.WalletService, transactionService transaction.TransactionServiceInterface) {
 	walletRoutes := r.Group("/wallets")
-	walletRoutes.Use(auth.JWTMiddleware(s.blackListService)) // Apply JWT middleware to all wallet routes
-
-	walletRoutes.GET("/balance", wallet.BalanceHandler(walletService))    // Get balance
-	walletRoutes.POST("/deposit", wallet.DepositHandler(walletService))   // Deposit money
-	walletRoutes.POST("/withdraw", wallet.WithdrawHandler(walletService)) // Withdraw money
-	walletRoutes.POST("/transfer", wallet.TransferHandler(walletService))
+	walletRoutes.Use(auth.JWTMiddleware(s.blackListService, s.sessionService, s.accountService)) // Apply JWT middleware to all wallet routes
+
+	// Retries carrying the same Idempotency-Key replay the original response instead of moving money twice.
+	// Deposits, withdrawals and transfers mark their key committed with the money, so an unfinished key can be reclaimed.
+	idempotent := idempotency.IdempotencyMiddleware(s.idempotencyService)
+	commitTracked := idempotency.CommitTrackingIdempotencyMiddleware(s.idempotencyService)
+
+	walletRoutes.GET("", wallet.ListWalletsHandler(walletService))                       // List the user's wallets
+	walletRoutes.POST("/default", wallet.SetDefaultWalletHandler(walletService))         // Pick the default wallet
+	walletRoutes.GET("/balance", wallet.BalanceHandler(walletService))                   // Get balance
+	walletRoutes.POST("/deposit", commitTracked, wallet.DepositHandler(walletService))   // Deposit money
+	walletRoutes.POST("/withdraw", commitTracked, wallet.WithdrawHandler(walletService)) // Withdraw money
+	walletRoutes.POST("/transfer", commitTracked, wallet.TransferHandler(walletService, s.recipientService))
+	walletRoutes.GET("/recipients", wallet.RecipientLookupHandler(s.recipientService))                                 // Preview who an email or $handle belongs to
+	walletRoutes.POST("/transfers/preview", transfer.PreviewTransferHandler(s.transferService))                        // Price a transfer to confirm
+	walletRoutes.POST("/transfers/:intent_id/confirm", idempotent, transfer.ConfirmTransferHandler(s.transferService)) // Execute a previewed transfer
 	walletRoutes.POST("/create", wallet.CreateWalletHandler(walletService))
+	walletRoutes.POST("/transactions/:id/reverse", idempotent, wallet.ReverseTransactionHandler(walletService)) // Refund a received transaction
+	walletRoutes.GET("/transactions/:id", wallet.TransactionDetailHandler(transactionService))                  // One transaction with receipt data
+
+	walletRoutes.POST("/holds", idempotent, hold.CreateHoldHandler(s.holdService))              // Reserve funds for another wallet
+	walletRoutes.POST("/holds/:id/capture", idempotent, hold.CaptureHoldHandler(s.holdService)) // Transfer held funds to the payee
+	walletRoutes.POST("/holds/:id/release", idempotent, hold.ReleaseHoldHandler(s.holdService)) // Give held funds back to the payer
+
+	walletRoutes.POST("/fx/quotes", exchange.CreateQuoteHandler(s.exchangeService))                          // Price a conversion into another currency
+	walletRoutes.POST("/fx/quotes/:id/execute", idempotent, exchange.ExecuteQuoteHandler(s.exchangeService)) // Convert at the quoted rate
+
+	walletRoutes.GET("/statements", statement.ExportStatementHandler(s.statementService))                          // Download posted transactions as CSV, JSON Lines or OFX
+	walletRoutes.GET("/statements/monthly", statement.ListMonthlyStatementsHandler(s.statementService))            // Monthly statements issued for a wallet
+	walletRoutes.GET("/statements/monthly/:number", statement.DownloadMonthlyStatementHandler(s.statementService)) // One monthly statement as PDF or HTML
+
+	walletRoutes.GET("/analytics", analytics.AnalyticsHandler(s.analyticsService)) // Money in and out over a period, by type, counterparty and day/week/month
 
 	walletRoutes.Use(wallet.WalletNumberMiddleware(s.walletService, &s.rd))
 
 	walletRoutes.GET("/transactions", wallet.TransactionHistoryHandler(transactionService)) // transaction history
 }
+
+// registerAdminRoutes registers the routes of support, admin and auditor staff. Lookups are open to all three and
+// read-only; only admins disable users and change roles.
+func (s *Server) registerAdminRoutes(r *gin.Engine, transactionService transaction.TransactionServiceInterface) {
+	adminRoutes := r.Group("/admin")
+	adminRoutes.Use(auth.JWTMiddleware(s.blackListService, s.sessionService, s.accountService)) // Apply JWT middleware to all admin routes
+	adminRoutes.Use(auth.RequireRole(models.UserRoleSupport, models.UserRoleAdmin, models.UserRoleAuditor))
+
+	adminRoutes.GET("/users", admin.SearchUsersHandler(s.adminService))                   // Find users by email, handle, name or ID
+	adminRoutes.GET("/wallets/:wallet_number", admin.WalletLookupHandler(s.adminService)) // Any wallet, with its owner
+	adminRoutes.GET("/wallets/:wallet_number/transactions", admin.WalletNumberParamMiddleware(s.adminService),
+		wallet.TransactionHistoryHandler(transactionService)) // Transaction history of any wallet
+
+	onlyAdmins := auth.RequireRole(models.UserRoleAdmin)
+	adminRoutes.PUT("/users/:id/status", onlyAdmins, admin.SetUserStatusHandler(s.adminService)) // Disable or enable a user
+	adminRoutes.PUT("/users/:id/role", onlyAdmins, admin.SetUserRoleHandler(s.adminService))     // Change a user's role
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strconv"
@@ -9,10 +10,19 @@ import (
 
 	_ "github.com/joho/godotenv/autoload"
 
+	"centralized-wallet/internal/admin"
+	"centralized-wallet/internal/analytics"
 	"centralized-wallet/internal/auth"
 	"centralized-wallet/internal/database"
+	"centralized-wallet/internal/exchange"
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/hold"
+	"centralized-wallet/internal/idempotency"
+	"centralized-wallet/internal/ledger"
 	"centralized-wallet/internal/redis"
+	"centralized-wallet/internal/statement"
 	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/transfer"
 	"centralized-wallet/internal/user"
 	"centralized-wallet/internal/wallet"
 )
@@ -23,35 +33,92 @@ type Server struct {
 	db                 database.Service
 	rd                 redis.RedisService
 	blackListService   *auth.BlacklistService
+	tokenService       *auth.TokenService
+	sessionService     *auth.SessionService
+	accountService     *user.AccountService
 	userService        *user.UserService
 	transactionService *transaction.TransactionService
 	walletService      *wallet.WalletService
+	idempotencyService *idempotency.IdempotencyService
+	holdService        *hold.HoldService
+	exchangeService    *exchange.ExchangeService
+	statementService   *statement.StatementService
+	analyticsService   *analytics.AnalyticsService
+	recipientService   *wallet.RecipientService
+	transferService    *transfer.TransferService
+	adminService       *admin.AdminService
 }
 
 func NewServer() *http.Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 
+	// Sign tokens with the keys of JWT_KEYS_DIR, reloaded every minute so keys can be rotated without a restart.
+	// Only local and test runs may start without them.
+	ks, err := auth.KeySetFromEnv(os.Getenv)
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	auth.UseKeySet(ks)
+	if keysDir := os.Getenv("JWT_KEYS_DIR"); keysDir != "" {
+		auth.StartKeyReloading(keysDir, os.Getenv("JWT_ACTIVE_KID"), time.Minute)
+	}
+
 	rd := redis.NewRedisService()
 	dbService := database.New()
 	// Initialize repositories
 	userRepo := user.NewUserRepository(dbService.GetDB())
 	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
 	transactionRepo := transaction.NewTransactionRepository(dbService.GetDB())
+	idempotencyRepo := idempotency.NewIdempotencyRepository(dbService.GetDB())
+	ledgerRepo := ledger.NewLedgerRepository(dbService.GetDB())
+	holdRepo := hold.NewHoldRepository(dbService.GetDB())
+	exchangeRepo := exchange.NewExchangeRepository(dbService.GetDB())
+	statementRepo := statement.NewStatementRepository(dbService.GetDB())
+	analyticsRepo := analytics.NewAnalyticsRepository(dbService.GetDB())
+	transferRepo := transfer.NewTransferRepository(dbService.GetDB())
+	refreshTokenRepo := auth.NewRefreshTokenRepository(dbService.GetDB())
+	sessionRepo := auth.NewSessionRepository(dbService.GetDB())
 
 	// Initialize services
 
 	transactionService := transaction.NewTransactionService(transactionRepo, rd)
-	walletService := wallet.NewWalletService(walletRepo, transactionService)
+	ledgerService := ledger.NewLedgerService(ledgerRepo, transactionService)
+	converter := fx.NewConverter(fx.NewDBRateProvider(dbService.GetDB()), fx.DefaultSpreadBps)
+	walletService := wallet.NewWalletService(walletRepo, ledgerService, transactionService, rd, converter)
 	userService := user.NewUserService(userRepo)
+	idempotencyService := idempotency.NewIdempotencyService(idempotencyRepo, rd)
+	idempotencyService.StartExpiryCleanup(time.Hour)
+	holdService := hold.NewHoldService(holdRepo, walletRepo, ledgerService)
+	holdService.StartExpiryRelease(time.Minute)
+	exchangeService := exchange.NewExchangeService(exchangeRepo, walletRepo, ledgerService, converter)
+	statementService := statement.NewStatementService(transactionRepo, walletRepo, ledgerService, statementRepo)
+	statementService.StartMonthlyGeneration(time.Hour)
+	analyticsService := analytics.NewAnalyticsService(analyticsRepo, walletRepo, rd)
+	recipientService := wallet.NewRecipientService(userRepo, walletRepo, rd)
+	sessionService := auth.NewSessionService(sessionRepo, rd)
+	accountService := user.NewAccountService(userRepo, sessionService, rd)
+	transferService := transfer.NewTransferService(transferRepo, walletRepo, walletService, recipientService, converter)
+	adminService := admin.NewAdminService(userRepo, walletRepo, accountService)
 	NewServer := &Server{
 		port: port,
 
 		db:                 dbService,
 		rd:                 *rd,
 		blackListService:   auth.NewBlacklistService(rd),
+		tokenService:       auth.NewTokenService(refreshTokenRepo, sessionService, accountService),
+		sessionService:     sessionService,
+		accountService:     accountService,
 		userService:        userService,
 		walletService:      walletService,
 		transactionService: transactionService,
+		idempotencyService: idempotencyService,
+		holdService:        holdService,
+		exchangeService:    exchangeService,
+		statementService:   statementService,
+		analyticsService:   analyticsService,
+		recipientService:   recipientService,
+		transferService:    transferService,
+		adminService:       adminService,
 	}
 
 	// Declare Server config
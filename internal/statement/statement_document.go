@@ -0,0 +1,192 @@
+package statement
+
+import (
+	"bytes"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"html/template"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	DocumentPDF  = "pdf"
+	DocumentHTML = "html"
+)
+
+// documentContentTypes maps each monthly statement document to the Content-Type it is served with
+var documentContentTypes = map[string]string{
+	DocumentPDF:  "application/pdf",
+	DocumentHTML: "text/html; charset=utf-8",
+}
+
+// DocumentContentType returns the Content-Type of a monthly statement document, empty for an unknown one
+func DocumentContentType(document string) string {
+	return documentContentTypes[document]
+}
+
+// monthlyDocument holds everything printed on a monthly statement
+type monthlyDocument struct {
+	*models.Statement
+	StatementNumber string
+	Lines           []models.StatementLine
+	Totals          []models.StatementTotal
+	TotalFees       money.Money
+	GeneratedAt     time.Time
+}
+
+// LastDay is the last day covered by the statement, To being exclusive
+func (d *monthlyDocument) LastDay() time.Time {
+	return d.To.Add(-time.Microsecond)
+}
+
+var htmlTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"date":     func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+	"datetime": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 MST") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Statement {{.StatementNumber}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 13px; color: #222; margin: 32px; }
+h1 { font-size: 20px; margin-bottom: 4px; }
+table { border-collapse: collapse; width: 100%; margin: 16px 0; }
+th, td { border-bottom: 1px solid #ddd; padding: 6px 8px; text-align: left; }
+td.amount, th.amount { text-align: right; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<h1>Statement {{.StatementNumber}}</h1>
+<p>{{.Name}} &middot; {{.WalletNumber}} &middot; {{.Currency}}<br>
+Period {{date .From}} to {{date .LastDay}}<br>
+<span class="muted">Generated {{datetime .GeneratedAt}}</span></p>
+
+<table>
+<tr><th>Opening balance</th><td class="amount">{{.OpeningBalance}}</td></tr>
+<tr><th>Fees</th><td class="amount">{{.TotalFees}}</td></tr>
+<tr><th>Closing balance</th><td class="amount">{{.ClosingBalance}}</td></tr>
+</table>
+
+<h2>Transactions</h2>
+{{if .Lines}}<table>
+<tr><th>Date</th><th>Reference</th><th>Type</th><th>Counterparty</th><th class="amount">Amount</th><th class="amount">Fee</th><th class="amount">Balance</th></tr>
+{{range .Lines}}<tr><td>{{date .Date}}</td><td>{{.ID}}</td><td>{{.TransactionType}}</td><td>{{if .CounterpartyEmail}}{{.CounterpartyEmail}}{{else}}{{.CounterpartyWallet}}{{end}}</td><td class="amount">{{.Amount}}</td><td class="amount">{{if .Fee}}{{.Fee}}{{end}}</td><td class="amount">{{if .BalanceAfter}}{{.BalanceAfter}}{{end}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No transactions in this period.</p>{{end}}
+
+{{if .Totals}}<h2>Totals</h2>
+<table>
+<tr><th>Type</th><th class="amount">Count</th><th class="amount">Credits</th><th class="amount">Debits</th></tr>
+{{range .Totals}}<tr><td>{{.TransactionType}}</td><td class="amount">{{.Count}}</td><td class="amount">{{.Credits}}</td><td class="amount">{{.Debits}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
+
+// renderHTML renders the statement as a standalone HTML page
+func renderHTML(document *monthlyDocument) ([]byte, error) {
+	var out bytes.Buffer
+	if err := htmlTemplate.Execute(&out, document); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// renderPDF renders the statement as an A4 PDF with the same contents as the HTML page
+func renderPDF(document *monthlyDocument) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Statement "+document.StatementNumber, true)
+	pdf.SetCreationDate(document.GeneratedAt)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+	// The core fonts are Latin-1, so names are translated rather than printed as raw UTF-8
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 6, tr(document.StatementNumber+" - page ")+strconv.Itoa(pdf.PageNo())+"/{nb}", "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 9, "Statement "+document.StatementNumber, "", 1, "", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, tr(document.Name+" - "+document.WalletNumber+" - "+document.Currency), "", 1, "", false, 0, "")
+	pdf.CellFormat(0, 5, "Period "+document.From.UTC().Format("2006-01-02")+" to "+document.LastDay().UTC().Format("2006-01-02"), "", 1, "", false, 0, "")
+	pdf.CellFormat(0, 5, "Generated "+document.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"), "", 1, "", false, 0, "")
+	pdf.Ln(4)
+
+	for _, row := range [][2]string{
+		{"Opening balance", document.OpeningBalance.String()},
+		{"Fees", document.TotalFees.String()},
+		{"Closing balance", document.ClosingBalance.String()},
+	} {
+		pdf.CellFormat(50, 6, row[0], "B", 0, "", false, 0, "")
+		pdf.CellFormat(40, 6, row[1], "B", 1, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 7, "Transactions", "", 1, "", false, 0, "")
+	widths := []float64{22, 18, 22, 58, 24, 18, 28}
+	header := []string{"Date", "Ref", "Type", "Counterparty", "Amount", "Fee", "Balance"}
+	pdf.SetFont("Helvetica", "B", 9)
+	for i, title := range header {
+		align := ""
+		if i >= 4 {
+			align = "R"
+		}
+		pdf.CellFormat(widths[i], 6, title, "B", 0, align, false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	if len(document.Lines) == 0 {
+		pdf.CellFormat(0, 6, "No transactions in this period.", "", 1, "", false, 0, "")
+	}
+	for _, line := range document.Lines {
+		counterparty := line.CounterpartyEmail
+		if counterparty == "" {
+			counterparty = line.CounterpartyWallet
+		}
+		fee, balance := "", ""
+		if line.Fee != nil {
+			fee = line.Fee.String()
+		}
+		if line.BalanceAfter != nil {
+			balance = line.BalanceAfter.String()
+		}
+		cells := []string{line.Date.UTC().Format("2006-01-02"), strconv.Itoa(line.ID), line.TransactionType, tr(counterparty), line.Amount.String(), fee, balance}
+		for i, cell := range cells {
+			align := ""
+			if i >= 4 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, cell, "", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if len(document.Totals) > 0 {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 7, "Totals", "", 1, "", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		for _, total := range document.Totals {
+			pdf.CellFormat(40, 6, total.TransactionType, "B", 0, "", false, 0, "")
+			pdf.CellFormat(20, 6, strconv.Itoa(total.Count), "B", 0, "R", false, 0, "")
+			pdf.CellFormat(35, 6, "+"+total.Credits.String(), "B", 0, "R", false, 0, "")
+			pdf.CellFormat(35, 6, "-"+total.Debits.String(), "B", 1, "R", false, 0, "")
+		}
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,252 @@
+package statement
+
+import (
+	"bufio"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV       = "csv"
+	FormatJSONLines = "jsonl"
+	FormatOFX       = "ofx"
+	DefaultFormat   = FormatCSV
+)
+
+// Record kinds of the CSV and JSON Lines exports
+const (
+	recordOpening = "opening_balance"
+	recordLine    = "transaction"
+	recordTotal   = "total"
+	recordClosing = "closing_balance"
+)
+
+const (
+	ofxDateLayout = "20060102150405.000"
+	ofxBankID     = "WALLET" // OFX requires a bank ID, wallets all belong to this service
+)
+
+// formatContentTypes maps each export format to the Content-Type it is served with
+var formatContentTypes = map[string]string{
+	FormatCSV:       "text/csv; charset=utf-8",
+	FormatJSONLines: "application/x-ndjson",
+	FormatOFX:       "application/x-ofx",
+}
+
+// IsValidFormat reports whether format is one of the export formats
+func IsValidFormat(format string) bool {
+	_, ok := formatContentTypes[format]
+	return ok
+}
+
+// ContentType returns the Content-Type of an export format
+func ContentType(format string) string {
+	return formatContentTypes[format]
+}
+
+// statementWriter renders a statement as it is streamed: the header first, then one call per transaction,
+// then the totals and closing balance once every transaction has been read
+type statementWriter interface {
+	Begin(statement *models.Statement) error
+	Line(line models.StatementLine) error
+	End(statement *models.Statement, totals []models.StatementTotal) error
+}
+
+func newStatementWriter(format string, w io.Writer) (statementWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatJSONLines:
+		return &jsonLinesWriter{enc: json.NewEncoder(w)}, nil
+	case FormatOFX:
+		return &ofxWriter{w: bufio.NewWriter(w)}, nil
+	}
+	return nil, utils.ServiceErrInvalidStatementFormat
+}
+
+// csvWriter writes one record per row. The record column tells the opening balance, transactions,
+// totals per type and closing balance apart, so the file stays a single table a spreadsheet can filter.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+var csvHeader = []string{"record", "date", "transaction_id", "transaction_type", "status", "direction",
+	"counterparty_wallet_number", "counterparty_email", "amount", "currency", "balance_after", "count", "credits", "debits"}
+
+func (cw *csvWriter) Begin(statement *models.Statement) error {
+	if err := cw.w.Write(csvHeader); err != nil {
+		return err
+	}
+	return cw.write(recordOpening, statement.From.Format(time.RFC3339), "", "", "", "", "", "", "", statement.Currency, statement.OpeningBalance.String(), "", "", "")
+}
+
+func (cw *csvWriter) Line(line models.StatementLine) error {
+	balanceAfter := ""
+	if line.BalanceAfter != nil {
+		balanceAfter = line.BalanceAfter.String()
+	}
+	return cw.write(recordLine, line.Date.Format(time.RFC3339), strconv.Itoa(line.ID), line.TransactionType, line.Status, line.Direction,
+		line.CounterpartyWallet, line.CounterpartyEmail, line.Amount.String(), line.Currency, balanceAfter, "", "", "")
+}
+
+func (cw *csvWriter) End(statement *models.Statement, totals []models.StatementTotal) error {
+	for _, total := range totals {
+		if err := cw.write(recordTotal, "", "", total.TransactionType, "", "", "", "", "", statement.Currency, "",
+			strconv.Itoa(total.Count), total.Credits.String(), total.Debits.String()); err != nil {
+			return err
+		}
+	}
+	if err := cw.write(recordClosing, statement.To.Format(time.RFC3339), "", "", "", "", "", "", "", statement.Currency, statement.ClosingBalance.String(), "", "", ""); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// write buffers one record, flushing regularly so long statements reach the client as they are read
+func (cw *csvWriter) write(record ...string) error {
+	if err := cw.w.Write(record); err != nil {
+		return err
+	}
+	if record[0] == recordLine {
+		return nil
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonLinesWriter writes one JSON object per line, tagged with its record kind like the CSV rows
+type jsonLinesWriter struct {
+	enc *json.Encoder
+}
+
+type jsonBalanceRecord struct {
+	Record       string      `json:"record"`
+	WalletNumber string      `json:"wallet_number"`
+	Currency     string      `json:"currency"`
+	AsOf         time.Time   `json:"as_of"`
+	Balance      money.Money `json:"balance"`
+}
+
+func (jw *jsonLinesWriter) Begin(statement *models.Statement) error {
+	return jw.enc.Encode(jsonBalanceRecord{
+		Record:       recordOpening,
+		WalletNumber: statement.WalletNumber,
+		Currency:     statement.Currency,
+		AsOf:         statement.From,
+		Balance:      statement.OpeningBalance,
+	})
+}
+
+func (jw *jsonLinesWriter) Line(line models.StatementLine) error {
+	return jw.enc.Encode(struct {
+		Record string `json:"record"`
+		models.StatementLine
+	}{recordLine, line})
+}
+
+func (jw *jsonLinesWriter) End(statement *models.Statement, totals []models.StatementTotal) error {
+	for _, total := range totals {
+		err := jw.enc.Encode(struct {
+			Record   string `json:"record"`
+			Currency string `json:"currency"`
+			models.StatementTotal
+		}{recordTotal, statement.Currency, total})
+		if err != nil {
+			return err
+		}
+	}
+	return jw.enc.Encode(jsonBalanceRecord{
+		Record:       recordClosing,
+		WalletNumber: statement.WalletNumber,
+		Currency:     statement.Currency,
+		AsOf:         statement.To,
+		Balance:      statement.ClosingBalance,
+	})
+}
+
+// ofxWriter writes an OFX 2.2 bank statement. The closing balance is the ledger balance; the opening balance
+// and the totals per type have no dedicated OFX element and go in the balance list.
+type ofxWriter struct {
+	w *bufio.Writer
+}
+
+func (ow *ofxWriter) Begin(statement *models.Statement) error {
+	fmt.Fprintf(ow.w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS>
+<CURDEF>%s</CURDEF>
+<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`, ofxDate(time.Now()), statement.Currency, ofxBankID, ofxEscape(statement.WalletNumber), ofxDate(statement.From), ofxDate(statement.To))
+	return ow.w.Flush()
+}
+
+func (ow *ofxWriter) Line(line models.StatementLine) error {
+	name := line.CounterpartyEmail
+	if name == "" {
+		name = line.CounterpartyWallet
+	}
+	fmt.Fprintf(ow.w, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%d</FITID>",
+		ofxTransactionType(line), ofxDate(line.Date), line.Amount.String(), line.ID)
+	if name != "" {
+		fmt.Fprintf(ow.w, "<NAME>%s</NAME>", ofxEscape(name))
+	}
+	_, err := fmt.Fprintf(ow.w, "<MEMO>%s</MEMO></STMTTRN>\n", ofxEscape(line.TransactionType))
+	return err
+}
+
+func (ow *ofxWriter) End(statement *models.Statement, totals []models.StatementTotal) error {
+	fmt.Fprintf(ow.w, "</BANKTRANLIST>\n<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n<BALLIST>\n",
+		statement.ClosingBalance.String(), ofxDate(statement.To))
+	ofxBalance(ow.w, "Opening balance", "Balance at the start of the period", statement.OpeningBalance.String(), statement.From)
+	for _, total := range totals {
+		ofxBalance(ow.w, "Credits "+total.TransactionType, fmt.Sprintf("%d %s transactions", total.Count, total.TransactionType), total.Credits.String(), statement.To)
+		ofxBalance(ow.w, "Debits "+total.TransactionType, fmt.Sprintf("%d %s transactions", total.Count, total.TransactionType), total.Debits.String(), statement.To)
+	}
+	fmt.Fprint(ow.w, "</BALLIST>\n</STMTRS>\n</STMTTRNRS></BANKMSGSRSV1>\n</OFX>\n")
+	return ow.w.Flush()
+}
+
+func ofxBalance(w io.Writer, name, desc, value string, asOf time.Time) {
+	fmt.Fprintf(w, "<BAL><NAME>%s</NAME><DESC>%s</DESC><BALTYPE>DOLLAR</BALTYPE><VALUE>%s</VALUE><DTASOF>%s</DTASOF></BAL>\n",
+		ofxEscape(name), ofxEscape(desc), value, ofxDate(asOf))
+}
+
+// ofxTransactionType maps a statement line to the closest OFX transaction type
+func ofxTransactionType(line models.StatementLine) string {
+	switch line.TransactionType {
+	case transaction.TypeDeposit:
+		return "DEP"
+	case transaction.TypeTransfer, transaction.TypeExchange:
+		return "XFER"
+	}
+	if line.Amount.IsNegative() {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// ofxDate renders a time in UTC with the OFX timezone suffix
+func ofxDate(t time.Time) string {
+	return t.UTC().Format(ofxDateLayout) + "[0:GMT]"
+}
+
+func ofxEscape(value string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
@@ -0,0 +1,133 @@
+package statement
+
+import (
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportStatementHandler downloads the posted transactions of one of the user's wallets between from and to,
+// with the opening and closing balances and the totals per type. wallet_number defaults to the default wallet
+// and format (csv, jsonl or ofx) to csv. The file is streamed, so errors past the first row cut the download short.
+func ExportStatementHandler(ss StatementServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		format := strings.ToLower(c.DefaultQuery("format", DefaultFormat))
+		if !IsValidFormat(format) {
+			utils.ErrorResponse(c, utils.ErrorInvalidStatementFormat, nil, "")
+			return
+		}
+
+		from, err := wallet.ParseHistoryTime(c.Query("from"), false)
+		if err != nil || from == nil {
+			utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+			return
+		}
+		to, err := wallet.ParseHistoryTime(c.Query("to"), true)
+		if err != nil || to == nil {
+			utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+			return
+		}
+
+		statement, err := ss.OpenStatement(userID.(int), c.Query("wallet_number"), *from, *to)
+		if err != nil {
+			switch err {
+			case utils.ServiceErrInvalidStatementPeriod:
+				utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[ExportStatementHandler] Error opening statement")
+			}
+			return
+		}
+
+		filename := fmt.Sprintf("statement-%s-%s-%s.%s", statement.WalletNumber,
+			statement.From.Format("20060102"), statement.To.Add(-time.Microsecond).Format("20060102"), format)
+		c.Header("Content-Type", ContentType(format))
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+		// A long period can take longer to stream than the server's write timeout allows for a regular response
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+		if err := ss.WriteStatement(statement, format, c.Writer); err != nil {
+			// Headers and rows may already be sent, so the error can only be logged
+			c.Set("internal_error", err.Error())
+			c.Abort()
+		}
+	}
+}
+
+// ListMonthlyStatementsHandler lists the monthly statements issued for one of the user's wallets, the latest first.
+// wallet_number defaults to the default wallet.
+func ListMonthlyStatementsHandler(ss StatementServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		statements, err := ss.ListMonthlyStatements(userID.(int), c.Query("wallet_number"))
+		if err != nil {
+			switch err {
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[ListMonthlyStatementsHandler] Error listing statements")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgStatementsRetrieved, gin.H{"statements": statements})
+	}
+}
+
+// DownloadMonthlyStatementHandler downloads a monthly statement of one of the user's wallets, as PDF by default or as HTML
+func DownloadMonthlyStatementHandler(ss StatementServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		document := strings.ToLower(c.DefaultQuery("format", DocumentPDF))
+		contentType := DocumentContentType(document)
+		if contentType == "" {
+			utils.ErrorResponse(c, utils.ErrorInvalidDocumentFormat, nil, "")
+			return
+		}
+
+		statement, err := ss.GetMonthlyStatement(userID.(int), c.Param("number"))
+		if err != nil {
+			switch err {
+			case utils.RepoErrStatementNotFound:
+				utils.ErrorResponse(c, utils.ErrStatementNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[DownloadMonthlyStatementHandler] Error fetching statement")
+			}
+			return
+		}
+
+		content := statement.PDF
+		if document == DocumentHTML {
+			content = statement.HTML
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+statement.StatementNumber+"."+document+`"`)
+		c.Data(http.StatusOK, contentType, content)
+	}
+}
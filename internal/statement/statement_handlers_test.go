@@ -0,0 +1,235 @@
+package statement
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	mockStatement "centralized-wallet/tests/mocks/statement"
+	"centralized-wallet/tests/testutils"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupStatementHandlerRouter(statementService *mockStatement.MockStatementService) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	token, _ := auth.GenerateJWT(testUserID)
+	blacklistService := new(mockAuth.MockBlacklistService)
+	blacklistService.On("IsTokenBlacklisted", token).Return(false, nil)
+	accountChecker := new(mockAuth.MockAccountChecker)
+	accountChecker.On("CurrentTokenVersion", testUserID).Return(1, nil)
+
+	walletRoutes := router.Group("/wallets")
+	walletRoutes.Use(auth.JWTMiddleware(blacklistService, new(mockAuth.MockSessionService), accountChecker))
+	{
+		walletRoutes.GET("/statements", ExportStatementHandler(statementService))
+		walletRoutes.GET("/statements/monthly", ListMonthlyStatementsHandler(statementService))
+		walletRoutes.GET("/statements/monthly/:number", DownloadMonthlyStatementHandler(statementService))
+	}
+	return router, token
+}
+
+func TestExportStatementHandler(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		url                   string
+		mockSetup             func(m *mockStatement.MockStatementService)
+		expectedContentType   string
+		expectedFilename      string
+		expectedBody          string
+		expectedResponseError *utils.AppError
+	}{
+		{
+			name: "CSV of a month, the default format",
+			url:  "/wallets/statements?from=2024-05-01&to=2024-05-31",
+			mockSetup: func(m *mockStatement.MockStatementService) {
+				m.On("OpenStatement", testUserID, "", testFrom, testTo).Return(testStatement(), nil)
+				m.On("WriteStatement", testStatement(), FormatCSV).Return("record,date\n", nil)
+			},
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedFilename:    `attachment; filename="statement-wallet123-20240501-20240531.csv"`,
+			expectedBody:        "record,date\n",
+		},
+		{
+			name: "OFX of a named wallet",
+			url:  "/wallets/statements?wallet_number=wallet123&format=OFX&from=2024-05-01T00:00:00Z&to=2024-06-01T00:00:00Z",
+			mockSetup: func(m *mockStatement.MockStatementService) {
+				m.On("OpenStatement", testUserID, testWalletNumber, testFrom, testTo).Return(testStatement(), nil)
+				m.On("WriteStatement", testStatement(), FormatOFX).Return("<OFX></OFX>\n", nil)
+			},
+			expectedContentType: "application/x-ofx",
+			expectedFilename:    `attachment; filename="statement-wallet123-20240501-20240531.ofx"`,
+			expectedBody:        "<OFX></OFX>\n",
+		},
+		{
+			name:                  "Unknown format",
+			url:                   "/wallets/statements?format=xlsx&from=2024-05-01&to=2024-05-31",
+			mockSetup:             func(m *mockStatement.MockStatementService) {},
+			expectedResponseError: utils.ErrorInvalidStatementFormat,
+		},
+		{
+			name:                  "Missing period",
+			url:                   "/wallets/statements?from=2024-05-01",
+			mockSetup:             func(m *mockStatement.MockStatementService) {},
+			expectedResponseError: utils.ErrorInvalidDateRange,
+		},
+		{
+			name:                  "Invalid date",
+			url:                   "/wallets/statements?from=May&to=2024-05-31",
+			mockSetup:             func(m *mockStatement.MockStatementService) {},
+			expectedResponseError: utils.ErrorInvalidDateRange,
+		},
+		{
+			name: "Period ending before it starts",
+			url:  "/wallets/statements?from=2024-06-01T00:00:00Z&to=2024-05-01T00:00:00Z",
+			mockSetup: func(m *mockStatement.MockStatementService) {
+				m.On("OpenStatement", testUserID, "", testTo, testFrom).Return(nil, utils.ServiceErrInvalidStatementPeriod)
+			},
+			expectedResponseError: utils.ErrorInvalidDateRange,
+		},
+		{
+			name: "Wallet of another user",
+			url:  "/wallets/statements?wallet_number=wallet456&from=2024-05-01&to=2024-05-31",
+			mockSetup: func(m *mockStatement.MockStatementService) {
+				m.On("OpenStatement", testUserID, "wallet456", testFrom, testTo).Return(nil, utils.RepoErrWalletNotFound)
+			},
+			expectedResponseError: utils.ErrWalletNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			statementService := new(mockStatement.MockStatementService)
+			router, token := setupStatementHandlerRouter(statementService)
+			tc.mockSetup(statementService)
+
+			w := testutils.ExecuteRequest(router, http.MethodGet, tc.url, nil, token)
+
+			if tc.expectedResponseError != nil {
+				testutils.AssertAPIErrorResponse(t, w, tc.expectedResponseError)
+			} else {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tc.expectedFilename, w.Header().Get("Content-Disposition"))
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+			statementService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListMonthlyStatementsHandler(t *testing.T) {
+	statements := []models.MonthlyStatement{{StatementNumber: "STM-202405-00000001", WalletNumber: testWalletNumber, Currency: "USD"}}
+
+	t.Run("statements of the default wallet", func(t *testing.T) {
+		statementService := new(mockStatement.MockStatementService)
+		router, token := setupStatementHandlerRouter(statementService)
+		statementService.On("ListMonthlyStatements", testUserID, "").Return(statements, nil)
+
+		w := testutils.ExecuteRequest(router, http.MethodGet, "/wallets/statements/monthly", nil, token)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Message string `json:"message"`
+			Data    struct {
+				Statements []map[string]interface{} `json:"statements"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, utils.MsgStatementsRetrieved, response.Message)
+		if assert.Len(t, response.Data.Statements, 1) {
+			assert.Equal(t, "STM-202405-00000001", response.Data.Statements[0]["statement_number"])
+			assert.NotContains(t, response.Data.Statements[0], "pdf")
+		}
+	})
+
+	t.Run("wallet of another user", func(t *testing.T) {
+		statementService := new(mockStatement.MockStatementService)
+		router, token := setupStatementHandlerRouter(statementService)
+		statementService.On("ListMonthlyStatements", testUserID, "wallet456").Return(nil, utils.RepoErrWalletNotFound)
+
+		w := testutils.ExecuteRequest(router, http.MethodGet, "/wallets/statements/monthly?wallet_number=wallet456", nil, token)
+
+		testutils.AssertAPIErrorResponse(t, w, utils.ErrWalletNotFound)
+	})
+}
+
+func TestDownloadMonthlyStatementHandler(t *testing.T) {
+	stored := &models.MonthlyStatement{
+		StatementNumber: "STM-202405-00000001",
+		WalletNumber:    testWalletNumber,
+		HTML:            []byte("<html></html>"),
+		PDF:             []byte("%PDF-1.3"),
+	}
+
+	testCases := []struct {
+		name                  string
+		url                   string
+		mockSetup             func(m *mockStatement.MockStatementService)
+		expectedContentType   string
+		expectedFilename      string
+		expectedBody          string
+		expectedResponseError *utils.AppError
+	}{
+		{
+			name: "PDF, the default format",
+			url:  "/wallets/statements/monthly/STM-202405-00000001",
+			mockSetup: func(m *mockStatement.MockStatementService) {
+				m.On("GetMonthlyStatement", testUserID, "STM-202405-00000001").Return(stored, nil)
+			},
+			expectedContentType: "application/pdf",
+			expectedFilename:    `attachment; filename="STM-202405-00000001.pdf"`,
+			expectedBody:        "%PDF-1.3",
+		},
+		{
+			name: "HTML",
+			url:  "/wallets/statements/monthly/STM-202405-00000001?format=HTML",
+			mockSetup: func(m *mockStatement.MockStatementService) {
+				m.On("GetMonthlyStatement", testUserID, "STM-202405-00000001").Return(stored, nil)
+			},
+			expectedContentType: "text/html; charset=utf-8",
+			expectedFilename:    `attachment; filename="STM-202405-00000001.html"`,
+			expectedBody:        "<html></html>",
+		},
+		{
+			name:                  "Unknown format",
+			url:                   "/wallets/statements/monthly/STM-202405-00000001?format=csv",
+			mockSetup:             func(m *mockStatement.MockStatementService) {},
+			expectedResponseError: utils.ErrorInvalidDocumentFormat,
+		},
+		{
+			name: "Statement not found",
+			url:  "/wallets/statements/monthly/STM-202405-00000002",
+			mockSetup: func(m *mockStatement.MockStatementService) {
+				m.On("GetMonthlyStatement", testUserID, "STM-202405-00000002").Return(nil, utils.RepoErrStatementNotFound)
+			},
+			expectedResponseError: utils.ErrStatementNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			statementService := new(mockStatement.MockStatementService)
+			router, token := setupStatementHandlerRouter(statementService)
+			tc.mockSetup(statementService)
+
+			w := testutils.ExecuteRequest(router, http.MethodGet, tc.url, nil, token)
+
+			if tc.expectedResponseError != nil {
+				testutils.AssertAPIErrorResponse(t, w, tc.expectedResponseError)
+			} else {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tc.expectedFilename, w.Header().Get("Content-Disposition"))
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+			statementService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package statement
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+	"time"
+)
+
+// StatementRepositoryInterface defines the methods for storing the monthly statement documents
+type StatementRepositoryInterface interface {
+	FindWalletsWithoutStatement(periodStart, periodEnd time.Time) ([]string, error)
+	CreateStatement(statement *models.MonthlyStatement) (bool, error)
+	ListStatements(walletNumber string) ([]models.MonthlyStatement, error)
+	GetStatement(statementNumber string) (*models.MonthlyStatement, error)
+}
+
+type StatementRepository struct {
+	db *sql.DB
+}
+
+// Ensure StatementRepository implements StatementRepositoryInterface
+var _ StatementRepositoryInterface = &StatementRepository{}
+
+// NewStatementRepository creates a new instance of StatementRepository
+func NewStatementRepository(db *sql.DB) *StatementRepository {
+	return &StatementRepository{db: db}
+}
+
+// FindWalletsWithoutStatement lists the wallets opened before the period ends that have no statement for it yet
+func (repo *StatementRepository) FindWalletsWithoutStatement(periodStart, periodEnd time.Time) ([]string, error) {
+	query := `SELECT w.wallet_number FROM wallets w
+			  WHERE w.created_at < $2
+			  AND NOT EXISTS (SELECT 1 FROM statements s WHERE s.wallet_number = w.wallet_number AND s.period_start = $1)
+			  ORDER BY w.id`
+
+	rows, err := repo.db.Query(query, periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	walletNumbers := []string{}
+	for rows.Next() {
+		var walletNumber string
+		if err := rows.Scan(&walletNumber); err != nil {
+			return nil, err
+		}
+		walletNumbers = append(walletNumbers, walletNumber)
+	}
+	return walletNumbers, rows.Err()
+}
+
+// CreateStatement stores a statement and sets its generated ID and creation time.
+// It returns false, storing nothing, when the wallet already has a statement for the period.
+func (repo *StatementRepository) CreateStatement(statement *models.MonthlyStatement) (bool, error) {
+	query := `INSERT INTO statements (statement_number, wallet_number, period_start, period_end, currency,
+				opening_balance, closing_balance, total_fees, transaction_count, html, pdf)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			  ON CONFLICT DO NOTHING
+			  RETURNING id, created_at`
+
+	err := repo.db.QueryRow(
+		query,
+		statement.StatementNumber,
+		statement.WalletNumber,
+		statement.PeriodStart,
+		statement.PeriodEnd,
+		statement.Currency,
+		statement.OpeningBalance,
+		statement.ClosingBalance,
+		statement.TotalFees,
+		statement.TransactionCount,
+		string(statement.HTML),
+		statement.PDF,
+	).Scan(&statement.ID, &statement.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListStatements lists a wallet's statements, the latest period first, without their documents
+func (repo *StatementRepository) ListStatements(walletNumber string) ([]models.MonthlyStatement, error) {
+	query := `SELECT id, statement_number, wallet_number, period_start, period_end, currency,
+					 opening_balance, closing_balance, total_fees, transaction_count, created_at
+			  FROM statements WHERE wallet_number = $1
+			  ORDER BY period_start DESC`
+
+	rows, err := repo.db.Query(query, walletNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statements := []models.MonthlyStatement{}
+	for rows.Next() {
+		var statement models.MonthlyStatement
+		err := rows.Scan(
+			&statement.ID,
+			&statement.StatementNumber,
+			&statement.WalletNumber,
+			&statement.PeriodStart,
+			&statement.PeriodEnd,
+			&statement.Currency,
+			&statement.OpeningBalance,
+			&statement.ClosingBalance,
+			&statement.TotalFees,
+			&statement.TransactionCount,
+			&statement.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		setStatementCurrency(&statement)
+		statements = append(statements, statement)
+	}
+	return statements, rows.Err()
+}
+
+// GetStatement fetches a statement with its HTML and PDF documents
+func (repo *StatementRepository) GetStatement(statementNumber string) (*models.MonthlyStatement, error) {
+	query := `SELECT id, statement_number, wallet_number, period_start, period_end, currency,
+					 opening_balance, closing_balance, total_fees, transaction_count, created_at, html, pdf
+			  FROM statements WHERE statement_number = $1`
+
+	var statement models.MonthlyStatement
+	var html string
+	err := repo.db.QueryRow(query, statementNumber).Scan(
+		&statement.ID,
+		&statement.StatementNumber,
+		&statement.WalletNumber,
+		&statement.PeriodStart,
+		&statement.PeriodEnd,
+		&statement.Currency,
+		&statement.OpeningBalance,
+		&statement.ClosingBalance,
+		&statement.TotalFees,
+		&statement.TransactionCount,
+		&statement.CreatedAt,
+		&html,
+		&statement.PDF,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.RepoErrStatementNotFound
+		}
+		return nil, err
+	}
+	statement.HTML = []byte(html)
+	setStatementCurrency(&statement)
+	return &statement, nil
+}
+
+// setStatementCurrency tags the scanned minor-unit amounts with the statement's currency
+func setStatementCurrency(statement *models.MonthlyStatement) {
+	statement.OpeningBalance = money.New(statement.OpeningBalance.Amount, statement.Currency)
+	statement.ClosingBalance = money.New(statement.ClosingBalance.Amount, statement.Currency)
+	statement.TotalFees = money.New(statement.TotalFees.Amount, statement.Currency)
+}
@@ -0,0 +1,333 @@
+package statement
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+)
+
+// StatementServiceInterface exports a wallet's posted transactions over a period, with the balances at both ends
+// and the totals per transaction type. Opening is split from writing so a caller can report errors before any output.
+// Monthly statements are the same contents issued once per wallet and month as stored HTML and PDF documents.
+type StatementServiceInterface interface {
+	OpenStatement(userID int, walletNumber string, from, to time.Time) (*models.Statement, error)
+	WriteStatement(statement *models.Statement, format string, w io.Writer) error
+	GenerateMonthlyStatements(month time.Time) (int, error)
+	ListMonthlyStatements(userID int, walletNumber string) ([]models.MonthlyStatement, error)
+	GetMonthlyStatement(userID int, statementNumber string) (*models.MonthlyStatement, error)
+}
+
+type StatementService struct {
+	transactionRepo transaction.TransactionRepositoryInterface
+	walletRepo      wallet.WalletRepositoryInterface
+	ledgerService   ledger.LedgerServiceInterface
+	statementRepo   StatementRepositoryInterface
+	now             func() time.Time
+}
+
+// Ensure StatementService implements StatementServiceInterface
+var _ StatementServiceInterface = &StatementService{}
+
+// NewStatementService creates a StatementService. Balances are read from the ledger, transactions are streamed from the repository.
+func NewStatementService(transactionRepo transaction.TransactionRepositoryInterface, walletRepo wallet.WalletRepositoryInterface, ledgerService ledger.LedgerServiceInterface, statementRepo StatementRepositoryInterface) *StatementService {
+	return &StatementService{
+		transactionRepo: transactionRepo,
+		walletRepo:      walletRepo,
+		ledgerService:   ledgerService,
+		statementRepo:   statementRepo,
+		now:             time.Now,
+	}
+}
+
+// OpenStatement resolves one of the user's wallets, the default one when walletNumber is empty,
+// and reads its balances right before from and right before to
+func (s *StatementService) OpenStatement(userID int, walletNumber string, from, to time.Time) (*models.Statement, error) {
+	if !from.Before(to) {
+		return nil, utils.ServiceErrInvalidStatementPeriod
+	}
+
+	owned, err := wallet.FindOwnedWallet(s.walletRepo, userID, walletNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.openWalletStatement(owned, from, to)
+}
+
+// openWalletStatement reads the wallet's balances right before from and right before to
+func (s *StatementService) openWalletStatement(owned *models.Wallet, from, to time.Time) (*models.Statement, error) {
+	// Postings are stamped with microsecond precision, so this excludes everything posted at or after the bound
+	opening, err := s.ledgerService.GetBalanceAsOf(owned.WalletNumber, owned.Currency, from.Add(-time.Microsecond))
+	if err != nil {
+		return nil, err
+	}
+	closing, err := s.ledgerService.GetBalanceAsOf(owned.WalletNumber, owned.Currency, to.Add(-time.Microsecond))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Statement{
+		WalletNumber:   owned.WalletNumber,
+		Name:           owned.Name,
+		Currency:       owned.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+	}, nil
+}
+
+// WriteStatement streams the statement to w in the given format. Only posted transactions are listed:
+// pending and failed ones never moved money. Totals are written once every transaction has been read.
+func (s *StatementService) WriteStatement(statement *models.Statement, format string, w io.Writer) error {
+	writer, err := newStatementWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Begin(statement); err != nil {
+		return err
+	}
+
+	totals, err := s.eachLine(statement, writer.Line)
+	if err != nil {
+		return err
+	}
+
+	return writer.End(statement, totals)
+}
+
+// eachLine calls fn with each posted transaction of the statement's period, oldest first, and returns the totals per type
+func (s *StatementService) eachLine(statement *models.Statement, fn func(line models.StatementLine) error) ([]models.StatementTotal, error) {
+	totals := map[string]*models.StatementTotal{}
+	filter := models.TransactionFilter{CreatedFrom: &statement.From, CreatedTo: &statement.To}
+	err := s.transactionRepo.StreamTransactionHistory(statement.WalletNumber, filter, func(tx *models.TransactionWithEmails) error {
+		if tx.Status != transaction.StatusCompleted && tx.Status != transaction.StatusReversed {
+			return nil
+		}
+
+		line := newStatementLine(statement.WalletNumber, tx)
+		if err := addToTotals(totals, statement.Currency, line); err != nil {
+			return err
+		}
+		return fn(line)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedTotals(totals), nil
+}
+
+// GenerateMonthlyStatements issues the statements of the calendar month (UTC) containing month for every wallet
+// that does not have one yet, and returns how many were issued. The month must be over. Each wallet is handled
+// on its own so one failure does not block the others, and a rerun only fills the gaps.
+func (s *StatementService) GenerateMonthlyStatements(month time.Time) (int, error) {
+	month = month.UTC()
+	periodStart := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := periodStart.AddDate(0, 1, 0)
+	if periodEnd.After(s.now()) {
+		return 0, utils.ServiceErrStatementMonthNotOver
+	}
+
+	walletNumbers, err := s.statementRepo.FindWalletsWithoutStatement(periodStart, periodEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	issued := 0
+	for _, walletNumber := range walletNumbers {
+		ok, err := s.generateMonthlyStatement(walletNumber, periodStart, periodEnd)
+		if err != nil {
+			log.Printf("Warning: Failed to generate the %s statement of wallet %s: %v", periodStart.Format("2006-01"), walletNumber, err)
+			continue
+		}
+		if ok {
+			issued++
+		}
+	}
+	return issued, nil
+}
+
+// StartMonthlyGeneration issues the statements of the previous month periodically in the background.
+// Wallets that already have theirs are skipped, so the check is cheap once the month is done.
+func (s *StatementService) StartMonthlyGeneration(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := s.now().UTC()
+			lastMonth := now.AddDate(0, 0, -now.Day()) // Last day of the previous month
+			if _, err := s.GenerateMonthlyStatements(lastMonth); err != nil {
+				log.Printf("Warning: Failed to generate monthly statements: %v", err)
+			}
+		}
+	}()
+}
+
+// generateMonthlyStatement renders and stores one wallet's statement. It returns false when another run stored it first.
+func (s *StatementService) generateMonthlyStatement(walletNumber string, periodStart, periodEnd time.Time) (bool, error) {
+	owned, err := s.walletRepo.FindByWalletNumber(walletNumber)
+	if err != nil {
+		return false, err
+	}
+
+	header, err := s.openWalletStatement(owned, periodStart, periodEnd)
+	if err != nil {
+		return false, err
+	}
+
+	document := &monthlyDocument{
+		Statement:       header,
+		StatementNumber: StatementNumber(owned.ID, periodStart),
+		TotalFees:       money.Zero(owned.Currency),
+		GeneratedAt:     s.now().UTC(),
+	}
+	document.Totals, err = s.eachLine(header, func(line models.StatementLine) error {
+		document.Lines = append(document.Lines, line)
+		if line.Fee == nil {
+			return nil
+		}
+		var err error
+		document.TotalFees, err = document.TotalFees.Add(*line.Fee)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	html, err := renderHTML(document)
+	if err != nil {
+		return false, err
+	}
+	pdf, err := renderPDF(document)
+	if err != nil {
+		return false, err
+	}
+
+	return s.statementRepo.CreateStatement(&models.MonthlyStatement{
+		StatementNumber:  document.StatementNumber,
+		WalletNumber:     owned.WalletNumber,
+		PeriodStart:      periodStart,
+		PeriodEnd:        periodEnd,
+		Currency:         owned.Currency,
+		OpeningBalance:   header.OpeningBalance,
+		ClosingBalance:   header.ClosingBalance,
+		TotalFees:        document.TotalFees,
+		TransactionCount: len(document.Lines),
+		HTML:             html,
+		PDF:              pdf,
+	})
+}
+
+// ListMonthlyStatements lists the statements issued for one of the user's wallets, the default one when walletNumber is empty
+func (s *StatementService) ListMonthlyStatements(userID int, walletNumber string) ([]models.MonthlyStatement, error) {
+	owned, err := wallet.FindOwnedWallet(s.walletRepo, userID, walletNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.statementRepo.ListStatements(owned.WalletNumber)
+}
+
+// GetMonthlyStatement fetches a statement with its documents. A statement of another user's wallet is not found.
+func (s *StatementService) GetMonthlyStatement(userID int, statementNumber string) (*models.MonthlyStatement, error) {
+	statement, err := s.statementRepo.GetStatement(statementNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := wallet.FindOwnedWallet(s.walletRepo, userID, statement.WalletNumber); err != nil {
+		if err == utils.RepoErrWalletNotFound {
+			return nil, utils.RepoErrStatementNotFound
+		}
+		return nil, err
+	}
+	return statement, nil
+}
+
+// StatementNumber identifies the statement of a wallet for the month starting at periodStart, e.g. STM-202405-00000042
+func StatementNumber(walletID int, periodStart time.Time) string {
+	return fmt.Sprintf("STM-%s-%08d", periodStart.Format("200601"), walletID)
+}
+
+// newStatementLine shows a transaction from the wallet's side, with a signed amount in the wallet's currency
+func newStatementLine(walletNumber string, tx *models.TransactionWithEmails) models.StatementLine {
+	line := models.StatementLine{
+		ID:              tx.ID,
+		Date:            tx.CreatedAt,
+		TransactionType: tx.TransactionType,
+		Status:          tx.Status,
+		Currency:        tx.Currency,
+	}
+
+	if tx.FromWalletNumber != nil && *tx.FromWalletNumber == walletNumber {
+		line.Direction = transaction.DirectionOutgoing
+		line.Amount = tx.Amount.Neg()
+		line.BalanceAfter = tx.FromBalanceAfter
+		line.Fee = tx.FXSpread
+		if tx.ToWalletNumber != nil {
+			line.CounterpartyWallet = *tx.ToWalletNumber
+		}
+		if tx.ToEmail != nil {
+			line.CounterpartyEmail = *tx.ToEmail
+		}
+		return line
+	}
+
+	line.Direction = transaction.DirectionIncoming
+	line.Amount = tx.Amount
+	if tx.ToAmount != nil && tx.ToCurrency != nil {
+		// Incoming exchanges are credited in the destination currency
+		line.Amount = *tx.ToAmount
+		line.Currency = *tx.ToCurrency
+	}
+	line.BalanceAfter = tx.ToBalanceAfter
+	if tx.FromWalletNumber != nil {
+		line.CounterpartyWallet = *tx.FromWalletNumber
+	}
+	if tx.FromEmail != nil {
+		line.CounterpartyEmail = *tx.FromEmail
+	}
+	return line
+}
+
+// addToTotals adds the line to the total of its transaction type
+func addToTotals(totals map[string]*models.StatementTotal, currency string, line models.StatementLine) error {
+	total, ok := totals[line.TransactionType]
+	if !ok {
+		total = &models.StatementTotal{
+			TransactionType: line.TransactionType,
+			Credits:         money.Zero(currency),
+			Debits:          money.Zero(currency),
+		}
+		totals[line.TransactionType] = total
+	}
+
+	var err error
+	if line.Amount.IsNegative() {
+		total.Debits, err = total.Debits.Add(line.Amount.Neg())
+	} else {
+		total.Credits, err = total.Credits.Add(line.Amount)
+	}
+	total.Count++
+	return err
+}
+
+// sortedTotals lists the totals by transaction type, so exports are stable
+func sortedTotals(totals map[string]*models.StatementTotal) []models.StatementTotal {
+	sorted := make([]models.StatementTotal, 0, len(totals))
+	for _, total := range totals {
+		sorted = append(sorted, *total)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].TransactionType < sorted[j].TransactionType
+	})
+	return sorted
+}
@@ -0,0 +1,338 @@
+package statement
+
+import (
+	"bytes"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	mockLedger "centralized-wallet/tests/mocks/ledger"
+	mockStatement "centralized-wallet/tests/mocks/statement"
+	mockTransaction "centralized-wallet/tests/mocks/transaction"
+	mockWallet "centralized-wallet/tests/mocks/wallet"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testUserID       = 1
+	testWalletNumber = "wallet123"
+	testFrom         = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	testTo           = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func usd(value string) money.Money {
+	return money.MustParse(value, money.DefaultCurrency)
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+type statementServiceMocks struct {
+	transactionRepo *mockTransaction.MockTransactionRepository
+	walletRepo      *mockWallet.MockWalletRepository
+	ledgerService   *mockLedger.MockLedgerService
+	statementRepo   *mockStatement.MockStatementRepository
+}
+
+func setupStatementServiceMock() (*StatementService, statementServiceMocks) {
+	mocks := statementServiceMocks{
+		transactionRepo: new(mockTransaction.MockTransactionRepository),
+		walletRepo:      new(mockWallet.MockWalletRepository),
+		ledgerService:   new(mockLedger.MockLedgerService),
+		statementRepo:   new(mockStatement.MockStatementRepository),
+	}
+	return NewStatementService(mocks.transactionRepo, mocks.walletRepo, mocks.ledgerService, mocks.statementRepo), mocks
+}
+
+func testStatement() *models.Statement {
+	return &models.Statement{
+		WalletNumber:   testWalletNumber,
+		Name:           "Main",
+		Currency:       "USD",
+		From:           testFrom,
+		To:             testTo,
+		OpeningBalance: usd("100.00"),
+		ClosingBalance: usd("140.00"),
+	}
+}
+
+// statementTransactions is a month of wallet123: a deposit, a transfer out, a failed withdrawal and a transfer in
+func statementTransactions() []models.TransactionWithEmails {
+	row := func(id int, txType, status string, from, to *string, amount string, fromBalance, toBalance *money.Money, email *string, day int) models.TransactionWithEmails {
+		txn := models.TransactionWithEmails{
+			Transaction: models.Transaction{
+				ID:               id,
+				FromWalletNumber: from,
+				ToWalletNumber:   to,
+				TransactionType:  txType,
+				Amount:           usd(amount),
+				Currency:         "USD",
+				Status:           status,
+				FromBalanceAfter: fromBalance,
+				ToBalanceAfter:   toBalance,
+				CreatedAt:        testFrom.AddDate(0, 0, day),
+			},
+		}
+		if from != nil && *from == testWalletNumber {
+			txn.ToEmail = email
+		} else {
+			txn.FromEmail = email
+		}
+		return txn
+	}
+
+	return []models.TransactionWithEmails{
+		row(1, transaction.TypeDeposit, transaction.StatusCompleted, nil, ptr(testWalletNumber), "50.00", nil, ptr(usd("150.00")), nil, 1),
+		row(2, transaction.TypeTransfer, transaction.StatusCompleted, ptr(testWalletNumber), ptr("wallet456"), "30.00", ptr(usd("120.00")), ptr(usd("230.00")), ptr("david@example.com"), 2),
+		row(3, transaction.TypeWithdraw, transaction.StatusFailed, ptr(testWalletNumber), nil, "500.00", nil, nil, nil, 3),
+		row(4, transaction.TypeTransfer, transaction.StatusCompleted, ptr("wallet789"), ptr(testWalletNumber), "20.00", ptr(usd("280.00")), ptr(usd("140.00")), ptr("carole@example.com"), 4),
+	}
+}
+
+func TestOpenStatementService(t *testing.T) {
+	wallet := &models.Wallet{ID: 1, UserID: testUserID, WalletNumber: testWalletNumber, Name: "Main", Currency: "USD"}
+
+	t.Run("reads the balances at both ends of the period", func(t *testing.T) {
+		service, m := setupStatementServiceMock()
+		m.walletRepo.On("GetDefaultWallet", testUserID).Return(wallet, nil)
+		m.ledgerService.On("GetBalanceAsOf", testWalletNumber, "USD", testFrom.Add(-time.Microsecond)).Return(usd("100.00"), nil)
+		m.ledgerService.On("GetBalanceAsOf", testWalletNumber, "USD", testTo.Add(-time.Microsecond)).Return(usd("140.00"), nil)
+
+		statement, err := service.OpenStatement(testUserID, "", testFrom, testTo)
+
+		assert.NoError(t, err)
+		assert.Equal(t, testStatement(), statement)
+		m.ledgerService.AssertExpectations(t)
+	})
+
+	t.Run("period ending before it starts", func(t *testing.T) {
+		service, m := setupStatementServiceMock()
+
+		statement, err := service.OpenStatement(testUserID, "", testTo, testFrom)
+
+		assert.ErrorIs(t, err, utils.ServiceErrInvalidStatementPeriod)
+		assert.Nil(t, statement)
+		m.walletRepo.AssertNotCalled(t, "GetDefaultWallet", mock.Anything)
+	})
+
+	t.Run("wallet of another user", func(t *testing.T) {
+		service, m := setupStatementServiceMock()
+		m.walletRepo.On("FindByWalletNumber", "wallet456").Return(&models.Wallet{UserID: 2, WalletNumber: "wallet456"}, nil)
+
+		statement, err := service.OpenStatement(testUserID, "wallet456", testFrom, testTo)
+
+		assert.ErrorIs(t, err, utils.RepoErrWalletNotFound)
+		assert.Nil(t, statement)
+		m.ledgerService.AssertNotCalled(t, "GetBalanceAsOf", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestWriteStatementCSV(t *testing.T) {
+	service, m := setupStatementServiceMock()
+	m.transactionRepo.On("StreamTransactionHistory", testWalletNumber, models.TransactionFilter{CreatedFrom: &testFrom, CreatedTo: &testTo}).
+		Return(statementTransactions(), nil)
+
+	var out bytes.Buffer
+	err := service.WriteStatement(testStatement(), FormatCSV, &out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"record,date,transaction_id,transaction_type,status,direction,counterparty_wallet_number,counterparty_email,amount,currency,balance_after,count,credits,debits",
+		"opening_balance,2024-05-01T00:00:00Z,,,,,,,,USD,100.00,,,",
+		"transaction,2024-05-02T00:00:00Z,1,deposit,completed,incoming,,,50.00,USD,150.00,,,",
+		"transaction,2024-05-03T00:00:00Z,2,transfer,completed,outgoing,wallet456,david@example.com,-30.00,USD,120.00,,,",
+		"transaction,2024-05-05T00:00:00Z,4,transfer,completed,incoming,wallet789,carole@example.com,20.00,USD,140.00,,,",
+		"total,,,deposit,,,,,,USD,,1,50.00,0.00",
+		"total,,,transfer,,,,,,USD,,2,20.00,30.00",
+		"closing_balance,2024-06-01T00:00:00Z,,,,,,,,USD,140.00,,,",
+		"",
+	}, "\n"), out.String())
+}
+
+func TestWriteStatementJSONLines(t *testing.T) {
+	service, m := setupStatementServiceMock()
+	m.transactionRepo.On("StreamTransactionHistory", testWalletNumber, mock.Anything).Return(statementTransactions(), nil)
+
+	var out bytes.Buffer
+	err := service.WriteStatement(testStatement(), FormatJSONLines, &out)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 7) {
+		assert.JSONEq(t, `{"record":"opening_balance","wallet_number":"wallet123","currency":"USD","as_of":"2024-05-01T00:00:00Z","balance":100}`, lines[0])
+		assert.JSONEq(t, `{"record":"transaction","id":2,"date":"2024-05-03T00:00:00Z","transaction_type":"transfer","status":"completed","direction":"outgoing",
+			"counterparty_wallet_number":"wallet456","counterparty_email":"david@example.com","amount":-30,"currency":"USD","balance_after":120}`, lines[2])
+		assert.JSONEq(t, `{"record":"total","currency":"USD","transaction_type":"transfer","count":2,"credits":20,"debits":30}`, lines[5])
+		assert.JSONEq(t, `{"record":"closing_balance","wallet_number":"wallet123","currency":"USD","as_of":"2024-06-01T00:00:00Z","balance":140}`, lines[6])
+	}
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), line)
+	}
+}
+
+func TestWriteStatementOFX(t *testing.T) {
+	service, m := setupStatementServiceMock()
+	m.transactionRepo.On("StreamTransactionHistory", testWalletNumber, mock.Anything).Return(statementTransactions(), nil)
+
+	var out bytes.Buffer
+	err := service.WriteStatement(testStatement(), FormatOFX, &out)
+	assert.NoError(t, err)
+
+	ofx := out.String()
+	assert.True(t, strings.HasPrefix(ofx, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`))
+	assert.Contains(t, ofx, "<CURDEF>USD</CURDEF>")
+	assert.Contains(t, ofx, "<ACCTID>wallet123</ACCTID>")
+	assert.Contains(t, ofx, "<DTSTART>20240501000000.000[0:GMT]</DTSTART><DTEND>20240601000000.000[0:GMT]</DTEND>")
+	assert.Contains(t, ofx, "<STMTTRN><TRNTYPE>DEP</TRNTYPE><DTPOSTED>20240502000000.000[0:GMT]</DTPOSTED><TRNAMT>50.00</TRNAMT><FITID>1</FITID><MEMO>deposit</MEMO></STMTTRN>")
+	assert.Contains(t, ofx, "<TRNTYPE>XFER</TRNTYPE><DTPOSTED>20240503000000.000[0:GMT]</DTPOSTED><TRNAMT>-30.00</TRNAMT><FITID>2</FITID><NAME>david@example.com</NAME>")
+	assert.NotContains(t, ofx, "<FITID>3</FITID>")
+	assert.Contains(t, ofx, "<LEDGERBAL><BALAMT>140.00</BALAMT><DTASOF>20240601000000.000[0:GMT]</DTASOF></LEDGERBAL>")
+	assert.Contains(t, ofx, "<NAME>Opening balance</NAME><DESC>Balance at the start of the period</DESC><BALTYPE>DOLLAR</BALTYPE><VALUE>100.00</VALUE>")
+	assert.Contains(t, ofx, "<NAME>Debits transfer</NAME><DESC>2 transfer transactions</DESC><BALTYPE>DOLLAR</BALTYPE><VALUE>30.00</VALUE>")
+	assert.True(t, strings.HasSuffix(ofx, "</OFX>\n"))
+}
+
+func TestWriteStatementErrors(t *testing.T) {
+	t.Run("unknown format", func(t *testing.T) {
+		service, m := setupStatementServiceMock()
+
+		err := service.WriteStatement(testStatement(), "xlsx", &bytes.Buffer{})
+
+		assert.ErrorIs(t, err, utils.ServiceErrInvalidStatementFormat)
+		m.transactionRepo.AssertNotCalled(t, "StreamTransactionHistory", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stream error stops before the totals", func(t *testing.T) {
+		service, m := setupStatementServiceMock()
+		m.transactionRepo.On("StreamTransactionHistory", testWalletNumber, mock.Anything).Return(nil, errors.New("db error"))
+
+		var out bytes.Buffer
+		err := service.WriteStatement(testStatement(), FormatCSV, &out)
+
+		assert.EqualError(t, err, "db error")
+		assert.NotContains(t, out.String(), "closing_balance")
+	})
+}
+
+func TestGenerateMonthlyStatements(t *testing.T) {
+	wallet := &models.Wallet{ID: 42, UserID: testUserID, WalletNumber: testWalletNumber, Name: "Main", Currency: "USD"}
+	// An outgoing exchange of the month: 10.00 USD debited, of which 0.20 kept as the spread
+	exchange := models.TransactionWithEmails{Transaction: models.Transaction{
+		ID:               5,
+		FromWalletNumber: ptr(testWalletNumber),
+		ToWalletNumber:   ptr("wallet999"),
+		TransactionType:  transaction.TypeExchange,
+		Amount:           usd("10.00"),
+		Currency:         "USD",
+		Status:           transaction.StatusCompleted,
+		FXSpread:         ptr(usd("0.20")),
+		CreatedAt:        testFrom.AddDate(0, 0, 10),
+	}}
+
+	setupService := func() (*StatementService, statementServiceMocks) {
+		service, m := setupStatementServiceMock()
+		service.now = func() time.Time { return time.Date(2024, 6, 1, 0, 5, 0, 0, time.UTC) }
+		return service, m
+	}
+
+	t.Run("issues the statements of a past month", func(t *testing.T) {
+		service, m := setupService()
+		m.statementRepo.On("FindWalletsWithoutStatement", testFrom, testTo).Return([]string{testWalletNumber}, nil)
+		m.walletRepo.On("FindByWalletNumber", testWalletNumber).Return(wallet, nil)
+		m.ledgerService.On("GetBalanceAsOf", testWalletNumber, "USD", testFrom.Add(-time.Microsecond)).Return(usd("100.00"), nil)
+		m.ledgerService.On("GetBalanceAsOf", testWalletNumber, "USD", testTo.Add(-time.Microsecond)).Return(usd("130.00"), nil)
+		m.transactionRepo.On("StreamTransactionHistory", testWalletNumber, models.TransactionFilter{CreatedFrom: &testFrom, CreatedTo: &testTo}).
+			Return(append(statementTransactions(), exchange), nil)
+		m.statementRepo.On("CreateStatement", mock.MatchedBy(func(s *models.MonthlyStatement) bool {
+			return s.StatementNumber == "STM-202405-00000042" &&
+				s.PeriodStart.Equal(testFrom) && s.PeriodEnd.Equal(testTo) &&
+				s.OpeningBalance == usd("100.00") && s.ClosingBalance == usd("130.00") &&
+				s.TotalFees == usd("0.20") && s.TransactionCount == 4 &&
+				bytes.Contains(s.HTML, []byte("STM-202405-00000042")) && bytes.HasPrefix(s.PDF, []byte("%PDF-"))
+		})).Return(true, nil)
+
+		issued, err := service.GenerateMonthlyStatements(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, issued)
+		m.statementRepo.AssertExpectations(t)
+	})
+
+	t.Run("month not over", func(t *testing.T) {
+		service, m := setupService()
+
+		issued, err := service.GenerateMonthlyStatements(testTo)
+
+		assert.ErrorIs(t, err, utils.ServiceErrStatementMonthNotOver)
+		assert.Zero(t, issued)
+		m.statementRepo.AssertNotCalled(t, "FindWalletsWithoutStatement", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a failing wallet does not block the others", func(t *testing.T) {
+		service, m := setupService()
+		m.statementRepo.On("FindWalletsWithoutStatement", testFrom, testTo).Return([]string{"wallet000", testWalletNumber}, nil)
+		m.walletRepo.On("FindByWalletNumber", "wallet000").Return(nil, errors.New("db error"))
+		m.walletRepo.On("FindByWalletNumber", testWalletNumber).Return(wallet, nil)
+		m.ledgerService.On("GetBalanceAsOf", testWalletNumber, "USD", mock.Anything).Return(usd("100.00"), nil)
+		m.transactionRepo.On("StreamTransactionHistory", testWalletNumber, mock.Anything).Return([]models.TransactionWithEmails{}, nil)
+		m.statementRepo.On("CreateStatement", mock.Anything).Return(true, nil)
+
+		issued, err := service.GenerateMonthlyStatements(testFrom)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, issued)
+		m.statementRepo.AssertNumberOfCalls(t, "CreateStatement", 1)
+	})
+
+	t.Run("statement stored by another run first", func(t *testing.T) {
+		service, m := setupService()
+		m.statementRepo.On("FindWalletsWithoutStatement", testFrom, testTo).Return([]string{testWalletNumber}, nil)
+		m.walletRepo.On("FindByWalletNumber", testWalletNumber).Return(wallet, nil)
+		m.ledgerService.On("GetBalanceAsOf", testWalletNumber, "USD", mock.Anything).Return(usd("100.00"), nil)
+		m.transactionRepo.On("StreamTransactionHistory", testWalletNumber, mock.Anything).Return([]models.TransactionWithEmails{}, nil)
+		m.statementRepo.On("CreateStatement", mock.Anything).Return(false, nil)
+
+		issued, err := service.GenerateMonthlyStatements(testFrom)
+
+		assert.NoError(t, err)
+		assert.Zero(t, issued)
+	})
+}
+
+func TestGetMonthlyStatementService(t *testing.T) {
+	stored := &models.MonthlyStatement{StatementNumber: "STM-202405-00000001", WalletNumber: testWalletNumber}
+
+	t.Run("statement of the user's wallet", func(t *testing.T) {
+		service, m := setupStatementServiceMock()
+		m.statementRepo.On("GetStatement", "STM-202405-00000001").Return(stored, nil)
+		m.walletRepo.On("FindByWalletNumber", testWalletNumber).Return(&models.Wallet{UserID: testUserID, WalletNumber: testWalletNumber}, nil)
+
+		statement, err := service.GetMonthlyStatement(testUserID, "STM-202405-00000001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, stored, statement)
+	})
+
+	t.Run("statement of another user's wallet is not found", func(t *testing.T) {
+		service, m := setupStatementServiceMock()
+		m.statementRepo.On("GetStatement", "STM-202405-00000001").Return(stored, nil)
+		m.walletRepo.On("FindByWalletNumber", testWalletNumber).Return(&models.Wallet{UserID: 2, WalletNumber: testWalletNumber}, nil)
+
+		statement, err := service.GetMonthlyStatement(testUserID, "STM-202405-00000001")
+
+		assert.ErrorIs(t, err, utils.RepoErrStatementNotFound)
+		assert.Nil(t, statement)
+	})
+}
+
+func TestStatementNumber(t *testing.T) {
+	assert.Equal(t, "STM-202405-00000042", StatementNumber(42, testFrom))
+}
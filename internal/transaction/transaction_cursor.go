@@ -0,0 +1,42 @@
+package transaction
+
+import (
+	"centralized-wallet/internal/utils"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Cursor is a position in a wallet's history, ordered by (created_at, id). Clients only see it encoded.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int       `json:"id"`
+	Order     string    `json:"o"`           // Order of the history the cursor was issued for
+	Before    bool      `json:"b,omitempty"` // Page before the position (prev_cursor) instead of after it
+}
+
+// EncodeCursor renders the cursor as an opaque, URL-safe string
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor issued for a history read in orderBy order.
+// Malformed cursors, or cursors of the other order, return ServiceErrInvalidCursor.
+func DecodeCursor(value, orderBy string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, utils.ServiceErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, utils.ServiceErrInvalidCursor
+	}
+	if cursor.ID <= 0 || cursor.CreatedAt.IsZero() || !strings.EqualFold(cursor.Order, orderBy) {
+		return nil, utils.ServiceErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
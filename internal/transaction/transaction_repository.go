@@ -2,13 +2,34 @@ package transaction
 
 import (
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
 	"database/sql"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type TransactionRepositoryInterface interface {
 	CreateTransaction(tx *sql.Tx, transaction *models.Transaction) error
-	GetTransactionHistory(walletNumber string, orderBy string, limit, offset int) ([]models.TransactionWithEmails, error)
+	UpdateStatus(tx *sql.Tx, transactionID int, fromStatus, toStatus string, at time.Time) error
+	LockTransactionByID(tx *sql.Tx, transactionID int) (*models.Transaction, error)
+	GetReversedAmount(tx *sql.Tx, transactionID int, currency string) (money.Money, error)
+	GetTransactionHistory(walletNumber string, filter models.TransactionFilter, orderBy string, limit, offset int) ([]models.TransactionWithEmails, error)
+	GetTransactionHistoryByCursor(walletNumber string, filter models.TransactionFilter, orderBy string, cursor *Cursor, limit int) ([]models.TransactionWithEmails, error)
+	GetTransactionWithParties(transactionID int) (*models.TransactionWithEmails, error)
+	StreamTransactionHistory(walletNumber string, filter models.TransactionFilter, fn func(transaction *models.TransactionWithEmails) error) error
+	SetBalancesAfter(tx *sql.Tx, transactionID int, fromBalance, toBalance *money.Money) error
+	MarkIdempotencyKeyCommitted(tx *sql.Tx, claim *models.IdempotencyClaim) error
 }
+
+// statusTimestampColumns maps each status a transaction can move into to the column stamping that transition
+var statusTimestampColumns = map[string]string{
+	StatusCompleted: "completed_at",
+	StatusFailed:    "failed_at",
+	StatusReversed:  "reversed_at",
+}
+
 type TransactionRepository struct {
 	db *sql.DB
 }
@@ -20,27 +41,135 @@ func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-// CreateTransaction inserts a new transaction with wallet numbers.
+// CreateTransaction inserts a new transaction with wallet numbers and sets its generated ID.
 func (r *TransactionRepository) CreateTransaction(tx *sql.Tx, transaction *models.Transaction) error {
-	query := `INSERT INTO transactions (from_wallet_number, to_wallet_number, transaction_type, amount, created_at)
-			  VALUES ($1, $2, $3, $4, $5)`
+	query := `INSERT INTO transactions (from_wallet_number, to_wallet_number, transaction_type, amount, currency, status, reverses_transaction_id,
+			  to_amount, to_currency, fx_rate, fx_spread, memo, external_reference, metadata, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
 
-	_, err := tx.Exec(
+	return tx.QueryRow(
 		query,
 		transaction.FromWalletNumber,
 		transaction.ToWalletNumber,
 		transaction.TransactionType,
 		transaction.Amount,
+		transaction.Currency,
+		transaction.Status,
+		transaction.ReversesTransactionID,
+		transaction.ToAmount,
+		transaction.ToCurrency,
+		transaction.FXRate,
+		transaction.FXSpread,
+		transaction.Memo,
+		transaction.ExternalReference,
+		transaction.Metadata,
 		transaction.CreatedAt,
+	).Scan(&transaction.ID)
+}
+
+// LockTransactionByID fetches a transaction and locks its row until the DB transaction ends
+func (r *TransactionRepository) LockTransactionByID(tx *sql.Tx, transactionID int) (*models.Transaction, error) {
+	query := `SELECT id, from_wallet_number, to_wallet_number, transaction_type, amount, currency, status,
+					 reverses_transaction_id, to_amount, to_currency, fx_rate, fx_spread,
+					 from_balance_after, to_balance_after, memo, external_reference, metadata,
+					 created_at, completed_at, failed_at, reversed_at
+			  FROM transactions WHERE id = $1 FOR UPDATE`
+
+	var transaction models.Transaction
+	err := tx.QueryRow(query, transactionID).Scan(
+		&transaction.ID,
+		&transaction.FromWalletNumber,
+		&transaction.ToWalletNumber,
+		&transaction.TransactionType,
+		&transaction.Amount,
+		&transaction.Currency,
+		&transaction.Status,
+		&transaction.ReversesTransactionID,
+		&transaction.ToAmount,
+		&transaction.ToCurrency,
+		&transaction.FXRate,
+		&transaction.FXSpread,
+		&transaction.FromBalanceAfter,
+		&transaction.ToBalanceAfter,
+		&transaction.Memo,
+		&transaction.ExternalReference,
+		&transaction.Metadata,
+		&transaction.CreatedAt,
+		&transaction.CompletedAt,
+		&transaction.FailedAt,
+		&transaction.ReversedAt,
 	)
-	return err
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.RepoErrTransactionNotFound
+		}
+		return nil, err
+	}
+	setAmountCurrencies(&transaction)
+	return &transaction, nil
 }
 
-// GetTransactionHistory fetches the transaction history for a given wallet number.
-func (repo *TransactionRepository) GetTransactionHistory(walletNumber string, orderBy string, limit, offset int) ([]models.TransactionWithEmails, error) {
-	transactions := []models.TransactionWithEmails{}
+// GetReversedAmount sums the reversals already recorded against a transaction, ignoring failed ones
+func (r *TransactionRepository) GetReversedAmount(tx *sql.Tx, transactionID int, currency string) (money.Money, error) {
+	query := `SELECT COALESCE(SUM(amount), 0)::BIGINT FROM transactions
+			  WHERE reverses_transaction_id = $1 AND status <> 'failed'`
 
-	query := `
+	reversed := money.Zero(currency)
+	if err := tx.QueryRow(query, transactionID).Scan(&reversed); err != nil {
+		return money.Money{}, err
+	}
+	return reversed, nil
+}
+
+// UpdateStatus moves a transaction from one status to another and stamps the transition time.
+// The update only applies while the row is still in fromStatus, so concurrent transitions cannot both win.
+func (r *TransactionRepository) UpdateStatus(tx *sql.Tx, transactionID int, fromStatus, toStatus string, at time.Time) error {
+	column, ok := statusTimestampColumns[toStatus]
+	if !ok {
+		return utils.RepoErrTransactionStatusChanged
+	}
+
+	query := `UPDATE transactions SET status = $1, ` + column + ` = $2
+			  WHERE id = $3 AND status = $4`
+
+	result, err := tx.Exec(query, toStatus, at, transactionID, fromStatus)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return utils.RepoErrTransactionStatusChanged
+	}
+	return nil
+}
+
+// MarkIdempotencyKeyCommitted marks the key of the request as committed along with the DB transaction.
+// It returns RepoErrIdempotencyClaimLost when the key is no longer held by the claim or was already marked.
+func (r *TransactionRepository) MarkIdempotencyKeyCommitted(tx *sql.Tx, claim *models.IdempotencyClaim) error {
+	query := `UPDATE idempotency_keys SET committed_at = NOW()
+			  WHERE user_id = $1 AND idempotency_key = $2 AND locked_until = $3 AND committed_at IS NULL`
+
+	result, err := tx.Exec(query, claim.UserID, claim.Key, claim.LockedUntil)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return utils.RepoErrIdempotencyClaimLost
+	}
+	return nil
+}
+
+// historyQuery selects a wallet's history rows with the emails of both sides; the caller appends WHERE, ORDER BY and LIMIT
+const historyQuery = `
 		SELECT
 			t.id,
 			uf.email as from_email,
@@ -49,22 +178,135 @@ func (repo *TransactionRepository) GetTransactionHistory(walletNumber string, or
 			t.to_wallet_number,
 			t.transaction_type,
 			t.amount,
-			t.created_at
+			t.currency,
+			t.status,
+			t.reverses_transaction_id,
+			t.to_amount,
+			t.to_currency,
+			t.fx_rate,
+			t.fx_spread,
+			t.from_balance_after,
+			t.to_balance_after,
+			t.memo,
+			t.external_reference,
+			t.metadata,
+			t.created_at,
+			t.completed_at,
+			t.failed_at,
+			t.reversed_at,
+			wf.user_id,
+			wtu.user_id
 		FROM transactions t
 		LEFT JOIN wallets wf ON t.from_wallet_number = wf.wallet_number
 		LEFT JOIN users uf ON wf.user_id = uf.id
 		LEFT JOIN wallets wtu ON t.to_wallet_number = wtu.wallet_number
-		LEFT JOIN users tu ON wtu.user_id = tu.id
-		WHERE t.from_wallet_number = $1 OR t.to_wallet_number = $1
-		ORDER BY t.created_at ` + orderBy + `
-		LIMIT $2
-		OFFSET $3`
+		LEFT JOIN users tu ON wtu.user_id = tu.id`
 
-	// Execute the query
-	rows, err := repo.db.Query(query, walletNumber, limit, offset)
+// GetTransactionHistory fetches the transaction history for a given wallet number, narrowed by the filter.
+// Empty filter fields match every transaction.
+func (repo *TransactionRepository) GetTransactionHistory(walletNumber string, filter models.TransactionFilter, orderBy string, limit, offset int) ([]models.TransactionWithEmails, error) {
+	conditions, args := historyConditions(walletNumber, filter)
+	args = append(args, limit, offset)
+
+	query := historyQuery + `
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY t.created_at ` + orderBy + `, t.id ` + orderBy + `
+		LIMIT $` + strconv.Itoa(len(args)-1) + `
+		OFFSET $` + strconv.Itoa(len(args))
+
+	return repo.queryHistory(query, args...)
+}
+
+// GetTransactionHistoryByCursor fetches up to limit transactions on one side of the cursor, narrowed by the filter.
+// Without a cursor it reads from the start of the history. Pages before the cursor are returned nearest first,
+// that is in the reverse of orderBy, so the caller flips them.
+func (repo *TransactionRepository) GetTransactionHistoryByCursor(walletNumber string, filter models.TransactionFilter, orderBy string, cursor *Cursor, limit int) ([]models.TransactionWithEmails, error) {
+	conditions, args := historyConditions(walletNumber, filter)
+
+	ascending := strings.EqualFold(orderBy, "ASC")
+	if cursor != nil {
+		if cursor.Before {
+			ascending = !ascending
+		}
+		comparison := "<"
+		if ascending {
+			comparison = ">"
+		}
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conditions = append(conditions, "(t.created_at, t.id) "+comparison+
+			" ($"+strconv.Itoa(len(args)-1)+"::TIMESTAMP, $"+strconv.Itoa(len(args))+"::INT)")
+	}
+
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+	args = append(args, limit)
+
+	query := historyQuery + `
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY t.created_at ` + direction + `, t.id ` + direction + `
+		LIMIT $` + strconv.Itoa(len(args))
+
+	return repo.queryHistory(query, args...)
+}
+
+// GetTransactionWithParties fetches a transaction with the emails and user IDs of both sides
+func (repo *TransactionRepository) GetTransactionWithParties(transactionID int) (*models.TransactionWithEmails, error) {
+	transactions, err := repo.queryHistory(historyQuery+`
+		WHERE t.id = $1`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, utils.RepoErrTransactionNotFound
+	}
+	return &transactions[0], nil
+}
+
+// SetBalancesAfter stores the balances the wallets were left with once the transaction was posted.
+// A nil balance leaves that side NULL.
+func (r *TransactionRepository) SetBalancesAfter(tx *sql.Tx, transactionID int, fromBalance, toBalance *money.Money) error {
+	query := `UPDATE transactions SET from_balance_after = $1, to_balance_after = $2 WHERE id = $3`
+
+	_, err := tx.Exec(query, fromBalance, toBalance, transactionID)
+	return err
+}
+
+// StreamTransactionHistory calls fn for each transaction of the wallet matching the filter, oldest first.
+// Rows are scanned one at a time, so the whole history is never held in memory. An error from fn stops the stream and is returned.
+func (repo *TransactionRepository) StreamTransactionHistory(walletNumber string, filter models.TransactionFilter, fn func(transaction *models.TransactionWithEmails) error) error {
+	conditions, args := historyConditions(walletNumber, filter)
+
+	query := historyQuery + `
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY t.created_at ASC, t.id ASC`
+
+	return repo.eachHistoryRow(query, args, fn)
+}
+
+// queryHistory runs a history query and scans its rows
+func (repo *TransactionRepository) queryHistory(query string, args ...interface{}) ([]models.TransactionWithEmails, error) {
+	transactions := []models.TransactionWithEmails{}
+
+	err := repo.eachHistoryRow(query, args, func(transaction *models.TransactionWithEmails) error {
+		transactions = append(transactions, *transaction)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	return transactions, nil
+}
+
+// eachHistoryRow runs a history query and calls fn with each scanned row
+func (repo *TransactionRepository) eachHistoryRow(query string, args []interface{}, fn func(transaction *models.TransactionWithEmails) error) error {
+	// Execute the query
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
 	// Iterate over the rows and scan the data into the struct
@@ -80,19 +322,111 @@ func (repo *TransactionRepository) GetTransactionHistory(walletNumber string, or
 			&transaction.ToWalletNumber,
 			&transaction.TransactionType,
 			&transaction.Amount,
+			&transaction.Currency,
+			&transaction.Status,
+			&transaction.ReversesTransactionID,
+			&transaction.ToAmount,
+			&transaction.ToCurrency,
+			&transaction.FXRate,
+			&transaction.FXSpread,
+			&transaction.FromBalanceAfter,
+			&transaction.ToBalanceAfter,
+			&transaction.Memo,
+			&transaction.ExternalReference,
+			&transaction.Metadata,
 			&transaction.CreatedAt,
+			&transaction.CompletedAt,
+			&transaction.FailedAt,
+			&transaction.ReversedAt,
+			&transaction.FromUserID,
+			&transaction.ToUserID,
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		setAmountCurrencies(&transaction.Transaction)
 
-		transactions = append(transactions, transaction)
+		if err := fn(&transaction); err != nil {
+			return err
+		}
 	}
 
 	// Check for any error that might have occurred during iteration
-	if err = rows.Err(); err != nil {
-		return nil, err
+	return rows.Err()
+}
+
+// historyConditions builds the WHERE conditions of a wallet's history and their arguments; $1 is always the wallet number.
+// Failed transfers are only listed for the wallet that tried to send them.
+func historyConditions(walletNumber string, filter models.TransactionFilter) ([]string, []interface{}) {
+	conditions := []string{"(t.from_wallet_number = $1 OR (t.to_wallet_number = $1 AND t.status <> '" + StatusFailed + "'))"}
+	args := []interface{}{walletNumber}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
 	}
 
-	return transactions, nil
+	// The amount that moved in or out of the wallet: the credited leg for incoming exchanges
+	walletAmount := "(CASE WHEN t.to_wallet_number = $1 AND t.to_amount IS NOT NULL THEN t.to_amount ELSE t.amount END)"
+
+	if filter.Status != "" {
+		conditions = append(conditions, "t.status = "+arg(filter.Status))
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "t.transaction_type = "+arg(filter.Type))
+	}
+	switch filter.Direction {
+	case DirectionIncoming:
+		conditions = append(conditions, "t.to_wallet_number = $1")
+	case DirectionOutgoing:
+		conditions = append(conditions, "t.from_wallet_number = $1")
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "t.created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "t.created_at < "+arg(*filter.CreatedTo))
+	}
+	if filter.MinAmount != nil {
+		conditions = append(conditions, walletAmount+" >= "+arg(filter.MinAmount.Amount))
+	}
+	if filter.MaxAmount != nil {
+		conditions = append(conditions, walletAmount+" <= "+arg(filter.MaxAmount.Amount))
+	}
+	if filter.Counterparty != "" {
+		if strings.Contains(filter.Counterparty, "@") {
+			conditions = append(conditions, "LOWER(CASE WHEN t.from_wallet_number = $1 THEN tu.email ELSE uf.email END) = LOWER("+arg(filter.Counterparty)+")")
+		} else {
+			conditions = append(conditions, "(CASE WHEN t.from_wallet_number = $1 THEN t.to_wallet_number ELSE t.from_wallet_number END) = "+arg(filter.Counterparty))
+		}
+	}
+	if filter.ExternalReference != "" {
+		conditions = append(conditions, "t.external_reference = "+arg(filter.ExternalReference))
+	}
+
+	return conditions, args
+}
+
+// setAmountCurrencies tags the scanned minor-unit amounts with the currency of their leg
+func setAmountCurrencies(transaction *models.Transaction) {
+	transaction.Amount = money.New(transaction.Amount.Amount, transaction.Currency)
+	if transaction.FXSpread != nil {
+		spread := money.New(transaction.FXSpread.Amount, transaction.Currency)
+		transaction.FXSpread = &spread
+	}
+	if transaction.ToAmount != nil && transaction.ToCurrency != nil {
+		toAmount := money.New(transaction.ToAmount.Amount, *transaction.ToCurrency)
+		transaction.ToAmount = &toAmount
+	}
+	if transaction.FromBalanceAfter != nil {
+		fromBalance := money.New(transaction.FromBalanceAfter.Amount, transaction.Currency)
+		transaction.FromBalanceAfter = &fromBalance
+	}
+	if transaction.ToBalanceAfter != nil {
+		toCurrency := transaction.Currency
+		if transaction.ToCurrency != nil {
+			toCurrency = *transaction.ToCurrency
+		}
+		toBalance := money.New(transaction.ToBalanceAfter.Amount, toCurrency)
+		transaction.ToBalanceAfter = &toBalance
+	}
 }
@@ -1,19 +1,30 @@
 package transaction
 
 import (
+	"centralized-wallet/internal/fx"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"centralized-wallet/internal/redis"
 	"centralized-wallet/internal/utils"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type TransactionServiceInterface interface {
-	RecordTransaction(tx *sql.Tx, fromWalletNumber *string, toWalletNumber *string, transactionType string, amount float64) error
-	GetTransactionHistory(walletNumber string, orderBy string, limit, offset int) ([]models.FormattedTransaction, error)
+	RecordTransaction(tx *sql.Tx, fromWalletNumber *string, toWalletNumber *string, transactionType string, amount money.Money, note models.TransactionNote) (*models.Transaction, error)
+	RecordReversal(tx *sql.Tx, original *models.Transaction, amount money.Money) (*models.Transaction, error)
+	RecordExchange(tx *sql.Tx, fromWalletNumber, toWalletNumber string, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, error)
+	UpdateStatus(tx *sql.Tx, transaction *models.Transaction, status string) error
+	SetBalancesAfter(tx *sql.Tx, transaction *models.Transaction, fromBalance, toBalance *money.Money) error
+	LockTransactionByID(tx *sql.Tx, transactionID int) (*models.Transaction, error)
+	GetReversedAmount(tx *sql.Tx, original *models.Transaction) (money.Money, error)
+	GetTransactionHistory(walletNumber string, filter models.TransactionFilter, orderBy string, limit, offset int) ([]models.FormattedTransaction, error)
+	GetTransactionHistoryPage(walletNumber string, filter models.TransactionFilter, orderBy string, limit int, cursor string) (*models.TransactionPage, error)
+	GetTransactionDetail(userID, transactionID int) (*models.TransactionDetail, error)
 	FormatTransactionResponse(walletNumber string, transactions []models.TransactionWithEmails) []models.FormattedTransaction
 }
 
@@ -29,11 +40,11 @@ func NewTransactionService(repo TransactionRepositoryInterface, redis redis.Redi
 	}
 }
 
-// RecordTransaction records a transaction
-func (ts *TransactionService) RecordTransaction(tx *sql.Tx, fromWalletNumber, toWalletNumber *string, transactionType string, amount float64) error {
+// RecordTransaction records a pending transaction with the client's note and returns it with its generated ID
+func (ts *TransactionService) RecordTransaction(tx *sql.Tx, fromWalletNumber, toWalletNumber *string, transactionType string, amount money.Money, note models.TransactionNote) (*models.Transaction, error) {
 	// Check if both fromWalletNumber and toWalletNumber are nil or empty
 	if (fromWalletNumber == nil || *fromWalletNumber == "") && (toWalletNumber == nil || *toWalletNumber == "") {
-		return utils.ServiceErrWalletNumberNil
+		return nil, utils.ServiceErrWalletNumberNil
 	}
 
 	// Create the transaction struct
@@ -42,41 +53,188 @@ func (ts *TransactionService) RecordTransaction(tx *sql.Tx, fromWalletNumber, to
 		ToWalletNumber:   toWalletNumber,
 		TransactionType:  transactionType,
 		Amount:           amount,
+		Currency:         amount.Currency,
+		Status:           StatusPending,
 		CreatedAt:        time.Now(),
 	}
+	note.Apply(&transaction)
 
 	// Save the transaction using the repository
 	if err := ts.repo.CreateTransaction(tx, &transaction); err != nil {
-		return err
+		return nil, err
 	}
 
-	if ts.redisService == nil {
+	if err := ts.markIdempotencyKey(tx, note); err != nil {
+		return nil, err
+	}
+
+	if err := ts.invalidateWalletCaches(fromWalletNumber, toWalletNumber); err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+// RecordReversal records a pending reversal of the original transaction, moving the amount in the opposite direction
+func (ts *TransactionService) RecordReversal(tx *sql.Tx, original *models.Transaction, amount money.Money) (*models.Transaction, error) {
+	if original.ToWalletNumber == nil || *original.ToWalletNumber == "" {
+		return nil, utils.ServiceErrTransactionNotReversible
+	}
+
+	transaction := models.Transaction{
+		FromWalletNumber:      original.ToWalletNumber,
+		ToWalletNumber:        original.FromWalletNumber,
+		TransactionType:       "reversal",
+		Amount:                amount,
+		Currency:              amount.Currency,
+		Status:                StatusPending,
+		ReversesTransactionID: &original.ID,
+		CreatedAt:             time.Now(),
+	}
+
+	if err := ts.repo.CreateTransaction(tx, &transaction); err != nil {
+		return nil, err
+	}
+
+	if err := ts.invalidateWalletCaches(transaction.FromWalletNumber, transaction.ToWalletNumber); err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+// RecordExchange records a pending cross-currency transfer. Amount is the debited leg, spread included,
+// and the credited leg, applied rate and spread are stored alongside so FX can be reconciled.
+func (ts *TransactionService) RecordExchange(tx *sql.Tx, fromWalletNumber, toWalletNumber string, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, error) {
+	if fromWalletNumber == "" || toWalletNumber == "" {
+		return nil, utils.ServiceErrWalletNumberNil
+	}
+
+	toAmount := conversion.Target
+	spread := conversion.Spread
+	rate := conversion.AppliedRate
+	transaction := models.Transaction{
+		FromWalletNumber: &fromWalletNumber,
+		ToWalletNumber:   &toWalletNumber,
+		TransactionType:  "exchange",
+		Amount:           conversion.Source,
+		Currency:         conversion.Source.Currency,
+		Status:           StatusPending,
+		ToAmount:         &toAmount,
+		ToCurrency:       &toAmount.Currency,
+		FXRate:           &rate,
+		FXSpread:         &spread,
+		CreatedAt:        time.Now(),
+	}
+	note.Apply(&transaction)
+
+	if err := ts.repo.CreateTransaction(tx, &transaction); err != nil {
+		return nil, err
+	}
+
+	if err := ts.markIdempotencyKey(tx, note); err != nil {
+		return nil, err
+	}
+
+	if err := ts.invalidateWalletCaches(transaction.FromWalletNumber, transaction.ToWalletNumber); err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+// markIdempotencyKey marks the key of the request committed in the DB transaction recording it.
+// Only the request still holding the key may mark it, so a request whose key was reclaimed cannot commit as well.
+func (ts *TransactionService) markIdempotencyKey(tx *sql.Tx, note models.TransactionNote) error {
+	if note.Idempotency == nil {
 		return nil
 	}
 
-	if fromWalletNumber != nil && *fromWalletNumber != "" {
-		pageKeyPatternFrom := fmt.Sprintf("user:%s:transactions:page:*", *fromWalletNumber)
-		err := ts.InvalidateTransactionCache(pageKeyPatternFrom)
-		if err != nil {
-			return err
+	err := ts.repo.MarkIdempotencyKeyCommitted(tx, note.Idempotency)
+	if err == utils.RepoErrIdempotencyClaimLost {
+		return utils.ServiceErrIdempotencyRequestInFlight
+	}
+	return err
+}
+
+// SetBalancesAfter records the balances the transaction left its wallets with, in the DB transaction that changed them.
+// A nil balance means that side has no wallet.
+func (ts *TransactionService) SetBalancesAfter(tx *sql.Tx, transaction *models.Transaction, fromBalance, toBalance *money.Money) error {
+	if err := ts.repo.SetBalancesAfter(tx, transaction.ID, fromBalance, toBalance); err != nil {
+		return err
+	}
+
+	transaction.FromBalanceAfter = fromBalance
+	transaction.ToBalanceAfter = toBalance
+	return nil
+}
+
+// LockTransactionByID fetches a transaction and locks it for the rest of the DB transaction
+func (ts *TransactionService) LockTransactionByID(tx *sql.Tx, transactionID int) (*models.Transaction, error) {
+	return ts.repo.LockTransactionByID(tx, transactionID)
+}
+
+// GetReversedAmount returns how much of the original transaction has already been reversed
+func (ts *TransactionService) GetReversedAmount(tx *sql.Tx, original *models.Transaction) (money.Money, error) {
+	return ts.repo.GetReversedAmount(tx, original.ID, original.Amount.Currency)
+}
+
+// UpdateStatus moves the transaction to a new status and stamps the time of the transition.
+// Transitions not allowed by the state machine, or racing with another change, return ServiceErrInvalidStatusTransition.
+func (ts *TransactionService) UpdateStatus(tx *sql.Tx, transaction *models.Transaction, status string) error {
+	if !CanTransition(transaction.Status, status) {
+		return utils.ServiceErrInvalidStatusTransition
+	}
+
+	now := time.Now()
+	if err := ts.repo.UpdateStatus(tx, transaction.ID, transaction.Status, status, now); err != nil {
+		if err == utils.RepoErrTransactionStatusChanged {
+			return utils.ServiceErrInvalidStatusTransition
 		}
+		return err
 	}
 
-	if toWalletNumber != nil && *toWalletNumber != "" {
-		pageKeyPatternTo := fmt.Sprintf("user:%s:transactions:page:*", *toWalletNumber)
-		err := ts.InvalidateTransactionCache(pageKeyPatternTo)
-		if err != nil {
-			return err
+	transaction.Status = status
+	switch status {
+	case StatusCompleted:
+		transaction.CompletedAt = &now
+	case StatusFailed:
+		transaction.FailedAt = &now
+	case StatusReversed:
+		transaction.ReversedAt = &now
+	}
+
+	return ts.invalidateWalletCaches(transaction.FromWalletNumber, transaction.ToWalletNumber)
+}
+
+// walletCacheKeyPatterns match the cached reads of a wallet that change when it records a transaction:
+// its history pages and its analytics
+var walletCacheKeyPatterns = []string{"user:%s:transactions:page:*", "user:%s:analytics:*"}
+
+// invalidateWalletCaches drops the cached history pages and analytics of both wallets involved in a transaction
+func (ts *TransactionService) invalidateWalletCaches(fromWalletNumber, toWalletNumber *string) error {
+	if ts.redisService == nil {
+		return nil
+	}
+
+	for _, walletNumber := range []*string{fromWalletNumber, toWalletNumber} {
+		if walletNumber == nil || *walletNumber == "" {
+			continue
+		}
+		for _, pattern := range walletCacheKeyPatterns {
+			if err := ts.InvalidateTransactionCache(fmt.Sprintf(pattern, *walletNumber)); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
-// GetTransactionHistory retrieves the transaction history for a specific wallet number.
-func (ts *TransactionService) GetTransactionHistory(walletNumber string, orderBy string, limit, offset int) ([]models.FormattedTransaction, error) {
+// GetTransactionHistory retrieves the transaction history for a specific wallet number, narrowed by the filter.
+func (ts *TransactionService) GetTransactionHistory(walletNumber string, filter models.TransactionFilter, orderBy string, limit, offset int) ([]models.FormattedTransaction, error) {
 	pageSize := 30
-	pageKey := fmt.Sprintf("user:%s:transactions:page:%d%s", walletNumber, offset/pageSize, orderBy)
+	pageKey := fmt.Sprintf("user:%s:transactions:page:%d%s:%s", walletNumber, offset/pageSize, orderBy, filter.CacheKey())
 
 	// Check Redis cache first if available
 	if ts.redisService != nil {
@@ -91,7 +249,7 @@ func (ts *TransactionService) GetTransactionHistory(walletNumber string, orderBy
 	}
 
 	// Fetch from the database if not cached
-	transactions, err := ts.repo.GetTransactionHistory(walletNumber, orderBy, limit, offset)
+	transactions, err := ts.repo.GetTransactionHistory(walletNumber, filter, orderBy, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +273,144 @@ func (ts *TransactionService) GetTransactionHistory(walletNumber string, orderBy
 	return formattedTransactions, nil
 }
 
+// GetTransactionHistoryPage reads one page of a wallet's history after or before an opaque cursor,
+// or the first page when cursor is empty. Unlike offsets, cursors stay on the same rows when new transactions arrive.
+// Pages are cached under their cursor, and dropped with the offset pages whenever the wallet records a transaction.
+func (ts *TransactionService) GetTransactionHistoryPage(walletNumber string, filter models.TransactionFilter, orderBy string, limit int, cursor string) (*models.TransactionPage, error) {
+	var position *Cursor
+	if cursor != "" {
+		var err error
+		if position, err = DecodeCursor(cursor, orderBy); err != nil {
+			return nil, err
+		}
+	}
+
+	pageKey := fmt.Sprintf("user:%s:transactions:page:cursor:%s:%d:%s:%s", walletNumber, orderBy, limit, cursor, filter.CacheKey())
+
+	// Check Redis cache first if available
+	if ts.redisService != nil {
+		cachedPage, err := ts.redisService.Get(context.Background(), pageKey)
+		if err == nil && cachedPage != "" {
+			var page models.TransactionPage
+			if err = json.Unmarshal([]byte(cachedPage), &page); err == nil {
+				return &page, nil
+			}
+		}
+	}
+
+	// One extra row tells whether there is a page beyond this one
+	transactions, err := ts.repo.GetTransactionHistoryByCursor(walletNumber, filter, orderBy, position, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(transactions) > limit
+	if hasMore {
+		transactions = transactions[:limit]
+	}
+
+	backward := position != nil && position.Before
+	if backward {
+		for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+			transactions[i], transactions[j] = transactions[j], transactions[i]
+		}
+	}
+
+	page := &models.TransactionPage{Transactions: ts.FormatTransactionResponse(walletNumber, transactions)}
+	if len(transactions) > 0 {
+		first, last := transactions[0], transactions[len(transactions)-1]
+		// Reading backwards always leaves the page we came from after this one
+		if hasMore && !backward || backward {
+			page.NextCursor = EncodeCursor(Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Order: orderBy})
+		}
+		if position != nil && !backward || hasMore && backward {
+			page.PrevCursor = EncodeCursor(Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Order: orderBy, Before: true})
+		}
+	}
+
+	// Cache the page if redis available
+	if ts.redisService != nil {
+		cacheData, err := json.Marshal(page)
+		if err == nil {
+			ts.redisService.Set(context.Background(), pageKey, cacheData, 10*time.Minute)
+		}
+	}
+
+	return page, nil
+}
+
+// GetTransactionDetail returns the full record of a transaction the user is a party to, seen from the user's wallet.
+// Transactions of other users are reported as not found so transaction IDs cannot be probed.
+func (ts *TransactionService) GetTransactionDetail(userID, transactionID int) (*models.TransactionDetail, error) {
+	tx, err := ts.repo.GetTransactionWithParties(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &models.TransactionDetail{
+		ID:                    tx.ID,
+		TransactionType:       tx.TransactionType,
+		Status:                tx.Status,
+		Amount:                tx.Amount,
+		Currency:              tx.Currency,
+		ReversesTransactionID: tx.ReversesTransactionID,
+		ToAmount:              tx.ToAmount,
+		FXRate:                tx.FXRate,
+		FXSpread:              tx.FXSpread,
+		CreatedAt:             tx.CreatedAt,
+		CompletedAt:           tx.CompletedAt,
+		FailedAt:              tx.FailedAt,
+		ReversedAt:            tx.ReversedAt,
+		Metadata:              tx.Metadata,
+	}
+	if tx.Memo != nil {
+		detail.Memo = *tx.Memo
+	}
+	if tx.ExternalReference != nil {
+		detail.ExternalReference = *tx.ExternalReference
+	}
+	if tx.FromWalletNumber != nil {
+		detail.FromWalletNumber = *tx.FromWalletNumber
+	}
+	if tx.FromEmail != nil {
+		detail.FromEmail = MaskEmail(*tx.FromEmail)
+	}
+	if tx.ToWalletNumber != nil {
+		detail.ToWalletNumber = *tx.ToWalletNumber
+	}
+	if tx.ToEmail != nil {
+		detail.ToEmail = MaskEmail(*tx.ToEmail)
+	}
+	if tx.ToCurrency != nil {
+		detail.ToCurrency = *tx.ToCurrency
+	}
+
+	// Between two wallets of the same user the transaction is shown from the sending side.
+	// A failed transfer never reached the recipient, so it is only shown to the sender.
+	switch {
+	case tx.FromUserID != nil && *tx.FromUserID == userID:
+		detail.Direction = DirectionOutgoing
+		detail.WalletNumber = detail.FromWalletNumber
+		detail.BalanceAfter = tx.FromBalanceAfter
+	case tx.ToUserID != nil && *tx.ToUserID == userID && tx.Status != StatusFailed:
+		detail.Direction = DirectionIncoming
+		detail.WalletNumber = detail.ToWalletNumber
+		detail.BalanceAfter = tx.ToBalanceAfter
+	default:
+		return nil, utils.RepoErrTransactionNotFound
+	}
+
+	return detail, nil
+}
+
+// MaskEmail hides the local part of an email but its first character, e.g. "a***@example.com"
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
 // transaction_service.go
 func (ts *TransactionService) FormatTransactionResponse(walletNumber string, transactions []models.TransactionWithEmails) []models.FormattedTransaction {
 	formattedTransactions := []models.FormattedTransaction{}
@@ -123,11 +419,28 @@ func (ts *TransactionService) FormatTransactionResponse(walletNumber string, tra
 		var formattedTx models.FormattedTransaction
 		formattedTx.TransactionType = tx.TransactionType
 		formattedTx.Amount = tx.Amount
+		formattedTx.Currency = tx.Currency
+		formattedTx.ID = tx.ID
+		formattedTx.Status = tx.Status
+		formattedTx.ReversesTransactionID = tx.ReversesTransactionID
+		formattedTx.ToAmount = tx.ToAmount
+		if tx.ToCurrency != nil {
+			formattedTx.ToCurrency = *tx.ToCurrency
+		}
+		formattedTx.FXRate = tx.FXRate
+		if tx.Memo != nil {
+			formattedTx.Memo = *tx.Memo
+		}
+		if tx.ExternalReference != nil {
+			formattedTx.ExternalReference = *tx.ExternalReference
+		}
+		formattedTx.Metadata = tx.Metadata
 
 		// Check direction based on the user's wallet number and the presence of from/to wallet numbers
 		if tx.FromWalletNumber != nil && *tx.FromWalletNumber == walletNumber {
 			// Outgoing transaction
 			formattedTx.Direction = "outgoing"
+			formattedTx.BalanceAfter = tx.FromBalanceAfter
 			if tx.ToWalletNumber != nil {
 				formattedTx.ToWalletNumber = *tx.ToWalletNumber
 			}
@@ -137,6 +450,7 @@ func (ts *TransactionService) FormatTransactionResponse(walletNumber string, tra
 		} else if tx.ToWalletNumber != nil && *tx.ToWalletNumber == walletNumber {
 			// Incoming transaction
 			formattedTx.Direction = "incoming"
+			formattedTx.BalanceAfter = tx.ToBalanceAfter
 			if tx.FromWalletNumber != nil {
 				formattedTx.FromWalletNumber = *tx.FromWalletNumber
 			}
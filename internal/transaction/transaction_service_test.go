@@ -1,21 +1,25 @@
 package transaction_test
 
 import (
+	"centralized-wallet/internal/fx"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"centralized-wallet/internal/transaction"
 	"centralized-wallet/internal/utils"
+	mockRedis "centralized-wallet/tests/mocks/redis"
 	mockTransaction "centralized-wallet/tests/mocks/transaction"
 	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
 	testToWalletNumber   = "1234567890"
 	testFromWalletNumber = "0987654321"
-	testAmount           = 50.0
+	testAmount           = money.MustParse("50.00", money.DefaultCurrency)
 	testEmail            = "user1@example.com"
 	toTestEmail          = "user1@example.com"
 	now                  = time.Now()
@@ -41,12 +45,12 @@ func TestRecordTransactionService_Error(t *testing.T) {
 	// Test when both fromWalletNumber and toWalletNumber are nil or empty
 	var fromWalletNumber *string = nil
 	var toWalletNumber *string = nil
-	amount := 100.0
+	amount := money.MustParse("100.00", money.DefaultCurrency)
 	transactionType := "transfer"
 
 	mockTx := new(sql.Tx)
 	// Act: Call the RecordTransaction method
-	err := ts.RecordTransaction(mockTx, fromWalletNumber, toWalletNumber, transactionType, amount)
+	_, err := ts.RecordTransaction(mockTx, fromWalletNumber, toWalletNumber, transactionType, amount, models.TransactionNote{})
 
 	// Assert: Check the expected results
 	assert.Error(t, err)
@@ -67,7 +71,8 @@ func TestFormatTransactionResponse(t *testing.T) {
 				FromWalletNumber: nil,
 				ToWalletNumber:   &testFromWalletNumber,
 				TransactionType:  "deposit",
-				Amount:           100.0,
+				Amount:           money.MustParse("100.00", money.DefaultCurrency),
+				Status:           transaction.StatusCompleted,
 				CreatedAt:        now,
 			},
 			FromEmail: nil,
@@ -93,7 +98,7 @@ func TestFormatTransactionResponse(t *testing.T) {
 				FromWalletNumber: &testToWalletNumber,
 				ToWalletNumber:   &testFromWalletNumber,
 				TransactionType:  "transfer",
-				Amount:           150.0,
+				Amount:           money.MustParse("150.00", money.DefaultCurrency),
 				CreatedAt:        now,
 			},
 			FromEmail: &testEmail,
@@ -106,7 +111,7 @@ func TestFormatTransactionResponse(t *testing.T) {
 				FromWalletNumber: &testFromWalletNumber,
 				ToWalletNumber:   &testToWalletNumber,
 				TransactionType:  "transfer",
-				Amount:           200.0,
+				Amount:           money.MustParse("200.00", money.DefaultCurrency),
 				CreatedAt:        now,
 			},
 			FromEmail: &testEmail,
@@ -123,7 +128,8 @@ func TestFormatTransactionResponse(t *testing.T) {
 	// Check first transaction (deposit - incoming)
 	assert.Equal(t, "incoming", formattedTransactions[0].Direction)
 	assert.Equal(t, "deposit", formattedTransactions[0].TransactionType)
-	assert.Equal(t, 100.0, formattedTransactions[0].Amount)
+	assert.Equal(t, transaction.StatusCompleted, formattedTransactions[0].Status)
+	assert.Equal(t, money.MustParse("100.00", money.DefaultCurrency), formattedTransactions[0].Amount)
 	assert.Equal(t, "", formattedTransactions[0].FromWalletNumber) // FromWalletNumber should be empty
 	assert.Equal(t, "", formattedTransactions[0].ToEmail)
 
@@ -137,14 +143,443 @@ func TestFormatTransactionResponse(t *testing.T) {
 	// Check third transaction (transfer in - incoming)
 	assert.Equal(t, "incoming", formattedTransactions[2].Direction)
 	assert.Equal(t, "transfer", formattedTransactions[2].TransactionType)
-	assert.Equal(t, 150.0, formattedTransactions[2].Amount)
+	assert.Equal(t, money.MustParse("150.00", money.DefaultCurrency), formattedTransactions[2].Amount)
 	assert.Equal(t, testToWalletNumber, formattedTransactions[2].FromWalletNumber) // Should be set to sender's wallet number
 	assert.Equal(t, testEmail, formattedTransactions[2].FromEmail)                 // Should match sender's email
 
 	// Check fourth transaction (transfer out - outgoing)
 	assert.Equal(t, "outgoing", formattedTransactions[3].Direction)
 	assert.Equal(t, "transfer", formattedTransactions[3].TransactionType)
-	assert.Equal(t, 200.0, formattedTransactions[3].Amount)
+	assert.Equal(t, money.MustParse("200.00", money.DefaultCurrency), formattedTransactions[3].Amount)
 	assert.Equal(t, testToWalletNumber, formattedTransactions[3].ToWalletNumber) // Should be set to sender's wallet number
 	assert.Equal(t, testEmail, formattedTransactions[3].ToEmail)                 // Should match recipient's email
 }
+
+func TestUpdateStatusService(t *testing.T) {
+	testCases := []struct {
+		name          string
+		from          string
+		to            string
+		repoErr       error
+		callsRepo     bool
+		expectedError error
+	}{
+		{name: "Pending to completed", from: transaction.StatusPending, to: transaction.StatusCompleted, callsRepo: true},
+		{name: "Pending to failed", from: transaction.StatusPending, to: transaction.StatusFailed, callsRepo: true},
+		{name: "Completed to reversed", from: transaction.StatusCompleted, to: transaction.StatusReversed, callsRepo: true},
+		{name: "Failed is final", from: transaction.StatusFailed, to: transaction.StatusCompleted, expectedError: utils.ServiceErrInvalidStatusTransition},
+		{name: "Reversed is final", from: transaction.StatusReversed, to: transaction.StatusCompleted, expectedError: utils.ServiceErrInvalidStatusTransition},
+		{name: "Pending cannot be reversed", from: transaction.StatusPending, to: transaction.StatusReversed, expectedError: utils.ServiceErrInvalidStatusTransition},
+		{
+			name:          "Status changed concurrently",
+			from:          transaction.StatusPending,
+			to:            transaction.StatusCompleted,
+			repoErr:       utils.RepoErrTransactionStatusChanged,
+			callsRepo:     true,
+			expectedError: utils.ServiceErrInvalidStatusTransition,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupTransactionServiceMock()
+			ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+
+			txn := &models.Transaction{ID: 1, ToWalletNumber: &testToWalletNumber, Status: tc.from}
+			if tc.callsRepo {
+				mockTransactionTestHelper.repo.On("UpdateStatus", 1, tc.from, tc.to, mock.AnythingOfType("time.Time")).Return(tc.repoErr)
+			}
+
+			err := ts.UpdateStatus(new(sql.Tx), txn, tc.to)
+
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.to, txn.Status)
+			} else {
+				assert.Equal(t, tc.from, txn.Status)
+			}
+			if !tc.callsRepo {
+				mockTransactionTestHelper.repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+			mockTransactionTestHelper.repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateStatusStampsTransitionTime(t *testing.T) {
+	setupTransactionServiceMock()
+	ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+	mockTransactionTestHelper.repo.On("UpdateStatus", 1, transaction.StatusCompleted, transaction.StatusReversed, mock.AnythingOfType("time.Time")).Return(nil)
+
+	completedAt := now.Add(-time.Hour)
+	txn := &models.Transaction{ID: 1, FromWalletNumber: &testFromWalletNumber, Status: transaction.StatusCompleted, CompletedAt: &completedAt}
+
+	err := ts.UpdateStatus(new(sql.Tx), txn, transaction.StatusReversed)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, txn.ReversedAt)
+	assert.Equal(t, &completedAt, txn.CompletedAt)
+	assert.Nil(t, txn.FailedAt)
+}
+
+func TestUpdateStatusInvalidatesHistoryAndAnalyticsCaches(t *testing.T) {
+	setupTransactionServiceMock()
+	redisClient := new(mockRedis.MockRedisClient)
+	ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, redisClient)
+	mockTransactionTestHelper.repo.On("UpdateStatus", 1, transaction.StatusPending, transaction.StatusCompleted, mock.AnythingOfType("time.Time")).Return(nil)
+	for _, walletNumber := range []string{testFromWalletNumber, testToWalletNumber} {
+		redisClient.On("DeleteKeysByPattern", mock.Anything, "user:"+walletNumber+":transactions:page:*").Return(nil)
+		redisClient.On("DeleteKeysByPattern", mock.Anything, "user:"+walletNumber+":analytics:*").Return(nil)
+	}
+
+	txn := &models.Transaction{ID: 1, FromWalletNumber: &testFromWalletNumber, ToWalletNumber: &testToWalletNumber, Status: transaction.StatusPending}
+
+	err := ts.UpdateStatus(new(sql.Tx), txn, transaction.StatusCompleted)
+
+	assert.NoError(t, err)
+	redisClient.AssertExpectations(t)
+}
+
+func TestRecordReversalService(t *testing.T) {
+	setupTransactionServiceMock()
+	ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+
+	original := &models.Transaction{
+		ID:               4,
+		FromWalletNumber: &testFromWalletNumber,
+		ToWalletNumber:   &testToWalletNumber,
+		TransactionType:  "transfer",
+		Amount:           money.MustParse("200.00", money.DefaultCurrency),
+		Status:           transaction.StatusCompleted,
+	}
+	mockTransactionTestHelper.repo.On("CreateTransaction", mock.MatchedBy(func(txn *models.Transaction) bool {
+		return *txn.FromWalletNumber == testToWalletNumber &&
+			*txn.ToWalletNumber == testFromWalletNumber &&
+			txn.TransactionType == "reversal" &&
+			txn.Status == transaction.StatusPending &&
+			*txn.ReversesTransactionID == original.ID
+	})).Return(nil)
+
+	reversal, err := ts.RecordReversal(new(sql.Tx), original, testAmount)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testAmount, reversal.Amount)
+	mockTransactionTestHelper.repo.AssertExpectations(t)
+}
+
+func TestRecordReversalRefusesWithdrawals(t *testing.T) {
+	setupTransactionServiceMock()
+	ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+
+	original := &models.Transaction{ID: 2, FromWalletNumber: &testFromWalletNumber, TransactionType: "withdraw", Amount: testAmount}
+
+	reversal, err := ts.RecordReversal(new(sql.Tx), original, testAmount)
+
+	assert.Equal(t, utils.ServiceErrTransactionNotReversible, err)
+	assert.Nil(t, reversal)
+	mockTransactionTestHelper.repo.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+}
+
+func TestRecordExchangeStoresBothLegs(t *testing.T) {
+	setupTransactionServiceMock()
+	ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+
+	conversion := &fx.Conversion{
+		Source:      money.MustParse("100.00", "USD"),
+		Target:      money.MustParse("91.54", "EUR"),
+		Spread:      money.MustParse("0.50", "USD"),
+		MidRate:     fx.MustParseRate("0.92"),
+		AppliedRate: fx.MustParseRate("0.9154"),
+	}
+	mockTransactionTestHelper.repo.On("CreateTransaction", mock.AnythingOfType("*models.Transaction")).Return(nil)
+
+	exchange, err := ts.RecordExchange(new(sql.Tx), testFromWalletNumber, testToWalletNumber, conversion, models.TransactionNote{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "exchange", exchange.TransactionType)
+	assert.Equal(t, transaction.StatusPending, exchange.Status)
+	assert.Equal(t, conversion.Source, exchange.Amount)
+	assert.Equal(t, "USD", exchange.Currency)
+	assert.Equal(t, conversion.Target, *exchange.ToAmount)
+	assert.Equal(t, "EUR", *exchange.ToCurrency)
+	assert.Equal(t, conversion.Spread, *exchange.FXSpread)
+	assert.Equal(t, "0.9154", exchange.FXRate.String())
+	mockTransactionTestHelper.repo.AssertExpectations(t)
+}
+
+// historyRows builds history rows, one minute apart, for a wallet receiving deposits
+func historyRows(ids ...int) []models.TransactionWithEmails {
+	rows := []models.TransactionWithEmails{}
+	for _, id := range ids {
+		rows = append(rows, models.TransactionWithEmails{Transaction: models.Transaction{
+			ID:              id,
+			ToWalletNumber:  &testToWalletNumber,
+			TransactionType: transaction.TypeDeposit,
+			Amount:          testAmount,
+			Currency:        testAmount.Currency,
+			Status:          transaction.StatusCompleted,
+			CreatedAt:       now.Add(time.Duration(id) * time.Minute),
+		}})
+	}
+	return rows
+}
+
+func pageIDs(page *models.TransactionPage) []int {
+	ids := []int{}
+	for _, tx := range page.Transactions {
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
+
+func TestGetTransactionHistoryPageService(t *testing.T) {
+	filter := models.TransactionFilter{}
+
+	t.Run("first page links only to the next page", func(t *testing.T) {
+		setupTransactionServiceMock()
+		ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+		mockTransactionTestHelper.repo.On("GetTransactionHistoryByCursor", testToWalletNumber, filter, "desc", (*transaction.Cursor)(nil), 3).
+			Return(historyRows(5, 4, 3), nil)
+
+		page, err := ts.GetTransactionHistoryPage(testToWalletNumber, filter, "desc", 2, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{5, 4}, pageIDs(page))
+		assert.Empty(t, page.PrevCursor)
+
+		next, err := transaction.DecodeCursor(page.NextCursor, "desc")
+		assert.NoError(t, err)
+		assert.Equal(t, 4, next.ID)
+		assert.False(t, next.Before)
+	})
+
+	t.Run("last page links only to the previous page", func(t *testing.T) {
+		setupTransactionServiceMock()
+		ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+		cursor := transaction.Cursor{CreatedAt: now.Add(4 * time.Minute), ID: 4, Order: "desc"}
+		mockTransactionTestHelper.repo.On("GetTransactionHistoryByCursor", testToWalletNumber, filter, "desc", mock.MatchedBy(func(c *transaction.Cursor) bool {
+			return c.ID == 4 && !c.Before
+		}), 3).Return(historyRows(3, 2), nil)
+
+		page, err := ts.GetTransactionHistoryPage(testToWalletNumber, filter, "desc", 2, transaction.EncodeCursor(cursor))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{3, 2}, pageIDs(page))
+		assert.Empty(t, page.NextCursor)
+
+		prev, err := transaction.DecodeCursor(page.PrevCursor, "desc")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, prev.ID)
+		assert.True(t, prev.Before)
+	})
+
+	t.Run("previous page is returned in the requested order", func(t *testing.T) {
+		setupTransactionServiceMock()
+		ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+		cursor := transaction.Cursor{CreatedAt: now.Add(3 * time.Minute), ID: 3, Order: "desc", Before: true}
+		// The repository reads backwards from the cursor, nearest row first
+		mockTransactionTestHelper.repo.On("GetTransactionHistoryByCursor", testToWalletNumber, filter, "desc", mock.AnythingOfType("*transaction.Cursor"), 3).
+			Return(historyRows(4, 5), nil)
+
+		page, err := ts.GetTransactionHistoryPage(testToWalletNumber, filter, "desc", 2, transaction.EncodeCursor(cursor))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{5, 4}, pageIDs(page))
+		assert.Empty(t, page.PrevCursor)
+		assert.NotEmpty(t, page.NextCursor)
+	})
+
+	t.Run("cursors are rejected when malformed or issued for the other order", func(t *testing.T) {
+		setupTransactionServiceMock()
+		ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+		ascending := transaction.EncodeCursor(transaction.Cursor{CreatedAt: now, ID: 4, Order: "asc"})
+
+		for _, cursor := range []string{"not-a-cursor", ascending} {
+			page, err := ts.GetTransactionHistoryPage(testToWalletNumber, filter, "desc", 2, cursor)
+
+			assert.ErrorIs(t, err, utils.ServiceErrInvalidCursor)
+			assert.Nil(t, page)
+		}
+		mockTransactionTestHelper.repo.AssertNotCalled(t, "GetTransactionHistoryByCursor", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetTransactionDetailService(t *testing.T) {
+	senderID, receiverID := 1, 2
+	fromEmail, toEmail := "alice@example.com", "bob@example.com"
+	fromBalance, toBalance := money.MustParse("50.00", "USD"), money.MustParse("150.00", "USD")
+	transfer := func() *models.TransactionWithEmails {
+		return &models.TransactionWithEmails{
+			Transaction: models.Transaction{
+				ID:               7,
+				FromWalletNumber: &testFromWalletNumber,
+				ToWalletNumber:   &testToWalletNumber,
+				TransactionType:  transaction.TypeTransfer,
+				Amount:           testAmount,
+				Currency:         testAmount.Currency,
+				Status:           transaction.StatusCompleted,
+				FromBalanceAfter: &fromBalance,
+				ToBalanceAfter:   &toBalance,
+				CreatedAt:        now,
+				CompletedAt:      &now,
+			},
+			FromEmail:  &fromEmail,
+			ToEmail:    &toEmail,
+			FromUserID: &senderID,
+			ToUserID:   &receiverID,
+		}
+	}
+
+	t.Run("receiver sees the transfer as incoming with their balance", func(t *testing.T) {
+		setupTransactionServiceMock()
+		ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+		mockTransactionTestHelper.repo.On("GetTransactionWithParties", 7).Return(transfer(), nil)
+
+		detail, err := ts.GetTransactionDetail(receiverID, 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, transaction.DirectionIncoming, detail.Direction)
+		assert.Equal(t, testToWalletNumber, detail.WalletNumber)
+		assert.Equal(t, "a***@example.com", detail.FromEmail)
+		assert.Equal(t, "b***@example.com", detail.ToEmail)
+		assert.Equal(t, toBalance, *detail.BalanceAfter)
+		assert.Equal(t, &now, detail.CompletedAt)
+	})
+
+	t.Run("sender sees the transfer as outgoing", func(t *testing.T) {
+		setupTransactionServiceMock()
+		ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+		mockTransactionTestHelper.repo.On("GetTransactionWithParties", 7).Return(transfer(), nil)
+
+		detail, err := ts.GetTransactionDetail(senderID, 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, transaction.DirectionOutgoing, detail.Direction)
+		assert.Equal(t, testFromWalletNumber, detail.WalletNumber)
+		assert.Equal(t, fromBalance, *detail.BalanceAfter)
+	})
+
+	t.Run("other users cannot read it", func(t *testing.T) {
+		setupTransactionServiceMock()
+		ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+		mockTransactionTestHelper.repo.On("GetTransactionWithParties", 7).Return(transfer(), nil)
+
+		detail, err := ts.GetTransactionDetail(3, 7)
+
+		assert.ErrorIs(t, err, utils.RepoErrTransactionNotFound)
+		assert.Nil(t, detail)
+	})
+
+	t.Run("a failed transfer is only shown to the sender", func(t *testing.T) {
+		setupTransactionServiceMock()
+		ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+		failed := transfer()
+		failed.Status = transaction.StatusFailed
+		failed.CompletedAt, failed.FailedAt = nil, &now
+		failed.FromBalanceAfter, failed.ToBalanceAfter = nil, nil
+		mockTransactionTestHelper.repo.On("GetTransactionWithParties", 7).Return(failed, nil)
+
+		detail, err := ts.GetTransactionDetail(senderID, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, transaction.StatusFailed, detail.Status)
+		assert.Nil(t, detail.BalanceAfter)
+
+		detail, err = ts.GetTransactionDetail(receiverID, 7)
+		assert.ErrorIs(t, err, utils.RepoErrTransactionNotFound)
+		assert.Nil(t, detail)
+	})
+}
+
+func TestMaskEmail(t *testing.T) {
+	assert.Equal(t, "a***@example.com", transaction.MaskEmail("alice@example.com"))
+	assert.Equal(t, "b***@example.com", transaction.MaskEmail("b@example.com"))
+	assert.Equal(t, "***", transaction.MaskEmail("not-an-email"))
+}
+
+func TestFormatTransactionResponsePicksTheWalletsBalance(t *testing.T) {
+	ts := transaction.NewTransactionService(nil, nil)
+	senderBalance, recipientBalance := money.MustParse("40.00", "USD"), money.MustParse("160.00", "USD")
+	transfer := models.TransactionWithEmails{Transaction: models.Transaction{
+		ID:               5,
+		FromWalletNumber: &testFromWalletNumber,
+		ToWalletNumber:   &testToWalletNumber,
+		TransactionType:  "transfer",
+		Amount:           testAmount,
+		Currency:         testAmount.Currency,
+		FromBalanceAfter: &senderBalance,
+		ToBalanceAfter:   &recipientBalance,
+	}}
+
+	sent := ts.FormatTransactionResponse(testFromWalletNumber, []models.TransactionWithEmails{transfer})
+	received := ts.FormatTransactionResponse(testToWalletNumber, []models.TransactionWithEmails{transfer})
+
+	assert.Equal(t, senderBalance, *sent[0].BalanceAfter)
+	assert.Equal(t, recipientBalance, *received[0].BalanceAfter)
+}
+
+func TestRecordTransactionStoresTheNote(t *testing.T) {
+	setupTransactionServiceMock()
+	ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+	note := models.TransactionNote{
+		Memo:              "Rent for May",
+		ExternalReference: "inv-2024-05",
+		Metadata:          models.Metadata{"order_id": "A-17"},
+	}
+	mockTransactionTestHelper.repo.On("CreateTransaction", mock.MatchedBy(func(tx *models.Transaction) bool {
+		return *tx.Memo == note.Memo && *tx.ExternalReference == note.ExternalReference && tx.Metadata["order_id"] == "A-17"
+	})).Return(nil)
+
+	recorded, err := ts.RecordTransaction(new(sql.Tx), &testFromWalletNumber, &testToWalletNumber, transaction.TypeTransfer, testAmount, note)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Rent for May", *recorded.Memo)
+	mockTransactionTestHelper.repo.AssertExpectations(t)
+}
+
+func TestRecordTransactionMarksTheIdempotencyKey(t *testing.T) {
+	claim := &models.IdempotencyClaim{UserID: 1, Key: "retry-key-1", LockedUntil: now}
+
+	testCases := []struct {
+		name          string
+		markErr       error
+		expectedError error
+	}{
+		{name: "Key held by the request is marked"},
+		{name: "Key reclaimed by another request", markErr: utils.RepoErrIdempotencyClaimLost, expectedError: utils.ServiceErrIdempotencyRequestInFlight},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupTransactionServiceMock()
+			ts := transaction.NewTransactionService(mockTransactionTestHelper.repo, nil)
+			mockTransactionTestHelper.repo.On("CreateTransaction", mock.AnythingOfType("*models.Transaction")).Return(nil)
+			mockTransactionTestHelper.repo.On("MarkIdempotencyKeyCommitted", claim).Return(tc.markErr)
+
+			_, err := ts.RecordTransaction(new(sql.Tx), &testFromWalletNumber, &testToWalletNumber, transaction.TypeTransfer, testAmount, models.TransactionNote{Idempotency: claim})
+
+			assert.Equal(t, tc.expectedError, err)
+			mockTransactionTestHelper.repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFormatTransactionResponseIncludesTheNote(t *testing.T) {
+	ts := transaction.NewTransactionService(nil, nil)
+	memo, reference := "Rent for May", "inv-2024-05"
+	tx := models.TransactionWithEmails{Transaction: models.Transaction{
+		ID:                9,
+		ToWalletNumber:    &testToWalletNumber,
+		TransactionType:   transaction.TypeDeposit,
+		Amount:            testAmount,
+		Currency:          testAmount.Currency,
+		Memo:              &memo,
+		ExternalReference: &reference,
+		Metadata:          models.Metadata{"order_id": "A-17"},
+	}}
+
+	formatted := ts.FormatTransactionResponse(testToWalletNumber, []models.TransactionWithEmails{tx})
+
+	assert.Equal(t, memo, formatted[0].Memo)
+	assert.Equal(t, reference, formatted[0].ExternalReference)
+	assert.Equal(t, models.Metadata{"order_id": "A-17"}, formatted[0].Metadata)
+}
@@ -0,0 +1,33 @@
+package transaction
+
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusReversed  = "reversed"
+)
+
+// allowedTransitions lists the statuses each status may move to. Failed and reversed are final.
+var allowedTransitions = map[string][]string{
+	StatusPending:   {StatusCompleted, StatusFailed},
+	StatusCompleted: {StatusReversed},
+}
+
+// IsValidStatus reports whether status is one of the known transaction statuses
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusCompleted, StatusFailed, StatusReversed:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether a transaction may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range allowedTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
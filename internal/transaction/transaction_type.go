@@ -0,0 +1,26 @@
+package transaction
+
+const (
+	TypeDeposit  = "deposit"
+	TypeWithdraw = "withdraw"
+	TypeTransfer = "transfer"
+	TypeReversal = "reversal"
+	TypeExchange = "exchange"
+
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+// IsValidType reports whether transactionType is one of the known transaction types
+func IsValidType(transactionType string) bool {
+	switch transactionType {
+	case TypeDeposit, TypeWithdraw, TypeTransfer, TypeReversal, TypeExchange:
+		return true
+	}
+	return false
+}
+
+// IsValidDirection reports whether direction is incoming or outgoing
+func IsValidDirection(direction string) bool {
+	return direction == DirectionIncoming || direction == DirectionOutgoing
+}
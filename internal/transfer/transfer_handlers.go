@@ -0,0 +1,126 @@
+package transfer
+
+import (
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewTransferHandler prices a transfer from one of the authenticated user's wallets without moving money and
+// returns an intent to confirm. The recipient is to_wallet_number or to, an email or $handle, as for a direct transfer.
+func PreviewTransferHandler(ts TransferServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		var request struct {
+			FromWalletNumber string      `json:"from_wallet_number"`
+			ToWalletNumber   string      `json:"to_wallet_number"`
+			To               string      `json:"to"` // Email or $handle
+			Amount           json.Number `json:"amount" binding:"required"`
+			Currency         string      `json:"currency"`
+			wallet.NoteRequest
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+
+		amount, ok := wallet.ParseAmount(c, request.Amount, request.Currency)
+		if !ok {
+			return
+		}
+		note, ok := wallet.ParseNote(c, request.NoteRequest)
+		if !ok {
+			return
+		}
+		if (request.To == "") == (request.ToWalletNumber == "") {
+			utils.ErrorResponse(c, utils.ErrRecipientRequired, nil, "")
+			return
+		}
+
+		intent, err := ts.PreviewTransfer(userID.(int), request.FromWalletNumber, request.ToWalletNumber, request.To, amount, note)
+		if err != nil {
+			switch err {
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			case utils.ServiceErrInvalidRecipient:
+				utils.ErrorResponse(c, utils.ErrInvalidRecipient, nil, "")
+			case utils.ServiceErrRecipientNotFound:
+				utils.ErrorResponse(c, utils.ErrRecipientNotFound, nil, "")
+			case utils.ServiceErrRecipientLookupLimited:
+				utils.ErrorResponse(c, utils.ErrTooManyRecipientLookups, nil, "")
+			case utils.ServiceErrTransferToSameWallet:
+				utils.ErrorResponse(c, utils.ErrTransferToSameWallet, nil, "")
+			case utils.RepoErrInsufficientFunds:
+				utils.ErrorResponse(c, utils.ErrorInsufficientFunds, nil, "")
+			case utils.ServiceErrCurrencyMismatch:
+				utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+			case utils.ServiceErrNoConversionPath:
+				utils.ErrorResponse(c, utils.ErrNoConversionPath, nil, "")
+			case utils.ServiceErrAmountTooSmallToConvert:
+				utils.ErrorResponse(c, utils.ErrAmountTooSmallToConvert, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[PreviewTransferHandler] Error previewing transfer")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgTransferPreviewed, gin.H{"intent": intent})
+	}
+}
+
+// ConfirmTransferHandler executes a previewed transfer, as long as its intent has not expired
+func ConfirmTransferHandler(ts TransferServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		intentID, err := strconv.Atoi(c.Param("intent_id"))
+		if err != nil || intentID <= 0 {
+			utils.ErrorResponse(c, utils.ErrInvalidTransferIntentID, nil, "")
+			return
+		}
+
+		intent, updatedWallet, err := ts.ConfirmTransfer(userID.(int), intentID)
+		if err != nil {
+			switch err {
+			case utils.RepoErrTransferIntentNotFound:
+				utils.ErrorResponse(c, utils.ErrTransferIntentNotFound, nil, "")
+			case utils.ServiceErrTransferIntentConfirmed:
+				utils.ErrorResponse(c, utils.ErrTransferIntentConfirmed, nil, "")
+			case utils.ServiceErrTransferIntentExpired:
+				utils.ErrorResponse(c, utils.ErrTransferIntentExpired, nil, "")
+			case utils.ServiceErrTransferIntentPriceChanged:
+				utils.ErrorResponse(c, utils.ErrTransferIntentPriceChanged, nil, "")
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			case utils.RepoErrInsufficientFunds:
+				utils.ErrorResponse(c, utils.ErrorInsufficientFunds, nil, "")
+			case utils.ServiceErrNoConversionPath:
+				utils.ErrorResponse(c, utils.ErrNoConversionPath, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[ConfirmTransferHandler] Error confirming transfer")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgTransferConfirmed, gin.H{
+			"intent":            intent,
+			"balance":           updatedWallet.Balance,
+			"available_balance": updatedWallet.AvailableBalance(),
+			"updated_at":        updatedWallet.UpdatedAt,
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package transfer
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	mockTransfer "centralized-wallet/tests/mocks/transfer"
+	"centralized-wallet/tests/testutils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupTransferHandlerRouter(transferService *mockTransfer.MockTransferService) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	token, _ := auth.GenerateJWT(testUserID)
+	blacklistService := new(mockAuth.MockBlacklistService)
+	blacklistService.On("IsTokenBlacklisted", token).Return(false, nil)
+	accountChecker := new(mockAuth.MockAccountChecker)
+	accountChecker.On("CurrentTokenVersion", testUserID).Return(1, nil)
+
+	walletRoutes := router.Group("/wallets")
+	walletRoutes.Use(auth.JWTMiddleware(blacklistService, new(mockAuth.MockSessionService), accountChecker))
+	{
+		walletRoutes.POST("/transfers/preview", PreviewTransferHandler(transferService))
+		walletRoutes.POST("/transfers/:intent_id/confirm", ConfirmTransferHandler(transferService))
+	}
+	return router, token
+}
+
+func TestPreviewTransferHandler(t *testing.T) {
+	intent := openIntent()
+
+	testCases := []testutils.BaseHandlerTestCase{
+		{
+			Name:     "Preview a transfer to a wallet number",
+			TestType: "success",
+			Body:     map[string]interface{}{"to_wallet_number": testToWalletNumber, "amount": 50.0, "memo": "Dinner"},
+			MockSetup: func() {
+				mockTransferService.On("PreviewTransfer", testUserID, "", testToWalletNumber, "", usd("50.00"), models.TransactionNote{Memo: "Dinner"}).Return(intent, nil)
+			},
+			ExpectedMessage: utils.MsgTransferPreviewed,
+			ExpectedEntity:  gin.H{"intent": intent},
+		},
+		{
+			Name:     "Preview a transfer to a handle",
+			TestType: "success",
+			Body:     map[string]interface{}{"from_wallet_number": testFromWalletNumber, "to": "$david", "amount": 50.0},
+			MockSetup: func() {
+				mockTransferService.On("PreviewTransfer", testUserID, testFromWalletNumber, "", "$david", usd("50.00"), models.TransactionNote{}).Return(intent, nil)
+			},
+			ExpectedMessage: utils.MsgTransferPreviewed,
+			ExpectedEntity:  gin.H{"intent": intent},
+		},
+		{
+			Name:                  "Missing recipient",
+			TestType:              "error",
+			Body:                  map[string]interface{}{"amount": 50.0},
+			MockSetup:             func() {},
+			ExpectedResponseError: utils.ErrRecipientRequired,
+		},
+		{
+			Name:                  "Both recipient fields",
+			TestType:              "error",
+			Body:                  map[string]interface{}{"to": "$david", "to_wallet_number": testToWalletNumber, "amount": 50.0},
+			MockSetup:             func() {},
+			ExpectedResponseError: utils.ErrRecipientRequired,
+		},
+		{
+			Name:                  "Invalid amount",
+			TestType:              "error",
+			Body:                  map[string]interface{}{"to_wallet_number": testToWalletNumber, "amount": -5.0},
+			MockSetup:             func() {},
+			ExpectedResponseError: utils.ErrInvalidRequest,
+		},
+		{
+			Name:     "Recipient not found",
+			TestType: "error",
+			Body:     map[string]interface{}{"to": "nobody@example.com", "amount": 50.0},
+			MockSetup: func() {
+				mockTransferService.On("PreviewTransfer", testUserID, "", "", "nobody@example.com", usd("50.00"), models.TransactionNote{}).Return(nil, utils.ServiceErrRecipientNotFound)
+			},
+			ExpectedResponseError: utils.ErrRecipientNotFound,
+		},
+		{
+			Name:     "Insufficient funds",
+			TestType: "error",
+			Body:     map[string]interface{}{"to_wallet_number": testToWalletNumber, "amount": 500.0},
+			MockSetup: func() {
+				mockTransferService.On("PreviewTransfer", testUserID, "", testToWalletNumber, "", usd("500.00"), models.TransactionNote{}).Return(nil, utils.RepoErrInsufficientFunds)
+			},
+			ExpectedResponseError: utils.ErrorInsufficientFunds,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			transferHandlerTestFlow(t, tc, http.MethodPost, "/wallets/transfers/preview")
+		})
+	}
+}
+
+func TestConfirmTransferHandler(t *testing.T) {
+	confirmed := openIntent()
+	confirmed.Status = StatusConfirmed
+	confirmed.ConfirmedAt = &testNow
+	wallet := &models.Wallet{Currency: "USD", Balance: usd("50.00"), HeldBalance: usd("30.00"), UpdatedAt: testNow}
+
+	testCases := []testutils.BaseHandlerTestCase{
+		{
+			Name:     "Confirm an open intent",
+			TestType: "success",
+			URL:      "/wallets/transfers/5/confirm",
+			MockSetup: func() {
+				mockTransferService.On("ConfirmTransfer", testUserID, 5).Return(confirmed, wallet, nil)
+			},
+			ExpectedMessage: utils.MsgTransferConfirmed,
+			ExpectedEntity: gin.H{
+				"intent":            confirmed,
+				"balance":           50.0,
+				"available_balance": 20.0,
+				"updated_at":        testNow.Format(time.RFC3339Nano),
+			},
+		},
+		{
+			Name:                  "Invalid intent ID",
+			TestType:              "error",
+			URL:                   "/wallets/transfers/abc/confirm",
+			MockSetup:             func() {},
+			ExpectedResponseError: utils.ErrInvalidTransferIntentID,
+		},
+		{
+			Name:     "Intent not found",
+			TestType: "error",
+			URL:      "/wallets/transfers/5/confirm",
+			MockSetup: func() {
+				mockTransferService.On("ConfirmTransfer", testUserID, 5).Return(nil, nil, utils.RepoErrTransferIntentNotFound)
+			},
+			ExpectedResponseError: utils.ErrTransferIntentNotFound,
+		},
+		{
+			Name:     "Intent expired",
+			TestType: "error",
+			URL:      "/wallets/transfers/5/confirm",
+			MockSetup: func() {
+				mockTransferService.On("ConfirmTransfer", testUserID, 5).Return(nil, nil, utils.ServiceErrTransferIntentExpired)
+			},
+			ExpectedResponseError: utils.ErrTransferIntentExpired,
+		},
+		{
+			Name:     "Intent already confirmed",
+			TestType: "error",
+			URL:      "/wallets/transfers/5/confirm",
+			MockSetup: func() {
+				mockTransferService.On("ConfirmTransfer", testUserID, 5).Return(nil, nil, utils.ServiceErrTransferIntentConfirmed)
+			},
+			ExpectedResponseError: utils.ErrTransferIntentConfirmed,
+		},
+		{
+			Name:     "Rate changed since the preview",
+			TestType: "error",
+			URL:      "/wallets/transfers/5/confirm",
+			MockSetup: func() {
+				mockTransferService.On("ConfirmTransfer", testUserID, 5).Return(nil, nil, utils.ServiceErrTransferIntentPriceChanged)
+			},
+			ExpectedResponseError: utils.ErrTransferIntentPriceChanged,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			transferHandlerTestFlow(t, tc, http.MethodPost, tc.URL)
+		})
+	}
+}
+
+var mockTransferService *mockTransfer.MockTransferService
+
+func transferHandlerTestFlow(t *testing.T, tc testutils.BaseHandlerTestCase, method, url string) {
+	mockTransferService = new(mockTransfer.MockTransferService)
+	router, token := setupTransferHandlerRouter(mockTransferService)
+	tc.MockSetup()
+
+	var body interface{}
+	if tc.Body != nil {
+		body = tc.Body
+	}
+	w := testutils.ExecuteRequest(router, method, url, body, token)
+
+	if tc.TestType == "success" {
+		testutils.AssertAPISuccessResponse(t, w, tc.ExpectedMessage, tc.ExpectedEntity)
+	} else {
+		testutils.AssertAPIErrorResponse(t, w, tc.ExpectedResponseError)
+	}
+	mockTransferService.AssertExpectations(t)
+}
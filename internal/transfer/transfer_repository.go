@@ -0,0 +1,152 @@
+package transfer
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"database/sql"
+)
+
+// TransferRepositoryInterface defines the methods for persisting transfer intents
+type TransferRepositoryInterface interface {
+	Begin() (*sql.Tx, error)
+	Commit(tx *sql.Tx) error
+	Rollback(tx *sql.Tx) error
+	CreateIntent(intent *models.TransferIntent) error
+	GetIntentByID(intentID int) (*models.TransferIntent, error)
+	LockIntentByID(tx *sql.Tx, intentID int) (*models.TransferIntent, error)
+	UpdateIntent(tx *sql.Tx, intent *models.TransferIntent) error
+}
+
+type TransferRepository struct {
+	db *sql.DB
+}
+
+// Ensure TransferRepository implements TransferRepositoryInterface
+var _ TransferRepositoryInterface = &TransferRepository{}
+
+// NewTransferRepository creates a new instance of TransferRepository
+func NewTransferRepository(db *sql.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// Begin a transaction
+func (repo *TransferRepository) Begin() (*sql.Tx, error) {
+	return repo.db.Begin()
+}
+
+// commit tx
+func (repo *TransferRepository) Commit(tx *sql.Tx) error {
+	return tx.Commit()
+}
+
+// rollback tx
+func (repo *TransferRepository) Rollback(tx *sql.Tx) error {
+	return tx.Rollback()
+}
+
+// CreateIntent inserts a new transfer intent and sets its generated ID
+func (repo *TransferRepository) CreateIntent(intent *models.TransferIntent) error {
+	query := `INSERT INTO transfer_intents (user_id, from_wallet_number, to_wallet_number, recipient_handle, recipient_name,
+			  recipient_email, amount, currency, fee, credited_amount, credited_currency, balance_after, memo,
+			  external_reference, metadata, status, expires_at, created_at)
+			  VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+			  RETURNING id`
+
+	return repo.db.QueryRow(
+		query,
+		intent.UserID,
+		intent.FromWalletNumber,
+		intent.ToWalletNumber,
+		intent.Recipient.Handle,
+		intent.Recipient.Name,
+		intent.Recipient.Email,
+		intent.Amount,
+		intent.Currency,
+		intent.Fee,
+		intent.CreditedAmount,
+		intent.Recipient.Currency,
+		intent.BalanceAfter,
+		intent.Memo,
+		intent.ExternalReference,
+		intent.Metadata,
+		intent.Status,
+		intent.ExpiresAt,
+		intent.CreatedAt,
+	).Scan(&intent.ID)
+}
+
+// GetIntentByID fetches a transfer intent
+func (repo *TransferRepository) GetIntentByID(intentID int) (*models.TransferIntent, error) {
+	return scanIntent(repo.db.QueryRow(selectIntentQuery+` WHERE id = $1`, intentID))
+}
+
+// LockIntentByID fetches a transfer intent and locks its row with SELECT ... FOR UPDATE until tx ends
+func (repo *TransferRepository) LockIntentByID(tx *sql.Tx, intentID int) (*models.TransferIntent, error) {
+	return scanIntent(tx.QueryRow(selectIntentQuery+` WHERE id = $1 FOR UPDATE`, intentID))
+}
+
+// UpdateIntent saves the outcome of confirming an intent
+func (repo *TransferRepository) UpdateIntent(tx *sql.Tx, intent *models.TransferIntent) error {
+	query := `UPDATE transfer_intents SET status = $1, transaction_id = $2, confirmed_at = $3
+			  WHERE id = $4`
+
+	result, err := tx.Exec(query, intent.Status, intent.TransactionID, intent.ConfirmedAt, intent.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.RepoErrTransferIntentNotFound
+	}
+	return nil
+}
+
+const selectIntentQuery = `SELECT id, user_id, from_wallet_number, to_wallet_number, COALESCE(recipient_handle, ''),
+					 COALESCE(recipient_name, ''), recipient_email, amount, currency, fee, credited_amount,
+					 credited_currency, balance_after, memo, external_reference, metadata, status, transaction_id,
+					 expires_at, created_at, confirmed_at
+			  FROM transfer_intents`
+
+func scanIntent(row *sql.Row) (*models.TransferIntent, error) {
+	var intent models.TransferIntent
+	err := row.Scan(
+		&intent.ID,
+		&intent.UserID,
+		&intent.FromWalletNumber,
+		&intent.ToWalletNumber,
+		&intent.Recipient.Handle,
+		&intent.Recipient.Name,
+		&intent.Recipient.Email,
+		&intent.Amount,
+		&intent.Currency,
+		&intent.Fee,
+		&intent.CreditedAmount,
+		&intent.Recipient.Currency,
+		&intent.BalanceAfter,
+		&intent.Memo,
+		&intent.ExternalReference,
+		&intent.Metadata,
+		&intent.Status,
+		&intent.TransactionID,
+		&intent.ExpiresAt,
+		&intent.CreatedAt,
+		&intent.ConfirmedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.RepoErrTransferIntentNotFound
+		}
+		return nil, err
+	}
+	intent.Recipient.WalletNumber = intent.ToWalletNumber
+	intent.Amount = money.New(intent.Amount.Amount, intent.Currency)
+	intent.Fee = money.New(intent.Fee.Amount, intent.Currency)
+	intent.BalanceAfter = money.New(intent.BalanceAfter.Amount, intent.Currency)
+	intent.CreditedAmount = money.New(intent.CreditedAmount.Amount, intent.Recipient.Currency)
+	return &intent, nil
+}
@@ -0,0 +1,211 @@
+package transfer
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"database/sql"
+	"time"
+)
+
+const (
+	StatusOpen      = "open"
+	StatusConfirmed = "confirmed"
+
+	// IntentTTL is how long a previewed transfer can be confirmed for
+	IntentTTL = 5 * time.Minute
+)
+
+// TransferServiceInterface previews transfers and executes them once the payer confirms.
+// A preview moves no money; confirming it within IntentTTL transfers exactly the previewed amount.
+type TransferServiceInterface interface {
+	PreviewTransfer(userID int, fromWalletNumber, toWalletNumber, to string, amount money.Money, note models.TransactionNote) (*models.TransferIntent, error)
+	ConfirmTransfer(userID, intentID int) (*models.TransferIntent, *models.Wallet, error)
+}
+
+type TransferService struct {
+	transferRepo     TransferRepositoryInterface
+	walletRepo       wallet.WalletRepositoryInterface
+	walletService    wallet.WalletServiceInterface
+	recipientService wallet.RecipientServiceInterface
+	converter        fx.ConverterInterface
+	now              func() time.Time
+}
+
+// Ensure TransferService implements TransferServiceInterface
+var _ TransferServiceInterface = &TransferService{}
+
+// NewTransferService creates a TransferService. Confirmed intents are executed by walletService.TransferTx,
+// so they follow the same checks and postings as direct transfers.
+func NewTransferService(transferRepo TransferRepositoryInterface, walletRepo wallet.WalletRepositoryInterface, walletService wallet.WalletServiceInterface, recipientService wallet.RecipientServiceInterface, converter fx.ConverterInterface) *TransferService {
+	return &TransferService{
+		transferRepo:     transferRepo,
+		walletRepo:       walletRepo,
+		walletService:    walletService,
+		recipientService: recipientService,
+		converter:        converter,
+		now:              time.Now,
+	}
+}
+
+// PreviewTransfer resolves the recipient, prices the transfer and stores it as an open intent. The recipient is
+// toWalletNumber or, when to is given, the email or $handle in to. An empty fromWalletNumber uses the default wallet.
+// Transfers the user could not make right now, e.g. for lack of funds, are refused at preview already.
+func (s *TransferService) PreviewTransfer(userID int, fromWalletNumber, toWalletNumber, to string, amount money.Money, note models.TransactionNote) (*models.TransferIntent, error) {
+	fromWallet, err := wallet.FindOwnedWallet(s.walletRepo, userID, fromWalletNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipient *models.Recipient
+	if to != "" {
+		recipient, err = s.recipientService.ResolveRecipient(userID, to)
+	} else {
+		recipient, err = s.recipientService.ResolveRecipientWallet(userID, toWalletNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if recipient.WalletNumber == fromWallet.WalletNumber {
+		return nil, utils.ServiceErrTransferToSameWallet
+	}
+	if err := wallet.EnsureWalletCurrency(fromWallet, amount); err != nil {
+		return nil, err
+	}
+
+	// Same-currency transfers are free; across currencies the fee is the spread kept on the conversion
+	fee, credited := money.Zero(fromWallet.Currency), amount
+	if recipient.Currency != fromWallet.Currency {
+		conversion, err := wallet.PriceConversion(s.converter, amount, recipient.Currency)
+		if err != nil {
+			return nil, err
+		}
+		fee, credited = conversion.Spread, conversion.Target
+	}
+
+	balanceAfter, err := fromWallet.AvailableBalance().Sub(amount)
+	if err != nil {
+		return nil, err
+	}
+	if balanceAfter.IsNegative() {
+		return nil, utils.RepoErrInsufficientFunds
+	}
+
+	now := s.now()
+	intent := &models.TransferIntent{
+		UserID:           userID,
+		FromWalletNumber: fromWallet.WalletNumber,
+		ToWalletNumber:   recipient.WalletNumber,
+		Recipient:        *recipient,
+		Amount:           amount,
+		Currency:         amount.Currency,
+		Fee:              fee,
+		CreditedAmount:   credited,
+		BalanceAfter:     balanceAfter,
+		Metadata:         note.Metadata,
+		Status:           StatusOpen,
+		ExpiresAt:        now.Add(IntentTTL),
+		CreatedAt:        now,
+	}
+	if note.Memo != "" {
+		intent.Memo = &note.Memo
+	}
+	if note.ExternalReference != "" {
+		intent.ExternalReference = &note.ExternalReference
+	}
+	if err := s.transferRepo.CreateIntent(intent); err != nil {
+		return nil, err
+	}
+
+	return intent, nil
+}
+
+// ConfirmTransfer executes an open intent of the user through WalletService.TransferTx and returns the confirmed
+// intent and the user's updated source wallet. The intent is locked and marked confirmed in the DB transaction
+// that posts the transfer, so it runs at most once and is never confirmed without its transfer. If the transfer
+// fails the intent stays open and can be confirmed again until it expires.
+func (s *TransferService) ConfirmTransfer(userID, intentID int) (*models.TransferIntent, *models.Wallet, error) {
+	tx, err := s.transferRepo.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer s.rollBackTxWhenErr(tx, &err)
+
+	// Lock the intent first so concurrent confirmations of the same intent run one after another
+	intent, err := s.transferRepo.LockIntentByID(tx, intentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Intents of other users are reported as not found so intent IDs cannot be probed
+	if intent.UserID != userID {
+		err = utils.RepoErrTransferIntentNotFound
+		return nil, nil, err
+	}
+	if intent.Status != StatusOpen {
+		err = utils.ServiceErrTransferIntentConfirmed
+		return nil, nil, err
+	}
+	now := s.now()
+	if !intent.ExpiresAt.After(now) {
+		err = utils.ServiceErrTransferIntentExpired
+		return nil, nil, err
+	}
+
+	conversion, err := s.checkPrice(intent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The conversion just checked against the preview is the one posted, so the price cannot move in between
+	txn, updatedWallet, err := s.walletService.TransferTx(tx, userID, intent.FromWalletNumber, intent.ToWalletNumber, intent.Amount, conversion, intent.Note())
+	if err == utils.RepoErrInsufficientFunds {
+		// Like a direct transfer, the refusal is kept as a failed transaction once the attempt is rolled back
+		s.transferRepo.Rollback(tx)
+		s.walletService.RecordFailedTransfer(intent.FromWalletNumber, intent.ToWalletNumber, intent.Amount, conversion, intent.Note())
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	intent.Status = StatusConfirmed
+	intent.TransactionID = &txn.ID
+	intent.ConfirmedAt = &now
+	if err = s.transferRepo.UpdateIntent(tx, intent); err != nil {
+		return nil, nil, err
+	}
+
+	err = s.transferRepo.Commit(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return intent, updatedWallet, nil
+}
+
+// checkPrice checks a cross-currency intent would still credit the previewed amount, returning the conversion
+func (s *TransferService) checkPrice(intent *models.TransferIntent) (*fx.Conversion, error) {
+	if intent.Recipient.Currency == intent.Currency {
+		return nil, nil
+	}
+
+	conversion, err := wallet.PriceConversion(s.converter, intent.Amount, intent.Recipient.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if conversion.Target != intent.CreditedAmount {
+		return nil, utils.ServiceErrTransferIntentPriceChanged
+	}
+	return conversion, nil
+}
+
+func (s *TransferService) rollBackTxWhenErr(tx *sql.Tx, err *error) {
+	if err != nil {
+		s.transferRepo.Rollback(tx)
+	}
+}
@@ -0,0 +1,344 @@
+package transfer
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	mockFx "centralized-wallet/tests/mocks/fx"
+	mockTransfer "centralized-wallet/tests/mocks/transfer"
+	mockWallet "centralized-wallet/tests/mocks/wallet"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	testUserID           = 1
+	testOtherUserID      = 2
+	testFromWalletNumber = "wallet123"
+	testToWalletNumber   = "wallet456"
+	testNow              = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func usd(value string) money.Money {
+	return money.MustParse(value, money.DefaultCurrency)
+}
+
+func eur(value string) money.Money {
+	return money.MustParse(value, "EUR")
+}
+
+type transferServiceMocks struct {
+	transferRepo     *mockTransfer.MockTransferRepository
+	walletRepo       *mockWallet.MockWalletRepository
+	walletService    *mockWallet.MockWalletService
+	recipientService *mockWallet.MockRecipientService
+	converter        *mockFx.MockConverter
+}
+
+func setupTransferServiceMock() (*TransferService, transferServiceMocks) {
+	mocks := transferServiceMocks{
+		transferRepo:     new(mockTransfer.MockTransferRepository),
+		walletRepo:       new(mockWallet.MockWalletRepository),
+		walletService:    new(mockWallet.MockWalletService),
+		recipientService: new(mockWallet.MockRecipientService),
+		converter:        new(mockFx.MockConverter),
+	}
+	service := NewTransferService(mocks.transferRepo, mocks.walletRepo, mocks.walletService, mocks.recipientService, mocks.converter)
+	service.now = func() time.Time { return testNow }
+	return service, mocks
+}
+
+func usdWallet() *models.Wallet {
+	return &models.Wallet{ID: 1, UserID: testUserID, WalletNumber: testFromWalletNumber, Currency: "USD", Balance: usd("100.00"), HeldBalance: usd("30.00")}
+}
+
+func testRecipient(currency string) *models.Recipient {
+	return &models.Recipient{
+		UserID:       testOtherUserID,
+		WalletNumber: testToWalletNumber,
+		Handle:       "david",
+		Name:         "D*** S***",
+		Email:        "d***@example.com",
+		Currency:     currency,
+	}
+}
+
+func testConversion() *fx.Conversion {
+	return &fx.Conversion{
+		Source:      usd("50.00"),
+		Target:      eur("45.77"),
+		Spread:      usd("0.25"),
+		MidRate:     fx.MustParseRate("0.92"),
+		AppliedRate: fx.MustParseRate("0.9154"),
+	}
+}
+
+func openIntent() *models.TransferIntent {
+	memo := "Dinner"
+	return &models.TransferIntent{
+		ID:               5,
+		UserID:           testUserID,
+		FromWalletNumber: testFromWalletNumber,
+		ToWalletNumber:   testToWalletNumber,
+		Recipient:        *testRecipient("USD"),
+		Amount:           usd("50.00"),
+		Currency:         "USD",
+		Fee:              usd("0.00"),
+		CreditedAmount:   usd("50.00"),
+		BalanceAfter:     usd("20.00"),
+		Memo:             &memo,
+		Status:           StatusOpen,
+		ExpiresAt:        testNow.Add(time.Minute),
+		CreatedAt:        testNow.Add(-time.Minute),
+	}
+}
+
+func TestPreviewTransferService(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		to                   string
+		amount               money.Money
+		mockSetup            func(m transferServiceMocks)
+		expectedFee          money.Money
+		expectedCredited     money.Money
+		expectedBalanceAfter money.Money
+		expectedError        error
+	}{
+		{
+			name:   "same currency transfer is free",
+			amount: usd("50.00"),
+			mockSetup: func(m transferServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.recipientService.On("ResolveRecipientWallet", testUserID, testToWalletNumber).Return(testRecipient("USD"), nil)
+				m.transferRepo.On("CreateIntent", mock.MatchedBy(func(i *models.TransferIntent) bool {
+					return i.UserID == testUserID && i.ToWalletNumber == testToWalletNumber && i.Status == StatusOpen &&
+						i.ExpiresAt.Equal(testNow.Add(IntentTTL)) && *i.Memo == "Dinner"
+				})).Return(nil)
+			},
+			expectedFee:          usd("0.00"),
+			expectedCredited:     usd("50.00"),
+			expectedBalanceAfter: usd("20.00"),
+		},
+		{
+			name:   "cross currency transfer to a handle is priced at the current rate",
+			to:     "$david",
+			amount: usd("50.00"),
+			mockSetup: func(m transferServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.recipientService.On("ResolveRecipient", testUserID, "$david").Return(testRecipient("EUR"), nil)
+				m.converter.On("Convert", usd("50.00"), "EUR").Return(testConversion(), nil)
+				m.transferRepo.On("CreateIntent", mock.AnythingOfType("*models.TransferIntent")).Return(nil)
+			},
+			expectedFee:          usd("0.25"),
+			expectedCredited:     eur("45.77"),
+			expectedBalanceAfter: usd("20.00"),
+		},
+		{
+			name:   "amount above the available balance",
+			amount: usd("80.00"),
+			mockSetup: func(m transferServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.recipientService.On("ResolveRecipientWallet", testUserID, testToWalletNumber).Return(testRecipient("USD"), nil)
+			},
+			expectedError: utils.RepoErrInsufficientFunds,
+		},
+		{
+			name:   "transfer to the source wallet",
+			amount: usd("50.00"),
+			mockSetup: func(m transferServiceMocks) {
+				self := testRecipient("USD")
+				self.WalletNumber = testFromWalletNumber
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.recipientService.On("ResolveRecipientWallet", testUserID, testToWalletNumber).Return(self, nil)
+			},
+			expectedError: utils.ServiceErrTransferToSameWallet,
+		},
+		{
+			name:   "unknown recipient",
+			amount: usd("50.00"),
+			mockSetup: func(m transferServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.recipientService.On("ResolveRecipientWallet", testUserID, testToWalletNumber).Return(nil, utils.ServiceErrRecipientNotFound)
+			},
+			expectedError: utils.ServiceErrRecipientNotFound,
+		},
+		{
+			name:   "amount not in the source wallet currency",
+			amount: eur("50.00"),
+			mockSetup: func(m transferServiceMocks) {
+				m.walletRepo.On("GetDefaultWallet", testUserID).Return(usdWallet(), nil)
+				m.recipientService.On("ResolveRecipientWallet", testUserID, testToWalletNumber).Return(testRecipient("USD"), nil)
+			},
+			expectedError: utils.ServiceErrCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, m := setupTransferServiceMock()
+			tc.mockSetup(m)
+
+			intent, err := service.PreviewTransfer(testUserID, "", testToWalletNumber, tc.to, tc.amount, models.TransactionNote{Memo: "Dinner"})
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, intent)
+				m.transferRepo.AssertNotCalled(t, "CreateIntent", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.amount, intent.Amount)
+				assert.Equal(t, tc.expectedFee, intent.Fee)
+				assert.Equal(t, tc.expectedCredited, intent.CreditedAmount)
+				assert.Equal(t, tc.expectedBalanceAfter, intent.BalanceAfter)
+			}
+			m.transferRepo.AssertExpectations(t)
+			m.walletRepo.AssertExpectations(t)
+			m.recipientService.AssertExpectations(t)
+			m.converter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConfirmTransferService(t *testing.T) {
+	updatedWallet := &models.Wallet{WalletNumber: testFromWalletNumber, Currency: "USD", Balance: usd("50.00"), HeldBalance: usd("30.00")}
+	transfer := &models.Transaction{ID: 9, TransactionType: "transfer"}
+	errTransfer := errors.New("transfer failed")
+
+	// lockIntent expects the confirmation's DB transaction, the intent locked in it and its rollback
+	lockIntent := func(m transferServiceMocks, intent *models.TransferIntent) {
+		m.transferRepo.On("Begin").Return(nil, nil)
+		m.transferRepo.On("LockIntentByID", mock.AnythingOfType("*sql.Tx"), 5).Return(intent, nil)
+		m.transferRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+	}
+	expectConfirmed := func(m transferServiceMocks) {
+		m.transferRepo.On("UpdateIntent", mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(i *models.TransferIntent) bool {
+			return i.Status == StatusConfirmed && *i.TransactionID == transfer.ID && i.ConfirmedAt.Equal(testNow)
+		})).Return(nil)
+		m.transferRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+	}
+
+	testCases := []struct {
+		name          string
+		userID        int
+		mockSetup     func(m transferServiceMocks)
+		expectedError error
+	}{
+		{
+			name:   "transfers the previewed amount with its note",
+			userID: testUserID,
+			mockSetup: func(m transferServiceMocks) {
+				lockIntent(m, openIntent())
+				m.walletService.On("TransferTx", mock.AnythingOfType("*sql.Tx"), testUserID, testFromWalletNumber, testToWalletNumber, usd("50.00"), (*fx.Conversion)(nil), models.TransactionNote{Memo: "Dinner"}).
+					Return(transfer, updatedWallet, nil)
+				expectConfirmed(m)
+			},
+		},
+		{
+			name:   "cross currency intent at an unchanged rate posts the checked conversion",
+			userID: testUserID,
+			mockSetup: func(m transferServiceMocks) {
+				intent := openIntent()
+				intent.Recipient.Currency = "EUR"
+				intent.CreditedAmount = eur("45.77")
+				lockIntent(m, intent)
+				// Priced once: the checked conversion is the one posted
+				m.converter.On("Convert", usd("50.00"), "EUR").Return(testConversion(), nil).Once()
+				m.walletService.On("TransferTx", mock.AnythingOfType("*sql.Tx"), testUserID, testFromWalletNumber, testToWalletNumber, usd("50.00"), testConversion(), mock.Anything).
+					Return(transfer, updatedWallet, nil)
+				expectConfirmed(m)
+			},
+		},
+		{
+			name:   "intent of another user",
+			userID: testOtherUserID,
+			mockSetup: func(m transferServiceMocks) {
+				lockIntent(m, openIntent())
+			},
+			expectedError: utils.RepoErrTransferIntentNotFound,
+		},
+		{
+			name:   "intent expired",
+			userID: testUserID,
+			mockSetup: func(m transferServiceMocks) {
+				intent := openIntent()
+				intent.ExpiresAt = testNow
+				lockIntent(m, intent)
+			},
+			expectedError: utils.ServiceErrTransferIntentExpired,
+		},
+		{
+			name:   "intent already confirmed, e.g. by a concurrent confirmation holding the lock first",
+			userID: testUserID,
+			mockSetup: func(m transferServiceMocks) {
+				intent := openIntent()
+				intent.Status = StatusConfirmed
+				lockIntent(m, intent)
+			},
+			expectedError: utils.ServiceErrTransferIntentConfirmed,
+		},
+		{
+			name:   "rate changed since the preview",
+			userID: testUserID,
+			mockSetup: func(m transferServiceMocks) {
+				intent := openIntent()
+				intent.Recipient.Currency = "EUR"
+				intent.CreditedAmount = eur("46.00")
+				lockIntent(m, intent)
+				m.converter.On("Convert", usd("50.00"), "EUR").Return(testConversion(), nil)
+			},
+			expectedError: utils.ServiceErrTransferIntentPriceChanged,
+		},
+		{
+			name:   "insufficient funds are recorded as a failed transfer and leave the intent open",
+			userID: testUserID,
+			mockSetup: func(m transferServiceMocks) {
+				lockIntent(m, openIntent())
+				m.walletService.On("TransferTx", mock.AnythingOfType("*sql.Tx"), testUserID, testFromWalletNumber, testToWalletNumber, usd("50.00"), (*fx.Conversion)(nil), mock.Anything).
+					Return(nil, nil, utils.RepoErrInsufficientFunds)
+				m.walletService.On("RecordFailedTransfer", testFromWalletNumber, testToWalletNumber, usd("50.00"), (*fx.Conversion)(nil), models.TransactionNote{Memo: "Dinner"}).Return()
+			},
+			expectedError: utils.RepoErrInsufficientFunds,
+		},
+		{
+			name:   "failed transfer leaves the intent open",
+			userID: testUserID,
+			mockSetup: func(m transferServiceMocks) {
+				lockIntent(m, openIntent())
+				m.walletService.On("TransferTx", mock.AnythingOfType("*sql.Tx"), testUserID, testFromWalletNumber, testToWalletNumber, usd("50.00"), (*fx.Conversion)(nil), mock.Anything).
+					Return(nil, nil, errTransfer)
+			},
+			expectedError: errTransfer,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, m := setupTransferServiceMock()
+			tc.mockSetup(m)
+
+			intent, wallet, err := service.ConfirmTransfer(tc.userID, 5)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, intent)
+				assert.Nil(t, wallet)
+				m.transferRepo.AssertNotCalled(t, "UpdateIntent", mock.Anything, mock.Anything)
+				m.transferRepo.AssertNotCalled(t, "Commit", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, StatusConfirmed, intent.Status)
+				assert.Equal(t, transfer.ID, *intent.TransactionID)
+				assert.True(t, intent.ConfirmedAt.Equal(testNow))
+				assert.Equal(t, updatedWallet, wallet)
+			}
+			m.transferRepo.AssertExpectations(t)
+			m.walletService.AssertExpectations(t)
+			m.converter.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package user
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/redis"
+	"centralized-wallet/internal/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// UserAccessCacheTTL is how long a user's status and token version are served from Redis. Changes made through
+// AccountService drop the cached entry at once; the TTL bounds how long other changes take to be seen.
+const UserAccessCacheTTL = 30 * time.Second
+
+// AccountServiceInterface checks whether a user may use their tokens, and makes the changes that invalidate them
+type AccountServiceInterface interface {
+	auth.AccountCheckerInterface
+	ChangePassword(userID int, currentPassword, newPassword string) error
+	SetUserStatus(userID int, status string) error
+	SetUserRole(userID int, role string) error
+}
+
+// AccountService serves the JWT middleware's user lookup from a short-lived Redis cache, and bumps the user's
+// token version when their password or status changes so every outstanding token is refused
+type AccountService struct {
+	repo         UserRepositoryInterface
+	sessions     auth.SessionServiceInterface
+	redisService redis.RedisServiceInterface
+}
+
+// Ensure AccountService implements AccountServiceInterface
+var _ AccountServiceInterface = &AccountService{}
+
+// NewAccountService creates an AccountService
+func NewAccountService(repo UserRepositoryInterface, sessions auth.SessionServiceInterface, redis redis.RedisServiceInterface) *AccountService {
+	return &AccountService{
+		repo:         repo,
+		sessions:     sessions,
+		redisService: redis,
+	}
+}
+
+// userAccess is the part of a user checked on every request, as cached in Redis
+type userAccess struct {
+	Status       string `json:"status"`
+	Role         string `json:"role"`
+	TokenVersion int    `json:"token_version"`
+}
+
+// CurrentTokenVersion returns the token version the user's tokens must carry. A disabled user gets
+// ServiceErrUserDisabled, a deleted one ErrUserNotFound.
+func (s *AccountService) CurrentTokenVersion(userID int) (int, error) {
+	access, err := s.userAccess(userID)
+	if err != nil {
+		return 0, err
+	}
+	if access.Status != models.UserStatusActive {
+		return 0, utils.ServiceErrUserDisabled
+	}
+	return access.TokenVersion, nil
+}
+
+// CurrentRole returns the user's role, carried by the tokens issued to them
+func (s *AccountService) CurrentRole(userID int) (string, error) {
+	access, err := s.userAccess(userID)
+	if err != nil {
+		return "", err
+	}
+	return access.Role, nil
+}
+
+// ChangePassword replaces the user's password once the current one is confirmed, then logs them out everywhere.
+// Every token issued before the change is refused from the next request.
+func (s *AccountService) ChangePassword(userID int, currentPassword, newPassword string) error {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := verifyPassword(user.Password, currentPassword); err != nil {
+		return utils.ServiceErrIncorrectPassword
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePassword(userID, hashedPassword); err != nil {
+		return err
+	}
+
+	s.dropCachedAccess(userID)
+	_, err = s.sessions.RevokeAllSessions(userID)
+	return err
+}
+
+// SetUserStatus enables or disables a user. Disabling refuses their tokens from the next request and ends their
+// sessions; a user enabled again has to log in.
+func (s *AccountService) SetUserStatus(userID int, status string) error {
+	if status != models.UserStatusActive && status != models.UserStatusDisabled {
+		return utils.ServiceErrInvalidUserStatus
+	}
+
+	if err := s.repo.UpdateStatus(userID, status); err != nil {
+		return err
+	}
+
+	s.dropCachedAccess(userID)
+	if status == models.UserStatusDisabled {
+		_, err := s.sessions.RevokeAllSessions(userID)
+		return err
+	}
+	return nil
+}
+
+// SetUserRole changes the user's role. Their tokens are refused from the next request so none keeps the previous
+// role; their sessions stay, and refreshing gets tokens of the new role.
+func (s *AccountService) SetUserRole(userID int, role string) error {
+	switch role {
+	case models.UserRoleUser, models.UserRoleSupport, models.UserRoleAdmin, models.UserRoleAuditor:
+	default:
+		return utils.ServiceErrInvalidUserRole
+	}
+
+	if err := s.repo.UpdateRole(userID, role); err != nil {
+		return err
+	}
+
+	s.dropCachedAccess(userID)
+	return nil
+}
+
+// userAccess reads the user's status and token version from Redis, or from the database when not cached
+func (s *AccountService) userAccess(userID int) (*userAccess, error) {
+	cacheKey := accessCacheKey(userID)
+	cached, err := s.redisService.Get(context.Background(), cacheKey)
+	if err == nil && cached != "" {
+		var access userAccess
+		if err = json.Unmarshal([]byte(cached), &access); err == nil {
+			return &access, nil
+		}
+	}
+
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &userAccess{Status: user.Status, Role: user.Role, TokenVersion: user.TokenVersion}
+	cacheData, err := json.Marshal(access)
+	if err == nil {
+		s.redisService.Set(context.Background(), cacheKey, cacheData, UserAccessCacheTTL)
+	}
+	return access, nil
+}
+
+// dropCachedAccess makes the next request read the user's new status and token version from the database
+func (s *AccountService) dropCachedAccess(userID int) {
+	s.redisService.DeleteKeysByPattern(context.Background(), accessCacheKey(userID))
+}
+
+func accessCacheKey(userID int) string {
+	return fmt.Sprintf("user:%d:access", userID)
+}
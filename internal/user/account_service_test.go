@@ -0,0 +1,166 @@
+package user
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockAuth "centralized-wallet/tests/mocks/auth"
+	mockRedis "centralized-wallet/tests/mocks/redis"
+	mockUser "centralized-wallet/tests/mocks/user"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupAccountServiceMock() (*AccountService, *mockUser.MockUserRepository, *mockAuth.MockSessionService, *mockRedis.MockRedisClient) {
+	repo := new(mockUser.MockUserRepository)
+	sessions := new(mockAuth.MockSessionService)
+	redisClient := new(mockRedis.MockRedisClient)
+	return NewAccountService(repo, sessions, redisClient), repo, sessions, redisClient
+}
+
+func TestCurrentTokenVersion(t *testing.T) {
+	testCases := []struct {
+		name            string
+		mockSetup       func(repo *mockUser.MockUserRepository, redisClient *mockRedis.MockRedisClient)
+		expectedVersion int
+		expectedError   error
+	}{
+		{
+			name: "active user cached",
+			mockSetup: func(repo *mockUser.MockUserRepository, redisClient *mockRedis.MockRedisClient) {
+				redisClient.On("Get", mock.Anything, "user:1:access").Return(`{"status":"active","role":"user","token_version":3}`, nil)
+			},
+			expectedVersion: 3,
+		},
+		{
+			name: "disabled user cached",
+			mockSetup: func(repo *mockUser.MockUserRepository, redisClient *mockRedis.MockRedisClient) {
+				redisClient.On("Get", mock.Anything, "user:1:access").Return(`{"status":"disabled","role":"user","token_version":3}`, nil)
+			},
+			expectedError: utils.ServiceErrUserDisabled,
+		},
+		{
+			name: "cache miss reads the user and caches it",
+			mockSetup: func(repo *mockUser.MockUserRepository, redisClient *mockRedis.MockRedisClient) {
+				redisClient.On("Get", mock.Anything, "user:1:access").Return("", redis.Nil)
+				repo.On("GetUserByID", 1).Return(&models.User{ID: 1, Status: models.UserStatusActive, Role: models.UserRoleAuditor, TokenVersion: 2}, nil)
+				redisClient.On("Set", mock.Anything, "user:1:access", []byte(`{"status":"active","role":"auditor","token_version":2}`), UserAccessCacheTTL).Return(nil)
+			},
+			expectedVersion: 2,
+		},
+		{
+			name: "user deleted",
+			mockSetup: func(repo *mockUser.MockUserRepository, redisClient *mockRedis.MockRedisClient) {
+				redisClient.On("Get", mock.Anything, "user:1:access").Return("", redis.Nil)
+				repo.On("GetUserByID", 1).Return(nil, utils.ErrUserNotFound)
+			},
+			expectedError: utils.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, repo, _, redisClient := setupAccountServiceMock()
+			tc.mockSetup(repo, redisClient)
+
+			version, err := service.CurrentTokenVersion(1)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedVersion, version)
+			repo.AssertExpectations(t)
+			redisClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCurrentRole(t *testing.T) {
+	service, _, _, redisClient := setupAccountServiceMock()
+	redisClient.On("Get", mock.Anything, "user:1:access").Return(`{"status":"active","role":"support","token_version":1}`, nil)
+
+	role, err := service.CurrentRole(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserRoleSupport, role)
+}
+
+func TestChangePassword(t *testing.T) {
+	t.Run("replaces the password and logs out everywhere", func(t *testing.T) {
+		service, repo, sessions, redisClient := setupAccountServiceMock()
+		repo.On("GetUserByID", 1).Return(&models.User{ID: 1, Password: testPasswordHash}, nil)
+		var stored string
+		repo.On("UpdatePassword", 1, mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { stored = args.String(1) }).
+			Return(nil)
+		redisClient.On("DeleteKeysByPattern", mock.Anything, "user:1:access").Return(nil)
+		sessions.On("RevokeAllSessions", 1).Return(2, nil)
+
+		assert.NoError(t, service.ChangePassword(1, "password", "new-password"))
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored), []byte("new-password")))
+		repo.AssertExpectations(t)
+		sessions.AssertExpectations(t)
+		redisClient.AssertExpectations(t)
+	})
+
+	t.Run("incorrect current password", func(t *testing.T) {
+		service, repo, sessions, _ := setupAccountServiceMock()
+		repo.On("GetUserByID", 1).Return(&models.User{ID: 1, Password: testPasswordHash}, nil)
+
+		assert.Equal(t, utils.ServiceErrIncorrectPassword, service.ChangePassword(1, "wrong", "new-password"))
+		repo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+		sessions.AssertNotCalled(t, "RevokeAllSessions", mock.Anything)
+	})
+}
+
+func TestSetUserStatus(t *testing.T) {
+	t.Run("disabling ends the sessions", func(t *testing.T) {
+		service, repo, sessions, redisClient := setupAccountServiceMock()
+		repo.On("UpdateStatus", 1, models.UserStatusDisabled).Return(nil)
+		redisClient.On("DeleteKeysByPattern", mock.Anything, "user:1:access").Return(nil)
+		sessions.On("RevokeAllSessions", 1).Return(1, nil)
+
+		assert.NoError(t, service.SetUserStatus(1, models.UserStatusDisabled))
+		repo.AssertExpectations(t)
+		sessions.AssertExpectations(t)
+		redisClient.AssertExpectations(t)
+	})
+
+	t.Run("enabling", func(t *testing.T) {
+		service, repo, sessions, redisClient := setupAccountServiceMock()
+		repo.On("UpdateStatus", 1, models.UserStatusActive).Return(nil)
+		redisClient.On("DeleteKeysByPattern", mock.Anything, "user:1:access").Return(nil)
+
+		assert.NoError(t, service.SetUserStatus(1, models.UserStatusActive))
+		sessions.AssertNotCalled(t, "RevokeAllSessions", mock.Anything)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		service, repo, _, _ := setupAccountServiceMock()
+
+		assert.Equal(t, utils.ServiceErrInvalidUserStatus, service.SetUserStatus(1, "suspended"))
+		repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+	})
+}
+
+func TestSetUserRole(t *testing.T) {
+	t.Run("changes the role and drops the cached access", func(t *testing.T) {
+		service, repo, sessions, redisClient := setupAccountServiceMock()
+		repo.On("UpdateRole", 1, models.UserRoleAuditor).Return(nil)
+		redisClient.On("DeleteKeysByPattern", mock.Anything, "user:1:access").Return(nil)
+
+		assert.NoError(t, service.SetUserRole(1, models.UserRoleAuditor))
+		repo.AssertExpectations(t)
+		redisClient.AssertExpectations(t)
+		// Sessions stay; refreshing gets tokens of the new role
+		sessions.AssertNotCalled(t, "RevokeAllSessions", mock.Anything)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		service, repo, _, _ := setupAccountServiceMock()
+
+		assert.Equal(t, utils.ServiceErrInvalidUserRole, service.SetUserRole(1, "root"))
+		repo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+	})
+}
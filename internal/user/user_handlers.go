@@ -50,8 +50,8 @@ func RegistrationHandler(us UserServiceInterface) gin.HandlerFunc {
 	}
 }
 
-// LoginHandler handles user login requests
-func LoginHandler(us UserServiceInterface) gin.HandlerFunc {
+// LoginHandler handles user login requests, returning an access token and the refresh token to renew it
+func LoginHandler(us UserServiceInterface, ts auth.TokenServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var request struct {
 			Email    string `json:"email" binding:"required,email"`
@@ -67,27 +67,161 @@ func LoginHandler(us UserServiceInterface) gin.HandlerFunc {
 		// Authenticate the user
 		user, err := us.LoginUser(request.Email, request.Password)
 		if err != nil {
+			if errors.Is(err, utils.ServiceErrUserDisabled) {
+				utils.ErrorResponse(c, utils.ErrUserDisabled, nil, "")
+				return
+			}
 			utils.ErrorResponse(c, utils.ErrInvalidCredentials, nil, "")
 			return
 		}
 
-		// Generate a JWT token
-		token, err := auth.GenerateJWT(user.ID)
+		// Start a session for this device and generate its access and refresh tokens
+		tokens, err := ts.IssueTokens(user.ID, c.Request.UserAgent(), c.ClientIP())
 		if err != nil {
-			utils.ErrorResponse(c, utils.ErrTokenGenerationFailed, nil, "")
+			if errors.Is(err, utils.ServiceErrUserDisabled) {
+				utils.ErrorResponse(c, utils.ErrUserDisabled, nil, "")
+				return
+			}
+			utils.ErrorResponse(c, utils.ErrTokenGenerationFailed, err, "[LoginHandler] Error issuing tokens")
 			return
 		}
 
-		// Success response with token
+		// Success response with tokens
 		utils.SuccessResponse(c, "Login successful", gin.H{
-			"token": token,
-			"user":  user,
+			"token":         tokens.AccessToken,
+			"refresh_token": tokens.RefreshToken,
+			"expires_in":    tokens.ExpiresIn,
+			"user":          user,
 		})
 	}
 }
 
-func LogoutHandler(blacklistService *auth.BlacklistService) gin.HandlerFunc {
+// RefreshTokenHandler exchanges a refresh token for a new access token and a new refresh token.
+// The presented refresh token cannot be used again.
+func RefreshTokenHandler(ts auth.TokenServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var request struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+
+		tokens, err := ts.RefreshTokens(request.RefreshToken)
+		if err != nil {
+			switch err {
+			case utils.ServiceErrInvalidRefreshToken:
+				utils.ErrorResponse(c, utils.ErrInvalidRefreshToken, nil, "")
+			case utils.ServiceErrRefreshTokenExpired:
+				utils.ErrorResponse(c, utils.ErrRefreshTokenExpired, nil, "")
+			case utils.ServiceErrRefreshTokenReused:
+				utils.ErrorResponse(c, utils.ErrRefreshTokenRevoked, nil, "")
+			case utils.ServiceErrUserDisabled:
+				utils.ErrorResponse(c, utils.ErrUserDisabled, nil, "")
+			case utils.ErrUserNotFound:
+				utils.ErrorResponse(c, utils.ErrInvalidRefreshToken, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[RefreshTokenHandler] Error refreshing token")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgTokenRefreshed, gin.H{
+			"token":         tokens.AccessToken,
+			"refresh_token": tokens.RefreshToken,
+			"expires_in":    tokens.ExpiresIn,
+		})
+	}
+}
+
+// UpdateProfileHandler sets the authenticated user's display name and $handle
+func UpdateProfileHandler(us UserServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		var request struct {
+			Name   string `json:"name"`
+			Handle string `json:"handle"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+
+		user, err := us.UpdateProfile(userID.(int), request.Name, request.Handle)
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.ServiceErrInvalidName):
+				utils.ErrorResponse(c, utils.ErrInvalidName, nil, "")
+			case errors.Is(err, utils.ServiceErrInvalidHandle):
+				utils.ErrorResponse(c, utils.ErrInvalidHandle, nil, "")
+			case errors.Is(err, utils.RepoErrHandleTaken):
+				utils.ErrorResponse(c, utils.ErrHandleTaken, nil, "")
+			case errors.Is(err, utils.ErrUserNotFound):
+				utils.ErrorResponse(c, utils.ErrUserNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[UpdateProfileHandler] Error updating profile")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgProfileUpdated, gin.H{"user": user})
+	}
+}
+
+// ChangePasswordHandler replaces the authenticated user's password. Every token issued before, including the one
+// making the request, stops working and the user logs in again with the new password.
+func ChangePasswordHandler(as AccountServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		var request struct {
+			CurrentPassword string `json:"current_password" binding:"required"`
+			NewPassword     string `json:"new_password" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+		if len(request.NewPassword) < 6 {
+			utils.ErrorResponse(c, utils.ErrPasswordTooShort, nil, "")
+			return
+		}
+
+		err := as.ChangePassword(userID.(int), request.CurrentPassword, request.NewPassword)
+		if err != nil {
+			switch {
+			case errors.Is(err, utils.ServiceErrIncorrectPassword):
+				utils.ErrorResponse(c, utils.ErrIncorrectPassword, nil, "")
+			case errors.Is(err, utils.ErrUserNotFound):
+				utils.ErrorResponse(c, utils.ErrUserNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[ChangePasswordHandler] Error changing password")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgPasswordChanged, nil)
+	}
+}
+
+// LogoutHandler blacklists the access token and ends its session, revoking the refresh tokens of this login
+func LogoutHandler(blacklistService auth.BlacklistServiceInterface, ss auth.SessionServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
 
 		// Get the token string from context (set by JWT middleware)
 		tokenString, exists := c.Get("token_string")
@@ -110,6 +244,15 @@ func LogoutHandler(blacklistService *auth.BlacklistService) gin.HandlerFunc {
 			return
 		}
 
+		// End the session the token belongs to (set by JWT middleware)
+		if sessionID := c.GetString("session_id"); sessionID != "" {
+			err := ss.RevokeSession(userID.(int), sessionID)
+			if err != nil && err != utils.RepoErrSessionNotFound {
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[LogoutHandler] Error revoking session")
+				return
+			}
+		}
+
 		// Return success message
 		utils.SuccessResponse(c, "Logged out successfully", nil)
 	}
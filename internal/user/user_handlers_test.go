@@ -3,7 +3,7 @@ package user
 import (
 	"errors"
 	"net/http"
-	"os"
+	"net/http/httptest"
 	"testing"
 
 	"centralized-wallet/internal/auth"
@@ -14,6 +14,8 @@ import (
 	"centralized-wallet/tests/testutils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 // Test registration handler
@@ -21,6 +23,9 @@ import (
 var mockHandlerTestHelper struct {
 	userService      *mockUser.MockUserService
 	blacklistService *mockAuth.MockBlacklistService
+	tokenService     *mockAuth.MockTokenService
+	sessionService   *mockAuth.MockSessionService
+	accountService   *mockUser.MockAccountService
 }
 
 // Helper function to setup the router with services
@@ -28,6 +33,9 @@ var mockHandlerTestHelper struct {
 func setupHandlerMock() {
 	mockHandlerTestHelper.userService = new(mockUser.MockUserService)
 	mockHandlerTestHelper.blacklistService = new(mockAuth.MockBlacklistService)
+	mockHandlerTestHelper.tokenService = new(mockAuth.MockTokenService)
+	mockHandlerTestHelper.sessionService = new(mockAuth.MockSessionService)
+	mockHandlerTestHelper.accountService = new(mockUser.MockAccountService)
 }
 
 func setupRouter() *gin.Engine {
@@ -66,8 +74,6 @@ func TestRegistrationHandler_DuplicateEmail(t *testing.T) {
 
 // Test login handler with JWT
 func TestLoginHandler_Success(t *testing.T) {
-	// Set JWT_SECRET environment variable for testing
-	os.Setenv("JWT_SECRET", "test-secret-key")
 	// Generate the hash for "password123" directly in the test
 	hashedPassword, _ := HashPassword(password)
 	// Mock LoginUser to return a valid user
@@ -80,13 +86,17 @@ func TestLoginHandler_Success(t *testing.T) {
 	token, _ := auth.GenerateJWT(user.ID)
 	router := setupRouter()
 	mockHandlerTestHelper.userService.On("LoginUser", user.Email, password).Return(user, nil)
-	router.POST("/login", LoginHandler(mockHandlerTestHelper.userService))
+	mockHandlerTestHelper.tokenService.On("IssueTokens", user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Return(&models.TokenPair{AccessToken: token, RefreshToken: "refresh-token", ExpiresIn: 900}, nil)
+	router.POST("/login", LoginHandler(mockHandlerTestHelper.userService, mockHandlerTestHelper.tokenService))
 
 	body := map[string]interface{}{"email": user.Email, "password": password}
 	w := testutils.ExecuteRequest(router, "POST", "/login", body, "")
 	testutils.AssertAPISuccessResponse(t, w, "Login successful",
 		gin.H{
-			"token": token,
+			"token":         token,
+			"refresh_token": "refresh-token",
+			"expires_in":    900,
 			"user": map[string]any{
 				"id":    1,
 				"email": user.Email,
@@ -100,10 +110,218 @@ func TestLoginHandler_IncorrectPassword(t *testing.T) {
 	router := setupRouter()
 	wrongpassword := "wrongpassword"
 	mockHandlerTestHelper.userService.On("LoginUser", email, wrongpassword).Return(nil, errors.New("invalid password"))
-	router.POST("/login", LoginHandler(mockHandlerTestHelper.userService))
+	router.POST("/login", LoginHandler(mockHandlerTestHelper.userService, mockHandlerTestHelper.tokenService))
 
 	body := map[string]interface{}{"email": email, "password": wrongpassword}
 	w := testutils.ExecuteRequest(router, "POST", "/login", body, "")
 
 	testutils.AssertAPIErrorResponse(t, w, utils.ErrInvalidCredentials)
 }
+
+// Test login handler with a disabled account
+func TestLoginHandler_UserDisabled(t *testing.T) {
+	router := setupRouter()
+	mockHandlerTestHelper.userService.On("LoginUser", email, password).Return(nil, utils.ServiceErrUserDisabled)
+	router.POST("/login", LoginHandler(mockHandlerTestHelper.userService, mockHandlerTestHelper.tokenService))
+
+	body := map[string]interface{}{"email": email, "password": password}
+	w := testutils.ExecuteRequest(router, "POST", "/login", body, "")
+
+	testutils.AssertAPIErrorResponse(t, w, utils.ErrUserDisabled)
+	mockHandlerTestHelper.tokenService.AssertNotCalled(t, "IssueTokens", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRefreshTokenHandler_Success(t *testing.T) {
+	router := setupRouter()
+	router.POST("/token/refresh", RefreshTokenHandler(mockHandlerTestHelper.tokenService))
+	mockHandlerTestHelper.tokenService.On("RefreshTokens", "old-refresh-token").
+		Return(&models.TokenPair{AccessToken: "new-access-token", RefreshToken: "new-refresh-token", ExpiresIn: 900}, nil)
+
+	body := map[string]interface{}{"refresh_token": "old-refresh-token"}
+	w := testutils.ExecuteRequest(router, "POST", "/token/refresh", body, "")
+	testutils.AssertAPISuccessResponse(t, w, utils.MsgTokenRefreshed, gin.H{
+		"token":         "new-access-token",
+		"refresh_token": "new-refresh-token",
+		"expires_in":    900,
+	}, http.StatusOK)
+}
+
+func TestRefreshTokenHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected *utils.AppError
+	}{
+		{"unknown token", utils.ServiceErrInvalidRefreshToken, utils.ErrInvalidRefreshToken},
+		{"expired token", utils.ServiceErrRefreshTokenExpired, utils.ErrRefreshTokenExpired},
+		{"reused token", utils.ServiceErrRefreshTokenReused, utils.ErrRefreshTokenRevoked},
+		{"user disabled", utils.ServiceErrUserDisabled, utils.ErrUserDisabled},
+		{"user deleted", utils.ErrUserNotFound, utils.ErrInvalidRefreshToken},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupRouter()
+			router.POST("/token/refresh", RefreshTokenHandler(mockHandlerTestHelper.tokenService))
+			mockHandlerTestHelper.tokenService.On("RefreshTokens", "old-refresh-token").Return(nil, tc.err)
+
+			w := testutils.ExecuteRequest(router, "POST", "/token/refresh", map[string]interface{}{"refresh_token": "old-refresh-token"}, "")
+			testutils.AssertAPIErrorResponse(t, w, tc.expected)
+		})
+	}
+
+	t.Run("missing token", func(t *testing.T) {
+		router := setupRouter()
+		router.POST("/token/refresh", RefreshTokenHandler(mockHandlerTestHelper.tokenService))
+
+		w := testutils.ExecuteRequest(router, "POST", "/token/refresh", map[string]interface{}{}, "")
+		testutils.AssertAPIErrorResponse(t, w, utils.ErrInvalidRequest)
+		mockHandlerTestHelper.tokenService.AssertNotCalled(t, "RefreshTokens", mock.Anything)
+	})
+}
+
+// setupLogoutRouter serves the logout route behind JWTMiddleware and returns an access token of user 1,
+// of session "session-1" when withSession is set
+func setupLogoutRouter(withSession bool) (*gin.Engine, string) {
+	router := setupRouter()
+	token, _ := auth.GenerateJWT(1)
+	if withSession {
+		token, _ = auth.GenerateAccessToken(1, auth.AccessTokenClaims{SessionID: "session-1"})
+		mockHandlerTestHelper.sessionService.On("CheckSession", "session-1").Return(nil)
+	}
+	mockHandlerTestHelper.blacklistService.On("IsTokenBlacklisted", token).Return(false, nil)
+	mockHandlerTestHelper.blacklistService.On("BlacklistToken", token, mock.AnythingOfType("*jwt.Token")).Return(nil)
+	mockHandlerTestHelper.accountService.On("CurrentTokenVersion", 1).Return(1, nil)
+	router.POST("/logout", auth.JWTMiddleware(mockHandlerTestHelper.blacklistService, mockHandlerTestHelper.sessionService, mockHandlerTestHelper.accountService),
+		LogoutHandler(mockHandlerTestHelper.blacklistService, mockHandlerTestHelper.sessionService))
+	return router, token
+}
+
+// assertLoggedOut checks the logout succeeded; its response carries no data
+func assertLoggedOut(t *testing.T, w *httptest.ResponseRecorder) {
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"success","message":"Logged out successfully"}`, w.Body.String())
+}
+
+func TestLogoutHandler(t *testing.T) {
+	t.Run("ends the session of the token", func(t *testing.T) {
+		router, token := setupLogoutRouter(true)
+		mockHandlerTestHelper.sessionService.On("RevokeSession", 1, "session-1").Return(nil)
+
+		w := testutils.ExecuteRequest(router, "POST", "/logout", nil, token)
+		assertLoggedOut(t, w)
+		mockHandlerTestHelper.blacklistService.AssertExpectations(t)
+		mockHandlerTestHelper.sessionService.AssertExpectations(t)
+	})
+
+	t.Run("a session revoked meanwhile does not fail the logout", func(t *testing.T) {
+		router, token := setupLogoutRouter(true)
+		mockHandlerTestHelper.sessionService.On("RevokeSession", 1, "session-1").Return(utils.RepoErrSessionNotFound)
+
+		w := testutils.ExecuteRequest(router, "POST", "/logout", nil, token)
+		assertLoggedOut(t, w)
+	})
+
+	t.Run("a token without a session is only blacklisted", func(t *testing.T) {
+		router, token := setupLogoutRouter(false)
+
+		w := testutils.ExecuteRequest(router, "POST", "/logout", nil, token)
+		assertLoggedOut(t, w)
+		mockHandlerTestHelper.sessionService.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything)
+	})
+
+	t.Run("session error", func(t *testing.T) {
+		router, token := setupLogoutRouter(true)
+		mockHandlerTestHelper.sessionService.On("RevokeSession", 1, "session-1").Return(errors.New("connection reset"))
+
+		w := testutils.ExecuteRequest(router, "POST", "/logout", nil, token)
+		testutils.AssertAPIErrorResponse(t, w, utils.ErrInternalServerError)
+	})
+}
+
+// setupProfileRouter serves the profile route as the given user, standing in for JWTMiddleware
+func setupProfileRouter(userID int) *gin.Engine {
+	router := setupRouter()
+	router.PUT("/profile", func(c *gin.Context) { c.Set("user_id", userID) }, UpdateProfileHandler(mockHandlerTestHelper.userService))
+	return router
+}
+
+func TestUpdateProfileHandler_Success(t *testing.T) {
+	router := setupProfileRouter(1)
+	name, handle := "Jane Doe", "jane"
+	mockHandlerTestHelper.userService.On("UpdateProfile", 1, "Jane Doe", "$jane").
+		Return(&models.User{ID: 1, Email: email, Name: &name, Handle: &handle}, nil)
+
+	body := map[string]interface{}{"name": "Jane Doe", "handle": "$jane"}
+	w := testutils.ExecuteRequest(router, "PUT", "/profile", body, "")
+	testutils.AssertAPISuccessResponse(t, w, utils.MsgProfileUpdated, gin.H{
+		"user": gin.H{"id": 1, "email": email, "name": name, "handle": handle},
+	}, http.StatusOK)
+}
+
+func TestUpdateProfileHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected *utils.AppError
+	}{
+		{"invalid handle", utils.ServiceErrInvalidHandle, utils.ErrInvalidHandle},
+		{"invalid name", utils.ServiceErrInvalidName, utils.ErrInvalidName},
+		{"handle taken", utils.RepoErrHandleTaken, utils.ErrHandleTaken},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupProfileRouter(1)
+			mockHandlerTestHelper.userService.On("UpdateProfile", 1, "", "$jane").Return(nil, tc.err)
+
+			w := testutils.ExecuteRequest(router, "PUT", "/profile", map[string]interface{}{"handle": "$jane"}, "")
+			testutils.AssertAPIErrorResponse(t, w, tc.expected)
+		})
+	}
+}
+
+// setupPasswordRouter serves the password route as user 1, standing in for JWTMiddleware
+func setupPasswordRouter() *gin.Engine {
+	router := setupRouter()
+	router.PUT("/password", func(c *gin.Context) { c.Set("user_id", 1) }, ChangePasswordHandler(mockHandlerTestHelper.accountService))
+	return router
+}
+
+func TestChangePasswordHandler_Success(t *testing.T) {
+	router := setupPasswordRouter()
+	mockHandlerTestHelper.accountService.On("ChangePassword", 1, password, "new-password").Return(nil)
+
+	body := map[string]interface{}{"current_password": password, "new_password": "new-password"}
+	w := testutils.ExecuteRequest(router, "PUT", "/password", body, "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"success","message":"Password changed successfully, log in again"}`, w.Body.String())
+	mockHandlerTestHelper.accountService.AssertExpectations(t)
+}
+
+func TestChangePasswordHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     map[string]interface{}
+		err      error
+		expected *utils.AppError
+	}{
+		{"missing current password", map[string]interface{}{"new_password": "new-password"}, nil, utils.ErrInvalidRequest},
+		{"new password too short", map[string]interface{}{"current_password": password, "new_password": "abc"}, nil, utils.ErrPasswordTooShort},
+		{"incorrect current password", map[string]interface{}{"current_password": "wrong", "new_password": "new-password"}, utils.ServiceErrIncorrectPassword, utils.ErrIncorrectPassword},
+		{"database error", map[string]interface{}{"current_password": password, "new_password": "new-password"}, errors.New("connection reset"), utils.ErrInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupPasswordRouter()
+			if tc.err != nil {
+				mockHandlerTestHelper.accountService.On("ChangePassword", 1, tc.body["current_password"], tc.body["new_password"]).Return(tc.err)
+			}
+
+			w := testutils.ExecuteRequest(router, "PUT", "/password", tc.body, "")
+			testutils.AssertAPIErrorResponse(t, w, tc.expected)
+			if tc.err == nil {
+				mockHandlerTestHelper.accountService.AssertNotCalled(t, "ChangePassword", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
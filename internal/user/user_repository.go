@@ -4,14 +4,28 @@ import (
 	"centralized-wallet/internal/models"
 	"centralized-wallet/internal/utils"
 	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// pgUniqueViolation is the Postgres error code raised when a unique index rejects a row
+const pgUniqueViolation = "23505"
+
 type UserRepositoryInterface interface {
 	IsEmailInUse(email string) (bool, error)
 	CreateUser(email, password string) (*models.User, error) // No transaction needed
 	GetUserByEmail(email string) (*models.User, error)
+	GetUserByID(userID int) (*models.User, error)
+	GetUserByHandle(handle string) (*models.User, error)
+	UpdateProfile(userID int, name, handle *string) (*models.User, error)
+	UpdatePassword(userID int, hashedPassword string) error
+	UpdateStatus(userID int, status string) error
+	UpdateRole(userID int, role string) error
+	SearchUsers(query string, limit, offset int) ([]models.User, error)
 }
 
 // Ensure UserRepository implements the UserRepositoryInterface
@@ -57,8 +71,38 @@ func (repo *UserRepository) CreateUser(email, password string) (*models.User, er
 // GetUserByEmail retrieves a user by their email from the database
 func (repo *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 	var user models.User
-	query := "SELECT id, email, password FROM users WHERE email = $1"
-	err := repo.db.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Password)
+	query := "SELECT id, email, password, handle, name, status, role, token_version FROM users WHERE email = $1"
+	err := repo.db.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Password, &user.Handle, &user.Name, &user.Status, &user.Role, &user.TokenVersion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUserByID retrieves a user by their ID
+func (repo *UserRepository) GetUserByID(userID int) (*models.User, error) {
+	var user models.User
+	query := "SELECT id, email, password, handle, name, status, role, token_version FROM users WHERE id = $1"
+	err := repo.db.QueryRow(query, userID).Scan(&user.ID, &user.Email, &user.Password, &user.Handle, &user.Name, &user.Status, &user.Role, &user.TokenVersion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUserByHandle retrieves a user by their handle, which must already be normalized
+func (repo *UserRepository) GetUserByHandle(handle string) (*models.User, error) {
+	var user models.User
+	query := "SELECT id, email, password, handle, name, status, role, token_version FROM users WHERE handle = $1"
+	err := repo.db.QueryRow(query, handle).Scan(&user.ID, &user.Email, &user.Password, &user.Handle, &user.Name, &user.Status, &user.Role, &user.TokenVersion)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, utils.ErrUserNotFound
@@ -69,6 +113,105 @@ func (repo *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+// UpdateProfile replaces the user's display name and handle, a nil value clearing it
+func (repo *UserRepository) UpdateProfile(userID int, name, handle *string) (*models.User, error) {
+	query := `UPDATE users SET name = $2, handle = $3, updated_at = NOW()
+			  WHERE id = $1 RETURNING id, email, handle, name, created_at, updated_at`
+	user := &models.User{}
+	err := repo.db.QueryRow(query, userID, name, handle).
+		Scan(&user.ID, &user.Email, &user.Handle, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.ErrUserNotFound
+		}
+		if isUniqueViolation(err, "idx_users_handle") {
+			return nil, utils.RepoErrHandleTaken
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdatePassword replaces the user's password hash and bumps their token version, invalidating their tokens
+func (repo *UserRepository) UpdatePassword(userID int, hashedPassword string) error {
+	query := `UPDATE users SET password = $2, token_version = token_version + 1, updated_at = NOW()
+			  WHERE id = $1`
+
+	return expectUserUpdated(repo.db.Exec(query, userID, hashedPassword))
+}
+
+// UpdateStatus sets the user's status and bumps their token version, invalidating their tokens
+func (repo *UserRepository) UpdateStatus(userID int, status string) error {
+	query := `UPDATE users SET status = $2, token_version = token_version + 1, updated_at = NOW()
+			  WHERE id = $1`
+
+	return expectUserUpdated(repo.db.Exec(query, userID, status))
+}
+
+// UpdateRole sets the user's role and bumps their token version, so no token keeps the previous role
+func (repo *UserRepository) UpdateRole(userID int, role string) error {
+	query := `UPDATE users SET role = $2, token_version = token_version + 1, updated_at = NOW()
+			  WHERE id = $1`
+
+	return expectUserUpdated(repo.db.Exec(query, userID, role))
+}
+
+// SearchUsers returns the users whose email, handle or name contains the query, case-insensitively, or whose ID
+// is the query, by ID. A query that is not a valid user ID, e.g. a number too large for the id column, matches no ID.
+func (repo *UserRepository) SearchUsers(query string, limit, offset int) ([]models.User, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	var userID sql.NullInt32
+	if id, err := strconv.ParseInt(query, 10, 32); err == nil {
+		userID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT id, email, handle, name, status, role, created_at, updated_at
+		FROM users
+		WHERE email ILIKE $1 OR handle ILIKE $1 OR name ILIKE $1 OR id = $2
+		ORDER BY id
+		LIMIT $3 OFFSET $4`, pattern, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(&user.ID, &user.Email, &user.Handle, &user.Name, &user.Status, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, so they are matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// expectUserUpdated turns an update that matched no user into ErrUserNotFound
+func expectUserUpdated(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.ErrUserNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether the error comes from the named unique index
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == constraint
+}
+
 // HashPassword hashes a plain text password using bcrypt
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
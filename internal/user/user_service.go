@@ -3,13 +3,23 @@ package user
 import (
 	"centralized-wallet/internal/models"
 	"centralized-wallet/internal/utils"
+	"regexp"
+	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// MaxNameLength is the longest display name, in characters
+const MaxNameLength = 100
+
+// handlePattern matches a normalized handle: 3 to 30 lowercase letters, digits or underscores
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
 type UserServiceInterface interface {
 	RegisterUser(email, password string) (*models.User, error)
 	LoginUser(email, password string) (*models.User, error)
+	UpdateProfile(userID int, name, handle string) (*models.User, error)
 }
 
 type UserService struct {
@@ -62,6 +72,11 @@ func (us *UserService) LoginUser(email, password string) (*models.User, error) {
 		return nil, utils.ErrInvalidCredentials
 	}
 
+	// Only told once the password is right, so the status of an account is not disclosed to others
+	if user.Status == models.UserStatusDisabled {
+		return nil, utils.ServiceErrUserDisabled
+	}
+
 	return &models.User{
 		ID:    user.ID,
 		Email: user.Email,
@@ -72,3 +87,32 @@ func (us *UserService) LoginUser(email, password string) (*models.User, error) {
 func verifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// UpdateProfile replaces the user's display name and handle. Empty values clear them. The handle may be
+// given with its leading "$" and in any case; it is stored lowercase without it.
+func (us *UserService) UpdateProfile(userID int, name, handle string) (*models.User, error) {
+	var namePtr, handlePtr *string
+
+	if name = strings.TrimSpace(name); name != "" {
+		if utf8.RuneCountInString(name) > MaxNameLength {
+			return nil, utils.ServiceErrInvalidName
+		}
+		namePtr = &name
+	}
+
+	if strings.TrimSpace(handle) != "" {
+		normalized, ok := NormalizeHandle(handle)
+		if !ok {
+			return nil, utils.ServiceErrInvalidHandle
+		}
+		handlePtr = &normalized
+	}
+
+	return us.repo.UpdateProfile(userID, namePtr, handlePtr)
+}
+
+// NormalizeHandle strips the leading "$" and lowercases a handle, reporting whether the result is a valid handle
+func NormalizeHandle(handle string) (string, bool) {
+	normalized := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "$"))
+	return normalized, handlePattern.MatchString(normalized)
+}
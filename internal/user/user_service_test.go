@@ -4,11 +4,16 @@ import (
 	"centralized-wallet/internal/models"
 	"centralized-wallet/internal/utils"
 	mockUser "centralized-wallet/tests/mocks/user"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// testPasswordHash is the bcrypt hash of "password"
+const testPasswordHash = "$2a$10$gjS3c/wGiZO4VMHO.bSOsex36CrnGO.lrFhYKltC/FIEPlT49XDNq"
+
 // Mock service test helper struct
 var mockServiceTestHelper struct {
 	userRepo *mockUser.MockUserRepository
@@ -69,7 +74,7 @@ func TestLoginUser_Success(t *testing.T) {
 	us := NewUserService(mockServiceTestHelper.userRepo)
 
 	// Mock the GetUserByEmail method
-	mockServiceTestHelper.userRepo.On("GetUserByEmail", "test@example.com").Return(&models.User{ID: 1, Email: "test@example.com", Password: "$2a$10$gjS3c/wGiZO4VMHO.bSOsex36CrnGO.lrFhYKltC/FIEPlT49XDNq"}, nil)
+	mockServiceTestHelper.userRepo.On("GetUserByEmail", "test@example.com").Return(&models.User{ID: 1, Email: "test@example.com", Password: testPasswordHash}, nil)
 	// Note: Since `VerifyPassword` is not being mocked, the real function will be used.
 
 	// Act: Call the LoginUser method
@@ -102,3 +107,63 @@ func TestLoginUser_InvalidEmailOrPassword(t *testing.T) {
 	assert.EqualError(t, err, utils.ErrInvalidCredentials.Error())
 	mockServiceTestHelper.userRepo.AssertExpectations(t)
 }
+
+func TestLoginUser_UserDisabled(t *testing.T) {
+	setupServiceMock()
+	us := NewUserService(mockServiceTestHelper.userRepo)
+
+	mockServiceTestHelper.userRepo.On("GetUserByEmail", "test@example.com").Return(&models.User{
+		ID:       1,
+		Email:    "test@example.com",
+		Password: testPasswordHash,
+		Status:   models.UserStatusDisabled,
+	}, nil)
+
+	// A wrong password is refused as usual, without telling the account is disabled
+	_, err := us.LoginUser("test@example.com", "wrongpassword")
+	assert.EqualError(t, err, utils.ErrInvalidCredentials.Error())
+
+	user, err := us.LoginUser("test@example.com", "password")
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, utils.ServiceErrUserDisabled)
+}
+
+func TestUpdateProfile_NormalizesTheHandle(t *testing.T) {
+	setupServiceMock()
+	us := NewUserService(mockServiceTestHelper.userRepo)
+	name, handle := "Jane Doe", "jane_doe"
+	mockServiceTestHelper.userRepo.On("UpdateProfile", 1, &name, &handle).
+		Return(&models.User{ID: 1, Email: "test@example.com", Name: &name, Handle: &handle}, nil)
+
+	user, err := us.UpdateProfile(1, " Jane Doe ", "$Jane_Doe")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "jane_doe", *user.Handle)
+	mockServiceTestHelper.userRepo.AssertExpectations(t)
+}
+
+func TestUpdateProfile_EmptyValuesClearTheProfile(t *testing.T) {
+	setupServiceMock()
+	us := NewUserService(mockServiceTestHelper.userRepo)
+	mockServiceTestHelper.userRepo.On("UpdateProfile", 1, (*string)(nil), (*string)(nil)).
+		Return(&models.User{ID: 1, Email: "test@example.com"}, nil)
+
+	_, err := us.UpdateProfile(1, "", " ")
+
+	assert.NoError(t, err)
+	mockServiceTestHelper.userRepo.AssertExpectations(t)
+}
+
+func TestUpdateProfile_InvalidValues(t *testing.T) {
+	setupServiceMock()
+	us := NewUserService(mockServiceTestHelper.userRepo)
+
+	for _, handle := range []string{"ab", "$jane doe", "jané", "$" + strings.Repeat("a", 31)} {
+		_, err := us.UpdateProfile(1, "", handle)
+		assert.Equal(t, utils.ServiceErrInvalidHandle, err, handle)
+	}
+
+	_, err := us.UpdateProfile(1, strings.Repeat("a", MaxNameLength+1), "")
+	assert.Equal(t, utils.ServiceErrInvalidName, err)
+	mockServiceTestHelper.userRepo.AssertNotCalled(t, "UpdateProfile", mock.Anything, mock.Anything, mock.Anything)
+}
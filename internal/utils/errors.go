@@ -44,14 +44,96 @@ var (
 	ErrInvalidRequest       = NewAppError(400, "Invalid request data", nil)
 	ErrEmailAlreadyInUse    = NewAppError(400, "Email already in use", nil)
 	ErrUserNotFound         = NewAppError(400, "User not found", nil)
-	ErrWalletAlreadyExists  = NewAppError(400, "wallet already exists for this user", nil)
+	ErrInvalidHandle        = NewAppError(400, "Invalid handle, must be 3 to 30 letters, digits or underscores", nil)
+	ErrInvalidName          = NewAppError(400, "Invalid name, must be at most 100 characters", nil)
+	ErrHandleTaken          = NewAppError(409, "Handle is already taken", nil)
+	ErrWalletNameTaken      = NewAppError(409, "A wallet with this name already exists", nil)
+	ErrInvalidWalletName    = NewAppError(400, "Invalid wallet name, must be 1 to 50 characters", nil)
+	ErrTransferToSameWallet = NewAppError(400, "Cannot transfer to the same wallet", nil)
 	ErrWalletNotFound       = NewAppError(400, "Wallet not found", nil)
 	ErrorWalletNumber       = NewAppError(400, "Invalid wallet number", nil)
 	ErrorInvalidOrder       = NewAppError(400, "Invalid order, must be 'asc' or 'desc'", nil)
 	ErrorInvalidLimit       = NewAppError(400, "Invalid limit, must be between 1 and 100", nil)
 	ErrorInvalidOffset      = NewAppError(400, "Invalid offset, must be a non-negative integer", nil)
+	ErrorInvalidCursor      = NewAppError(400, "Invalid cursor, must be a next_cursor or prev_cursor returned for the same order and cannot be combined with offset", nil)
 	ErrorInsufficientFunds  = NewAppError(400, "Insufficient funds", nil)
 
+	ErrInvalidAmountPrecision   = NewAppError(400, "Invalid amount, too many decimal places for the currency", nil)
+	ErrUnsupportedCurrency      = NewAppError(400, "Unsupported currency, must be a supported ISO 4217 code", nil)
+	ErrCurrencyMismatch         = NewAppError(400, "Amount currency does not match the wallet currency", nil)
+	ErrNoConversionPath         = NewAppError(400, "Wallets hold different currencies and no conversion is available", nil)
+	ErrAmountTooSmallToConvert  = NewAppError(400, "Amount is too small to convert into the destination currency", nil)
+	ErrorInvalidStatus          = NewAppError(400, "Invalid status, must be 'pending', 'completed', 'failed' or 'reversed'", nil)
+	ErrorInvalidType            = NewAppError(400, "Invalid type, must be 'deposit', 'withdraw', 'transfer', 'reversal' or 'exchange'", nil)
+	ErrorInvalidDirection       = NewAppError(400, "Invalid direction, must be 'incoming' or 'outgoing'", nil)
+	ErrorInvalidDateRange       = NewAppError(400, "Invalid date range, from and to must be dates (YYYY-MM-DD) or RFC 3339 timestamps and from must be before to", nil)
+	ErrorInvalidAmountRange     = NewAppError(400, "Invalid amount range, min_amount and max_amount must not be negative and min_amount must not exceed max_amount", nil)
+	ErrorInvalidCounterparty    = NewAppError(400, "Invalid counterparty, must be a wallet number or an email", nil)
+	ErrorInvalidAsOf            = NewAppError(400, "Invalid as_of, must be a date (YYYY-MM-DD) or an RFC 3339 timestamp", nil)
+	ErrorInvalidStatementFormat = NewAppError(400, "Invalid format, must be 'csv', 'jsonl' or 'ofx'", nil)
+	ErrorInvalidDocumentFormat  = NewAppError(400, "Invalid format, must be 'pdf' or 'html'", nil)
+	ErrorInvalidInterval        = NewAppError(400, "Invalid interval, must be 'day', 'week' or 'month'", nil)
+	ErrorInvalidTop             = NewAppError(400, "Invalid top, must be between 1 and 20", nil)
+
+	ErrInvalidMemo              = NewAppError(400, "Invalid memo, must be at most 255 characters", nil)
+	ErrInvalidExternalReference = NewAppError(400, "Invalid external_reference, must be at most 100 characters", nil)
+	ErrInvalidMetadata          = NewAppError(400, "Invalid metadata, must be a JSON object of at most 4096 bytes", nil)
+
+	ErrInvalidRecipient        = NewAppError(400, "Invalid recipient, must be an email or a $handle", nil)
+	ErrRecipientNotFound       = NewAppError(404, "Recipient not found", nil)
+	ErrTooManyRecipientLookups = NewAppError(429, "Too many recipient lookups, try again later", nil)
+	ErrRecipientRequired       = NewAppError(400, "Exactly one of to and to_wallet_number is required", nil)
+
+	ErrInvalidTransactionID     = NewAppError(400, "Invalid transaction ID", nil)
+	ErrTransactionNotFound      = NewAppError(404, "Transaction not found", nil)
+	ErrTransactionNotReversible = NewAppError(409, "Transaction cannot be reversed", nil)
+	ErrReversalExceedsRemaining = NewAppError(400, "Reversal amount exceeds the amount left to reverse", nil)
+
+	ErrInvalidHoldID        = NewAppError(400, "Invalid hold ID", nil)
+	ErrInvalidHoldExpiry    = NewAppError(400, "Invalid expires_in, must be between 1 second and 30 days", nil)
+	ErrHoldNotFound         = NewAppError(404, "Hold not found", nil)
+	ErrHoldActionNotAllowed = NewAppError(403, "Only the wallet the funds are held for can capture or release a hold", nil)
+	ErrHoldNotActive        = NewAppError(409, "Hold has already been captured, released or expired", nil)
+	ErrHoldExpired          = NewAppError(409, "Hold has expired", nil)
+	ErrHoldOnOwnWallet      = NewAppError(400, "Cannot hold funds for your own wallet", nil)
+	ErrCaptureExceedsHold   = NewAppError(400, "Capture amount exceeds the held amount", nil)
+
+	ErrStatementNotFound = NewAppError(404, "Statement not found", nil)
+
+	ErrInvalidQuoteID       = NewAppError(400, "Invalid quote ID", nil)
+	ErrQuoteNotFound        = NewAppError(404, "Quote not found", nil)
+	ErrQuoteExpired         = NewAppError(409, "Quote has expired, request a new one", nil)
+	ErrQuoteAlreadyExecuted = NewAppError(409, "Quote has already been executed", nil)
+	ErrQuoteSameCurrency    = NewAppError(400, "Wallets hold the same currency, use a transfer instead", nil)
+
+	ErrInvalidTransferIntentID    = NewAppError(400, "Invalid transfer intent ID", nil)
+	ErrTransferIntentNotFound     = NewAppError(404, "Transfer intent not found", nil)
+	ErrTransferIntentExpired      = NewAppError(409, "Transfer intent has expired, preview the transfer again", nil)
+	ErrTransferIntentConfirmed    = NewAppError(409, "Transfer intent has already been confirmed", nil)
+	ErrTransferIntentPriceChanged = NewAppError(409, "Exchange rate has changed since the preview, preview the transfer again", nil)
+
+	ErrInvalidRefreshToken = NewAppError(401, "Invalid refresh token", nil)
+	ErrRefreshTokenExpired = NewAppError(401, "Refresh token expired, log in again", nil)
+	ErrRefreshTokenRevoked = NewAppError(401, "Refresh token has been revoked, log in again", nil)
+
+	ErrSessionNotFound = NewAppError(404, "Session not found", nil)
+	ErrSessionRevoked  = NewAppError(401, "Session has been revoked, log in again", nil)
+
+	ErrTokenRevoked      = NewAppError(401, "Token has been revoked, log in again", nil)
+	ErrUserDisabled      = NewAppError(403, "Account has been disabled", nil)
+	ErrIncorrectPassword = NewAppError(403, "Current password is incorrect", nil)
+
+	ErrInsufficientRole     = NewAppError(403, "Your role does not allow this request", nil)
+	ErrInvalidPathUserID    = NewAppError(400, "Invalid user ID, must be a positive integer", nil)
+	ErrInvalidUserStatus    = NewAppError(400, "Invalid status, must be 'active' or 'disabled'", nil)
+	ErrInvalidUserRole      = NewAppError(400, "Invalid role, must be 'user', 'support', 'admin' or 'auditor'", nil)
+	ErrCannotChangeOwnUser  = NewAppError(403, "Cannot change your own status or role", nil)
+	ErrorInvalidSearchQuery = NewAppError(400, "Invalid q, must be 1 to 100 characters", nil)
+
+	ErrInvalidIdempotencyKey      = NewAppError(400, "Invalid Idempotency-Key header, must be 1 to 255 characters", nil)
+	ErrIdempotencyKeyReused       = NewAppError(409, "Idempotency-Key has already been used with a different request", nil)
+	ErrIdempotencyRequestInFlight = NewAppError(409, "A request with this Idempotency-Key is still being processed", nil)
+
 	// 500 level errors
 	ErrInternalServerError   = NewAppError(500, "Internal server error", nil)
 	ErrDatabaseError         = NewAppError(500, "Database operation failed", nil)
@@ -60,14 +142,92 @@ var (
 
 	// Repository errors
 	RepoErrWalletNotFound    = errors.New("from_wallet_number does not exist")
+	RepoErrWalletNameTaken   = errors.New("wallet name already used by this user")
 	RepoErrUserNotFound      = errors.New("from_user does not exist")
 	RepoErrToUserNotFound    = errors.New("to_user does not exist")
 	RepoErrToWalletNotFound  = errors.New("to_wallet_number does not exist")
 	RepoErrInsufficientFunds = errors.New("insufficient funds")
+	RepoErrCurrencyMismatch  = errors.New("amount currency does not match the wallet currency")
 	RepoErrDatabaseOperation = errors.New("database operation failed")
 	RepoErrTransactionFailed = errors.New("transaction failed")
 
+	RepoErrHandleTaken = errors.New("handle already used by another user")
+
+	RepoErrIdempotencyKeyNotFound = errors.New("idempotency key does not exist")
+	RepoErrIdempotencyClaimLost   = errors.New("idempotency key is no longer held by this request")
+
+	RepoErrTransactionNotFound      = errors.New("transaction does not exist")
+	RepoErrTransactionStatusChanged = errors.New("transaction is no longer in the expected status")
+
+	RepoErrHoldNotFound = errors.New("hold does not exist")
+
+	RepoErrQuoteNotFound = errors.New("quote does not exist")
+
+	RepoErrStatementNotFound = errors.New("statement does not exist")
+
+	RepoErrTransferIntentNotFound = errors.New("transfer intent does not exist")
+
+	RepoErrRefreshTokenNotFound = errors.New("refresh token does not exist")
+
+	RepoErrSessionNotFound = errors.New("session does not exist or has been revoked")
+
 	// Service errors
-	ServiceErrWalletAlreadyExists = errors.New("wallet already exists for this user")
-	ServiceErrWalletNumberNil     = errors.New("either fromWalletNumber or toWalletNumber must be provided")
+	ServiceErrWalletNumberNil      = errors.New("either fromWalletNumber or toWalletNumber must be provided")
+	ServiceErrTransferToSameWallet = errors.New("source and destination wallet are the same")
+	ServiceErrInvalidWalletName    = errors.New("wallet name is empty or too long")
+
+	ServiceErrInvalidHandle          = errors.New("handle is malformed")
+	ServiceErrInvalidName            = errors.New("display name is too long")
+	ServiceErrInvalidRecipient       = errors.New("recipient is neither an email nor a handle")
+	ServiceErrRecipientNotFound      = errors.New("recipient does not exist or has no wallet")
+	ServiceErrRecipientLookupLimited = errors.New("too many recipient lookups")
+
+	ServiceErrUnsupportedCurrency     = errors.New("currency is not supported")
+	ServiceErrCurrencyMismatch        = errors.New("amount currency does not match the wallet currency")
+	ServiceErrNoConversionPath        = errors.New("no conversion path between the wallet currencies")
+	ServiceErrAmountTooSmallToConvert = errors.New("amount converts to nothing in the destination currency")
+
+	ServiceErrUnbalancedEntry       = errors.New("journal entry debits and credits do not balance")
+	ServiceErrLedgerBalanceMismatch = errors.New("wallet balance does not match its ledger postings")
+
+	ServiceErrIdempotencyKeyReused       = errors.New("idempotency key reused with a different request")
+	ServiceErrIdempotencyRequestInFlight = errors.New("idempotency key request still in progress")
+
+	ServiceErrInvalidStatusTransition  = errors.New("transaction status transition is not allowed")
+	ServiceErrTransactionNotReversible = errors.New("transaction cannot be reversed")
+	ServiceErrReversalExceedsRemaining = errors.New("reversal amount exceeds the amount left to reverse")
+
+	ServiceErrInvalidCursor = errors.New("history cursor is malformed or was issued for another order")
+
+	ServiceErrInvalidStatementPeriod = errors.New("statement period must start before it ends")
+	ServiceErrInvalidStatementFormat = errors.New("statement format is not supported")
+	ServiceErrStatementMonthNotOver  = errors.New("statement month has not ended yet")
+
+	ServiceErrInvalidAnalyticsPeriod = errors.New("analytics period must start before it ends")
+
+	ServiceErrHoldNotActive        = errors.New("hold is no longer active")
+	ServiceErrHoldExpired          = errors.New("hold has expired")
+	ServiceErrHoldActionNotAllowed = errors.New("only the payee can capture or release a hold")
+	ServiceErrHoldOnOwnWallet      = errors.New("cannot hold funds for the same wallet")
+	ServiceErrCaptureExceedsHold   = errors.New("capture amount exceeds the held amount")
+	ServiceErrInvalidHoldExpiry    = errors.New("hold expiry is out of range")
+
+	ServiceErrQuoteExpired         = errors.New("quote has expired")
+	ServiceErrQuoteAlreadyExecuted = errors.New("quote has already been executed")
+	ServiceErrQuoteSameCurrency    = errors.New("wallets hold the same currency")
+
+	ServiceErrTransferIntentExpired      = errors.New("transfer intent has expired")
+	ServiceErrTransferIntentConfirmed    = errors.New("transfer intent has already been confirmed")
+	ServiceErrTransferIntentPriceChanged = errors.New("credited amount differs from the previewed one")
+
+	ServiceErrInvalidRefreshToken = errors.New("refresh token is unknown or belongs to another user")
+	ServiceErrRefreshTokenExpired = errors.New("refresh token has expired")
+	ServiceErrRefreshTokenReused  = errors.New("revoked refresh token was presented again")
+
+	ServiceErrSessionRevoked = errors.New("session has been revoked")
+
+	ServiceErrUserDisabled      = errors.New("user account is disabled")
+	ServiceErrIncorrectPassword = errors.New("current password does not match")
+	ServiceErrInvalidUserStatus = errors.New("user status is neither active nor disabled")
+	ServiceErrInvalidUserRole   = errors.New("user role is not a known role")
 )
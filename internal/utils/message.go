@@ -1,13 +1,37 @@
 package utils
 
 var (
-	MsgUserRegistered       = "User registered successfully"
-	MsgLoginSuccessful      = "Login successful"
-	MsgLogoutSuccessful     = "Logged out successfully"
-	MsgDepositSuccessful    = "Deposit successful"
-	MsgWithdrawSuccessful   = "Withdrawal successful"
-	MsgTransferSuccessful   = "Transfer successful"
-	MsgWalletCreated        = "Wallet created successfully"
-	MsgTransactionRetrieved = "Transaction history retrieved successfully"
-	MsgBalanceRetrieved     = "Balance retrieved successfully"
+	MsgUserRegistered             = "User registered successfully"
+	MsgLoginSuccessful            = "Login successful"
+	MsgLogoutSuccessful           = "Logged out successfully"
+	MsgDepositSuccessful          = "Deposit successful"
+	MsgWithdrawSuccessful         = "Withdrawal successful"
+	MsgTransferSuccessful         = "Transfer successful"
+	MsgWalletCreated              = "Wallet created successfully"
+	MsgWalletsRetrieved           = "Wallets retrieved successfully"
+	MsgDefaultWalletUpdated       = "Default wallet updated successfully"
+	MsgTransactionRetrieved       = "Transaction history retrieved successfully"
+	MsgTransactionDetailRetrieved = "Transaction retrieved successfully"
+	MsgBalanceRetrieved           = "Balance retrieved successfully"
+	MsgReversalSuccessful         = "Transaction reversed successfully"
+	MsgHoldCreated                = "Hold created successfully"
+	MsgHoldCaptured               = "Hold captured successfully"
+	MsgHoldReleased               = "Hold released successfully"
+	MsgQuoteCreated               = "Quote created successfully"
+	MsgQuoteExecuted              = "Quote executed successfully"
+	MsgStatementsRetrieved        = "Statements retrieved successfully"
+	MsgAnalyticsRetrieved         = "Analytics retrieved successfully"
+	MsgProfileUpdated             = "Profile updated successfully"
+	MsgRecipientFound             = "Recipient found"
+	MsgTransferPreviewed          = "Transfer previewed successfully"
+	MsgTransferConfirmed          = "Transfer confirmed successfully"
+	MsgTokenRefreshed             = "Token refreshed successfully"
+	MsgSessionsRetrieved          = "Sessions retrieved successfully"
+	MsgSessionRevoked             = "Session revoked successfully"
+	MsgAllSessionsRevoked         = "Logged out of all sessions"
+	MsgPasswordChanged            = "Password changed successfully, log in again"
+	MsgUsersRetrieved             = "Users retrieved successfully"
+	MsgWalletRetrieved            = "Wallet retrieved successfully"
+	MsgUserStatusUpdated          = "User status updated successfully"
+	MsgUserRoleUpdated            = "User role updated successfully"
 )
@@ -0,0 +1,155 @@
+package wallet
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/redis"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/user"
+	"centralized-wallet/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// RecipientLookupLimit is how many recipients a user may resolve by email or handle per window,
+	// so the lookup cannot be used to enumerate who has an account
+	RecipientLookupLimit  = 10
+	RecipientLookupWindow = time.Minute
+)
+
+// RecipientServiceInterface resolves the recipient of a transfer addressed by email or $handle,
+// or describes the owner of a wallet addressed by number
+type RecipientServiceInterface interface {
+	ResolveRecipient(userID int, identifier string) (*models.Recipient, error)
+	ResolveRecipientWallet(userID int, walletNumber string) (*models.Recipient, error)
+}
+
+// RecipientService finds users by email or handle and the default wallet that receives their transfers
+type RecipientService struct {
+	userRepo     user.UserRepositoryInterface
+	walletRepo   WalletRepositoryInterface
+	redisService redis.RedisServiceInterface
+}
+
+// Ensure RecipientService implements RecipientServiceInterface
+var _ RecipientServiceInterface = &RecipientService{}
+
+// NewRecipientService creates a RecipientService. Lookups are rate limited in Redis when redis is not nil.
+func NewRecipientService(userRepo user.UserRepositoryInterface, walletRepo WalletRepositoryInterface, redis redis.RedisServiceInterface) *RecipientService {
+	return &RecipientService{
+		userRepo:     userRepo,
+		walletRepo:   walletRepo,
+		redisService: redis,
+	}
+}
+
+// ResolveRecipient finds the user behind an email or a "$handle" and their default wallet. Every well-formed
+// lookup counts against the caller's RecipientLookupLimit, whether or not the recipient exists.
+func (s *RecipientService) ResolveRecipient(userID int, identifier string) (*models.Recipient, error) {
+	identifier = strings.TrimSpace(identifier)
+	isHandle := strings.HasPrefix(identifier, "$")
+	handle, validHandle := user.NormalizeHandle(identifier)
+	if (isHandle && !validHandle) || (!isHandle && !strings.Contains(identifier, "@")) {
+		return nil, utils.ServiceErrInvalidRecipient
+	}
+
+	if err := s.countLookup(userID); err != nil {
+		return nil, err
+	}
+
+	var found *models.User
+	var err error
+	if isHandle {
+		found, err = s.userRepo.GetUserByHandle(handle)
+	} else {
+		found, err = s.userRepo.GetUserByEmail(identifier)
+	}
+	if err != nil {
+		if errors.Is(err, utils.ErrUserNotFound) {
+			return nil, utils.ServiceErrRecipientNotFound
+		}
+		return nil, err
+	}
+
+	wallet, err := s.walletRepo.GetDefaultWallet(found.ID)
+	if err != nil {
+		if errors.Is(err, utils.RepoErrWalletNotFound) {
+			return nil, utils.ServiceErrRecipientNotFound
+		}
+		return nil, err
+	}
+
+	return newRecipient(found, wallet), nil
+}
+
+// ResolveRecipientWallet describes the owner of a wallet addressed by number. It counts against the same
+// limit as ResolveRecipient, since it reveals who a wallet number belongs to.
+func (s *RecipientService) ResolveRecipientWallet(userID int, walletNumber string) (*models.Recipient, error) {
+	if err := s.countLookup(userID); err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.walletRepo.FindByWalletNumber(walletNumber)
+	if err != nil {
+		if errors.Is(err, utils.RepoErrWalletNotFound) {
+			return nil, utils.ServiceErrRecipientNotFound
+		}
+		return nil, err
+	}
+
+	owner, err := s.userRepo.GetUserByID(wallet.UserID)
+	if err != nil {
+		if errors.Is(err, utils.ErrUserNotFound) {
+			return nil, utils.ServiceErrRecipientNotFound
+		}
+		return nil, err
+	}
+
+	return newRecipient(owner, wallet), nil
+}
+
+// newRecipient builds the masked preview of a user and the wallet that receives their transfers
+func newRecipient(found *models.User, wallet *models.Wallet) *models.Recipient {
+	recipient := &models.Recipient{
+		UserID:       found.ID,
+		WalletNumber: wallet.WalletNumber,
+		Email:        transaction.MaskEmail(found.Email),
+		Currency:     wallet.Currency,
+	}
+	if found.Handle != nil {
+		recipient.Handle = *found.Handle
+	}
+	if found.Name != nil {
+		recipient.Name = MaskName(*found.Name)
+	}
+	return recipient
+}
+
+// countLookup records one lookup for the user and refuses it once the window's limit is reached
+func (s *RecipientService) countLookup(userID int) error {
+	if s.redisService == nil {
+		return nil
+	}
+
+	key := fmt.Sprintf("user:%d:recipient_lookups", userID)
+	count, err := s.redisService.Increment(context.Background(), key, RecipientLookupWindow)
+	if err != nil {
+		return err
+	}
+	if count > RecipientLookupLimit {
+		return utils.ServiceErrRecipientLookupLimited
+	}
+	return nil
+}
+
+// MaskName keeps the first letter of each word of a name, e.g. "Jane Doe" becomes "J*** D***"
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		words[i] = string([]rune(word)[:1]) + "***"
+	}
+	return strings.Join(words, " ")
+}
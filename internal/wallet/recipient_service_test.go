@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	mockRedis "centralized-wallet/tests/mocks/redis"
+	mockUser "centralized-wallet/tests/mocks/user"
+	mockWallet "centralized-wallet/tests/mocks/wallet"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var mockRecipientTestHelper struct {
+	userRepo    *mockUser.MockUserRepository
+	walletRepo  *mockWallet.MockWalletRepository
+	redisClient *mockRedis.MockRedisClient
+}
+
+func setupRecipientServiceMock(lookups int64) *RecipientService {
+	mockRecipientTestHelper.userRepo = new(mockUser.MockUserRepository)
+	mockRecipientTestHelper.walletRepo = new(mockWallet.MockWalletRepository)
+	mockRecipientTestHelper.redisClient = new(mockRedis.MockRedisClient)
+	mockRecipientTestHelper.redisClient.On("Increment", mock.Anything, "user:1:recipient_lookups", RecipientLookupWindow).Return(lookups, nil)
+
+	return NewRecipientService(mockRecipientTestHelper.userRepo, mockRecipientTestHelper.walletRepo, mockRecipientTestHelper.redisClient)
+}
+
+func TestResolveRecipientByHandle(t *testing.T) {
+	rs := setupRecipientServiceMock(1)
+	handle, name := "jane", "Jane Doe"
+	mockRecipientTestHelper.userRepo.On("GetUserByHandle", "jane").
+		Return(&models.User{ID: testToUserID, Email: "jane@example.com", Handle: &handle, Name: &name}, nil)
+	mockRecipientTestHelper.walletRepo.On("GetDefaultWallet", testToUserID).Return(createMockWallet(testToWalletNumber, testToUserID), nil)
+
+	recipient, err := rs.ResolveRecipient(testUserID, " $Jane ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, testToWalletNumber, recipient.WalletNumber)
+	assert.Equal(t, "jane", recipient.Handle)
+	assert.Equal(t, "J*** D***", recipient.Name)
+	assert.Equal(t, "j***@example.com", recipient.Email)
+	mockRecipientTestHelper.userRepo.AssertExpectations(t)
+}
+
+func TestResolveRecipientByEmail(t *testing.T) {
+	rs := setupRecipientServiceMock(1)
+	mockRecipientTestHelper.userRepo.On("GetUserByEmail", "jane@example.com").
+		Return(&models.User{ID: testToUserID, Email: "jane@example.com"}, nil)
+	mockRecipientTestHelper.walletRepo.On("GetDefaultWallet", testToUserID).Return(createMockWallet(testToWalletNumber, testToUserID), nil)
+
+	recipient, err := rs.ResolveRecipient(testUserID, "jane@example.com")
+
+	assert.NoError(t, err)
+	assert.Equal(t, testToWalletNumber, recipient.WalletNumber)
+	assert.Empty(t, recipient.Name)
+}
+
+func TestResolveRecipientNotFound(t *testing.T) {
+	t.Run("no such user", func(t *testing.T) {
+		rs := setupRecipientServiceMock(1)
+		mockRecipientTestHelper.userRepo.On("GetUserByHandle", "ghost").Return(nil, utils.ErrUserNotFound)
+
+		_, err := rs.ResolveRecipient(testUserID, "$ghost")
+
+		assert.Equal(t, utils.ServiceErrRecipientNotFound, err)
+	})
+
+	t.Run("user without a wallet", func(t *testing.T) {
+		rs := setupRecipientServiceMock(1)
+		mockRecipientTestHelper.userRepo.On("GetUserByEmail", "new@example.com").Return(&models.User{ID: 9, Email: "new@example.com"}, nil)
+		mockRecipientTestHelper.walletRepo.On("GetDefaultWallet", 9).Return(nil, utils.RepoErrWalletNotFound)
+
+		_, err := rs.ResolveRecipient(testUserID, "new@example.com")
+
+		assert.Equal(t, utils.ServiceErrRecipientNotFound, err)
+	})
+}
+
+func TestResolveRecipientRefusesMalformedIdentifiersWithoutCounting(t *testing.T) {
+	rs := setupRecipientServiceMock(1)
+
+	for _, identifier := range []string{"", "jane", "$j", "$jane doe"} {
+		_, err := rs.ResolveRecipient(testUserID, identifier)
+		assert.Equal(t, utils.ServiceErrInvalidRecipient, err, identifier)
+	}
+	mockRecipientTestHelper.redisClient.AssertNotCalled(t, "Increment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResolveRecipientIsRateLimited(t *testing.T) {
+	rs := setupRecipientServiceMock(RecipientLookupLimit + 1)
+
+	_, err := rs.ResolveRecipient(testUserID, "jane@example.com")
+
+	assert.Equal(t, utils.ServiceErrRecipientLookupLimited, err)
+	mockRecipientTestHelper.userRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything)
+}
+
+func TestMaskName(t *testing.T) {
+	assert.Equal(t, "J*** D***", MaskName("Jane Doe"))
+	assert.Equal(t, "É***", MaskName("  Élodie "))
+	assert.Equal(t, "", MaskName(""))
+}
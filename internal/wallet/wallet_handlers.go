@@ -1,15 +1,84 @@
 package wallet
 
 import (
+	"centralized-wallet/internal/idempotency"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"centralized-wallet/internal/transaction"
 	"centralized-wallet/internal/utils"
-
+	"encoding/json"
+	"errors"
+	"io"
+	"net/mail"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
 
-// BalanceHandler returns the wallet balance of the authenticated user
+// ListWalletsHandler returns every wallet of the authenticated user, the default wallet first
+func ListWalletsHandler(ws WalletServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from context (already set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		wallets, err := ws.ListWallets(userID.(int))
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[ListWalletsHandler] Error listing wallets")
+			return
+		}
+
+		items := make([]gin.H, 0, len(wallets))
+		for _, wallet := range wallets {
+			items = append(items, walletSummary(&wallet))
+		}
+
+		utils.SuccessResponse(c, utils.MsgWalletsRetrieved, gin.H{"wallets": items})
+	}
+}
+
+// SetDefaultWalletHandler makes one of the authenticated user's wallets the default
+func SetDefaultWalletHandler(ws WalletServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		var request struct {
+			WalletNumber string `json:"wallet_number" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+			return
+		}
+
+		wallet, err := ws.SetDefaultWallet(userID.(int), request.WalletNumber)
+		if err != nil {
+			switch err {
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[SetDefaultWalletHandler] Error setting default wallet")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgDefaultWalletUpdated, walletSummary(wallet))
+	}
+}
+
+// BalanceHandler returns the wallet balance of the authenticated user.
+// The wallet_number query parameter picks the wallet; without it the default wallet is used.
+// With as_of the balance at that point in time is returned instead, without held funds.
 func BalanceHandler(ws WalletServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from context (already set by JWTMiddleware)
@@ -20,8 +89,37 @@ func BalanceHandler(ws WalletServiceInterface) gin.HandlerFunc {
 			return
 		}
 
+		// A past balance is read from the ledger instead of the wallet row
+		if value := c.Query("as_of"); value != "" {
+			asOf, err := parseAsOf(value)
+			if err != nil {
+				utils.ErrorResponse(c, utils.ErrorInvalidAsOf, nil, "")
+				return
+			}
+
+			wallet, balance, err := ws.GetBalanceAsOf(userID.(int), c.Query("wallet_number"), asOf)
+			if err != nil {
+				switch err {
+				case utils.RepoErrWalletNotFound:
+					utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+				default:
+					utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[BalanceHandler] Error getting balance as of "+value)
+				}
+				return
+			}
+
+			utils.SuccessResponse(c, utils.MsgBalanceRetrieved, gin.H{
+				"wallet_number": wallet.WalletNumber,
+				"name":          wallet.Name,
+				"currency":      wallet.Currency,
+				"balance":       balance,
+				"as_of":         asOf,
+			})
+			return
+		}
+
 		// Fetch balance from the WalletService
-		wallet, err := ws.GetWalletByUserID(userID.(int))
+		wallet, err := ws.GetWallet(userID.(int), c.Query("wallet_number"))
 		if err != nil {
 			// Handle specific error cases
 			switch err {
@@ -35,14 +133,31 @@ func BalanceHandler(ws WalletServiceInterface) gin.HandlerFunc {
 
 		// Respond with balance
 		utils.SuccessResponse(c, utils.MsgBalanceRetrieved, gin.H{
-			"wallet_number": wallet.WalletNumber,
-			"balance":       wallet.Balance,
-			"updated_at":    wallet.UpdatedAt, // timestamp of last wallet update
+			"wallet_number":     wallet.WalletNumber,
+			"name":              wallet.Name,
+			"currency":          wallet.Currency,
+			"balance":           wallet.Balance,
+			"available_balance": wallet.AvailableBalance(), // balance minus funds reserved by holds
+			"updated_at":        wallet.UpdatedAt,          // timestamp of last wallet update
 		})
 	}
 }
 
-// DepositHandler handles deposit requests and returns the updated balance and updated_at time
+// parseAsOf parses an RFC 3339 timestamp, or a date meaning the end of that day
+func parseAsOf(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// Timestamps are stored with microsecond precision, so this is the last instant of the day
+	return t.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+}
+
+// DepositHandler handles deposit requests and returns the updated balance and updated_at time.
+// wallet_number is optional and defaults to the user's default wallet.
 func DepositHandler(ws WalletServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from the context (set by JWTMiddleware)
@@ -54,19 +169,37 @@ func DepositHandler(ws WalletServiceInterface) gin.HandlerFunc {
 
 		// Parse request body
 		var request struct {
-			Amount float64 `json:"amount" binding:"required,gt=0"`
+			WalletNumber string      `json:"wallet_number"`
+			Amount       json.Number `json:"amount" binding:"required"`
+			Currency     string      `json:"currency"`
+			NoteRequest
 		}
 		if err := c.ShouldBindJSON(&request); err != nil {
 			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
 			return
 		}
 
+		amount, ok := ParseAmount(c, request.Amount, request.Currency)
+		if !ok {
+			return
+		}
+		note, ok := ParseNote(c, request.NoteRequest)
+		if !ok {
+			return
+		}
+		// The DB transaction moving the money marks the request's Idempotency-Key committed
+		note.Idempotency = idempotency.TakeClaim(c)
+
 		// Perform the deposit and get the updated Wallet struct
-		wallet, err := ws.Deposit(userID.(int), request.Amount)
+		wallet, err := ws.Deposit(userID.(int), request.WalletNumber, amount, note)
 		if err != nil {
 			switch err {
 			case utils.RepoErrWalletNotFound:
 				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			case utils.ServiceErrCurrencyMismatch:
+				utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+			case utils.ServiceErrIdempotencyRequestInFlight:
+				utils.ErrorResponse(c, utils.ErrIdempotencyRequestInFlight, nil, "")
 			default:
 				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[DepositHandler] Error depositing amount")
 			}
@@ -81,7 +214,8 @@ func DepositHandler(ws WalletServiceInterface) gin.HandlerFunc {
 	}
 }
 
-// WithdrawHandler handles withdraw requests and returns the updated balance and updated_at time
+// WithdrawHandler handles withdraw requests and returns the updated balance and updated_at time.
+// wallet_number is optional and defaults to the user's default wallet.
 func WithdrawHandler(ws WalletServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from the context (set by JWTMiddleware)
@@ -93,21 +227,41 @@ func WithdrawHandler(ws WalletServiceInterface) gin.HandlerFunc {
 
 		// Parse the request body
 		var request struct {
-			Amount float64 `json:"amount" binding:"required,gt=0"`
+			WalletNumber string      `json:"wallet_number"`
+			Amount       json.Number `json:"amount" binding:"required"`
+			Currency     string      `json:"currency"`
+			NoteRequest
 		}
 		if err := c.ShouldBindJSON(&request); err != nil {
 			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
 			return
 		}
 
+		amount, ok := ParseAmount(c, request.Amount, request.Currency)
+		if !ok {
+			return
+		}
+		note, ok := ParseNote(c, request.NoteRequest)
+		if !ok {
+			return
+		}
+		// The DB transaction moving the money marks the request's Idempotency-Key committed
+		note.Idempotency = idempotency.TakeClaim(c)
+
 		// Perform the withdrawal and get the updated Wallet struct
-		wallet, err := ws.Withdraw(userID.(int), request.Amount)
+		wallet, err := ws.Withdraw(userID.(int), request.WalletNumber, amount, note)
 		if err != nil {
 			switch err {
 			case utils.RepoErrUserNotFound:
 				utils.ErrorResponse(c, utils.ErrUserNotFound, nil, "")
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
 			case utils.RepoErrInsufficientFunds:
 				utils.ErrorResponse(c, utils.ErrorInsufficientFunds, nil, "")
+			case utils.ServiceErrCurrencyMismatch:
+				utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+			case utils.ServiceErrIdempotencyRequestInFlight:
+				utils.ErrorResponse(c, utils.ErrIdempotencyRequestInFlight, nil, "")
 			default:
 				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[WithdrawHandler] Error withdrawing amount")
 			}
@@ -116,13 +270,17 @@ func WithdrawHandler(ws WalletServiceInterface) gin.HandlerFunc {
 
 		// Return structured success response
 		utils.SuccessResponse(c, utils.MsgWithdrawSuccessful, gin.H{
-			"balance":    wallet.Balance,
-			"updated_at": wallet.UpdatedAt, // last updated time
+			"balance":           wallet.Balance,
+			"available_balance": wallet.AvailableBalance(),
+			"updated_at":        wallet.UpdatedAt, // last updated time
 		})
 	}
 }
 
-func TransferHandler(ws WalletServiceInterface) gin.HandlerFunc {
+// TransferHandler moves money from one of the authenticated user's wallets to another wallet.
+// from_wallet_number is optional and defaults to the user's default wallet.
+// The recipient is either a wallet number or, in to, an email or $handle resolved to the user's default wallet.
+func TransferHandler(ws WalletServiceInterface, rs RecipientServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the fromUserID from context (set by JWTMiddleware)
 		fromUserID, exists := c.Get("user_id")
@@ -133,16 +291,45 @@ func TransferHandler(ws WalletServiceInterface) gin.HandlerFunc {
 
 		// Parse and validate request payload
 		var request struct {
-			ToWalletNumber string  `json:"to_wallet_number" binding:"required"`
-			Amount         float64 `json:"amount" binding:"required,gt=0"`
+			FromWalletNumber string      `json:"from_wallet_number"`
+			ToWalletNumber   string      `json:"to_wallet_number"`
+			To               string      `json:"to"` // Email or $handle
+			Amount           json.Number `json:"amount" binding:"required"`
+			Currency         string      `json:"currency"`
+			NoteRequest
 		}
 		if err := c.ShouldBindJSON(&request); err != nil {
 			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
 			return
 		}
 
+		amount, ok := ParseAmount(c, request.Amount, request.Currency)
+		if !ok {
+			return
+		}
+		note, ok := ParseNote(c, request.NoteRequest)
+		if !ok {
+			return
+		}
+		// The DB transaction moving the money marks the request's Idempotency-Key committed
+		note.Idempotency = idempotency.TakeClaim(c)
+
+		toWalletNumber := request.ToWalletNumber
+		if (request.To == "") == (toWalletNumber == "") {
+			utils.ErrorResponse(c, utils.ErrRecipientRequired, nil, "")
+			return
+		}
+		if request.To != "" {
+			recipient, err := rs.ResolveRecipient(fromUserID.(int), request.To)
+			if err != nil {
+				recipientErrorResponse(c, err, "[TransferHandler] Error resolving recipient")
+				return
+			}
+			toWalletNumber = recipient.WalletNumber
+		}
+
 		// Perform the transfer operation
-		wallet, err := ws.Transfer(fromUserID.(int), request.ToWalletNumber, request.Amount)
+		wallet, err := ws.Transfer(fromUserID.(int), request.FromWalletNumber, toWalletNumber, amount, note)
 		if err != nil {
 			// Handle specific error cases based on the returned error
 			switch err {
@@ -152,6 +339,16 @@ func TransferHandler(ws WalletServiceInterface) gin.HandlerFunc {
 				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
 			case utils.RepoErrInsufficientFunds:
 				utils.ErrorResponse(c, utils.ErrorInsufficientFunds, nil, "")
+			case utils.ServiceErrTransferToSameWallet:
+				utils.ErrorResponse(c, utils.ErrTransferToSameWallet, nil, "")
+			case utils.ServiceErrCurrencyMismatch:
+				utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+			case utils.ServiceErrNoConversionPath:
+				utils.ErrorResponse(c, utils.ErrNoConversionPath, nil, "")
+			case utils.ServiceErrAmountTooSmallToConvert:
+				utils.ErrorResponse(c, utils.ErrAmountTooSmallToConvert, nil, "")
+			case utils.ServiceErrIdempotencyRequestInFlight:
+				utils.ErrorResponse(c, utils.ErrIdempotencyRequestInFlight, nil, "")
 			default:
 				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[TransferHandler] Error transferring amount")
 			}
@@ -160,13 +357,118 @@ func TransferHandler(ws WalletServiceInterface) gin.HandlerFunc {
 
 		// Success response with the updated wallet balance
 		utils.SuccessResponse(c, utils.MsgTransferSuccessful, gin.H{
-			"balance":    wallet.Balance,
-			"updated_at": wallet.UpdatedAt, // last updated time
+			"balance":           wallet.Balance,
+			"available_balance": wallet.AvailableBalance(),
+			"updated_at":        wallet.UpdatedAt, // last updated time
 		})
 	}
 }
 
-// TransactionHistoryHandler returns the transaction history for the authenticated user
+// RecipientLookupHandler previews who a transfer to an email or $handle would go to, with masked details,
+// so the payer can check it before sending. Lookups are rate limited per user.
+func RecipientLookupHandler(rs RecipientServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		recipient, err := rs.ResolveRecipient(userID.(int), c.Query("to"))
+		if err != nil {
+			recipientErrorResponse(c, err, "[RecipientLookupHandler] Error resolving recipient")
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgRecipientFound, gin.H{"recipient": recipient})
+	}
+}
+
+// recipientErrorResponse writes the error response for a recipient that could not be resolved
+func recipientErrorResponse(c *gin.Context, err error, logMessage string) {
+	switch err {
+	case utils.ServiceErrInvalidRecipient:
+		utils.ErrorResponse(c, utils.ErrInvalidRecipient, nil, "")
+	case utils.ServiceErrRecipientNotFound:
+		utils.ErrorResponse(c, utils.ErrRecipientNotFound, nil, "")
+	case utils.ServiceErrRecipientLookupLimited:
+		utils.ErrorResponse(c, utils.ErrTooManyRecipientLookups, nil, "")
+	default:
+		utils.ErrorResponse(c, utils.ErrInternalServerError, err, logMessage)
+	}
+}
+
+// ReverseTransactionHandler refunds part or all of a transaction received by the authenticated user.
+// The amount is optional; without it the whole amount not yet reversed is refunded.
+func ReverseTransactionHandler(ws WalletServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		transactionID, err := strconv.Atoi(c.Param("id"))
+		if err != nil || transactionID <= 0 {
+			utils.ErrorResponse(c, utils.ErrInvalidTransactionID, nil, "")
+			return
+		}
+
+		// An empty body is allowed and means a full reversal
+		var request struct {
+			Amount   json.Number `json:"amount"`
+			Currency string      `json:"currency"`
+		}
+		if c.Request.Body != nil && c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+				utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+				return
+			}
+		}
+
+		var amount *money.Money
+		if request.Amount != "" {
+			parsed, ok := ParseAmount(c, request.Amount, request.Currency)
+			if !ok {
+				return
+			}
+			amount = &parsed
+		}
+
+		reversal, wallet, err := ws.ReverseTransaction(userID.(int), transactionID, amount)
+		if err != nil {
+			switch err {
+			case utils.RepoErrWalletNotFound:
+				utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			case utils.RepoErrTransactionNotFound:
+				utils.ErrorResponse(c, utils.ErrTransactionNotFound, nil, "")
+			case utils.ServiceErrTransactionNotReversible:
+				utils.ErrorResponse(c, utils.ErrTransactionNotReversible, nil, "")
+			case utils.ServiceErrReversalExceedsRemaining:
+				utils.ErrorResponse(c, utils.ErrReversalExceedsRemaining, nil, "")
+			case utils.ServiceErrCurrencyMismatch:
+				utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+			case utils.RepoErrInsufficientFunds:
+				utils.ErrorResponse(c, utils.ErrorInsufficientFunds, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[ReverseTransactionHandler] Error reversing transaction")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgReversalSuccessful, gin.H{
+			"transaction_id":          reversal.ID,
+			"reverses_transaction_id": transactionID,
+			"amount":                  reversal.Amount,
+			"balance":                 wallet.Balance,
+			"updated_at":              wallet.UpdatedAt,
+		})
+	}
+}
+
+// TransactionHistoryHandler returns the transaction history for the authenticated user.
+// Pages are read by offset, or by cursor when a cursor query parameter is given.
 func TransactionHistoryHandler(ts transaction.TransactionServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the user ID from the context (set by JWT middleware)
@@ -196,6 +498,13 @@ func TransactionHistoryHandler(ts transaction.TransactionServiceInterface) gin.H
 			return
 		}
 
+		// Amount filters are read in the wallet's currency, set by the same middleware
+		walletCurrency := c.GetString("wallet_currency")
+		if walletCurrency == "" {
+			utils.ErrorResponse(c, utils.ErrWalletNotFound, nil, "")
+			return
+		}
+
 		// Parse query parameters for sorting and limiting
 		orderBy := c.DefaultQuery("order", "desc")
 		if orderBy != "asc" && orderBy != "desc" {
@@ -212,6 +521,38 @@ func TransactionHistoryHandler(ts transaction.TransactionServiceInterface) gin.H
 			return
 		}
 
+		filter, ok := parseHistoryFilter(c, walletCurrency)
+		if !ok {
+			return
+		}
+
+		// A cursor query parameter, empty for the first page, switches to keyset pagination
+		if cursor, useCursor := c.GetQuery("cursor"); useCursor {
+			if _, hasOffset := c.GetQuery("offset"); hasOffset {
+				utils.ErrorResponse(c, utils.ErrorInvalidCursor, nil, "")
+				return
+			}
+
+			page, err := ts.GetTransactionHistoryPage(walletNumber, filter, orderBy, limit, cursor)
+			if err != nil {
+				switch err {
+				case utils.ServiceErrInvalidCursor:
+					utils.ErrorResponse(c, utils.ErrorInvalidCursor, nil, "")
+				default:
+					utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[TransactionHistoryHandler] Error getting transaction history page")
+				}
+				return
+			}
+
+			utils.SuccessResponse(c, utils.MsgTransactionRetrieved, gin.H{
+				"wallet_number": walletNumber,
+				"transactions":  page.Transactions,
+				"next_cursor":   page.NextCursor,
+				"prev_cursor":   page.PrevCursor,
+			})
+			return
+		}
+
 		// Offset query parameter
 		offsetStr := c.DefaultQuery("offset", "0")
 		offset, err := strconv.Atoi(offsetStr)
@@ -221,7 +562,7 @@ func TransactionHistoryHandler(ts transaction.TransactionServiceInterface) gin.H
 		}
 
 		// Get the transaction history using the wallet number
-		transactions, err := ts.GetTransactionHistory(walletNumber, orderBy, limit, offset)
+		transactions, err := ts.GetTransactionHistory(walletNumber, filter, orderBy, limit, offset)
 		if err != nil {
 			utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[TransactionHistoryHandler] Error getting transaction history")
 			return
@@ -232,6 +573,159 @@ func TransactionHistoryHandler(ts transaction.TransactionServiceInterface) gin.H
 	}
 }
 
+// TransactionDetailHandler returns one transaction the authenticated user sent or received, with receipt data
+func TransactionDetailHandler(ts transaction.TransactionServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get user ID from the context (set by JWTMiddleware)
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, utils.ErrUnauthorized, nil, "")
+			return
+		}
+
+		transactionID, err := strconv.Atoi(c.Param("id"))
+		if err != nil || transactionID <= 0 {
+			utils.ErrorResponse(c, utils.ErrInvalidTransactionID, nil, "")
+			return
+		}
+
+		detail, err := ts.GetTransactionDetail(userID.(int), transactionID)
+		if err != nil {
+			switch err {
+			case utils.RepoErrTransactionNotFound:
+				utils.ErrorResponse(c, utils.ErrTransactionNotFound, nil, "")
+			default:
+				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[TransactionDetailHandler] Error getting transaction")
+			}
+			return
+		}
+
+		utils.SuccessResponse(c, utils.MsgTransactionDetailRetrieved, gin.H{"transaction": detail})
+	}
+}
+
+// maxCounterpartyLength matches the size of users.email, which is longer than any wallet number
+const maxCounterpartyLength = 255
+
+// parseHistoryFilter reads the optional history filters from the query, writing the error response when one is invalid.
+// from and to take a date or an RFC 3339 timestamp; a date given as to includes that whole day.
+// min_amount and max_amount are read in the wallet's currency; a currency query parameter naming another one is refused.
+func parseHistoryFilter(c *gin.Context, walletCurrency string) (models.TransactionFilter, bool) {
+	filter := models.TransactionFilter{
+		Status:            c.Query("status"),
+		Type:              c.Query("type"),
+		Direction:         c.Query("direction"),
+		Counterparty:      strings.TrimSpace(c.Query("counterparty")),
+		ExternalReference: strings.TrimSpace(c.Query("external_reference")),
+	}
+
+	if filter.Status != "" && !transaction.IsValidStatus(filter.Status) {
+		utils.ErrorResponse(c, utils.ErrorInvalidStatus, nil, "")
+		return filter, false
+	}
+	if filter.Type != "" && !transaction.IsValidType(filter.Type) {
+		utils.ErrorResponse(c, utils.ErrorInvalidType, nil, "")
+		return filter, false
+	}
+	if filter.Direction != "" && !transaction.IsValidDirection(filter.Direction) {
+		utils.ErrorResponse(c, utils.ErrorInvalidDirection, nil, "")
+		return filter, false
+	}
+
+	var err error
+	if filter.CreatedFrom, err = ParseHistoryTime(c.Query("from"), false); err != nil {
+		utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+		return filter, false
+	}
+	if filter.CreatedTo, err = ParseHistoryTime(c.Query("to"), true); err != nil {
+		utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+		return filter, false
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		utils.ErrorResponse(c, utils.ErrorInvalidDateRange, nil, "")
+		return filter, false
+	}
+
+	// Amounts are compared with the wallet's own minor units, so they can only be given in its currency
+	if currency := c.Query("currency"); currency != "" && money.NormalizeCurrency(currency) != walletCurrency {
+		utils.ErrorResponse(c, utils.ErrCurrencyMismatch, nil, "")
+		return filter, false
+	}
+	var ok bool
+	if filter.MinAmount, ok = parseHistoryAmount(c, c.Query("min_amount"), walletCurrency); !ok {
+		return filter, false
+	}
+	if filter.MaxAmount, ok = parseHistoryAmount(c, c.Query("max_amount"), walletCurrency); !ok {
+		return filter, false
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && filter.MinAmount.Amount > filter.MaxAmount.Amount {
+		utils.ErrorResponse(c, utils.ErrorInvalidAmountRange, nil, "")
+		return filter, false
+	}
+
+	// Emails are matched case-insensitively, wallet numbers exactly
+	if strings.Contains(filter.Counterparty, "@") {
+		address, err := mail.ParseAddress(filter.Counterparty)
+		if err != nil || address.Address != filter.Counterparty {
+			utils.ErrorResponse(c, utils.ErrorInvalidCounterparty, nil, "")
+			return filter, false
+		}
+		filter.Counterparty = strings.ToLower(filter.Counterparty)
+	}
+	if len(filter.Counterparty) > maxCounterpartyLength {
+		utils.ErrorResponse(c, utils.ErrorInvalidCounterparty, nil, "")
+		return filter, false
+	}
+	if utf8.RuneCountInString(filter.ExternalReference) > maxExternalReferenceLength {
+		utils.ErrorResponse(c, utils.ErrInvalidExternalReference, nil, "")
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// ParseHistoryTime parses an optional date or RFC 3339 timestamp, nil when empty. When endOfDay is set a plain date
+// moves to the start of the next day, so the exclusive upper bound still covers the whole day.
+func ParseHistoryTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// parseHistoryAmount parses an optional, non-negative amount filter, writing the error response when it is invalid
+func parseHistoryAmount(c *gin.Context, value, currency string) (*money.Money, bool) {
+	if value == "" {
+		return nil, true
+	}
+
+	amount, err := money.Parse(value, currency)
+	switch {
+	case err == money.ErrTooManyDecimals:
+		utils.ErrorResponse(c, utils.ErrInvalidAmountPrecision, nil, "")
+		return nil, false
+	case err == money.ErrUnknownCurrency:
+		utils.ErrorResponse(c, utils.ErrUnsupportedCurrency, nil, "")
+		return nil, false
+	case err != nil || amount.IsNegative():
+		utils.ErrorResponse(c, utils.ErrorInvalidAmountRange, nil, "")
+		return nil, false
+	}
+	return &amount, true
+}
+
+// CreateWalletHandler opens a new wallet for the authenticated user.
+// name and currency are optional; the currency defaults to USD and a user's first wallet becomes the default.
 func CreateWalletHandler(ws WalletServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from the context (set by JWTMiddleware)
@@ -241,12 +735,28 @@ func CreateWalletHandler(ws WalletServiceInterface) gin.HandlerFunc {
 			return
 		}
 
+		// An empty body is allowed and gives the wallet the default name
+		var request struct {
+			Name     string `json:"name"`
+			Currency string `json:"currency"`
+		}
+		if c.Request.Body != nil && c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+				utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+				return
+			}
+		}
+
 		// Create the wallet using the WalletService
-		wallet, err := ws.CreateWallet(userID.(int))
+		wallet, err := ws.CreateWallet(userID.(int), request.Name, request.Currency)
 		if err != nil {
 			switch err {
-			case utils.ErrWalletAlreadyExists:
-				utils.ErrorResponse(c, utils.ErrWalletAlreadyExists, nil, "")
+			case utils.RepoErrWalletNameTaken:
+				utils.ErrorResponse(c, utils.ErrWalletNameTaken, nil, "")
+			case utils.ServiceErrInvalidWalletName:
+				utils.ErrorResponse(c, utils.ErrInvalidWalletName, nil, "")
+			case utils.ServiceErrUnsupportedCurrency:
+				utils.ErrorResponse(c, utils.ErrUnsupportedCurrency, nil, "")
 			default:
 				utils.ErrorResponse(c, utils.ErrInternalServerError, err, "[CreateWalletHandler] Error creating wallet")
 			}
@@ -256,6 +766,87 @@ func CreateWalletHandler(ws WalletServiceInterface) gin.HandlerFunc {
 		// Return structured success response with wallet number
 		utils.SuccessResponse(c, utils.MsgWalletCreated, gin.H{
 			"wallet_number": wallet.WalletNumber,
+			"name":          wallet.Name,
+			"currency":      wallet.Currency,
+			"is_default":    wallet.IsDefault,
 		})
 	}
 }
+
+// walletSummary is how a wallet is shown when listing a user's wallets
+func walletSummary(wallet *models.Wallet) gin.H {
+	return gin.H{
+		"wallet_number":     wallet.WalletNumber,
+		"name":              wallet.Name,
+		"is_default":        wallet.IsDefault,
+		"currency":          wallet.Currency,
+		"balance":           wallet.Balance,
+		"available_balance": wallet.AvailableBalance(),
+		"updated_at":        wallet.UpdatedAt,
+	}
+}
+
+const (
+	maxMemoLength              = 255  // Matches the size of transactions.memo
+	maxExternalReferenceLength = 100  // Matches the size of transactions.external_reference
+	maxMetadataSize            = 4096 // Bytes of the metadata encoded as JSON
+)
+
+// NoteRequest holds the optional memo, external reference and metadata accepted with a deposit, withdrawal or transfer
+type NoteRequest struct {
+	Memo              string          `json:"memo"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          models.Metadata `json:"metadata"`
+}
+
+// ParseNote trims and checks the note of a request, writing the error response when it is invalid.
+// Metadata that is not a JSON object is already refused when the body is bound.
+func ParseNote(c *gin.Context, request NoteRequest) (models.TransactionNote, bool) {
+	note := models.TransactionNote{
+		Memo:              strings.TrimSpace(request.Memo),
+		ExternalReference: strings.TrimSpace(request.ExternalReference),
+		Metadata:          request.Metadata,
+	}
+
+	if utf8.RuneCountInString(note.Memo) > maxMemoLength {
+		utils.ErrorResponse(c, utils.ErrInvalidMemo, nil, "")
+		return note, false
+	}
+	if utf8.RuneCountInString(note.ExternalReference) > maxExternalReferenceLength {
+		utils.ErrorResponse(c, utils.ErrInvalidExternalReference, nil, "")
+		return note, false
+	}
+	if len(note.Metadata) > 0 {
+		encoded, err := json.Marshal(note.Metadata)
+		if err != nil || len(encoded) > maxMetadataSize {
+			utils.ErrorResponse(c, utils.ErrInvalidMetadata, nil, "")
+			return note, false
+		}
+	}
+
+	return note, true
+}
+
+// ParseAmount converts the raw JSON amount into Money in the given currency (USD when empty),
+// writing the error response when it is invalid. The precision allowed depends on the currency.
+func ParseAmount(c *gin.Context, raw json.Number, currency string) (money.Money, bool) {
+	amount, err := money.Parse(raw.String(), currency)
+	if err != nil {
+		switch err {
+		case money.ErrTooManyDecimals:
+			utils.ErrorResponse(c, utils.ErrInvalidAmountPrecision, nil, "")
+		case money.ErrUnknownCurrency:
+			utils.ErrorResponse(c, utils.ErrUnsupportedCurrency, nil, "")
+		default:
+			utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+		}
+		return money.Money{}, false
+	}
+
+	if !amount.IsPositive() {
+		utils.ErrorResponse(c, utils.ErrInvalidRequest, nil, "")
+		return money.Money{}, false
+	}
+
+	return amount, true
+}
@@ -2,16 +2,19 @@ package wallet
 
 import (
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"centralized-wallet/internal/utils"
 
 	"centralized-wallet/tests/testutils"
 	"fmt"
 
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
 )
 
 // Balance Handler Test
@@ -31,9 +34,11 @@ func TestBalanceHandler(t *testing.T) {
 				URL:      testRequest.URL,
 				Method:   testRequest.Method,
 				MockSetup: func() {
-					// Mock successful balance retrieval
+					// Mock successful balance retrieval with part of the balance on hold
 					mockWallet := createMockWallet(testWalletNumber, testUserID)
-					mockHandlerTestHelper.walletService.On("GetWalletByUserID", testUserID).
+					mockWallet.HeldBalance = usd("30.00")
+					mockWallet.Name = "Main"
+					mockHandlerTestHelper.walletService.On("GetWallet", testUserID, "").
 						Return(mockWallet, nil)
 				},
 				MockAssert: func(t *testing.T) {
@@ -41,15 +46,46 @@ func TestBalanceHandler(t *testing.T) {
 				},
 				ExpectedStatus: http.StatusOK,
 				ExpectedEntity: gin.H{
-					"wallet_number": testWalletNumber,
-					"balance":       100.0,
-					"updated_at":    now.Format(time.RFC3339Nano),
+					"wallet_number":     testWalletNumber,
+					"name":              "Main",
+					"currency":          money.DefaultCurrency,
+					"balance":           100.0,
+					"available_balance": 70.0,
+					"updated_at":        now.Format(time.RFC3339Nano),
 				},
 				ExpectedResponseError: nil,
 				ExpectedMessage:       utils.MsgBalanceRetrieved,
 			},
 			userID: testUserID,
 		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Balance of a named wallet",
+				TestType: "success",
+				URL:      testRequest.URL + "?wallet_number=" + testToWalletNumber,
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					savings := createMockWallet(testToWalletNumber, testUserID)
+					savings.Name = "Savings"
+					mockHandlerTestHelper.walletService.On("GetWallet", testUserID, testToWalletNumber).
+						Return(savings, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number":     testToWalletNumber,
+					"name":              "Savings",
+					"currency":          money.DefaultCurrency,
+					"balance":           100.0,
+					"available_balance": 100.0,
+					"updated_at":        now.Format(time.RFC3339Nano),
+				},
+				ExpectedMessage: utils.MsgBalanceRetrieved,
+			},
+			userID: testUserID,
+		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
 				Name:     "Wallet not found",
@@ -58,7 +94,7 @@ func TestBalanceHandler(t *testing.T) {
 				Method:   testRequest.Method,
 				MockSetup: func() {
 					// Mock wallet not found error
-					mockHandlerTestHelper.walletService.On("GetWalletByUserID", testUserID).
+					mockHandlerTestHelper.walletService.On("GetWallet", testUserID, "").
 						Return(nil, utils.RepoErrWalletNotFound)
 				},
 				MockAssert: func(t *testing.T) {
@@ -77,7 +113,7 @@ func TestBalanceHandler(t *testing.T) {
 				Method:   testRequest.Method,
 				MockSetup: func() {
 					// Mock an internal server error
-					mockHandlerTestHelper.walletService.On("GetWalletByUserID", testUserID).
+					mockHandlerTestHelper.walletService.On("GetWallet", testUserID, "").
 						Return(nil, utils.ErrInternalServerError)
 				},
 				MockAssert: func(t *testing.T) {
@@ -88,6 +124,73 @@ func TestBalanceHandler(t *testing.T) {
 			},
 			userID: testUserID,
 		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Balance as of a timestamp",
+				TestType: "success",
+				URL:      testRequest.URL + "?as_of=2024-05-01T12:00:00Z",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					asOf := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+					mockWallet := createMockWallet(testWalletNumber, testUserID)
+					mockWallet.Name = "Main"
+					mockHandlerTestHelper.walletService.On("GetBalanceAsOf", testUserID, "", asOf).
+						Return(mockWallet, usd("42.50"), nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number": testWalletNumber,
+					"name":          "Main",
+					"currency":      money.DefaultCurrency,
+					"balance":       42.5,
+					"as_of":         "2024-05-01T12:00:00Z",
+				},
+				ExpectedMessage: utils.MsgBalanceRetrieved,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Balance as of a date covers the whole day",
+				TestType: "success",
+				URL:      testRequest.URL + "?as_of=2024-05-01",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					endOfDay := time.Date(2024, 5, 1, 23, 59, 59, 999999000, time.UTC)
+					mockHandlerTestHelper.walletService.On("GetBalanceAsOf", testUserID, "", endOfDay).
+						Return(createMockWallet(testWalletNumber, testUserID), usd("10.00"), nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number": testWalletNumber,
+					"name":          "",
+					"currency":      money.DefaultCurrency,
+					"balance":       10.0,
+					"as_of":         "2024-05-01T23:59:59.999999Z",
+				},
+				ExpectedMessage: utils.MsgBalanceRetrieved,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Invalid as_of",
+				TestType:              "error",
+				URL:                   testRequest.URL + "?as_of=yesterday",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidAsOf,
+			},
+			userID: testUserID,
+		},
 	}
 
 	// Iterate over the test cases
@@ -120,10 +223,10 @@ func TestDepositHandler(t *testing.T) {
 				},
 				MockSetup: func() {
 					// Mock successful deposit
-					mockHandlerTestHelper.walletService.On("Deposit", testUserID, testAmount).
+					mockHandlerTestHelper.walletService.On("Deposit", testUserID, "", testAmount, models.TransactionNote{}).
 						Return(&models.Wallet{
 							UserID:    testUserID,
-							Balance:   testAmount + 100.0, // Assume balance is updated after deposit
+							Balance:   usd("150.00"), // Assume balance is updated after deposit
 							UpdatedAt: now,
 						}, nil)
 				},
@@ -132,7 +235,7 @@ func TestDepositHandler(t *testing.T) {
 				},
 				ExpectedStatus: http.StatusOK,
 				ExpectedEntity: gin.H{
-					"balance":    testAmount + 100.0,
+					"balance":    150.0,
 					"updated_at": now.Format(time.RFC3339Nano),
 				},
 				ExpectedResponseError: nil,
@@ -140,6 +243,127 @@ func TestDepositHandler(t *testing.T) {
 			},
 			userID: testUserID,
 		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Deposit into a named wallet",
+				TestType: "success",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"wallet_number": testToWalletNumber,
+					"amount":        testAmount,
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("Deposit", testUserID, testToWalletNumber, testAmount, models.TransactionNote{}).
+						Return(&models.Wallet{
+							UserID:    testUserID,
+							Balance:   usd("50.00"),
+							UpdatedAt: now,
+						}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"balance":    50.0,
+					"updated_at": now.Format(time.RFC3339Nano),
+				},
+				ExpectedMessage: utils.MsgDepositSuccessful,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Deposit in a zero-decimal currency",
+				TestType: "success",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount":   1500,
+					"currency": "JPY",
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("Deposit", testUserID, "", money.MustParse("1500", "JPY"), models.TransactionNote{}).
+						Return(&models.Wallet{
+							UserID:    testUserID,
+							Currency:  "JPY",
+							Balance:   money.MustParse("2500", "JPY"),
+							UpdatedAt: now,
+						}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"balance":    2500.0,
+					"updated_at": now.Format(time.RFC3339Nano),
+				},
+				ExpectedMessage: utils.MsgDepositSuccessful,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Decimals on a zero-decimal currency",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount":   1.5,
+					"currency": "JPY",
+				},
+				MockSetup: func() {},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrInvalidAmountPrecision,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Unsupported currency",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount":   10,
+					"currency": "XYZ",
+				},
+				MockSetup: func() {},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrUnsupportedCurrency,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Amount not in the wallet currency",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount":   10,
+					"currency": "EUR",
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("Deposit", testUserID, "", money.MustParse("10", "EUR"), models.TransactionNote{}).
+						Return(nil, utils.ServiceErrCurrencyMismatch)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrCurrencyMismatch,
+			},
+			userID: testUserID,
+		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
 				Name:     "Wallet not found",
@@ -151,7 +375,7 @@ func TestDepositHandler(t *testing.T) {
 				},
 				MockSetup: func() {
 					// Mock wallet not found error
-					mockHandlerTestHelper.walletService.On("Deposit", testUserID, testAmount).
+					mockHandlerTestHelper.walletService.On("Deposit", testUserID, "", testAmount, models.TransactionNote{}).
 						Return(nil, utils.RepoErrWalletNotFound)
 				},
 				MockAssert: func(t *testing.T) {
@@ -162,6 +386,27 @@ func TestDepositHandler(t *testing.T) {
 			},
 			userID: testUserID,
 		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Idempotency key reclaimed by a retry",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount": testAmount,
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("Deposit", testUserID, "", testAmount, models.TransactionNote{}).
+						Return(nil, utils.ServiceErrIdempotencyRequestInFlight)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusConflict,
+				ExpectedResponseError: utils.ErrIdempotencyRequestInFlight,
+			},
+			userID: testUserID,
+		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
 				Name:     "Invalid request body",
@@ -180,6 +425,42 @@ func TestDepositHandler(t *testing.T) {
 			},
 			userID: testUserID,
 		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Amount with too many decimals",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount": 10.005, // USD only allows two decimals
+				},
+				MockSetup: func() {
+					// Rejected before reaching the service layer
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrInvalidAmountPrecision,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Non-positive amount",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount": -5,
+				},
+				MockSetup: func() {
+					// Rejected before reaching the service layer
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrInvalidRequest,
+			},
+			userID: testUserID,
+		},
 	}
 
 	// Iterate over the test cases
@@ -213,10 +494,10 @@ func TestWithdrawHandler(t *testing.T) {
 				},
 				MockSetup: func() {
 					// Mock successful withdrawal
-					mockHandlerTestHelper.walletService.On("Withdraw", testUserID, testAmount).
+					mockHandlerTestHelper.walletService.On("Withdraw", testUserID, "", testAmount, models.TransactionNote{}).
 						Return(&models.Wallet{
 							UserID:    testUserID,
-							Balance:   50.0,
+							Balance:   usd("50.00"),
 							UpdatedAt: now,
 						}, nil)
 				},
@@ -225,8 +506,9 @@ func TestWithdrawHandler(t *testing.T) {
 				},
 				ExpectedStatus: http.StatusOK,
 				ExpectedEntity: gin.H{
-					"balance":    50.0,
-					"updated_at": now.Format(time.RFC3339Nano),
+					"balance":           50.0,
+					"available_balance": 50.0,
+					"updated_at":        now.Format(time.RFC3339Nano),
 				},
 				ExpectedResponseError: nil,
 				ExpectedMessage:       utils.MsgWithdrawSuccessful,
@@ -244,7 +526,7 @@ func TestWithdrawHandler(t *testing.T) {
 				},
 				MockSetup: func() {
 					// Mock user not found error
-					mockHandlerTestHelper.walletService.On("Withdraw", testUserID, testAmount).
+					mockHandlerTestHelper.walletService.On("Withdraw", testUserID, "", testAmount, models.TransactionNote{}).
 						Return(nil, utils.RepoErrUserNotFound)
 				},
 				MockAssert: func(t *testing.T) {
@@ -266,7 +548,7 @@ func TestWithdrawHandler(t *testing.T) {
 				},
 				MockSetup: func() {
 					// Mock insufficient funds error
-					mockHandlerTestHelper.walletService.On("Withdraw", testUserID, testAmount).
+					mockHandlerTestHelper.walletService.On("Withdraw", testUserID, "", testAmount, models.TransactionNote{}).
 						Return(nil, utils.RepoErrInsufficientFunds)
 				},
 				MockAssert: func(t *testing.T) {
@@ -329,7 +611,7 @@ func TestTransferHandler(t *testing.T) {
 				ExpectedResponseError: utils.ErrWalletNotFound,
 				MockSetup: func() {
 					// Mock Transfer with user existence failure
-					mockHandlerTestHelper.walletService.On("Transfer", testUserID, testToWalletNumber, 50.0).
+					mockHandlerTestHelper.walletService.On("Transfer", testUserID, "", testToWalletNumber, testAmount, models.TransactionNote{}).
 						Return((*models.Wallet)(nil), utils.RepoErrWalletNotFound)
 				},
 				MockAssert: func(t *testing.T) {
@@ -351,7 +633,7 @@ func TestTransferHandler(t *testing.T) {
 				ExpectedResponseError: utils.ErrUserNotFound,
 				MockSetup: func() {
 					// Mock Transfer with from_user_id failure
-					mockHandlerTestHelper.walletService.On("Transfer", testUserID, testToWalletNumber, 50.0).
+					mockHandlerTestHelper.walletService.On("Transfer", testUserID, "", testToWalletNumber, testAmount, models.TransactionNote{}).
 						Return((*models.Wallet)(nil), utils.RepoErrUserNotFound)
 				},
 				MockAssert: func(t *testing.T) {
@@ -372,10 +654,10 @@ func TestTransferHandler(t *testing.T) {
 				},
 				MockSetup: func() {
 					// Mock successful transfer
-					mockHandlerTestHelper.walletService.On("Transfer", testUserID, testToWalletNumber, 50.0).
+					mockHandlerTestHelper.walletService.On("Transfer", testUserID, "", testToWalletNumber, testAmount, models.TransactionNote{}).
 						Return(&models.Wallet{
 							UserID:    testUserID,
-							Balance:   100.0,
+							Balance:   usd("100.00"),
 							UpdatedAt: now,
 						}, nil)
 				},
@@ -387,107 +669,796 @@ func TestTransferHandler(t *testing.T) {
 				ExpectedResponseError: nil,
 				ExpectedMessage:       utils.MsgTransferSuccessful,
 				ExpectedEntity: gin.H{
-					"balance":    100.0,
-					"updated_at": now.Format(time.RFC3339Nano),
+					"balance":           100.0,
+					"available_balance": 100.0,
+					"updated_at":        now.Format(time.RFC3339Nano),
 				},
 			},
 			userID: testUserID,
 		},
-	}
-
-	// Iterate over the test cases
-	for _, tc := range testCases {
-		t.Run(tc.Name, func(t *testing.T) {
-			walletHandlerTestFlow(tc, t)
-		})
-	}
-}
-
-func TestCreateWalletHandler(t *testing.T) {
-
-	testRequest := testutils.TestHandlerRequest{
-		Method: "POST",
-		URL:    "/wallets/create",
-	}
-
-	// Define the test cases
-	testCases := []testWalletHandler{
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:     "successful wallet creation",
+				Name:     "Transfer from a named wallet",
 				TestType: "success",
 				URL:      testRequest.URL,
 				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"from_wallet_number": testFromWalletNumber,
+					"to_wallet_number":   testToWalletNumber,
+					"amount":             50.0,
+				},
 				MockSetup: func() {
-					// Mock wallet creation success
-					mockHandlerTestHelper.walletService.On("CreateWallet", testUserID).
+					mockHandlerTestHelper.walletService.On("Transfer", testUserID, testFromWalletNumber, testToWalletNumber, testAmount, models.TransactionNote{}).
 						Return(&models.Wallet{
-							WalletNumber: testWalletNumber,
-							UserID:       testUserID,
-							Balance:      0.0,
-							UpdatedAt:    time.Now(),
+							UserID:    testUserID,
+							Balance:   usd("25.00"),
+							UpdatedAt: now,
 						}, nil)
 				},
 				MockAssert: func(t *testing.T) {
 					mockHandlerTestHelper.walletService.AssertExpectations(t)
 				},
 				ExpectedStatus:  http.StatusOK,
-				ExpectedMessage: utils.MsgWalletCreated,
+				ExpectedMessage: utils.MsgTransferSuccessful,
 				ExpectedEntity: gin.H{
-					"wallet_number": testWalletNumber,
+					"balance":           25.0,
+					"available_balance": 25.0,
+					"updated_at":        now.Format(time.RFC3339Nano),
 				},
-				ExpectedResponseError: nil,
 			},
 			userID: testUserID,
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:     "user already has a wallet",
+				Name:     "Transfer to the same wallet",
 				TestType: "error",
 				URL:      testRequest.URL,
 				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"from_wallet_number": testToWalletNumber,
+					"to_wallet_number":   testToWalletNumber,
+					"amount":             50.0,
+				},
 				MockSetup: func() {
-					// Mock wallet already exists error
-					mockHandlerTestHelper.walletService.On("CreateWallet", testUserID).
-						Return(nil, utils.ErrWalletAlreadyExists)
+					mockHandlerTestHelper.walletService.On("Transfer", testUserID, testToWalletNumber, testToWalletNumber, testAmount, models.TransactionNote{}).
+						Return(nil, utils.ServiceErrTransferToSameWallet)
 				},
 				MockAssert: func(t *testing.T) {
 					mockHandlerTestHelper.walletService.AssertExpectations(t)
 				},
-				ExpectedStatus:        http.StatusConflict,
-				ExpectedResponseError: utils.ErrWalletAlreadyExists,
+				ExpectedResponseError: utils.ErrTransferToSameWallet,
 			},
 			userID: testUserID,
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:     "unknown internal error",
-				TestType: "error",
+				Name:     "Transfer with a memo, external reference and metadata",
+				TestType: "success",
 				URL:      testRequest.URL,
 				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"to_wallet_number":   testToWalletNumber,
+					"amount":             50.0,
+					"memo":               "  Dinner on Friday ",
+					"external_reference": "order-1042",
+					"metadata":           map[string]interface{}{"channel": "mobile"},
+				},
 				MockSetup: func() {
-					// Mock unknown error
-					mockHandlerTestHelper.walletService.On("CreateWallet", testUserID).
-						Return(nil, fmt.Errorf("some random error"))
+					note := models.TransactionNote{
+						Memo:              "Dinner on Friday",
+						ExternalReference: "order-1042",
+						Metadata:          models.Metadata{"channel": "mobile"},
+					}
+					mockHandlerTestHelper.walletService.On("Transfer", testUserID, "", testToWalletNumber, testAmount, note).
+						Return(&models.Wallet{
+							UserID:    testUserID,
+							Balance:   usd("25.00"),
+							UpdatedAt: now,
+						}, nil)
 				},
 				MockAssert: func(t *testing.T) {
 					mockHandlerTestHelper.walletService.AssertExpectations(t)
 				},
-				ExpectedStatus:        http.StatusInternalServerError,
-				ExpectedResponseError: utils.ErrInternalServerError,
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgTransferSuccessful,
+				ExpectedEntity: gin.H{
+					"balance":           25.0,
+					"available_balance": 25.0,
+					"updated_at":        now.Format(time.RFC3339Nano),
+				},
 			},
 			userID: testUserID,
 		},
-	}
-
-	// Iterate over the test cases
-	for _, tc := range testCases {
-		t.Run(tc.Name, func(t *testing.T) {
-			// Call the common test flow handler
-			walletHandlerTestFlow(tc, t)
-		})
-	}
-}
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Memo too long",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"to_wallet_number": testToWalletNumber,
+					"amount":           50.0,
+					"memo":             strings.Repeat("é", 256),
+				},
+				MockSetup: func() {
+					// Rejected before reaching the service layer
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedResponseError: utils.ErrInvalidMemo,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "External reference too long",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"to_wallet_number":   testToWalletNumber,
+					"amount":             50.0,
+					"external_reference": strings.Repeat("x", 101),
+				},
+				MockSetup: func() {
+					// Rejected before reaching the service layer
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedResponseError: utils.ErrInvalidExternalReference,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Metadata that is not an object",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"to_wallet_number": testToWalletNumber,
+					"amount":           50.0,
+					"metadata":         []string{"mobile"},
+				},
+				MockSetup: func() {
+					// Rejected before reaching the service layer
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedResponseError: utils.ErrInvalidRequest,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Metadata too large",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"to_wallet_number": testToWalletNumber,
+					"amount":           50.0,
+					"metadata":         map[string]interface{}{"notes": strings.Repeat("x", 4096)},
+				},
+				MockSetup: func() {
+					// Rejected before reaching the service layer
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedResponseError: utils.ErrInvalidMetadata,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Transfer to a $handle",
+				TestType: "success",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"to":     "$jane",
+					"amount": 50.0,
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.recipientService.On("ResolveRecipient", testUserID, "$jane").
+						Return(&models.Recipient{UserID: testToUserID, WalletNumber: testToWalletNumber, Handle: "jane"}, nil)
+					mockHandlerTestHelper.walletService.On("Transfer", testUserID, "", testToWalletNumber, testAmount, models.TransactionNote{}).
+						Return(&models.Wallet{UserID: testUserID, Balance: usd("50.00"), UpdatedAt: now}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.recipientService.AssertExpectations(t)
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgTransferSuccessful,
+				ExpectedEntity: gin.H{
+					"balance":           50.0,
+					"available_balance": 50.0,
+					"updated_at":        now.Format(time.RFC3339Nano),
+				},
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Transfer to an unknown email",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"to":     "nobody@example.com",
+					"amount": 50.0,
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.recipientService.On("ResolveRecipient", testUserID, "nobody@example.com").
+						Return(nil, utils.ServiceErrRecipientNotFound)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.recipientService.AssertExpectations(t)
+					mockHandlerTestHelper.walletService.AssertNotCalled(t, "Transfer")
+				},
+				ExpectedResponseError: utils.ErrRecipientNotFound,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Both a wallet number and a recipient",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"to":               "$jane",
+					"to_wallet_number": testToWalletNumber,
+					"amount":           50.0,
+				},
+				MockSetup: func() {
+					// Rejected before reaching the service layer
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedResponseError: utils.ErrRecipientRequired,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "No recipient",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount": 50.0,
+				},
+				MockSetup: func() {
+					// Rejected before reaching the service layer
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedResponseError: utils.ErrRecipientRequired,
+			},
+			userID: testUserID,
+		},
+	}
+
+	// Iterate over the test cases
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			walletHandlerTestFlow(tc, t)
+		})
+	}
+}
+
+func TestRecipientLookupHandler(t *testing.T) {
+	testRequest := testutils.TestHandlerRequest{
+		Method: "GET",
+		URL:    "/wallets/recipients",
+	}
+
+	testCases := []testWalletHandler{
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Masked preview of a handle",
+				TestType: "success",
+				URL:      testRequest.URL + "?to=%24Jane",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.recipientService.On("ResolveRecipient", testUserID, "$Jane").
+						Return(&models.Recipient{
+							UserID:       testToUserID,
+							WalletNumber: testToWalletNumber,
+							Handle:       "jane",
+							Name:         "J*** D***",
+							Email:        "j***@example.com",
+							Currency:     money.DefaultCurrency,
+						}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.recipientService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgRecipientFound,
+				ExpectedEntity: gin.H{
+					"recipient": gin.H{
+						"handle":   "jane",
+						"name":     "J*** D***",
+						"email":    "j***@example.com",
+						"currency": money.DefaultCurrency,
+					},
+				},
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Neither an email nor a handle",
+				TestType: "error",
+				URL:      testRequest.URL + "?to=jane",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.recipientService.On("ResolveRecipient", testUserID, "jane").
+						Return(nil, utils.ServiceErrInvalidRecipient)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.recipientService.AssertExpectations(t)
+				},
+				ExpectedResponseError: utils.ErrInvalidRecipient,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Too many lookups",
+				TestType: "error",
+				URL:      testRequest.URL + "?to=jane%40example.com",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.recipientService.On("ResolveRecipient", testUserID, "jane@example.com").
+						Return(nil, utils.ServiceErrRecipientLookupLimited)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.recipientService.AssertExpectations(t)
+				},
+				ExpectedResponseError: utils.ErrTooManyRecipientLookups,
+			},
+			userID: testUserID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			walletHandlerTestFlow(tc, t)
+		})
+	}
+}
+
+func TestReverseTransactionHandler(t *testing.T) {
+
+	testRequest := testutils.TestHandlerRequest{
+		Method: "POST",
+		URL:    "/wallets/transactions/5/reverse",
+	}
+
+	partial := usd("20.00")
+	reversedWallet := &models.Wallet{
+		UserID:    testUserID,
+		Balance:   usd("50.00"),
+		UpdatedAt: now,
+	}
+
+	// Define the test cases
+	testCases := []testWalletHandler{
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Full reversal without a body",
+				TestType: "success",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("ReverseTransaction", testUserID, 5, (*money.Money)(nil)).
+						Return(&models.Transaction{ID: 6, Amount: testAmount}, reversedWallet, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgReversalSuccessful,
+				ExpectedEntity: gin.H{
+					"transaction_id":          6,
+					"reverses_transaction_id": 5,
+					"amount":                  50.0,
+					"balance":                 50.0,
+					"updated_at":              now.Format(time.RFC3339Nano),
+				},
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Partial refund",
+				TestType: "success",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount": 20.0,
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("ReverseTransaction", testUserID, 5, &partial).
+						Return(&models.Transaction{ID: 6, Amount: partial}, reversedWallet, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgReversalSuccessful,
+				ExpectedEntity: gin.H{
+					"transaction_id":          6,
+					"reverses_transaction_id": 5,
+					"amount":                  20.0,
+					"balance":                 50.0,
+					"updated_at":              now.Format(time.RFC3339Nano),
+				},
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Invalid transaction ID",
+				TestType:              "error",
+				URL:                   "/wallets/transactions/abc/reverse",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrInvalidTransactionID,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Transaction not found",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("ReverseTransaction", testUserID, 5, (*money.Money)(nil)).
+						Return(nil, nil, utils.RepoErrTransactionNotFound)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusNotFound,
+				ExpectedResponseError: utils.ErrTransactionNotFound,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Already reversed",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("ReverseTransaction", testUserID, 5, (*money.Money)(nil)).
+						Return(nil, nil, utils.ServiceErrTransactionNotReversible)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusConflict,
+				ExpectedResponseError: utils.ErrTransactionNotReversible,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Receiving wallet lacks funds",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"amount": 20.0,
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("ReverseTransaction", testUserID, 5, &partial).
+						Return(nil, nil, utils.RepoErrInsufficientFunds)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInsufficientFunds,
+			},
+			userID: testUserID,
+		},
+	}
+
+	// Iterate over the test cases
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			walletHandlerTestFlow(tc, t)
+		})
+	}
+}
+
+func TestCreateWalletHandler(t *testing.T) {
+
+	testRequest := testutils.TestHandlerRequest{
+		Method: "POST",
+		URL:    "/wallets/create",
+	}
+
+	// Define the test cases
+	testCases := []testWalletHandler{
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "successful wallet creation",
+				TestType: "success",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					// Mock wallet creation success
+					mockHandlerTestHelper.walletService.On("CreateWallet", testUserID, "", "").
+						Return(&models.Wallet{
+							WalletNumber: testWalletNumber,
+							UserID:       testUserID,
+							Name:         DefaultWalletName,
+							Currency:     money.DefaultCurrency,
+							IsDefault:    true,
+							Balance:      usd("0"),
+							UpdatedAt:    time.Now(),
+						}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgWalletCreated,
+				ExpectedEntity: gin.H{
+					"wallet_number": testWalletNumber,
+					"name":          DefaultWalletName,
+					"currency":      money.DefaultCurrency,
+					"is_default":    true,
+				},
+				ExpectedResponseError: nil,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "named second wallet",
+				TestType: "success",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"name": "Savings",
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("CreateWallet", testUserID, "Savings", "").
+						Return(&models.Wallet{
+							WalletNumber: testToWalletNumber,
+							UserID:       testUserID,
+							Name:         "Savings",
+							Currency:     money.DefaultCurrency,
+							Balance:      usd("0"),
+							UpdatedAt:    time.Now(),
+						}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgWalletCreated,
+				ExpectedEntity: gin.H{
+					"wallet_number": testToWalletNumber,
+					"name":          "Savings",
+					"currency":      money.DefaultCurrency,
+					"is_default":    false,
+				},
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "wallet name already used",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				Body: map[string]interface{}{
+					"name": "Savings",
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("CreateWallet", testUserID, "Savings", "").
+						Return(nil, utils.RepoErrWalletNameTaken)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusConflict,
+				ExpectedResponseError: utils.ErrWalletNameTaken,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "unknown internal error",
+				TestType: "error",
+				URL:      testRequest.URL,
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					// Mock unknown error
+					mockHandlerTestHelper.walletService.On("CreateWallet", testUserID, "", "").
+						Return(nil, fmt.Errorf("some random error"))
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusInternalServerError,
+				ExpectedResponseError: utils.ErrInternalServerError,
+			},
+			userID: testUserID,
+		},
+	}
+
+	// Iterate over the test cases
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			// Call the common test flow handler
+			walletHandlerTestFlow(tc, t)
+		})
+	}
+}
+
+func TestListWalletsHandler(t *testing.T) {
+
+	main := createMockWallet(testWalletNumber, testUserID)
+	main.Name = DefaultWalletName
+	main.IsDefault = true
+	savings := createMockWallet(testToWalletNumber, testUserID)
+	savings.Name = "Savings"
+	savings.HeldBalance = usd("40.00")
+
+	testCases := []testWalletHandler{
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Wallets listed default first",
+				TestType: "success",
+				URL:      "/wallets",
+				Method:   "GET",
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("ListWallets", testUserID).
+						Return([]models.Wallet{*main, *savings}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgWalletsRetrieved,
+				ExpectedEntity: gin.H{
+					"wallets": []gin.H{
+						{
+							"wallet_number":     testWalletNumber,
+							"name":              DefaultWalletName,
+							"is_default":        true,
+							"currency":          money.DefaultCurrency,
+							"balance":           100.0,
+							"available_balance": 100.0,
+							"updated_at":        now.Format(time.RFC3339Nano),
+						},
+						{
+							"wallet_number":     testToWalletNumber,
+							"name":              "Savings",
+							"is_default":        false,
+							"currency":          money.DefaultCurrency,
+							"balance":           100.0,
+							"available_balance": 60.0,
+							"updated_at":        now.Format(time.RFC3339Nano),
+						},
+					},
+				},
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "User without wallets",
+				TestType: "success",
+				URL:      "/wallets",
+				Method:   "GET",
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("ListWallets", testUserID).
+						Return([]models.Wallet{}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgWalletsRetrieved,
+				ExpectedEntity:  gin.H{"wallets": []gin.H{}},
+			},
+			userID: testUserID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			walletHandlerTestFlow(tc, t)
+		})
+	}
+}
+
+func TestSetDefaultWalletHandler(t *testing.T) {
+
+	savings := createMockWallet(testToWalletNumber, testUserID)
+	savings.Name = "Savings"
+	savings.IsDefault = true
+
+	testCases := []testWalletHandler{
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Default wallet changed",
+				TestType: "success",
+				URL:      "/wallets/default",
+				Method:   "POST",
+				Body: map[string]interface{}{
+					"wallet_number": testToWalletNumber,
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("SetDefaultWallet", testUserID, testToWalletNumber).
+						Return(savings, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgDefaultWalletUpdated,
+				ExpectedEntity: gin.H{
+					"wallet_number":     testToWalletNumber,
+					"name":              "Savings",
+					"is_default":        true,
+					"currency":          money.DefaultCurrency,
+					"balance":           100.0,
+					"available_balance": 100.0,
+					"updated_at":        now.Format(time.RFC3339Nano),
+				},
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Wallet of another user",
+				TestType: "error",
+				URL:      "/wallets/default",
+				Method:   "POST",
+				Body: map[string]interface{}{
+					"wallet_number": testToWalletNumber,
+				},
+				MockSetup: func() {
+					mockHandlerTestHelper.walletService.On("SetDefaultWallet", testUserID, testToWalletNumber).
+						Return(nil, utils.RepoErrWalletNotFound)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.walletService.AssertExpectations(t)
+				},
+				ExpectedResponseError: utils.ErrWalletNotFound,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Missing wallet number",
+				TestType:              "error",
+				URL:                   "/wallets/default",
+				Method:                "POST",
+				Body:                  map[string]interface{}{},
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedResponseError: utils.ErrInvalidRequest,
+			},
+			userID: testUserID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			walletHandlerTestFlow(tc, t)
+		})
+	}
+}
 
 func TestTransactionHistoryHandler(t *testing.T) {
 
@@ -499,7 +1470,7 @@ func TestTransactionHistoryHandler(t *testing.T) {
 	formatTransactions := []models.FormattedTransaction{
 		{
 			TransactionType: "deposit",
-			Amount:          100.0,
+			Amount:          usd("100.00"),
 			Direction:       "incoming",
 		},
 		{
@@ -533,7 +1504,7 @@ func TestTransactionHistoryHandler(t *testing.T) {
 				Method:   testRequest.Method,
 				MockSetup: func() {
 					// Mock successful transaction history retrieval
-					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", testFromWalletNumber, "desc", 10, 0).
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", testFromWalletNumber, models.TransactionFilter{}, "desc", 10, 0).
 						Return(formatTransactions, nil)
 				},
 				MockAssert: func(t *testing.T) {
@@ -557,7 +1528,7 @@ func TestTransactionHistoryHandler(t *testing.T) {
 				Method:   testRequest.Method,
 				MockSetup: func() {
 					// Mock no transactions found (empty array)
-					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", testFromWalletNumber, "desc", 10, 0).
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", testFromWalletNumber, models.TransactionFilter{}, "desc", 10, 0).
 						Return([]models.FormattedTransaction{}, nil)
 				},
 				MockAssert: func(t *testing.T) {
@@ -588,6 +1559,338 @@ func TestTransactionHistoryHandler(t *testing.T) {
 			},
 			userID: testUserID,
 		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Filter by status",
+				TestType: "success",
+				URL:      "/wallets/transactions?status=completed",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", testFromWalletNumber, models.TransactionFilter{Status: "completed"}, "desc", 10, 0).
+						Return(formatTransactions, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number": testFromWalletNumber,
+					"transactions":  formatTransactions,
+				},
+				ExpectedResponseError: nil,
+				ExpectedMessage:       utils.MsgTransactionRetrieved,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Invalid query parameters (status)",
+				TestType: "error",
+				URL:      "/wallets/transactions?status=settled",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					// No need to mock service for invalid request
+				},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidStatus,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Filter by type, direction, date range, amount range and counterparty",
+				TestType: "success",
+				URL:      "/wallets/transactions?type=transfer&direction=outgoing&from=2024-05-01&to=2024-05-31&min_amount=10&max_amount=99.50&counterparty=Bob@Example.com",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+					to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+					minAmount := money.MustParse("10.00", "USD")
+					maxAmount := money.MustParse("99.50", "USD")
+					filter := models.TransactionFilter{
+						Type:         "transfer",
+						Direction:    "outgoing",
+						CreatedFrom:  &from,
+						CreatedTo:    &to,
+						MinAmount:    &minAmount,
+						MaxAmount:    &maxAmount,
+						Counterparty: "bob@example.com",
+					}
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", testFromWalletNumber, filter, "desc", 10, 0).
+						Return(formatTransactions, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number": testFromWalletNumber,
+					"transactions":  formatTransactions,
+				},
+				ExpectedResponseError: nil,
+				ExpectedMessage:       utils.MsgTransactionRetrieved,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Amount range of a JPY wallet is read in yen",
+				TestType: "success",
+				URL:      "/wallets/transactions?wallet_number=WAL-JPY&min_amount=100&max_amount=2500",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.redisClient.On("Get", mock.Anything, "user:1:wallets:WAL-JPY").
+						Return(`{"wallet_number":"WAL-JPY","currency":"JPY"}`, nil)
+
+					// ¥100 is 100 minor units, not 10000
+					minAmount := money.New(100, "JPY")
+					maxAmount := money.New(2500, "JPY")
+					filter := models.TransactionFilter{MinAmount: &minAmount, MaxAmount: &maxAmount}
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", "WAL-JPY", filter, "desc", 10, 0).
+						Return(formatTransactions, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number": "WAL-JPY",
+					"transactions":  formatTransactions,
+				},
+				ExpectedMessage: utils.MsgTransactionRetrieved,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Amount range of a BHD wallet is read in dinars",
+				TestType: "success",
+				URL:      "/wallets/transactions?wallet_number=WAL-BHD&min_amount=1.5&currency=bhd",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.redisClient.On("Get", mock.Anything, "user:1:wallets:WAL-BHD").
+						Return(`{"wallet_number":"WAL-BHD","currency":"BHD"}`, nil)
+
+					// BHD has three decimals: 1.5 is 1500 minor units
+					minAmount := money.New(1500, "BHD")
+					filter := models.TransactionFilter{MinAmount: &minAmount}
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", "WAL-BHD", filter, "desc", 10, 0).
+						Return(formatTransactions, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number": "WAL-BHD",
+					"transactions":  formatTransactions,
+				},
+				ExpectedMessage: utils.MsgTransactionRetrieved,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Amount with decimals on a JPY wallet",
+				TestType: "error",
+				URL:      "/wallets/transactions?wallet_number=WAL-JPY&min_amount=1.5",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.redisClient.On("Get", mock.Anything, "user:1:wallets:WAL-JPY").
+						Return(`{"wallet_number":"WAL-JPY","currency":"JPY"}`, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertNotCalled(t, "GetTransactionHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrInvalidAmountPrecision,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Currency other than the wallet's",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?min_amount=10&currency=EUR",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrCurrencyMismatch,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Search by external reference",
+				TestType: "success",
+				URL:      "/wallets/transactions?external_reference=order-1042",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					filter := models.TransactionFilter{ExternalReference: "order-1042"}
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistory", testFromWalletNumber, filter, "desc", 10, 0).
+						Return(formatTransactions, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number": testFromWalletNumber,
+					"transactions":  formatTransactions,
+				},
+				ExpectedMessage: utils.MsgTransactionRetrieved,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Invalid query parameters (type)",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?type=payment",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidType,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Invalid query parameters (direction)",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?direction=sideways",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidDirection,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Invalid query parameters (date)",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?from=yesterday",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidDateRange,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Date range ending before it starts",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?from=2024-05-02&to=2024-05-01",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidDateRange,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Negative amount",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?min_amount=-5",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidAmountRange,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Minimum amount above maximum",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?min_amount=50&max_amount=10",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidAmountRange,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Invalid counterparty email",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?counterparty=bob@",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidCounterparty,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "First page by cursor",
+				TestType: "success",
+				URL:      "/wallets/transactions?cursor=",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistoryPage", testFromWalletNumber, models.TransactionFilter{}, "desc", 10, "").
+						Return(&models.TransactionPage{Transactions: formatTransactions, NextCursor: "next-token"}, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus: http.StatusOK,
+				ExpectedEntity: gin.H{
+					"wallet_number": testFromWalletNumber,
+					"transactions":  formatTransactions,
+					"next_cursor":   "next-token",
+					"prev_cursor":   "",
+				},
+				ExpectedResponseError: nil,
+				ExpectedMessage:       utils.MsgTransactionRetrieved,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Invalid cursor",
+				TestType: "error",
+				URL:      "/wallets/transactions?cursor=garbage",
+				Method:   testRequest.Method,
+				MockSetup: func() {
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionHistoryPage", testFromWalletNumber, models.TransactionFilter{}, "desc", 10, "garbage").
+						Return(nil, utils.ServiceErrInvalidCursor)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidCursor,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Cursor combined with offset",
+				TestType:              "error",
+				URL:                   "/wallets/transactions?cursor=&offset=10",
+				Method:                testRequest.Method,
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrorInvalidCursor,
+			},
+			userID: testUserID,
+		},
 	}
 
 	// Iterate over the test cases
@@ -599,3 +1902,80 @@ func TestTransactionHistoryHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionDetailHandler(t *testing.T) {
+	balanceAfter := usd("150.00")
+	detail := &models.TransactionDetail{
+		ID:               5,
+		TransactionType:  "transfer",
+		Status:           "completed",
+		Direction:        "incoming",
+		Amount:           testAmount,
+		Currency:         "USD",
+		FromWalletNumber: testToWalletNumber,
+		FromEmail:        "b***@example.com",
+		ToWalletNumber:   testFromWalletNumber,
+		ToEmail:          "a***@example.com",
+		CreatedAt:        now,
+		CompletedAt:      &now,
+		WalletNumber:     testFromWalletNumber,
+		BalanceAfter:     &balanceAfter,
+	}
+
+	testCases := []testWalletHandler{
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Transaction of the user",
+				TestType: "success",
+				URL:      "/wallets/transactions/5",
+				Method:   "GET",
+				MockSetup: func() {
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionDetail", testUserID, 5).Return(detail, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus:  http.StatusOK,
+				ExpectedMessage: utils.MsgTransactionDetailRetrieved,
+				ExpectedEntity:  gin.H{"transaction": detail},
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:                  "Invalid transaction ID",
+				TestType:              "error",
+				URL:                   "/wallets/transactions/abc",
+				Method:                "GET",
+				MockSetup:             func() {},
+				MockAssert:            func(t *testing.T) {},
+				ExpectedStatus:        http.StatusBadRequest,
+				ExpectedResponseError: utils.ErrInvalidTransactionID,
+			},
+			userID: testUserID,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:     "Transaction of another user",
+				TestType: "error",
+				URL:      "/wallets/transactions/5",
+				Method:   "GET",
+				MockSetup: func() {
+					mockHandlerTestHelper.transactionSerivce.On("GetTransactionDetail", testUserID, 5).Return(nil, utils.RepoErrTransactionNotFound)
+				},
+				MockAssert: func(t *testing.T) {
+					mockHandlerTestHelper.transactionSerivce.AssertExpectations(t)
+				},
+				ExpectedStatus:        http.StatusNotFound,
+				ExpectedResponseError: utils.ErrTransactionNotFound,
+			},
+			userID: testUserID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			walletHandlerTestFlow(tc, t)
+		})
+	}
+}
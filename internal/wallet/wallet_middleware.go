@@ -4,6 +4,7 @@ import (
 	redisService "centralized-wallet/internal/redis"
 	"centralized-wallet/internal/utils"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -12,7 +13,8 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-// WalletNumberMiddleware fetches the wallet number for the user and adds it to the context, using Redis for caching.
+// WalletNumberMiddleware fetches the wallet number and currency for the user and adds them to the context, using Redis for caching.
+// The wallet can be picked with the wallet_number query parameter; without it the user's default wallet is used.
 func WalletNumberMiddleware(walletService WalletServiceInterface, redisClient redisService.RedisServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the user ID from the context (set by JWT middleware)
@@ -23,8 +25,8 @@ func WalletNumberMiddleware(walletService WalletServiceInterface, redisClient re
 			return
 		}
 
-		// Fetch the wallet number using the helper function
-		walletNumber, err := getWalletNumber(walletService, redisClient, userID.(int))
+		// Fetch the wallet using the helper function
+		wallet, err := getCachedWallet(walletService, redisClient, userID.(int), c.Query("wallet_number"))
 		if err != nil {
 			switch err {
 			case utils.RepoErrWalletNotFound:
@@ -36,45 +38,54 @@ func WalletNumberMiddleware(walletService WalletServiceInterface, redisClient re
 			return
 		}
 
-		// Store the wallet number in the context for further usage
-		c.Set("wallet_number", walletNumber)
+		// Store the wallet number and currency in the context for further usage
+		c.Set("wallet_number", wallet.WalletNumber)
+		c.Set("wallet_currency", wallet.Currency)
 
 		// Proceed with the next middleware or handler
 		c.Next()
 	}
 }
 
-func getWalletNumber(walletService WalletServiceInterface, redisClient redisService.RedisServiceInterface, userID int) (string, error) {
-	userIDStr := fmt.Sprintf("user:%d:wallet_number", userID)
+// cachedWallet is what the middleware caches of a wallet: its number, and its currency for reading amounts in queries
+type cachedWallet struct {
+	WalletNumber string `json:"wallet_number"`
+	Currency     string `json:"currency"`
+}
 
-	// Try to get the wallet number from Redis
-	walletNumber, err := redisClient.Get(context.Background(), userIDStr)
-	if err == redis.Nil {
-		// Fetch wallet number from the database if not found in Redis
-		wallet, err := walletService.GetWalletByUserID(userID)
-		if err != nil {
-			return "", err
-		}
-		walletNumber = wallet.WalletNumber
+func getCachedWallet(walletService WalletServiceInterface, redisClient redisService.RedisServiceInterface, userID int, requested string) (*cachedWallet, error) {
+	// A cached explicit wallet number also records that the wallet belongs to the user
+	userIDStr := DefaultWalletCacheKey(userID)
+	if requested != "" {
+		userIDStr = fmt.Sprintf("user:%d:wallets:%s", userID, requested)
+	}
 
-		// Cache the wallet number in Redis with an expiration time (e.g., 24 hours)
-		if err := redisClient.Set(context.Background(), userIDStr, walletNumber, 24*time.Hour); err != nil {
-			log.Printf("Warning: Failed to cache wallet number in Redis: %v", err)
+	// Try to get the wallet from Redis. Entries cached before the currency was are fetched again.
+	cached, err := redisClient.Get(context.Background(), userIDStr)
+	if err == nil {
+		var wallet cachedWallet
+		if json.Unmarshal([]byte(cached), &wallet) == nil && wallet.Currency != "" {
+			return &wallet, nil
 		}
-	} else if err != nil {
-		// If there's a Redis error, attempt to fetch the wallet number from the DB
+	} else if err != redis.Nil {
 		log.Printf("Warning: Redis error, fetching wallet number from DB: %v", err)
-		wallet, err := walletService.GetWalletByUserID(userID)
-		if err != nil {
-			return "", err
-		}
-		walletNumber = wallet.WalletNumber
+	}
 
-		// Optionally try to cache it in Redis again
-		if err := redisClient.Set(context.Background(), userIDStr, walletNumber, 24*time.Hour); err != nil {
-			log.Printf("Warning: Failed to cache wallet number in Redis after fallback: %v", err)
-		}
+	// Fetch the wallet from the database if not found in Redis
+	found, err := walletService.GetWallet(userID, requested)
+	if err != nil {
+		return nil, err
+	}
+	wallet := &cachedWallet{WalletNumber: found.WalletNumber, Currency: found.Currency}
+
+	// Cache the wallet in Redis with an expiration time (e.g., 24 hours)
+	data, err := json.Marshal(wallet)
+	if err != nil {
+		return nil, err
+	}
+	if err := redisClient.Set(context.Background(), userIDStr, string(data), 24*time.Hour); err != nil {
+		log.Printf("Warning: Failed to cache wallet number in Redis: %v", err)
 	}
 
-	return walletNumber, nil
+	return wallet, nil
 }
@@ -22,7 +22,8 @@ import (
 var (
 	userID            = 1
 	walletNumber      = "WAL-123456"
-	userIDStr         = "user:1:wallet_number"
+	userIDStr         = "user:1:default_wallet_number"
+	cachedWalletJSON  = `{"wallet_number":"WAL-123456","currency":"USD"}`
 	mockWalletService = new(mockWallet.MockWalletService)
 	mockRedisService  = new(mockRedis.MockRedisClient)
 )
@@ -43,7 +44,7 @@ func setupRouterForMiddlewareTest() *gin.Engine {
 	router.GET("/test", func(c *gin.Context) {
 		walletNumber, exists := c.Get("wallet_number")
 		if exists {
-			c.JSON(http.StatusOK, gin.H{"wallet_number": walletNumber})
+			c.JSON(http.StatusOK, gin.H{"wallet_number": walletNumber, "currency": c.GetString("wallet_currency")})
 		} else {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Wallet number not found"})
 		}
@@ -70,6 +71,7 @@ func executeRequest(method, path string) *httptest.ResponseRecorder {
 func TestWalletNumberMiddleware(t *testing.T) {
 	testCases := []struct {
 		name                  string
+		path                  string
 		mockSetup             func()
 		expectedStatus        int
 		expectedErrorResponse *utils.AppError
@@ -77,33 +79,64 @@ func TestWalletNumberMiddleware(t *testing.T) {
 	}{
 		{
 			name: "FetchFromRedis",
+			mockSetup: func() {
+				mockRedisService.On("Get", mock.Anything, userIDStr).Return(cachedWalletJSON, nil)
+			},
+			expectedStatus:       http.StatusOK,
+			expectedResponseBody: `{"wallet_number":"WAL-123456","currency":"USD"}`,
+		},
+		{
+			name: "EntryCachedWithoutCurrencyIsRefetched",
 			mockSetup: func() {
 				mockRedisService.On("Get", mock.Anything, userIDStr).Return(walletNumber, nil)
+				mockWalletService.On("GetWallet", userID, "").Return(&models.Wallet{WalletNumber: walletNumber, Currency: "USD"}, nil)
+				mockRedisService.On("Set", mock.Anything, userIDStr, cachedWalletJSON, 24*time.Hour).Return(nil)
 			},
 			expectedStatus:       http.StatusOK,
-			expectedResponseBody: `{"wallet_number":"WAL-123456"}`,
+			expectedResponseBody: `{"wallet_number":"WAL-123456","currency":"USD"}`,
 		},
 		{
 			name: "FetchFromDatabaseAndCacheIt",
 			mockSetup: func() {
 				// Redis returns nil, so fallback to DB
 				mockRedisService.On("Get", mock.Anything, userIDStr).Return("", redis.Nil)
-				mockWalletService.On("GetWalletByUserID", userID).Return(&models.Wallet{WalletNumber: walletNumber}, nil)
-				mockRedisService.On("Set", mock.Anything, userIDStr, walletNumber, 24*time.Hour).Return(nil)
+				mockWalletService.On("GetWallet", userID, "").Return(&models.Wallet{WalletNumber: walletNumber, Currency: "USD"}, nil)
+				mockRedisService.On("Set", mock.Anything, userIDStr, cachedWalletJSON, 24*time.Hour).Return(nil)
 			},
 			expectedStatus:       http.StatusOK,
-			expectedResponseBody: `{"wallet_number":"WAL-123456"}`,
+			expectedResponseBody: `{"wallet_number":"WAL-123456","currency":"USD"}`,
 		},
 		{
 			name: "DatabaseError",
 			mockSetup: func() {
 				// Redis returns nil, so fallback to DB
 				mockRedisService.On("Get", mock.Anything, userIDStr).Return("", redis.Nil)
-				mockWalletService.On("GetWalletByUserID", userID).Return(nil, errors.New("database error"))
+				mockWalletService.On("GetWallet", userID, "").Return(nil, errors.New("database error"))
 			},
 			expectedStatus:        http.StatusInternalServerError,
 			expectedErrorResponse: utils.ErrInternalServerError,
 		},
+		{
+			name: "ExplicitWalletCachedSeparately",
+			path: "/test?wallet_number=WAL-654321",
+			mockSetup: func() {
+				mockRedisService.On("Get", mock.Anything, "user:1:wallets:WAL-654321").Return("", redis.Nil)
+				mockWalletService.On("GetWallet", userID, "WAL-654321").Return(&models.Wallet{WalletNumber: "WAL-654321", Currency: "JPY"}, nil)
+				mockRedisService.On("Set", mock.Anything, "user:1:wallets:WAL-654321", `{"wallet_number":"WAL-654321","currency":"JPY"}`, 24*time.Hour).Return(nil)
+			},
+			expectedStatus:       http.StatusOK,
+			expectedResponseBody: `{"wallet_number":"WAL-654321","currency":"JPY"}`,
+		},
+		{
+			name: "ExplicitWalletOfAnotherUser",
+			path: "/test?wallet_number=WAL-999999",
+			mockSetup: func() {
+				mockRedisService.On("Get", mock.Anything, "user:1:wallets:WAL-999999").Return("", redis.Nil)
+				mockWalletService.On("GetWallet", userID, "WAL-999999").Return(nil, utils.RepoErrWalletNotFound)
+			},
+			expectedStatus:        http.StatusNotFound,
+			expectedErrorResponse: utils.ErrWalletNotFound,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -113,7 +146,11 @@ func TestWalletNumberMiddleware(t *testing.T) {
 			tt.mockSetup()
 
 			// Execute the request and get the response
-			w := executeRequest("GET", "/test")
+			path := tt.path
+			if path == "" {
+				path = "/test"
+			}
+			w := executeRequest("GET", path)
 
 			if tt.expectedErrorResponse != nil {
 				testutils.AssertAPIErrorResponse(t, w, tt.expectedErrorResponse)
@@ -2,20 +2,30 @@ package wallet
 
 import (
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"centralized-wallet/internal/utils"
 	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// pgUniqueViolation is the Postgres error code raised when a unique index rejects a row
+const pgUniqueViolation = "23505"
+
+// walletColumns lists the wallet columns in the order scanWallet reads them
+const walletColumns = "id, user_id, wallet_number, name, is_default, currency, balance, held_balance, created_at, updated_at"
+
 // WalletRepositoryInterface defines the methods for wallet operations
 type WalletRepositoryInterface interface {
 	Begin() (*sql.Tx, error)
 	Commit(tx *sql.Tx) error
 	Rollback(tx *sql.Tx) error
-	CreateWallet(wallet *models.Wallet) error // Removed transaction
-	GetWalletByUserID(userID int) (*models.Wallet, error)
-	Deposit(tx *sql.Tx, userID int, amount float64) (*models.Wallet, error)
-	Withdraw(tx *sql.Tx, userID int, amount float64) (*models.Wallet, error)
-	UserExists(userID int) (bool, error)
+	CreateWallet(wallet *models.Wallet) error
+	GetDefaultWallet(userID int) (*models.Wallet, error)
+	ListWalletsByUserID(userID int) ([]models.Wallet, error)
+	SetDefaultWallet(tx *sql.Tx, userID int, walletNumber string) error
+	LockWalletByID(tx *sql.Tx, walletID int) (*models.Wallet, error)
 	FindByWalletNumber(walletNumber string) (*models.Wallet, error)
 }
 
@@ -31,17 +41,6 @@ func NewWalletRepository(db *sql.DB) *WalletRepository {
 	return &WalletRepository{db: db}
 }
 
-// Check if a user exists
-func (repo *WalletRepository) UserExists(userID int) (bool, error) {
-	var exists bool
-	query := "SELECT EXISTS(SELECT 1 FROM wallets WHERE user_id = $1)"
-	err := repo.db.QueryRow(query, userID).Scan(&exists)
-	if err != nil {
-		return false, err
-	}
-	return exists, nil
-}
-
 // Begin a transaction
 func (repo *WalletRepository) Begin() (*sql.Tx, error) {
 	return repo.db.Begin()
@@ -57,68 +56,110 @@ func (repo *WalletRepository) Rollback(tx *sql.Tx) error {
 	return tx.Rollback()
 }
 
+// CreateWallet inserts a wallet and sets its ID. The user's first wallet becomes their default.
 func (repo *WalletRepository) CreateWallet(wallet *models.Wallet) error {
-	query := `INSERT INTO wallets (user_id, balance, wallet_number, created_at, updated_at)
-			  VALUES ($1, $2, $3, $4, $5)`
-	_, err := repo.db.Exec(query, wallet.UserID, wallet.Balance, wallet.WalletNumber, wallet.CreatedAt, wallet.UpdatedAt)
-	return err
-}
-
-func (repo *WalletRepository) GetWalletByUserID(userID int) (*models.Wallet, error) {
-	var wallet models.Wallet
-	query := "SELECT id, user_id, wallet_number, balance, created_at, updated_at FROM wallets WHERE user_id = $1"
-	err := repo.db.QueryRow(query, userID).Scan(&wallet.ID, &wallet.UserID, &wallet.WalletNumber, &wallet.Balance, &wallet.CreatedAt, &wallet.UpdatedAt)
+	query := `INSERT INTO wallets (user_id, name, is_default, currency, balance, wallet_number, created_at, updated_at)
+			  VALUES ($1, $2, NOT EXISTS (SELECT 1 FROM wallets WHERE user_id = $1), $3, $4, $5, $6, $7)
+			  RETURNING id, is_default`
+	err := repo.db.QueryRow(query, wallet.UserID, wallet.Name, wallet.Currency, wallet.Balance, wallet.WalletNumber, wallet.CreatedAt, wallet.UpdatedAt).
+		Scan(&wallet.ID, &wallet.IsDefault)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, utils.RepoErrWalletNotFound
+		if isUniqueViolation(err, "idx_wallets_user_name") {
+			return utils.RepoErrWalletNameTaken
 		}
-		return nil, err
+		return err
 	}
-	return &wallet, nil
+	return nil
 }
 
-// Deposit updates the user's balance and returns the updated Wallet struct
-func (repo *WalletRepository) Deposit(tx *sql.Tx, userID int, amount float64) (*models.Wallet, error) {
-	query := "UPDATE wallets SET balance = balance + $1, updated_at = NOW() WHERE user_id = $2 RETURNING id, user_id, balance, wallet_number, created_at, updated_at"
-	row := tx.QueryRow(query, amount, userID)
+// GetDefaultWallet fetches the wallet used when a request does not name one
+func (repo *WalletRepository) GetDefaultWallet(userID int) (*models.Wallet, error) {
+	query := "SELECT " + walletColumns + " FROM wallets WHERE user_id = $1 AND is_default"
+	return scanWallet(repo.db.QueryRow(query, userID))
+}
 
-	// Create a Wallet struct to store the result
-	var updatedWallet models.Wallet
-	err := row.Scan(&updatedWallet.ID, &updatedWallet.UserID, &updatedWallet.Balance, &updatedWallet.WalletNumber, &updatedWallet.CreatedAt, &updatedWallet.UpdatedAt)
+// ListWalletsByUserID returns every wallet of the user, the default first and then by creation
+func (repo *WalletRepository) ListWalletsByUserID(userID int) ([]models.Wallet, error) {
+	query := "SELECT " + walletColumns + " FROM wallets WHERE user_id = $1 ORDER BY is_default DESC, id"
+	rows, err := repo.db.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &updatedWallet, nil
+	wallets := []models.Wallet{}
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, *wallet)
+	}
+	return wallets, rows.Err()
 }
 
-// Withdraw deducts the amount from the user's wallet and returns the updated balance and updated_at time
-func (repo *WalletRepository) Withdraw(tx *sql.Tx, userID int, amount float64) (*models.Wallet, error) {
-	// Withdraw the amount
-	query := "UPDATE wallets SET balance = balance - $1, updated_at = NOW() WHERE user_id = $2 RETURNING balance, wallet_number, updated_at"
-	// row := repo.db.QueryRow(query, amount, userID)
-	row := tx.QueryRow(query, amount, userID)
+// SetDefaultWallet makes the wallet the user's default. The previous default is cleared first
+// because the one-default-per-user index is checked row by row.
+func (repo *WalletRepository) SetDefaultWallet(tx *sql.Tx, userID int, walletNumber string) error {
+	if _, err := tx.Exec("UPDATE wallets SET is_default = FALSE WHERE user_id = $1 AND is_default", userID); err != nil {
+		return err
+	}
 
-	// Fetch updated balance and updated_at
-	var updatedWallet models.Wallet
-	err := row.Scan(&updatedWallet.Balance, &updatedWallet.WalletNumber, &updatedWallet.UpdatedAt)
+	result, err := tx.Exec("UPDATE wallets SET is_default = TRUE WHERE user_id = $1 AND wallet_number = $2", userID, walletNumber)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Return the updated wallet information
-	return &updatedWallet, nil
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.RepoErrWalletNotFound
+	}
+	return nil
+}
+
+// LockWalletByID selects the wallet row with FOR UPDATE so concurrent debits on it are serialized
+func (repo *WalletRepository) LockWalletByID(tx *sql.Tx, walletID int) (*models.Wallet, error) {
+	query := "SELECT " + walletColumns + " FROM wallets WHERE id = $1 FOR UPDATE"
+	return scanWallet(tx.QueryRow(query, walletID))
 }
 
 func (repo *WalletRepository) FindByWalletNumber(walletNumber string) (*models.Wallet, error) {
-	query := "SELECT id, user_id, balance, wallet_number, updated_at FROM wallets WHERE wallet_number = $1"
-	wallet := &models.Wallet{}
-	err := repo.db.QueryRow(query, walletNumber).Scan(&wallet.ID, &wallet.UserID, &wallet.Balance, &wallet.WalletNumber, &wallet.UpdatedAt)
+	query := "SELECT " + walletColumns + " FROM wallets WHERE wallet_number = $1"
+	return scanWallet(repo.db.QueryRow(query, walletNumber))
+}
+
+// scanWallet reads a row selected with walletColumns
+func scanWallet(row interface{ Scan(dest ...any) error }) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := row.Scan(
+		&wallet.ID,
+		&wallet.UserID,
+		&wallet.WalletNumber,
+		&wallet.Name,
+		&wallet.IsDefault,
+		&wallet.Currency,
+		&wallet.Balance,
+		&wallet.HeldBalance,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+	)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, utils.RepoErrWalletNotFound
 		}
 		return nil, err
 	}
-	return wallet, nil
+	// Balances are stored as minor units; their currency comes from the wallet
+	wallet.Balance = money.New(wallet.Balance.Amount, wallet.Currency)
+	wallet.HeldBalance = money.New(wallet.HeldBalance.Amount, wallet.Currency)
+	return &wallet, nil
+}
+
+// isUniqueViolation reports whether the error comes from the named unique index
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == constraint
 }
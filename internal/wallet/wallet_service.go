@@ -1,66 +1,118 @@
 package wallet
 
 import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/ledger"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/redis"
 	"centralized-wallet/internal/transaction"
 	"centralized-wallet/internal/utils"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"unicode/utf8"
 
 	"math/rand"
+	"sort"
 	"time"
 )
 
-// WalletServiceInterface defines the methods for the WalletService
+// DefaultWalletName is the name given to a wallet created without one
+const DefaultWalletName = "Main"
+
+// maxWalletNameLength matches the size of wallets.name
+const maxWalletNameLength = 50
+
+// WalletServiceInterface defines the methods for the WalletService.
+// An empty wallet number always means the user's default wallet.
 type WalletServiceInterface interface {
-	// GetBalance(userID int) (float64, error)
-	UserExists(userID int) (bool, error)
-	Deposit(userID int, amount float64) (*models.Wallet, error)
-	Withdraw(userID int, amount float64) (*models.Wallet, error)
-	Transfer(fromUserID int, toWalletNumber string, amount float64) (*models.Wallet, error)
-	GetWalletByUserID(userID int) (*models.Wallet, error)
-	CreateWallet(userID int) (*models.Wallet, error)
+	ListWallets(userID int) ([]models.Wallet, error)
+	GetWallet(userID int, walletNumber string) (*models.Wallet, error)
+	GetBalanceAsOf(userID int, walletNumber string, asOf time.Time) (*models.Wallet, money.Money, error)
+	CreateWallet(userID int, name, currency string) (*models.Wallet, error)
+	SetDefaultWallet(userID int, walletNumber string) (*models.Wallet, error)
+	Deposit(userID int, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error)
+	Withdraw(userID int, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error)
+	Transfer(userID int, fromWalletNumber, toWalletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error)
+	TransferTx(tx *sql.Tx, userID int, fromWalletNumber, toWalletNumber string, amount money.Money, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, *models.Wallet, error)
+	RecordFailedTransfer(fromWalletNumber, toWalletNumber string, amount money.Money, conversion *fx.Conversion, note models.TransactionNote)
+	ReverseTransaction(userID, transactionID int, amount *money.Money) (*models.Transaction, *models.Wallet, error)
 }
 
-// WalletService handles wallet operations using the repository interface
+// WalletService handles wallet operations using the repository interface.
+// Balances are only ever changed through the ledger service.
 type WalletService struct {
 	walletRepo         WalletRepositoryInterface
+	ledgerService      ledger.LedgerServiceInterface
 	transactionService transaction.TransactionServiceInterface
+	redisService       redis.RedisServiceInterface
+	converter          fx.ConverterInterface
+}
+
+// NewWalletService creates a new WalletService with the provided repository, ledger and transaction service.
+// The redis service is optional and only used to drop the cached default wallet when it changes.
+// The converter is optional too; without it transfers between wallets of different currencies are refused.
+func NewWalletService(walletRepo WalletRepositoryInterface, ledgerService ledger.LedgerServiceInterface, transactionService transaction.TransactionServiceInterface, redis redis.RedisServiceInterface, converter fx.ConverterInterface) *WalletService {
+	return &WalletService{walletRepo: walletRepo, ledgerService: ledgerService, transactionService: transactionService, redisService: redis, converter: converter}
 }
 
-// GetWalletByUserID fetches the wallet by the user ID
-func (ws *WalletService) GetWalletByUserID(userID int) (*models.Wallet, error) {
-	return ws.walletRepo.GetWalletByUserID(userID)
+// ListWallets returns every wallet of the user, the default first
+func (ws *WalletService) ListWallets(userID int) ([]models.Wallet, error) {
+	return ws.walletRepo.ListWalletsByUserID(userID)
 }
 
-// NewWalletService creates a new WalletService with the provided repository
-func NewWalletService(walletRepo WalletRepositoryInterface, transactionService transaction.TransactionServiceInterface) *WalletService {
-	return &WalletService{walletRepo: walletRepo, transactionService: transactionService}
+// GetWallet fetches one of the user's wallets, or the default wallet when walletNumber is empty
+func (ws *WalletService) GetWallet(userID int, walletNumber string) (*models.Wallet, error) {
+	return FindOwnedWallet(ws.walletRepo, userID, walletNumber)
 }
 
-func (ws *WalletService) CreateWallet(userID int) (*models.Wallet, error) {
-	// check the user is already exists
-	userExists, err := ws.UserExists(userID)
+// GetBalanceAsOf returns one of the user's wallets with its balance at a point in time, read from the ledger
+func (ws *WalletService) GetBalanceAsOf(userID int, walletNumber string, asOf time.Time) (*models.Wallet, money.Money, error) {
+	wallet, err := FindOwnedWallet(ws.walletRepo, userID, walletNumber)
 	if err != nil {
-		return nil, err
+		return nil, money.Money{}, err
+	}
+
+	balance, err := ws.ledgerService.GetBalanceAsOf(wallet.WalletNumber, wallet.Currency, asOf)
+	if err != nil {
+		return nil, money.Money{}, err
+	}
+	return wallet, balance, nil
+}
+
+// CreateWallet opens a new named wallet for the user in the given currency, USD when empty.
+// The first wallet a user opens becomes the default.
+func (ws *WalletService) CreateWallet(userID int, name, currency string) (*models.Wallet, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = DefaultWalletName
 	}
-	// if user already exists, return error
-	if userExists {
-		return nil, utils.ErrWalletAlreadyExists
+	if utf8.RuneCountInString(name) > maxWalletNameLength {
+		return nil, utils.ServiceErrInvalidWalletName
+	}
+
+	currency = money.NormalizeCurrency(strings.TrimSpace(currency))
+	if !money.IsSupported(currency) {
+		return nil, utils.ServiceErrUnsupportedCurrency
 	}
 
 	walletNumber := generateUniqueWalletNumber(userID)
 	wallet := &models.Wallet{
 		UserID:       userID,
-		Balance:      0.0,
 		WalletNumber: walletNumber,
+		Name:         name,
+		Currency:     currency,
+		Balance:      money.Zero(currency),
+		HeldBalance:  money.Zero(currency),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	// Call repository to insert wallet in the database using the transaction
-	err = ws.walletRepo.CreateWallet(wallet)
+	// Call repository to insert wallet in the database
+	err := ws.walletRepo.CreateWallet(wallet)
 	if err != nil {
 		return nil, err
 	}
@@ -68,37 +120,57 @@ func (ws *WalletService) CreateWallet(userID int) (*models.Wallet, error) {
 	return wallet, nil
 }
 
-func (ws *WalletService) UserExists(userID int) (bool, error) {
-	return ws.walletRepo.UserExists(userID)
-}
-
-// Deposit adds money to the user's wallet and records the transaction, returning balance and timestamp
-func (ws *WalletService) Deposit(userID int, amount float64) (*models.Wallet, error) {
-	exists, err := ws.walletRepo.UserExists(userID)
+// SetDefaultWallet makes one of the user's wallets the one used when a request names no wallet
+func (ws *WalletService) SetDefaultWallet(userID int, walletNumber string) (*models.Wallet, error) {
+	wallet, err := FindOwnedWallet(ws.walletRepo, userID, walletNumber)
 	if err != nil {
 		return nil, err
 	}
-	if !exists {
-		// Return a specific error if the wallet is not found
-		return nil, utils.RepoErrWalletNotFound
-	}
 
 	tx, err := ws.walletRepo.Begin()
 	if err != nil {
 		return nil, err
 	}
 
-	// Rollback the transaction if an error occurs
 	defer ws.rollBackTxWhenErr(tx, &err)
 
-	// Perform the deposit and get the updated Wallet struct
-	wallet, err := ws.walletRepo.Deposit(tx, userID, amount)
+	if err = ws.walletRepo.SetDefaultWallet(tx, userID, wallet.WalletNumber); err != nil {
+		return nil, err
+	}
+
+	err = ws.walletRepo.Commit(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.invalidateDefaultWalletCache(userID)
+
+	wallet.IsDefault = true
+	return wallet, nil
+}
+
+// Deposit adds money to one of the user's wallets through the ledger, returning balance and timestamp.
+// The note is stored on the recorded transaction.
+func (ws *WalletService) Deposit(userID int, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	checkWallet, err := FindOwnedWallet(ws.walletRepo, userID, walletNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	// Record the deposit transaction
-	err = ws.transactionService.RecordTransaction(tx, nil, &wallet.WalletNumber, "deposit", amount)
+	if err := EnsureWalletCurrency(checkWallet, amount); err != nil {
+		return nil, err
+	}
+
+	tx, err := ws.walletRepo.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback the transaction if an error occurs
+	defer ws.rollBackTxWhenErr(tx, &err)
+
+	// Post the deposit to the ledger, which records the transaction and updates the balance
+	wallet, err := ws.ledgerService.Deposit(tx, checkWallet.WalletNumber, amount, note)
 	if err != nil {
 		return nil, err
 	}
@@ -111,15 +183,15 @@ func (ws *WalletService) Deposit(userID int, amount float64) (*models.Wallet, er
 	return wallet, nil
 }
 
-// Withdraw subtracts money from the user's wallet, records the transaction, and returns updated balance and updated_at time
-func (ws *WalletService) Withdraw(userID int, amount float64) (*models.Wallet, error) {
-	checkWallet, err := ws.walletRepo.GetWalletByUserID(userID)
+// Withdraw subtracts money from one of the user's wallets through the ledger, and returns updated balance and updated_at time
+func (ws *WalletService) Withdraw(userID int, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	checkWallet, err := FindOwnedWallet(ws.walletRepo, userID, walletNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	if checkWallet.Balance < amount {
-		return nil, utils.RepoErrInsufficientFunds
+	if err := EnsureWalletCurrency(checkWallet, amount); err != nil {
+		return nil, err
 	}
 
 	tx, err := ws.walletRepo.Begin()
@@ -129,14 +201,20 @@ func (ws *WalletService) Withdraw(userID int, amount float64) (*models.Wallet, e
 
 	defer ws.rollBackTxWhenErr(tx, &err)
 
-	// Withdraw and get the updated wallet data
-	wallet, err := ws.walletRepo.Withdraw(tx, userID, amount)
+	// Lock the wallet row so the funds check and the debit happen atomically
+	lockedWallets, err := LockWalletsInOrder(ws.walletRepo, tx, checkWallet.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Record the withdrawal transaction
-	err = ws.transactionService.RecordTransaction(tx, &wallet.WalletNumber, nil, "withdraw", amount)
+	if err = EnsureSufficientFunds(lockedWallets[checkWallet.ID].AvailableBalance(), amount); err != nil {
+		ws.walletRepo.Rollback(tx)
+		ws.recordFailed(&checkWallet.WalletNumber, nil, transaction.TypeWithdraw, amount, nil, note)
+		return nil, err
+	}
+
+	// Post the withdrawal to the ledger and get the updated wallet data
+	wallet, err := ws.ledgerService.Withdraw(tx, checkWallet.WalletNumber, amount, note)
 	if err != nil {
 		return nil, err
 	}
@@ -150,53 +228,356 @@ func (ws *WalletService) Withdraw(userID int, amount float64) (*models.Wallet, e
 	return wallet, nil
 }
 
-// Transfer subtracts from one user and adds to another, returning the updated Wallet for the from_user
-func (ws *WalletService) Transfer(fromUserID int, toWalletNumber string, amount float64) (*models.Wallet, error) {
+// Transfer moves money from one of the user's wallets to any other wallet through the ledger,
+// returning the updated source wallet. The destination may be another wallet of the same user.
+func (ws *WalletService) Transfer(userID int, fromWalletNumber, toWalletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	checkWallet, toWallet, err := ws.findTransferWallets(userID, fromWalletNumber, toWalletNumber, amount)
+	if err != nil {
+		return nil, err
+	}
 
-	checkWallet, err := ws.walletRepo.GetWalletByUserID(fromUserID)
+	// Wallets in different currencies are converted at the current rate, the amount being what the sender pays
+	var conversion *fx.Conversion
+	if toWallet.Currency != checkWallet.Currency {
+		conversion, err = PriceConversion(ws.converter, amount, toWallet.Currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := ws.walletRepo.Begin()
 	if err != nil {
-		log.Printf("Error getting wallet balance: %v", err)
 		return nil, err
 	}
 
-	if checkWallet.Balance < amount {
-		return nil, utils.RepoErrInsufficientFunds
+	defer ws.rollBackTxWhenErr(tx, &err)
+
+	_, fromWallet, err := ws.postTransfer(tx, checkWallet, toWallet, amount, conversion, note)
+	if err == utils.RepoErrInsufficientFunds {
+		ws.walletRepo.Rollback(tx)
+		ws.recordFailed(&checkWallet.WalletNumber, &toWallet.WalletNumber, transaction.TypeTransfer, amount, conversion, note)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	toWallet, err := ws.walletRepo.FindByWalletNumber(toWalletNumber)
+	err = ws.walletRepo.Commit(tx)
 	if err != nil {
 		return nil, err
 	}
 
+	return fromWallet, nil
+}
+
+// TransferTx makes the same transfer as Transfer in the caller's DB transaction, so the caller can commit it
+// together with its own changes. It returns the transfer transaction and the updated source wallet.
+// Between wallets of different currencies the caller passes the conversion it priced and checked, which is
+// posted as is; it is nil otherwise. A transfer refused for insufficient funds is not recorded: once tx is
+// rolled back, the caller may record it with RecordFailedTransfer.
+func (ws *WalletService) TransferTx(tx *sql.Tx, userID int, fromWalletNumber, toWalletNumber string, amount money.Money, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, *models.Wallet, error) {
+	fromWallet, toWallet, err := ws.findTransferWallets(userID, fromWalletNumber, toWalletNumber, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The conversion must debit exactly amount and credit the destination's currency
+	if toWallet.Currency != fromWallet.Currency {
+		if conversion == nil || conversion.Source != amount || conversion.Target.Currency != toWallet.Currency {
+			return nil, nil, utils.ServiceErrCurrencyMismatch
+		}
+	} else if conversion != nil {
+		return nil, nil, utils.ServiceErrCurrencyMismatch
+	}
+
+	return ws.postTransfer(tx, fromWallet, toWallet, amount, conversion, note)
+}
+
+// RecordFailedTransfer keeps a transfer refused for insufficient funds as a failed transaction, like Transfer does.
+// It must be called after the DB transaction of the attempt has been rolled back.
+func (ws *WalletService) RecordFailedTransfer(fromWalletNumber, toWalletNumber string, amount money.Money, conversion *fx.Conversion, note models.TransactionNote) {
+	ws.recordFailed(&fromWalletNumber, &toWalletNumber, transaction.TypeTransfer, amount, conversion, note)
+}
+
+// findTransferWallets finds the source wallet of the user and the destination wallet of a transfer of amount
+func (ws *WalletService) findTransferWallets(userID int, fromWalletNumber, toWalletNumber string, amount money.Money) (*models.Wallet, *models.Wallet, error) {
+	fromWallet, err := FindOwnedWallet(ws.walletRepo, userID, fromWalletNumber)
+	if err != nil {
+		log.Printf("Error getting wallet balance: %v", err)
+		return nil, nil, err
+	}
+
+	toWallet, err := ws.walletRepo.FindByWalletNumber(toWalletNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if toWallet.WalletNumber == fromWallet.WalletNumber {
+		return nil, nil, utils.ServiceErrTransferToSameWallet
+	}
+
+	if err := EnsureWalletCurrency(fromWallet, amount); err != nil {
+		return nil, nil, err
+	}
+
+	return fromWallet, toWallet, nil
+}
+
+// postTransfer locks both wallets, checks the source can cover the amount and posts the transfer, converted when
+// a conversion is given, in tx. It returns the transfer transaction and the updated source wallet.
+func (ws *WalletService) postTransfer(tx *sql.Tx, fromWallet, toWallet *models.Wallet, amount money.Money, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, *models.Wallet, error) {
+	// Lock both wallets in a consistent order so opposite transfers cannot deadlock
+	lockedWallets, err := LockWalletsInOrder(ws.walletRepo, tx, fromWallet.ID, toWallet.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := EnsureSufficientFunds(lockedWallets[fromWallet.ID].AvailableBalance(), amount); err != nil {
+		return nil, nil, err
+	}
+
+	// Post both legs of the transfer as one balanced journal entry
+	var txn *models.Transaction
+	var updatedWallet *models.Wallet
+	if conversion != nil {
+		txn, updatedWallet, _, err = ws.ledgerService.Exchange(tx, fromWallet.WalletNumber, toWallet.WalletNumber, conversion, note)
+	} else {
+		txn, updatedWallet, _, err = ws.ledgerService.Transfer(tx, fromWallet.WalletNumber, toWallet.WalletNumber, amount, note)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return txn, updatedWallet, nil
+}
+
+// ReverseTransaction refunds part or all of a completed deposit or transfer received by the user's wallet.
+// A nil amount reverses whatever has not been reversed yet. Once the full amount is reversed the original
+// transaction is marked reversed, so it can never be reversed twice.
+// It returns the reversal transaction and the user's updated wallet.
+func (ws *WalletService) ReverseTransaction(userID, transactionID int, amount *money.Money) (*models.Transaction, *models.Wallet, error) {
 	tx, err := ws.walletRepo.Begin()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	defer ws.rollBackTxWhenErr(tx, &err)
 
-	fromWallet, err := ws.walletRepo.Withdraw(tx, fromUserID, amount)
+	// Lock the original first so concurrent reversals of the same transaction run one after another
+	original, err := ws.transactionService.LockTransactionByID(tx, transactionID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// Users only see transactions involving one of their own wallets
+	checkWallet, err := ws.ownedWalletOf(userID, original.ToWalletNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	sendingWallet, err := ws.ownedWalletOf(userID, original.FromWalletNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	if checkWallet == nil && sendingWallet == nil {
+		err = utils.RepoErrTransactionNotFound
+		return nil, nil, err
+	}
+
+	// Only the wallet that received the funds can give them back
+	if checkWallet == nil || !isReversible(original) {
+		err = utils.ServiceErrTransactionNotReversible
+		return nil, nil, err
+	}
+
+	reversed, err := ws.transactionService.GetReversedAmount(tx, original)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remaining, err := original.Amount.Sub(reversed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refund := remaining
+	if amount != nil {
+		refund = *amount
+	}
+	if refund.Currency != original.Amount.Currency {
+		err = utils.ServiceErrCurrencyMismatch
+		return nil, nil, err
+	}
+	if cmp, cmpErr := refund.Cmp(remaining); cmpErr != nil || cmp > 0 || !refund.IsPositive() {
+		err = utils.ServiceErrReversalExceedsRemaining
+		return nil, nil, err
+	}
+
+	// Lock the wallets the money moves between, then check the receiving wallet can pay it back
+	walletIDs := []int{checkWallet.ID}
+	if original.FromWalletNumber != nil {
+		fromWallet, findErr := ws.walletRepo.FindByWalletNumber(*original.FromWalletNumber)
+		if findErr != nil {
+			err = findErr
+			return nil, nil, err
+		}
+		walletIDs = append(walletIDs, fromWallet.ID)
+	}
+
+	lockedWallets, err := LockWalletsInOrder(ws.walletRepo, tx, walletIDs...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = EnsureSufficientFunds(lockedWallets[checkWallet.ID].AvailableBalance(), refund); err != nil {
+		return nil, nil, err
 	}
 
-	toWallet, err = ws.walletRepo.Deposit(tx, toWallet.UserID, amount)
+	reversal, wallet, err := ws.ledgerService.Reverse(tx, original, refund)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A full reversal closes the original for good
+	if refund == remaining {
+		if err = ws.transactionService.UpdateStatus(tx, original, transaction.StatusReversed); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = ws.walletRepo.Commit(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reversal, wallet, nil
+}
+
+// ownedWalletOf returns the wallet with the given number when it belongs to the user, or nil when it does not
+func (ws *WalletService) ownedWalletOf(userID int, walletNumber *string) (*models.Wallet, error) {
+	if walletNumber == nil || *walletNumber == "" {
+		return nil, nil
+	}
+	wallet, err := FindOwnedWallet(ws.walletRepo, userID, *walletNumber)
+	if err == utils.RepoErrWalletNotFound {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	return wallet, nil
+}
+
+// isReversible reports whether the transaction is a completed deposit or transfer
+func isReversible(original *models.Transaction) bool {
+	if original.Status != transaction.StatusCompleted {
+		return false
+	}
+	return original.TransactionType == "deposit" || original.TransactionType == "transfer"
+}
+
+// FindOwnedWallet fetches a wallet of the user by number, or the user's default wallet when walletNumber is empty.
+// A wallet of another user is reported as not found so wallet numbers cannot be probed.
+func FindOwnedWallet(walletRepo WalletRepositoryInterface, userID int, walletNumber string) (*models.Wallet, error) {
+	if walletNumber == "" {
+		return walletRepo.GetDefaultWallet(userID)
+	}
 
-	// Record the transfer transaction
-	err = ws.transactionService.RecordTransaction(tx, &fromWallet.WalletNumber, &toWallet.WalletNumber, "transfer", amount)
+	wallet, err := walletRepo.FindByWalletNumber(walletNumber)
 	if err != nil {
 		return nil, err
 	}
+	if wallet.UserID != userID {
+		return nil, utils.RepoErrWalletNotFound
+	}
+	return wallet, nil
+}
 
-	err = ws.walletRepo.Commit(tx)
+// LockWalletsInOrder locks the given wallets with SELECT ... FOR UPDATE in ascending ID order.
+// Always acquiring row locks in the same order prevents deadlocks between concurrent transfers.
+func LockWalletsInOrder(walletRepo WalletRepositoryInterface, tx *sql.Tx, walletIDs ...int) (map[int]*models.Wallet, error) {
+	ids := append([]int(nil), walletIDs...)
+	sort.Ints(ids)
+
+	locked := make(map[int]*models.Wallet, len(ids))
+	for _, id := range ids {
+		if _, ok := locked[id]; ok {
+			continue
+		}
+		wallet, err := walletRepo.LockWalletByID(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		locked[id] = wallet
+	}
+	return locked, nil
+}
+
+// EnsureSufficientFunds checks that the balance covers the requested amount in the same currency
+func EnsureSufficientFunds(balance, amount money.Money) error {
+	cmp, err := balance.Cmp(amount)
 	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return utils.RepoErrInsufficientFunds
+	}
+	return nil
+}
+
+// EnsureWalletCurrency checks that the amount is expressed in the wallet's currency
+func EnsureWalletCurrency(wallet *models.Wallet, amount money.Money) error {
+	if amount.Currency != wallet.Currency {
+		return utils.ServiceErrCurrencyMismatch
+	}
+	return nil
+}
+
+// PriceConversion prices amount in toCurrency. A missing converter or rate is reported as ServiceErrNoConversionPath.
+func PriceConversion(converter fx.ConverterInterface, amount money.Money, toCurrency string) (*fx.Conversion, error) {
+	if converter == nil {
+		return nil, utils.ServiceErrNoConversionPath
+	}
+
+	conversion, err := converter.Convert(amount, toCurrency)
+	switch err {
+	case nil:
+		return conversion, nil
+	case fx.ErrRateNotFound, fx.ErrSameCurrency:
+		return nil, utils.ServiceErrNoConversionPath
+	case fx.ErrAmountTooSmall:
+		return nil, utils.ServiceErrAmountTooSmallToConvert
+	default:
 		return nil, err
 	}
+}
 
-	return fromWallet, nil
+// recordFailed keeps a refused money movement, e.g. a withdrawal without sufficient funds, as a failed transaction
+// so the sender can see it in their history. The attempt's own DB transaction has been rolled back, releasing
+// its wallet locks, so the failure is recorded in a new one. Errors are only logged: the caller reports the refusal.
+func (ws *WalletService) recordFailed(fromWalletNumber, toWalletNumber *string, transactionType string, amount money.Money, conversion *fx.Conversion, note models.TransactionNote) {
+	tx, err := ws.walletRepo.Begin()
+	if err != nil {
+		log.Printf("Warning: Failed to record failed %s: %v", transactionType, err)
+		return
+	}
+
+	defer ws.rollBackTxWhenErr(tx, &err)
+
+	var txn *models.Transaction
+	if conversion != nil {
+		txn, err = ws.transactionService.RecordExchange(tx, *fromWalletNumber, *toWalletNumber, conversion, note)
+	} else {
+		txn, err = ws.transactionService.RecordTransaction(tx, fromWalletNumber, toWalletNumber, transactionType, amount, note)
+	}
+	if err == nil {
+		err = ws.transactionService.UpdateStatus(tx, txn, transaction.StatusFailed)
+	}
+	if err == nil {
+		err = ws.walletRepo.Commit(tx)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to record failed %s: %v", transactionType, err)
+	}
 }
 
 func (ws *WalletService) rollBackTxWhenErr(tx *sql.Tx, err *error) {
@@ -205,6 +586,21 @@ func (ws *WalletService) rollBackTxWhenErr(tx *sql.Tx, err *error) {
 	}
 }
 
+// DefaultWalletCacheKey is the Redis key caching the number and currency of the user's default wallet
+func DefaultWalletCacheKey(userID int) string {
+	return fmt.Sprintf("user:%d:default_wallet_number", userID)
+}
+
+// invalidateDefaultWalletCache drops the cached default wallet number after the default changes
+func (ws *WalletService) invalidateDefaultWalletCache(userID int) {
+	if ws.redisService == nil {
+		return
+	}
+	if err := ws.redisService.DeleteKeysByPattern(context.Background(), DefaultWalletCacheKey(userID)); err != nil {
+		log.Printf("Warning: Failed to invalidate cached default wallet in Redis: %v", err)
+	}
+}
+
 func generateUniqueWalletNumber(userID int) string {
 	// Get the current timestamp in the format YYYYMMDDHHMMSS
 	timestamp := time.Now().Format("20060102150405")
@@ -1,10 +1,17 @@
 package wallet
 
 import (
+	"centralized-wallet/internal/fx"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
 	"centralized-wallet/internal/utils"
+	mockFx "centralized-wallet/tests/mocks/fx"
 	"centralized-wallet/tests/testutils"
+	"database/sql"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,23 +27,20 @@ func TestDepositService(t *testing.T) {
 				TestType:      "success",
 				ExpectedError: nil,
 				MockSetup: func() {
-					// Mock user existence check
-					mockServiceTestHelper.walletRepo.On("UserExists", mock.Anything).Return(true, nil)
+					// Mock fetching the user's wallet
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(createMockWallet(testWalletNumber, testUserID), nil)
 
 					// Mock transaction begin
 					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
 
-					// Mock deposit
+					// Mock posting the deposit to the ledger
 					mockWallet := &models.Wallet{
 						UserID:       testUserID,
 						WalletNumber: testWalletNumber,
-						Balance:      150.0, // After deposit
+						Balance:      usd("150.00"), // After deposit
 						UpdatedAt:    now,
 					}
-					mockServiceTestHelper.walletRepo.On("Deposit", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(mockWallet, nil)
-
-					// Mock recording the transaction
-					mockServiceTestHelper.transactionService.On("RecordTransaction", mock.AnythingOfType("*sql.Tx"), (*string)(nil), mock.Anything, "deposit", 50.0).Return(nil)
+					mockServiceTestHelper.ledgerService.On("Deposit", mock.AnythingOfType("*sql.Tx"), testWalletNumber, testAmount, models.TransactionNote{}).Return(mockWallet, nil)
 
 					// // Mock commit
 					mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
@@ -46,19 +50,19 @@ func TestDepositService(t *testing.T) {
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
 				},
 			},
 			userID: testUserID,
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error checking user existence",
+				Name:          "wallet not found",
 				TestType:      "error",
-				ExpectedError: utils.ErrDatabaseError,
+				ExpectedError: utils.RepoErrWalletNotFound,
 				MockSetup: func() {
-					// Mock error when checking if the user exists
-					mockServiceTestHelper.walletRepo.On("UserExists", mock.Anything).Return(false, utils.ErrDatabaseError)
+					// Mock the user having no wallet
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(nil, utils.RepoErrWalletNotFound)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
@@ -66,60 +70,85 @@ func TestDepositService(t *testing.T) {
 			},
 			userID: testUserID,
 		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:          "deposit into a named wallet",
+				TestType:      "success",
+				ExpectedError: nil,
+				MockSetup: func() {
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(createMockWallet(testToWalletNumber, testUserID), nil)
+					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.ledgerService.On("Deposit", mock.AnythingOfType("*sql.Tx"), testToWalletNumber, testAmount, models.TransactionNote{}).Return(createMockWallet(testToWalletNumber, testUserID), nil)
+					mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
+				},
+			},
+			userID:       testUserID,
+			walletNumber: testToWalletNumber,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:          "wallet of another user",
+				TestType:      "error",
+				ExpectedError: utils.RepoErrWalletNotFound,
+				MockSetup: func() {
+					// The wallet exists but belongs to someone else, which must look like it does not exist
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(createMockWallet(testToWalletNumber, testToUserID), nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Deposit", mock.Anything, mock.Anything, mock.Anything)
+				},
+			},
+			userID:       testUserID,
+			walletNumber: testToWalletNumber,
+		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
 				Name:          "error during deposit",
 				TestType:      "error",
 				ExpectedError: utils.ErrDatabaseError,
 				MockSetup: func() {
-					// Mock user existence check
-					mockServiceTestHelper.walletRepo.On("UserExists", mock.Anything).Return(true, nil)
+					// Mock fetching the user's wallet
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(createMockWallet(testWalletNumber, testUserID), nil)
 
 					// Mock transaction begin
 					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
 
-					// Mock deposit returning an error
-					mockServiceTestHelper.walletRepo.On("Deposit", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(nil, utils.ErrDatabaseError)
+					// Mock the ledger posting returning an error
+					mockServiceTestHelper.ledgerService.On("Deposit", mock.AnythingOfType("*sql.Tx"), testWalletNumber, testAmount, models.TransactionNote{}).Return(nil, utils.ErrDatabaseError)
 
 					// Mock rollback
 					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
 				},
 			},
 			userID: testUserID,
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error recording transaction",
+				Name:          "error committing",
 				TestType:      "error",
 				ExpectedError: utils.ErrDatabaseError,
 				MockSetup: func() {
-					// Mock user existence check
-					mockServiceTestHelper.walletRepo.On("UserExists", mock.Anything).Return(true, nil)
-
-					// Mock transaction begin
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(createMockWallet(testWalletNumber, testUserID), nil)
 					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.ledgerService.On("Deposit", mock.AnythingOfType("*sql.Tx"), testWalletNumber, testAmount, models.TransactionNote{}).Return(createMockWallet(testWalletNumber, testUserID), nil)
 
-					// Mock deposit
-					mockWallet := &models.Wallet{
-						UserID:       testUserID,
-						WalletNumber: testWalletNumber,
-						Balance:      150.0, // After deposit
-						UpdatedAt:    now,
-					}
-					mockServiceTestHelper.walletRepo.On("Deposit", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(mockWallet, nil)
-
-					// Mock recording the transaction returning an error
-					mockServiceTestHelper.transactionService.On("RecordTransaction", mock.AnythingOfType("*sql.Tx"), (*string)(nil), &mockWallet.WalletNumber, "deposit", 50.0).Return(utils.ErrDatabaseError)
-
-					// Mock rollback
+					// Mock commit returning an error
+					mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(utils.ErrDatabaseError)
 					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
 				},
 			},
 			userID: testUserID,
@@ -130,7 +159,7 @@ func TestDepositService(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 
 			walletService := walletServiceTestInit(tc)
-			wallet, err := walletService.Deposit(tc.userID, 50.0)
+			wallet, err := walletService.Deposit(tc.userID, tc.walletNumber, testAmount, models.TransactionNote{})
 
 			if tc.TestType == "success" {
 				assert.NoError(t, err)
@@ -156,23 +185,38 @@ func TestWithdrawService(t *testing.T) {
 				MockSetup: func() {
 					// Mock getting wallet balance successfully
 					mockWallet := createMockWallet(testWalletNumber, testUserID)
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(mockWallet, nil)
-					// Mock withdrawal of amount
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(mockWallet, nil)
 
 					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
-					mockServiceTestHelper.walletRepo.On("Withdraw", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(mockWallet, nil)
-					// Mock recording the transaction
-					mockServiceTestHelper.transactionService.On("RecordTransaction", mock.AnythingOfType("*sql.Tx"), mock.Anything, (*string)(nil), "withdraw", 50.0).Return(nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), mock.Anything).Return(createMockWallet(testWalletNumber, testUserID), nil)
+					// Mock posting the withdrawal to the ledger
+					mockServiceTestHelper.ledgerService.On("Withdraw", mock.AnythingOfType("*sql.Tx"), testWalletNumber, testAmount, models.TransactionNote{}).Return(mockWallet, nil)
 					mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
 					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
+				},
+			},
+			userID: testUserID,
+			amount: testAmount,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:          "amount in another currency",
+				TestType:      "error",
+				ExpectedError: utils.ServiceErrCurrencyMismatch,
+				MockSetup: func() {
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(createMockWallet(testWalletNumber, testUserID), nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockServiceTestHelper.walletRepo.AssertNotCalled(t, "Begin")
+					mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Withdraw", mock.Anything, mock.Anything, mock.Anything)
 				},
 			},
 			userID: testUserID,
-			amount: 50,
+			amount: money.MustParse("50", "JPY"),
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
@@ -182,87 +226,99 @@ func TestWithdrawService(t *testing.T) {
 				MockSetup: func() {
 					// Mock balance less than the amount being withdrawn
 					mmockWallet := createMockWallet(testWalletNumber, testUserID)
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(mmockWallet, nil)
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(mmockWallet, nil)
+
+					// The funds check happens on the locked row inside the DB transaction
+					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), mmockWallet.ID).Return(mmockWallet, nil)
+					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+
+					// The refused withdrawal is kept as a failed transaction
+					expectFailureRecorded(&testWalletNumber, nil, transaction.TypeWithdraw)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Withdraw", mock.Anything, mock.Anything, mock.Anything)
 				},
 			},
 			userID: testUserID,
-			amount: 150,
+			amount: usd("150.00"),
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error getting wallet balance",
+				Name:          "funds on hold are not available",
 				TestType:      "error",
-				ExpectedError: utils.ErrDatabaseError,
+				ExpectedError: utils.RepoErrInsufficientFunds,
 				MockSetup: func() {
-					// Mock error when getting wallet balance
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(nil, utils.ErrDatabaseError)
+					// The balance covers the amount but most of it is reserved by a hold
+					mockWallet := createMockWallet(testWalletNumber, testUserID)
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(mockWallet, nil)
+
+					lockedWallet := createMockWallet(testWalletNumber, testUserID)
+					lockedWallet.HeldBalance = usd("80.00")
+					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), mockWallet.ID).Return(lockedWallet, nil)
+					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+
+					// The refused withdrawal is kept as a failed transaction
+					expectFailureRecorded(&testWalletNumber, nil, transaction.TypeWithdraw)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Withdraw", mock.Anything, mock.Anything, mock.Anything)
 				},
 			},
 			userID: testUserID,
-			amount: 50,
+			amount: testAmount,
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error during withdrawal",
+				Name:          "error getting wallet balance",
 				TestType:      "error",
 				ExpectedError: utils.ErrDatabaseError,
 				MockSetup: func() {
-					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
-					// Mock getting wallet balance successfully
-					mockWallet := createMockWallet(testWalletNumber, testUserID)
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(mockWallet, nil)
-					// Mock withdrawal returning an error
-					mockServiceTestHelper.walletRepo.On("Withdraw", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(nil, utils.ErrDatabaseError)
-					// Mock rollback
-					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+					// Mock error when getting wallet balance
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(nil, utils.ErrDatabaseError)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
 				},
 			},
 			userID: testUserID,
-			amount: 50,
+			amount: testAmount,
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error recording transaction",
+				Name:          "error during withdrawal",
 				TestType:      "error",
 				ExpectedError: utils.ErrDatabaseError,
 				MockSetup: func() {
-					mockWallet := createMockWallet(testWalletNumber, testUserID)
-
-					// Mock getting wallet balance successfully
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(mockWallet, nil)
-
 					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
-					// Mock withdrawal of amount
-					mockServiceTestHelper.walletRepo.On("Withdraw", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(mockWallet, nil)
-					// Mock recording the transaction returning an error
-					mockServiceTestHelper.transactionService.On("RecordTransaction", mock.AnythingOfType("*sql.Tx"), &mockWallet.WalletNumber, (*string)(nil), "withdraw", 50.0).Return(utils.ErrDatabaseError)
-
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), mock.Anything).Return(createMockWallet(testWalletNumber, testUserID), nil)
+					// Mock getting wallet balance successfully
+					mockWallet := createMockWallet(testWalletNumber, testUserID)
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(mockWallet, nil)
+					// Mock the ledger posting returning an error
+					mockServiceTestHelper.ledgerService.On("Withdraw", mock.AnythingOfType("*sql.Tx"), testWalletNumber, testAmount, models.TransactionNote{}).Return(nil, utils.ErrDatabaseError)
 					// Mock rollback
 					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
 				},
 			},
 			userID: testUserID,
-			amount: 50,
+			amount: testAmount,
 		},
 	}
 
 	for _, tt := range testCases {
 		t.Run(tt.Name, func(t *testing.T) {
 			walletService := walletServiceTestInit(tt)
-			wallet, err := walletService.Withdraw(tt.userID, tt.amount) // Example amount to withdraw
+			wallet, err := walletService.Withdraw(tt.userID, tt.walletNumber, tt.amount, models.TransactionNote{})
 
 			if tt.TestType == "success" {
 				assert.NoError(t, err)
@@ -286,30 +342,20 @@ func TestTransferService(t *testing.T) {
 				TestType:      "success",
 				ExpectedError: nil,
 				MockSetup: func() {
-					mockWallet := createMockWallet(testWalletNumber, testUserID)
+					mockWallet := createMockWallet(testFromWalletNumber, testUserID)
 					// Mock getting wallet balance successfully
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(mockWallet, nil)
-
-					// Mock withdrawal of amount from the sender
-					mockFromWallet := createMockWallet(testWalletNumber, testUserID)
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(mockWallet, nil)
 
 					// Mock the recipient wallet details
 					mockToWallet := createMockWallet(testToWalletNumber, testToUserID)
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", mock.Anything).Return(mockToWallet, nil)
 
 					// Begin transaction
 					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), mock.Anything).Return(createMockWallet(testFromWalletNumber, testUserID), nil)
 
-					// Mock withdrawal from sender's wallet
-					mockServiceTestHelper.walletRepo.On("Withdraw", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(mockFromWallet, nil)
-
-					// Mock finding recipient wallet
-					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", mock.Anything).Return(mockToWallet, nil)
-
-					// Mock deposit into recipient's wallet
-					mockServiceTestHelper.walletRepo.On("Deposit", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(mockToWallet, nil)
-
-					// Mock recording the transaction
-					mockServiceTestHelper.transactionService.On("RecordTransaction", mock.AnythingOfType("*sql.Tx"), &mockFromWallet.WalletNumber, &mockToWallet.WalletNumber, "transfer", 50.0).Return(nil)
+					// Mock posting both legs to the ledger
+					mockServiceTestHelper.ledgerService.On("Transfer", mock.AnythingOfType("*sql.Tx"), testFromWalletNumber, testToWalletNumber, testAmount, models.TransactionNote{}).Return(&models.Transaction{ID: 1}, mockWallet, mockToWallet, nil)
 
 					// Mock commit
 					mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
@@ -319,11 +365,31 @@ func TestTransferService(t *testing.T) {
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
 				},
 			},
 			userID: testUserID,
-			amount: 50.0,
+			amount: testAmount,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:          "wallets in different currencies",
+				TestType:      "error",
+				ExpectedError: utils.ServiceErrNoConversionPath,
+				MockSetup: func() {
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(createMockWallet(testFromWalletNumber, testUserID), nil)
+
+					euroWallet := createMockWallet(testToWalletNumber, testToUserID)
+					euroWallet.Currency = "EUR"
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", mock.Anything).Return(euroWallet, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockServiceTestHelper.walletRepo.AssertNotCalled(t, "Begin")
+					mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				},
+			},
+			userID: testUserID,
+			amount: testAmount,
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
@@ -331,97 +397,154 @@ func TestTransferService(t *testing.T) {
 				TestType:      "error",
 				ExpectedError: utils.RepoErrInsufficientFunds,
 				MockSetup: func() {
-					mockWallet := createMockWallet(testWalletNumber, testUserID)
+					mockWallet := createMockWallet(testFromWalletNumber, testUserID)
 					// Mock balance less than the amount being transferred
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(mockWallet, nil)
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(mockWallet, nil)
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", mock.Anything).Return(createMockWallet(testToWalletNumber, testToUserID), nil)
+
+					// The funds check happens on the locked row inside the DB transaction
+					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), mockWallet.ID).Return(mockWallet, nil)
+					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+
+					// The refused transfer is kept as a failed transaction
+					expectFailureRecorded(&testFromWalletNumber, &testToWalletNumber, transaction.TypeTransfer)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 				},
 			},
 			userID: testUserID,
-			amount: 150,
+			amount: usd("150.00"),
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error during withdrawal",
+				Name:          "funds on hold are not available",
 				TestType:      "error",
-				ExpectedError: utils.ErrDatabaseError,
+				ExpectedError: utils.RepoErrInsufficientFunds,
 				MockSetup: func() {
-					// Mock transaction begin
-					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
-
-					mockWallet := createMockWallet(testWalletNumber, testUserID)
-					// Mock getting wallet balance successfully
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(mockWallet, nil)
-
-					mockToWallet := createMockWallet(testToWalletNumber, testToUserID)
+					mockWallet := createMockWallet(testFromWalletNumber, testUserID)
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(mockWallet, nil)
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", mock.Anything).Return(createMockWallet(testToWalletNumber, testToUserID), nil)
 
-					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", mock.Anything).Return(mockToWallet, nil)
-					// Mock withdrawal returning an error
-					mockServiceTestHelper.walletRepo.On("Withdraw", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(nil, utils.ErrDatabaseError)
+					// The balance covers the amount but most of it is reserved by a hold
+					lockedWallet := createMockWallet(testFromWalletNumber, testUserID)
+					lockedWallet.HeldBalance = usd("80.00")
+					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), mockWallet.ID).Return(lockedWallet, nil)
+					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
 
-					// Mock rollback
+					// The refused transfer is kept as a failed transaction
+					expectFailureRecorded(&testFromWalletNumber, &testToWalletNumber, transaction.TypeTransfer)
+				},
+				MockAssert: func(t *testing.T) {
+					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				},
+			},
+			userID: testUserID,
+			amount: testAmount,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:          "recipient wallet not found",
+				TestType:      "error",
+				ExpectedError: utils.RepoErrWalletNotFound,
+				MockSetup: func() {
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(createMockWallet(testFromWalletNumber, testUserID), nil)
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", mock.Anything).Return(nil, utils.RepoErrWalletNotFound)
+				},
+				MockAssert: func(t *testing.T) {
+					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+				},
+			},
+			userID: testUserID,
+			amount: testAmount,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:          "transfer between own wallets",
+				TestType:      "success",
+				ExpectedError: nil,
+				MockSetup: func() {
+					fromWallet := &models.Wallet{ID: 1, UserID: testUserID, WalletNumber: testFromWalletNumber, Currency: money.DefaultCurrency, Balance: usd("100.00")}
+					toWallet := &models.Wallet{ID: 2, UserID: testUserID, WalletNumber: testToWalletNumber, Currency: money.DefaultCurrency, Balance: usd("0.00")}
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testFromWalletNumber).Return(fromWallet, nil)
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(toWallet, nil)
+					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 1).Return(fromWallet, nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 2).Return(toWallet, nil)
+					mockServiceTestHelper.ledgerService.On("Transfer", mock.AnythingOfType("*sql.Tx"), testFromWalletNumber, testToWalletNumber, testAmount, models.TransactionNote{}).Return(&models.Transaction{ID: 1}, fromWallet, toWallet, nil)
+					mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
 					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
+				},
+			},
+			userID:       testUserID,
+			walletNumber: testFromWalletNumber,
+			amount:       testAmount,
+		},
+		{
+			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
+				Name:          "transfer to the same wallet",
+				TestType:      "error",
+				ExpectedError: utils.ServiceErrTransferToSameWallet,
+				MockSetup: func() {
+					sameWallet := createMockWallet(testToWalletNumber, testUserID)
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(sameWallet, nil)
+					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(sameWallet, nil)
+				},
+				MockAssert: func(t *testing.T) {
+					mockServiceTestHelper.walletRepo.AssertExpectations(t)
+					mockServiceTestHelper.walletRepo.AssertNotCalled(t, "Begin")
 				},
 			},
 			userID: testUserID,
-			amount: 50.0,
+			amount: testAmount,
 		},
 		{
 			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error recording transaction",
+				Name:          "error during ledger posting",
 				TestType:      "error",
 				ExpectedError: utils.ErrDatabaseError,
 				MockSetup: func() {
 					// Mock transaction begin
 					mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+					mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), mock.Anything).Return(createMockWallet(testFromWalletNumber, testUserID), nil)
 
-					mockWallet := createMockWallet(testWalletNumber, testUserID)
+					mockWallet := createMockWallet(testFromWalletNumber, testUserID)
 					// Mock getting wallet balance successfully
-					mockServiceTestHelper.walletRepo.On("GetWalletByUserID", mock.Anything).Return(mockWallet, nil)
+					mockServiceTestHelper.walletRepo.On("GetDefaultWallet", mock.Anything).Return(mockWallet, nil)
 
-					// Mock withdrawal of amount from sender
-					mockFromWallet := createMockWallet(testWalletNumber, testUserID)
-					mockServiceTestHelper.walletRepo.On("Withdraw", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(mockFromWallet, nil)
-
-					// Mock finding recipient wallet
-					mockToWallet := &models.Wallet{
-						UserID:       testToUserID,
-						WalletNumber: testToWalletNumber,
-						Balance:      150.0, // After deposit
-						UpdatedAt:    now,
-					}
+					mockToWallet := createMockWallet(testToWalletNumber, testToUserID)
 					mockServiceTestHelper.walletRepo.On("FindByWalletNumber", mock.Anything).Return(mockToWallet, nil)
 
-					// Mock deposit into recipient's wallet
-					mockServiceTestHelper.walletRepo.On("Deposit", mock.AnythingOfType("*sql.Tx"), mock.Anything, mock.Anything).Return(mockToWallet, nil)
-
-					// Mock recording the transaction returning an error
-					mockServiceTestHelper.transactionService.On("RecordTransaction", mock.AnythingOfType("*sql.Tx"), &mockFromWallet.WalletNumber, &mockToWallet.WalletNumber, "transfer", 50.0).Return(utils.ErrDatabaseError)
+					// Mock the ledger posting returning an error
+					mockServiceTestHelper.ledgerService.On("Transfer", mock.AnythingOfType("*sql.Tx"), testFromWalletNumber, testToWalletNumber, testAmount, models.TransactionNote{}).Return(nil, nil, nil, utils.ErrDatabaseError)
 
 					// Mock rollback
 					mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
 				},
 				MockAssert: func(t *testing.T) {
 					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-					mockServiceTestHelper.transactionService.AssertExpectations(t)
+					mockServiceTestHelper.ledgerService.AssertExpectations(t)
 				},
 			},
 			userID: testUserID,
-			amount: 50.0,
+			amount: testAmount,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			setupServiceMock()
-			tc.MockSetup()
-			walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.transactionService)
-			_, err := walletService.Transfer(tc.userID, tc.walletNumber, tc.amount)
+			walletService := walletServiceTestInit(tc)
+			_, err := walletService.Transfer(tc.userID, tc.walletNumber, testToWalletNumber, tc.amount, models.TransactionNote{})
 			if tc.TestType == "error" {
 				assert.ErrorIs(t, err, tc.ExpectedError)
 			} else {
@@ -432,74 +555,420 @@ func TestTransferService(t *testing.T) {
 	}
 }
 
+func TestTransferAcrossCurrenciesService(t *testing.T) {
+	conversion := &fx.Conversion{
+		Source:      testAmount,
+		Target:      money.MustParse("45.77", "EUR"),
+		Spread:      usd("0.25"),
+		MidRate:     fx.MustParseRate("0.92"),
+		AppliedRate: fx.MustParseRate("0.9154"),
+	}
+
+	testCases := []struct {
+		name          string
+		convertResult *fx.Conversion
+		convertError  error
+		expectedError error
+	}{
+		{name: "converts and posts an exchange", convertResult: conversion},
+		{name: "no rate for the pair", convertError: fx.ErrRateNotFound, expectedError: utils.ServiceErrNoConversionPath},
+		{name: "amount too small to convert", convertError: fx.ErrAmountTooSmall, expectedError: utils.ServiceErrAmountTooSmallToConvert},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupServiceMock()
+			converter := new(mockFx.MockConverter)
+			walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, nil, converter)
+
+			fromWallet := &models.Wallet{ID: 1, UserID: testUserID, WalletNumber: testFromWalletNumber, Currency: "USD", Balance: usd("100.00")}
+			toWallet := &models.Wallet{ID: 2, UserID: testToUserID, WalletNumber: testToWalletNumber, Currency: "EUR", Balance: money.Zero("EUR")}
+			mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(fromWallet, nil)
+			mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(toWallet, nil)
+			converter.On("Convert", testAmount, "EUR").Return(tc.convertResult, tc.convertError)
+
+			if tc.expectedError == nil {
+				mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+				mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 1).Return(fromWallet, nil)
+				mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 2).Return(toWallet, nil)
+				mockServiceTestHelper.ledgerService.On("Exchange", mock.AnythingOfType("*sql.Tx"), testFromWalletNumber, testToWalletNumber, conversion, models.TransactionNote{}).
+					Return(&models.Transaction{ID: 1}, fromWallet, toWallet, nil)
+				mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+				mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+			}
+
+			_, err := walletService.Transfer(testUserID, "", testToWalletNumber, testAmount, models.TransactionNote{})
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockServiceTestHelper.walletRepo.AssertNotCalled(t, "Begin")
+			} else {
+				assert.NoError(t, err)
+			}
+			mockServiceTestHelper.walletRepo.AssertExpectations(t)
+			mockServiceTestHelper.ledgerService.AssertExpectations(t)
+			mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			converter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransferTxPostsTheGivenConversion(t *testing.T) {
+	conversion := &fx.Conversion{
+		Source:      testAmount,
+		Target:      money.MustParse("45.77", "EUR"),
+		Spread:      usd("0.25"),
+		MidRate:     fx.MustParseRate("0.92"),
+		AppliedRate: fx.MustParseRate("0.9154"),
+	}
+	otherAmount := *conversion
+	otherAmount.Source = usd("60.00")
+
+	testCases := []struct {
+		name          string
+		toCurrency    string
+		conversion    *fx.Conversion
+		expectedError error
+	}{
+		{name: "posts the conversion priced by the caller", toCurrency: "EUR", conversion: conversion},
+		{name: "same currency transfer without a conversion", toCurrency: "USD"},
+		{name: "missing conversion between currencies", toCurrency: "EUR", expectedError: utils.ServiceErrCurrencyMismatch},
+		{name: "conversion of another amount", toCurrency: "EUR", conversion: &otherAmount, expectedError: utils.ServiceErrCurrencyMismatch},
+		{name: "conversion into another currency", toCurrency: "GBP", conversion: conversion, expectedError: utils.ServiceErrCurrencyMismatch},
+		{name: "conversion between wallets of one currency", toCurrency: "USD", conversion: conversion, expectedError: utils.ServiceErrCurrencyMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupServiceMock()
+			converter := new(mockFx.MockConverter)
+			walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, nil, converter)
+			tx := new(sql.Tx)
+
+			fromWallet := &models.Wallet{ID: 1, UserID: testUserID, WalletNumber: testFromWalletNumber, Currency: "USD", Balance: usd("100.00")}
+			toWallet := &models.Wallet{ID: 2, UserID: testToUserID, WalletNumber: testToWalletNumber, Currency: tc.toCurrency, Balance: money.Zero(tc.toCurrency)}
+			mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(fromWallet, nil)
+			mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(toWallet, nil)
+
+			if tc.expectedError == nil {
+				mockServiceTestHelper.walletRepo.On("LockWalletByID", tx, 1).Return(fromWallet, nil)
+				mockServiceTestHelper.walletRepo.On("LockWalletByID", tx, 2).Return(toWallet, nil)
+				if tc.conversion != nil {
+					mockServiceTestHelper.ledgerService.On("Exchange", tx, testFromWalletNumber, testToWalletNumber, tc.conversion, models.TransactionNote{}).
+						Return(&models.Transaction{ID: 7}, fromWallet, toWallet, nil)
+				} else {
+					mockServiceTestHelper.ledgerService.On("Transfer", tx, testFromWalletNumber, testToWalletNumber, testAmount, models.TransactionNote{}).
+						Return(&models.Transaction{ID: 7}, fromWallet, toWallet, nil)
+				}
+			}
+
+			txn, wallet, err := walletService.TransferTx(tx, testUserID, "", testToWalletNumber, testAmount, tc.conversion, models.TransactionNote{})
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockServiceTestHelper.walletRepo.AssertNotCalled(t, "LockWalletByID", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 7, txn.ID)
+				assert.Equal(t, fromWallet, wallet)
+			}
+			// The caller owns the DB transaction and has already priced the conversion
+			mockServiceTestHelper.walletRepo.AssertNotCalled(t, "Begin")
+			mockServiceTestHelper.walletRepo.AssertNotCalled(t, "Commit", mock.Anything)
+			converter.AssertNotCalled(t, "Convert", mock.Anything, mock.Anything)
+			mockServiceTestHelper.walletRepo.AssertExpectations(t)
+			mockServiceTestHelper.ledgerService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestCreateWalletService(t *testing.T) {
 
-	testCases := []testWalletService{
+	testCases := []struct {
+		name             string
+		walletName       string
+		currency         string
+		mockSetup        func()
+		expectedName     string
+		expectedCurrency string
+		expectedError    error
+	}{
 		{
-			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "successful wallet creation",
-				TestType:      "success",
-				ExpectedError: nil,
-				MockSetup: func() {
-					mockServiceTestHelper.walletRepo.On("UserExists", mock.Anything).Return(false, nil)
-					mockServiceTestHelper.walletRepo.On("CreateWallet", mock.Anything).Return(nil)
-				},
-				MockAssert: func(t *testing.T) {
-					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-				},
+			name: "wallet without a name is called Main",
+			mockSetup: func() {
+				mockServiceTestHelper.walletRepo.On("CreateWallet", mock.MatchedBy(func(w *models.Wallet) bool {
+					return w.UserID == testUserID && w.Name == DefaultWalletName
+				})).Return(nil)
 			},
-			userID:       testUserID,
-			walletNumber: testWalletNumber,
+			expectedName:     DefaultWalletName,
+			expectedCurrency: money.DefaultCurrency,
 		},
 		{
-			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error checking if user exists",
-				TestType:      "error",
-				ExpectedError: utils.ErrDatabaseError,
-				MockSetup: func() {
-					mockServiceTestHelper.walletRepo.On("UserExists", mock.Anything).Return(false, utils.ErrDatabaseError)
-				},
-				MockAssert: func(t *testing.T) {
-					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-				},
+			name:       "wallet in a zero-decimal currency",
+			walletName: "Travel",
+			currency:   "jpy",
+			mockSetup: func() {
+				mockServiceTestHelper.walletRepo.On("CreateWallet", mock.MatchedBy(func(w *models.Wallet) bool {
+					return w.Currency == "JPY" && w.Balance == money.Zero("JPY")
+				})).Return(nil)
 			},
-			userID:       testUserID,
-			walletNumber: testWalletNumber,
+			expectedName:     "Travel",
+			expectedCurrency: "JPY",
 		},
 		{
-			BaseHandlerTestCase: testutils.BaseHandlerTestCase{
-				Name:          "error creating wallet",
-				TestType:      "error",
-				ExpectedError: utils.ErrDatabaseError,
-				MockSetup: func() {
-					mockServiceTestHelper.walletRepo.On("UserExists", mock.Anything).Return(false, nil)
-					mockServiceTestHelper.walletRepo.On("CreateWallet", mock.Anything).Return(utils.ErrDatabaseError)
-				},
-				MockAssert: func(t *testing.T) {
-					mockServiceTestHelper.walletRepo.AssertExpectations(t)
-				},
+			name:          "unsupported currency",
+			currency:      "XYZ",
+			mockSetup:     func() {},
+			expectedError: utils.ServiceErrUnsupportedCurrency,
+		},
+		{
+			name:       "name is trimmed",
+			walletName: "  Savings ",
+			mockSetup: func() {
+				mockServiceTestHelper.walletRepo.On("CreateWallet", mock.MatchedBy(func(w *models.Wallet) bool {
+					return w.Name == "Savings"
+				})).Return(nil)
 			},
-			userID:       testUserID,
-			walletNumber: testWalletNumber,
+			expectedName:     "Savings",
+			expectedCurrency: money.DefaultCurrency,
+		},
+		{
+			name:          "name too long",
+			walletName:    strings.Repeat("a", maxWalletNameLength+1),
+			mockSetup:     func() {},
+			expectedError: utils.ServiceErrInvalidWalletName,
+		},
+		{
+			name:       "name already used by the user",
+			walletName: "Savings",
+			mockSetup: func() {
+				mockServiceTestHelper.walletRepo.On("CreateWallet", mock.Anything).Return(utils.RepoErrWalletNameTaken)
+			},
+			expectedError: utils.RepoErrWalletNameTaken,
+		},
+		{
+			name: "error creating wallet",
+			mockSetup: func() {
+				mockServiceTestHelper.walletRepo.On("CreateWallet", mock.Anything).Return(utils.ErrDatabaseError)
+			},
+			expectedError: utils.ErrDatabaseError,
 		},
 	}
 
 	for _, tt := range testCases {
-		t.Run(tt.Name, func(t *testing.T) {
+		t.Run(tt.name, func(t *testing.T) {
 			setupServiceMock()
-			tt.MockSetup()
-			walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.transactionService)
-			wallet, err := walletService.CreateWallet(tt.userID)
+			tt.mockSetup()
+			walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, nil, nil)
+			wallet, err := walletService.CreateWallet(testUserID, tt.walletName, tt.currency)
 
-			if tt.TestType == "success" {
+			if tt.expectedError == nil {
 				assert.NoError(t, err)
-				assert.NotNil(t, *wallet)
-				assert.Equal(t, tt.userID, wallet.UserID)
+				assert.Equal(t, testUserID, wallet.UserID)
+				assert.Equal(t, tt.expectedName, wallet.Name)
+				assert.Equal(t, tt.expectedCurrency, wallet.Currency)
 			} else {
-				assert.EqualError(t, err, tt.ExpectedError.Error())
+				assert.ErrorIs(t, err, tt.expectedError)
 				assert.Nil(t, wallet)
 			}
-			tt.MockAssert(t)
+			mockServiceTestHelper.walletRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSetDefaultWalletService(t *testing.T) {
+	t.Run("switches the default and drops the cached one", func(t *testing.T) {
+		setupServiceMock()
+		savings := createMockWallet(testToWalletNumber, testUserID)
+		mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(savings, nil)
+		mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+		mockServiceTestHelper.walletRepo.On("SetDefaultWallet", mock.AnythingOfType("*sql.Tx"), testUserID, testToWalletNumber).Return(nil)
+		mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+		mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+		mockServiceTestHelper.redisClient.On("DeleteKeysByPattern", mock.Anything, "user:1:default_wallet_number").Return(nil)
+
+		walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, mockServiceTestHelper.redisClient, nil)
+		wallet, err := walletService.SetDefaultWallet(testUserID, testToWalletNumber)
+
+		assert.NoError(t, err)
+		assert.True(t, wallet.IsDefault)
+		mockServiceTestHelper.walletRepo.AssertExpectations(t)
+		mockServiceTestHelper.redisClient.AssertExpectations(t)
+	})
+
+	t.Run("wallet of another user", func(t *testing.T) {
+		setupServiceMock()
+		mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(createMockWallet(testToWalletNumber, testToUserID), nil)
+
+		walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, mockServiceTestHelper.redisClient, nil)
+		wallet, err := walletService.SetDefaultWallet(testUserID, testToWalletNumber)
+
+		assert.ErrorIs(t, err, utils.RepoErrWalletNotFound)
+		assert.Nil(t, wallet)
+		mockServiceTestHelper.walletRepo.AssertNotCalled(t, "SetDefaultWallet", mock.Anything, mock.Anything, mock.Anything)
+		mockServiceTestHelper.redisClient.AssertNotCalled(t, "DeleteKeysByPattern", mock.Anything, mock.Anything)
+	})
+}
+
+func TestReverseTransactionService(t *testing.T) {
+	partial := usd("20.00")
+	tooMuch := usd("20.00")
+
+	// receivedTransfer is a completed transfer from another user into the test user's wallet
+	receivedTransfer := func(status string) *models.Transaction {
+		return &models.Transaction{
+			ID:               5,
+			FromWalletNumber: &testToWalletNumber,
+			ToWalletNumber:   &testFromWalletNumber,
+			TransactionType:  "transfer",
+			Amount:           testAmount,
+			Status:           status,
+		}
+	}
+
+	userWallet := &models.Wallet{ID: 1, UserID: testUserID, WalletNumber: testFromWalletNumber, Balance: usd("100.00")}
+	senderWallet := &models.Wallet{ID: 2, UserID: testToUserID, WalletNumber: testToWalletNumber, Balance: usd("10.00")}
+
+	// expectReversibleOriginal mocks locking the original and the wallets it moved money between
+	expectReversibleOriginal := func(original *models.Transaction, reversed money.Money, lockedBalance money.Money) {
+		mockServiceTestHelper.walletRepo.On("Begin").Return(nil, nil)
+		mockServiceTestHelper.transactionService.On("LockTransactionByID", mock.AnythingOfType("*sql.Tx"), 5).Return(original, nil)
+		mockServiceTestHelper.transactionService.On("GetReversedAmount", mock.AnythingOfType("*sql.Tx"), original).Return(reversed, nil)
+		mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testFromWalletNumber).Return(userWallet, nil)
+		mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(senderWallet, nil)
+		mockServiceTestHelper.walletRepo.On("FindByWalletNumber", "other-wallet").Return(&models.Wallet{ID: 3, UserID: 3, WalletNumber: "other-wallet"}, nil)
+		mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 1).Return(&models.Wallet{ID: 1, Balance: lockedBalance}, nil)
+		mockServiceTestHelper.walletRepo.On("LockWalletByID", mock.AnythingOfType("*sql.Tx"), 2).Return(senderWallet, nil)
+		mockServiceTestHelper.walletRepo.On("Rollback", mock.AnythingOfType("*sql.Tx")).Return(nil)
+	}
+
+	testCases := []struct {
+		name           string
+		amount         *money.Money
+		mockSetup      func()
+		expectedError  error
+		expectReversed bool
+	}{
+		{
+			name: "full reversal marks the original reversed",
+			mockSetup: func() {
+				original := receivedTransfer(transaction.StatusCompleted)
+				expectReversibleOriginal(original, usd("0.00"), usd("100.00"))
+				mockServiceTestHelper.ledgerService.On("Reverse", mock.AnythingOfType("*sql.Tx"), original, testAmount).
+					Return(&models.Transaction{ID: 6, Amount: testAmount}, userWallet, nil)
+				mockServiceTestHelper.transactionService.On("UpdateStatus", mock.AnythingOfType("*sql.Tx"), original, transaction.StatusReversed).Return(nil)
+				mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+			},
+			expectReversed: true,
+		},
+		{
+			name:   "partial refund keeps the original completed",
+			amount: &partial,
+			mockSetup: func() {
+				original := receivedTransfer(transaction.StatusCompleted)
+				expectReversibleOriginal(original, usd("0.00"), usd("100.00"))
+				mockServiceTestHelper.ledgerService.On("Reverse", mock.AnythingOfType("*sql.Tx"), original, partial).
+					Return(&models.Transaction{ID: 6, Amount: partial}, userWallet, nil)
+				mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
+			},
+		},
+		{
+			name:   "refund larger than what is left",
+			amount: &tooMuch,
+			mockSetup: func() {
+				expectReversibleOriginal(receivedTransfer(transaction.StatusCompleted), usd("40.00"), usd("100.00"))
+			},
+			expectedError: utils.ServiceErrReversalExceedsRemaining,
+		},
+		{
+			name: "receiving wallet lacks funds",
+			mockSetup: func() {
+				expectReversibleOriginal(receivedTransfer(transaction.StatusCompleted), usd("0.00"), usd("49.99"))
+			},
+			expectedError: utils.RepoErrInsufficientFunds,
+		},
+		{
+			name: "already reversed",
+			mockSetup: func() {
+				expectReversibleOriginal(receivedTransfer(transaction.StatusReversed), usd("50.00"), usd("100.00"))
+			},
+			expectedError: utils.ServiceErrTransactionNotReversible,
+		},
+		{
+			name: "sender cannot reverse their own transfer",
+			mockSetup: func() {
+				original := receivedTransfer(transaction.StatusCompleted)
+				original.FromWalletNumber, original.ToWalletNumber = &testFromWalletNumber, &testToWalletNumber
+				expectReversibleOriginal(original, usd("0.00"), usd("100.00"))
+			},
+			expectedError: utils.ServiceErrTransactionNotReversible,
+		},
+		{
+			name: "transaction of another user",
+			mockSetup: func() {
+				other := "other-wallet"
+				original := receivedTransfer(transaction.StatusCompleted)
+				original.FromWalletNumber, original.ToWalletNumber = &testToWalletNumber, &other
+				expectReversibleOriginal(original, usd("0.00"), usd("100.00"))
+			},
+			expectedError: utils.RepoErrTransactionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupServiceMock()
+			tc.mockSetup()
+			walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, nil, nil)
+
+			reversal, wallet, err := walletService.ReverseTransaction(testUserID, 5, tc.amount)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, reversal)
+				mockServiceTestHelper.ledgerService.AssertNotCalled(t, "Reverse", mock.Anything, mock.Anything, mock.Anything)
+				mockServiceTestHelper.walletRepo.AssertNotCalled(t, "Commit", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, 6, reversal.ID)
+			assert.Equal(t, userWallet, wallet)
+			if !tc.expectReversed {
+				mockServiceTestHelper.transactionService.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+			}
+			mockServiceTestHelper.ledgerService.AssertExpectations(t)
+			mockServiceTestHelper.transactionService.AssertExpectations(t)
 		})
 	}
 }
+
+func TestGetBalanceAsOfService(t *testing.T) {
+	asOf := now.Add(-24 * time.Hour)
+
+	t.Run("reads the ledger balance of the default wallet", func(t *testing.T) {
+		setupServiceMock()
+		mockServiceTestHelper.walletRepo.On("GetDefaultWallet", testUserID).Return(createMockWallet(testFromWalletNumber, testUserID), nil)
+		mockServiceTestHelper.ledgerService.On("GetBalanceAsOf", testFromWalletNumber, money.DefaultCurrency, asOf).Return(usd("40.00"), nil)
+
+		walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, mockServiceTestHelper.redisClient, nil)
+		wallet, balance, err := walletService.GetBalanceAsOf(testUserID, "", asOf)
+
+		assert.NoError(t, err)
+		assert.Equal(t, testFromWalletNumber, wallet.WalletNumber)
+		assert.Equal(t, usd("40.00"), balance)
+		mockServiceTestHelper.ledgerService.AssertExpectations(t)
+	})
+
+	t.Run("wallet of another user", func(t *testing.T) {
+		setupServiceMock()
+		mockServiceTestHelper.walletRepo.On("FindByWalletNumber", testToWalletNumber).Return(createMockWallet(testToWalletNumber, testToUserID), nil)
+
+		walletService := NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, mockServiceTestHelper.redisClient, nil)
+		wallet, _, err := walletService.GetBalanceAsOf(testUserID, testToWalletNumber, asOf)
+
+		assert.ErrorIs(t, err, utils.RepoErrWalletNotFound)
+		assert.Nil(t, wallet)
+		mockServiceTestHelper.ledgerService.AssertNotCalled(t, "GetBalanceAsOf", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -3,7 +3,10 @@ package wallet
 import (
 	"centralized-wallet/internal/auth"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
 	mockAuth "centralized-wallet/tests/mocks/auth"
+	mockLedger "centralized-wallet/tests/mocks/ledger"
 	mockRedis "centralized-wallet/tests/mocks/redis"
 	mockTransaction "centralized-wallet/tests/mocks/transaction"
 	mockWallet "centralized-wallet/tests/mocks/wallet"
@@ -21,7 +24,7 @@ var (
 	testToUserID         = 2
 	testToWalletNumber   = "1234567890"
 	testFromWalletNumber = "0987654321"
-	testAmount           = 50.0
+	testAmount           = usd("50.00")
 	testEmail            = "user1@example.com"
 	toTestEmail          = "user1@example.com"
 	now                  = time.Now()
@@ -37,17 +40,22 @@ type testWalletHandler struct {
 var mockHandlerTestHelper struct {
 	transactionSerivce *mockTransaction.MockTransactionService
 	walletService      *mockWallet.MockWalletService
+	recipientService   *mockWallet.MockRecipientService
 	blacklistService   *mockAuth.MockBlacklistService
+	accountChecker     *mockAuth.MockAccountChecker
 	redisClient        *mockRedis.MockRedisClient
 }
 
 func setupHandlerMock() {
 	mockHandlerTestHelper.transactionSerivce = new(mockTransaction.MockTransactionService)
 	mockHandlerTestHelper.walletService = new(mockWallet.MockWalletService)
+	mockHandlerTestHelper.recipientService = new(mockWallet.MockRecipientService)
 	mockHandlerTestHelper.blacklistService = new(mockAuth.MockBlacklistService)
+	mockHandlerTestHelper.accountChecker = new(mockAuth.MockAccountChecker)
 	mockHandlerTestHelper.redisClient = new(mockRedis.MockRedisClient)
 
-	mockHandlerTestHelper.redisClient.On("Get", mock.Anything, "user:1:wallet_number").Return(testFromWalletNumber, nil)
+	mockHandlerTestHelper.redisClient.On("Get", mock.Anything, "user:1:default_wallet_number").Return(`{"wallet_number":"`+testFromWalletNumber+`","currency":"USD"}`, nil)
+	mockHandlerTestHelper.accountChecker.On("CurrentTokenVersion", mock.Anything).Return(1, nil)
 }
 
 func generateJWTForTest(userID int) string {
@@ -83,13 +91,18 @@ func setupHandlerRouter() *gin.Engine {
 	mockHandlerTestHelper.blacklistService.On("IsTokenBlacklisted", generateJWTForTest(testUserID)).Return(false, nil)
 
 	walletRoutes := router.Group("/wallets")
-	walletRoutes.Use(auth.JWTMiddleware(mockHandlerTestHelper.blacklistService))
+	walletRoutes.Use(auth.JWTMiddleware(mockHandlerTestHelper.blacklistService, new(mockAuth.MockSessionService), mockHandlerTestHelper.accountChecker))
 	{
+		walletRoutes.GET("", ListWalletsHandler(mockHandlerTestHelper.walletService))
+		walletRoutes.POST("/default", SetDefaultWalletHandler(mockHandlerTestHelper.walletService))
 		walletRoutes.GET("/balance", BalanceHandler(mockHandlerTestHelper.walletService))
 		walletRoutes.POST("/deposit", DepositHandler(mockHandlerTestHelper.walletService))
 		walletRoutes.POST("/withdraw", WithdrawHandler(mockHandlerTestHelper.walletService))
-		walletRoutes.POST("/transfer", TransferHandler(mockHandlerTestHelper.walletService))
+		walletRoutes.POST("/transfer", TransferHandler(mockHandlerTestHelper.walletService, mockHandlerTestHelper.recipientService))
+		walletRoutes.GET("/recipients", RecipientLookupHandler(mockHandlerTestHelper.recipientService))
 		walletRoutes.POST("/create", CreateWalletHandler(mockHandlerTestHelper.walletService))
+		walletRoutes.POST("/transactions/:id/reverse", ReverseTransactionHandler(mockHandlerTestHelper.walletService))
+		walletRoutes.GET("/transactions/:id", TransactionDetailHandler(mockHandlerTestHelper.transactionSerivce))
 		walletRoutes.GET("/transactions",
 			WalletNumberMiddleware(mockHandlerTestHelper.walletService, mockHandlerTestHelper.redisClient),
 			TransactionHistoryHandler(mockHandlerTestHelper.transactionSerivce),
@@ -98,10 +111,16 @@ func setupHandlerRouter() *gin.Engine {
 	return router
 }
 
+// usd builds a Money value in the default currency for test fixtures
+func usd(amount string) money.Money {
+	return money.MustParse(amount, money.DefaultCurrency)
+}
+
 func createMockWallet(walletNumber string, userId int) *models.Wallet {
 	return &models.Wallet{
 		UserID:       userId,
-		Balance:      100.0,
+		Currency:     money.DefaultCurrency,
+		Balance:      usd("100.00"),
 		WalletNumber: walletNumber,
 		UpdatedAt:    now,
 	}
@@ -112,21 +131,33 @@ type testWalletService struct {
 	testutils.BaseHandlerTestCase
 	userID       int
 	walletNumber string
-	amount       float64
+	amount       money.Money
 }
 
 func walletServiceTestInit(tt testWalletService) WalletServiceInterface {
 	setupServiceMock()
 	tt.MockSetup()
-	return NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.transactionService)
+	return NewWalletService(mockServiceTestHelper.walletRepo, mockServiceTestHelper.ledgerService, mockServiceTestHelper.transactionService, mockServiceTestHelper.redisClient, nil)
 }
 
 func setupServiceMock() {
 	mockServiceTestHelper.walletRepo = new(mockWallet.MockWalletRepository)
+	mockServiceTestHelper.ledgerService = new(mockLedger.MockLedgerService)
 	mockServiceTestHelper.transactionService = new(mockTransaction.MockTransactionService)
+	mockServiceTestHelper.redisClient = new(mockRedis.MockRedisClient)
+}
+
+// expectFailureRecorded expects a refused money movement to be recorded as a failed transaction in its own DB transaction
+func expectFailureRecorded(fromWalletNumber, toWalletNumber *string, transactionType string) {
+	failed := &models.Transaction{ID: 99, FromWalletNumber: fromWalletNumber, ToWalletNumber: toWalletNumber, TransactionType: transactionType, Status: transaction.StatusPending}
+	mockServiceTestHelper.transactionService.On("RecordTransaction", mock.AnythingOfType("*sql.Tx"), fromWalletNumber, toWalletNumber, transactionType, mock.AnythingOfType("money.Money"), mock.AnythingOfType("models.TransactionNote")).Return(failed, nil)
+	mockServiceTestHelper.transactionService.On("UpdateStatus", mock.AnythingOfType("*sql.Tx"), failed, transaction.StatusFailed).Return(nil)
+	mockServiceTestHelper.walletRepo.On("Commit", mock.AnythingOfType("*sql.Tx")).Return(nil)
 }
 
 var mockServiceTestHelper struct {
 	walletRepo         *mockWallet.MockWalletRepository
+	ledgerService      *mockLedger.MockLedgerService
 	transactionService *mockTransaction.MockTransactionService
+	redisClient        *mockRedis.MockRedisClient
 }
@@ -0,0 +1,5 @@
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(12, 2) USING (amount::NUMERIC / 100);
+
+ALTER TABLE wallets ALTER COLUMN balance DROP DEFAULT;
+ALTER TABLE wallets ALTER COLUMN balance TYPE NUMERIC(12, 2) USING (balance::NUMERIC / 100);
+ALTER TABLE wallets ALTER COLUMN balance SET DEFAULT 0.00;
@@ -0,0 +1,6 @@
+-- Store monetary values as integer minor units (cents) instead of NUMERIC(12, 2)
+ALTER TABLE wallets ALTER COLUMN balance DROP DEFAULT;
+ALTER TABLE wallets ALTER COLUMN balance TYPE BIGINT USING ROUND(balance * 100)::BIGINT;
+ALTER TABLE wallets ALTER COLUMN balance SET DEFAULT 0;
+
+ALTER TABLE transactions ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
@@ -0,0 +1,2 @@
+-- Guarantee at the database level that a wallet can never be overdrawn
+ALTER TABLE wallets ADD CONSTRAINT chk_wallet_balance_non_negative CHECK (balance >= 0);
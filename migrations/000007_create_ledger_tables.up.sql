@@ -0,0 +1,81 @@
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(100) NOT NULL,
+    account_type VARCHAR(20) NOT NULL CHECK (account_type IN ('wallet', 'system')),
+    wallet_number VARCHAR(50),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_ledger_account_code_currency UNIQUE (code, currency)
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT REFERENCES transactions(id),
+    entry_type VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id SERIAL PRIMARY KEY,
+    journal_entry_id INT NOT NULL REFERENCES journal_entries(id),
+    account_id INT NOT NULL REFERENCES ledger_accounts(id),
+    direction VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ledger_account_wallet_number ON ledger_accounts(wallet_number);
+CREATE INDEX idx_journal_entry_transaction_id ON journal_entries(transaction_id);
+CREATE INDEX idx_posting_journal_entry_id ON postings(journal_entry_id);
+CREATE INDEX idx_posting_account_id ON postings(account_id);
+
+-- Reject any journal entry whose debits and credits differ once the DB transaction commits
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM postings
+        WHERE journal_entry_id = NEW.journal_entry_id
+        GROUP BY currency
+        HAVING SUM(CASE WHEN direction = 'debit' THEN amount ELSE -amount END) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER trg_postings_balanced
+    AFTER INSERT OR UPDATE ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- System accounts the wallets post against
+INSERT INTO ledger_accounts (code, account_type, currency) VALUES
+    ('system:external_cash', 'system', 'USD'),
+    ('system:fees', 'system', 'USD'),
+    ('system:opening_balances', 'system', 'USD');
+
+-- Open a ledger account for every existing wallet
+INSERT INTO ledger_accounts (code, account_type, wallet_number, currency)
+SELECT 'wallet:' || wallet_number, 'wallet', wallet_number, 'USD' FROM wallets;
+
+-- Carry existing balances into the ledger so postings and wallets.balance agree from day one
+INSERT INTO journal_entries (entry_type)
+SELECT 'opening_balance:' || wallet_number FROM wallets WHERE balance > 0;
+
+INSERT INTO postings (journal_entry_id, account_id, direction, amount, currency)
+SELECT je.id, la.id, 'credit', w.balance, 'USD'
+FROM wallets w
+JOIN journal_entries je ON je.entry_type = 'opening_balance:' || w.wallet_number
+JOIN ledger_accounts la ON la.code = 'wallet:' || w.wallet_number
+WHERE w.balance > 0;
+
+INSERT INTO postings (journal_entry_id, account_id, direction, amount, currency)
+SELECT je.id, la.id, 'debit', w.balance, 'USD'
+FROM wallets w
+JOIN journal_entries je ON je.entry_type = 'opening_balance:' || w.wallet_number
+JOIN ledger_accounts la ON la.code = 'system:opening_balances'
+WHERE w.balance > 0;
+
+UPDATE journal_entries SET entry_type = 'opening_balance' WHERE entry_type LIKE 'opening_balance:%';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transaction_status;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS chk_transaction_status,
+    DROP COLUMN IF EXISTS reversed_at,
+    DROP COLUMN IF EXISTS failed_at,
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS status;
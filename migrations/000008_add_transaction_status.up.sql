@@ -0,0 +1,12 @@
+ALTER TABLE transactions
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    ADD COLUMN completed_at TIMESTAMP,
+    ADD COLUMN failed_at TIMESTAMP,
+    ADD COLUMN reversed_at TIMESTAMP,
+    ADD CONSTRAINT chk_transaction_status CHECK (status IN ('pending', 'completed', 'failed', 'reversed'));
+
+-- Every transaction recorded before statuses existed was final
+UPDATE transactions SET status = 'completed', completed_at = created_at;
+
+-- Add an index on status to filter history efficiently
+CREATE INDEX idx_transaction_status ON transactions(status);
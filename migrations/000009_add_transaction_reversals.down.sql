@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_reverses_transaction_id;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS reverses_transaction_id;
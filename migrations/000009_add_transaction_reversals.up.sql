@@ -0,0 +1,5 @@
+-- A reversal points at the transaction it compensates; a transaction may have several partial reversals
+ALTER TABLE transactions
+    ADD COLUMN reverses_transaction_id INT REFERENCES transactions(id);
+
+CREATE INDEX idx_reverses_transaction_id ON transactions(reverses_transaction_id);
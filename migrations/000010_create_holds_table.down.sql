@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS holds;
+
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS chk_wallet_held_balance,
+    DROP COLUMN IF EXISTS held_balance;
@@ -0,0 +1,23 @@
+-- Funds reserved by active holds; the available balance is balance - held_balance
+ALTER TABLE wallets
+    ADD COLUMN held_balance BIGINT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT chk_wallet_held_balance CHECK (held_balance >= 0 AND held_balance <= balance);
+
+CREATE TABLE IF NOT EXISTS holds (
+    id SERIAL PRIMARY KEY,
+    wallet_number VARCHAR(50) NOT NULL REFERENCES wallets(wallet_number),
+    to_wallet_number VARCHAR(50) NOT NULL REFERENCES wallets(wallet_number),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'captured', 'released', 'expired')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    captured_at TIMESTAMPTZ,
+    released_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_holds_wallet_number ON holds(wallet_number);
+CREATE INDEX idx_holds_to_wallet_number ON holds(to_wallet_number);
+
+-- Only active holds are scanned for expiry
+CREATE INDEX idx_holds_active_expires_at ON holds(expires_at) WHERE status = 'active';
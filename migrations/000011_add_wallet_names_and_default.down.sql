@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_wallets_one_default_per_user;
+DROP INDEX IF EXISTS idx_wallets_user_name;
+
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS is_default,
+    DROP COLUMN IF EXISTS name;
@@ -0,0 +1,15 @@
+-- Users can own several wallets, told apart by name, with exactly one default
+ALTER TABLE wallets
+    ADD COLUMN name VARCHAR(50) NOT NULL DEFAULT 'Main',
+    ADD COLUMN is_default BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Existing users keep their oldest wallet as the default
+UPDATE wallets w SET is_default = TRUE
+WHERE w.id = (SELECT MIN(id) FROM wallets WHERE user_id = w.user_id);
+
+-- Existing users with several wallets get distinct names
+UPDATE wallets w SET name = 'Wallet ' || w.id
+WHERE NOT w.is_default;
+
+CREATE UNIQUE INDEX idx_wallets_user_name ON wallets(user_id, name);
+CREATE UNIQUE INDEX idx_wallets_one_default_per_user ON wallets(user_id) WHERE is_default;
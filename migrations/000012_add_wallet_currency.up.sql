@@ -0,0 +1,4 @@
+-- Each wallet holds a single ISO 4217 currency; amounts on its transactions and holds are in that currency
+ALTER TABLE wallets ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE transactions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE holds ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS fx_quotes;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS fx_spread,
+    DROP COLUMN IF EXISTS fx_rate,
+    DROP COLUMN IF EXISTS to_currency,
+    DROP COLUMN IF EXISTS to_amount;
+
+DROP TABLE IF EXISTS fx_rates;
@@ -0,0 +1,56 @@
+-- Mid-market rates used to price conversions, one row per currency pair.
+-- A pair only needs to be stored in one direction; the opposite direction uses the inverse.
+CREATE TABLE IF NOT EXISTS fx_rates (
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate NUMERIC(20, 8) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency)
+);
+
+-- Reference rates so the service can price conversions offline; keep them up to date in production
+INSERT INTO fx_rates (base_currency, quote_currency, rate) VALUES
+    ('USD', 'EUR', 0.92),
+    ('USD', 'GBP', 0.79),
+    ('USD', 'CHF', 0.88),
+    ('USD', 'CAD', 1.36),
+    ('USD', 'AUD', 1.52),
+    ('USD', 'SGD', 1.34),
+    ('USD', 'THB', 36.50),
+    ('USD', 'JPY', 150.00),
+    ('USD', 'KRW', 1380.00),
+    ('USD', 'VND', 25400.00),
+    ('USD', 'BHD', 0.376),
+    ('USD', 'KWD', 0.307),
+    ('USD', 'OMR', 0.385)
+ON CONFLICT (base_currency, quote_currency) DO NOTHING;
+
+-- Cross-currency transactions record the credited leg, the applied rate and the spread kept by the platform.
+-- amount and currency stay the debited leg.
+ALTER TABLE transactions
+    ADD COLUMN to_amount BIGINT,
+    ADD COLUMN to_currency CHAR(3),
+    ADD COLUMN fx_rate NUMERIC(20, 8),
+    ADD COLUMN fx_spread BIGINT;
+
+-- A quote locks a conversion price for a short time; executing it creates the exchange transaction
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    from_wallet_number VARCHAR(50) NOT NULL REFERENCES wallets(wallet_number),
+    to_wallet_number VARCHAR(50) NOT NULL REFERENCES wallets(wallet_number),
+    source_amount BIGINT NOT NULL CHECK (source_amount > 0),
+    source_currency CHAR(3) NOT NULL,
+    target_amount BIGINT NOT NULL CHECK (target_amount > 0),
+    target_currency CHAR(3) NOT NULL,
+    spread_amount BIGINT NOT NULL CHECK (spread_amount >= 0),
+    mid_rate NUMERIC(20, 8) NOT NULL,
+    applied_rate NUMERIC(20, 8) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'executed')),
+    transaction_id INT REFERENCES transactions(id),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    executed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_fx_quotes_user_id ON fx_quotes(user_id);
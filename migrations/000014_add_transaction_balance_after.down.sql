@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_posting_account_id_created_at;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS to_balance_after,
+    DROP COLUMN IF EXISTS from_balance_after;
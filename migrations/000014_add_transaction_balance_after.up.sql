@@ -0,0 +1,33 @@
+-- The balance each side's wallet was left with once the transaction was posted.
+-- Withdrawals only have a source wallet and deposits only a destination wallet, so the other side stays NULL.
+ALTER TABLE transactions
+    ADD COLUMN from_balance_after BIGINT,
+    ADD COLUMN to_balance_after BIGINT;
+
+-- Backfill posted transactions with the running balance of each wallet account at their journal entry
+WITH postings_running AS (
+    SELECT
+        je.transaction_id,
+        la.wallet_number,
+        p.journal_entry_id,
+        p.id,
+        SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END)
+            OVER (PARTITION BY p.account_id ORDER BY p.journal_entry_id, p.id) AS balance
+    FROM postings p
+    JOIN ledger_accounts la ON la.id = p.account_id
+    JOIN journal_entries je ON je.id = p.journal_entry_id
+    WHERE la.account_type = 'wallet'
+),
+running AS (
+    SELECT DISTINCT ON (transaction_id, wallet_number) transaction_id, wallet_number, balance
+    FROM postings_running
+    WHERE transaction_id IS NOT NULL
+    ORDER BY transaction_id, wallet_number, journal_entry_id DESC, id DESC
+)
+UPDATE transactions t SET
+    from_balance_after = (SELECT r.balance FROM running r WHERE r.transaction_id = t.id AND r.wallet_number = t.from_wallet_number),
+    to_balance_after = (SELECT r.balance FROM running r WHERE r.transaction_id = t.id AND r.wallet_number = t.to_wallet_number)
+WHERE EXISTS (SELECT 1 FROM running r WHERE r.transaction_id = t.id);
+
+-- Point-in-time balances sum a wallet account's postings up to a date
+CREATE INDEX idx_posting_account_id_created_at ON postings(account_id, created_at);
@@ -0,0 +1,20 @@
+-- Monthly statement documents, generated once per wallet and period and never edited afterwards
+CREATE TABLE IF NOT EXISTS statements (
+    id SERIAL PRIMARY KEY,
+    statement_number VARCHAR(40) NOT NULL UNIQUE,
+    wallet_number VARCHAR(50) NOT NULL REFERENCES wallets(wallet_number),
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    currency CHAR(3) NOT NULL,
+    opening_balance BIGINT NOT NULL,
+    closing_balance BIGINT NOT NULL,
+    total_fees BIGINT NOT NULL DEFAULT 0,
+    transaction_count INT NOT NULL DEFAULT 0,
+    html TEXT NOT NULL,
+    pdf BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (period_start < period_end)
+);
+
+-- One statement per wallet and period, so a rerun of the job cannot issue a second one
+CREATE UNIQUE INDEX idx_statements_wallet_period ON statements(wallet_number, period_start);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transaction_external_reference;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS external_reference,
+    DROP COLUMN IF EXISTS memo;
@@ -0,0 +1,9 @@
+-- What the client attached to a deposit, withdrawal or transfer: a memo shown to both parties,
+-- a reference ID from the client's own system and free-form metadata. All optional.
+ALTER TABLE transactions
+    ADD COLUMN memo VARCHAR(255),
+    ADD COLUMN external_reference VARCHAR(100),
+    ADD COLUMN metadata JSONB;
+
+-- History can be searched by external reference
+CREATE INDEX idx_transaction_external_reference ON transactions(external_reference);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_handle;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS name,
+    DROP COLUMN IF EXISTS handle;
@@ -0,0 +1,8 @@
+-- A unique handle lets users be paid as $handle instead of by wallet number, and the display name
+-- is shown masked when a payer looks them up. Both optional.
+ALTER TABLE users
+    ADD COLUMN handle VARCHAR(30),
+    ADD COLUMN name VARCHAR(100);
+
+-- Handles are stored lowercase, so the unique index is case-insensitive
+CREATE UNIQUE INDEX idx_users_handle ON users(handle);
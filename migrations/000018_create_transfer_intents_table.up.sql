@@ -0,0 +1,26 @@
+-- A transfer intent is a previewed transfer waiting for the payer's confirmation. It keeps the numbers shown
+-- on the confirmation screen, the masked recipient among them, so the confirmed transfer matches what was shown.
+CREATE TABLE IF NOT EXISTS transfer_intents (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    from_wallet_number VARCHAR(50) NOT NULL REFERENCES wallets(wallet_number),
+    to_wallet_number VARCHAR(50) NOT NULL REFERENCES wallets(wallet_number),
+    recipient_handle VARCHAR(30),
+    recipient_name VARCHAR(255),
+    recipient_email VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    fee BIGINT NOT NULL CHECK (fee >= 0),
+    credited_amount BIGINT NOT NULL CHECK (credited_amount > 0),
+    credited_currency CHAR(3) NOT NULL,
+    balance_after BIGINT NOT NULL,
+    memo VARCHAR(255),
+    external_reference VARCHAR(100),
+    metadata JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'confirmed')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    confirmed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_transfer_intents_user_id ON transfer_intents(user_id);
@@ -0,0 +1,5 @@
+-- A disabled user cannot log in or use their tokens. Access tokens carry the token version they were issued
+-- with; bumping it, when the password changes or the user is disabled, invalidates every outstanding token.
+ALTER TABLE users
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'disabled')),
+    ADD COLUMN token_version INT NOT NULL DEFAULT 1;
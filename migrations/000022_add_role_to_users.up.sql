@@ -0,0 +1,5 @@
+-- The role grants access to the admin API: support and auditor staff read users, wallets and transactions,
+-- admins also disable users and change roles. Access tokens carry the role; changing it bumps the token version
+-- so no token keeps a role the user no longer has.
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'support', 'admin', 'auditor'));
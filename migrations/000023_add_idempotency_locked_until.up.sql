@@ -0,0 +1,8 @@
+-- A pending key is only held for a short lease. A retry may reclaim it once the lease has passed,
+-- so a crashed or panicked request does not block the key until it expires.
+ALTER TABLE idempotency_keys ADD COLUMN locked_until TIMESTAMPTZ;
+
+-- Keys already pending may belong to requests still in flight, so they keep being held until they expire
+UPDATE idempotency_keys SET locked_until = expires_at;
+
+ALTER TABLE idempotency_keys ALTER COLUMN locked_until SET NOT NULL;
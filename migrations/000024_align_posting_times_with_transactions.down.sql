@@ -0,0 +1,2 @@
+-- The previous posting times are not kept; they only differed from the transaction times by the time it took to post
+SELECT 1;
@@ -0,0 +1,11 @@
+-- Journal entries and postings take the time of their transaction, so statements reading balances from postings
+-- and lines from transactions agree at period bounds. Entries posted before this were stamped a moment later.
+UPDATE journal_entries je
+SET created_at = t.created_at
+FROM transactions t
+WHERE je.transaction_id = t.id AND je.created_at <> t.created_at;
+
+UPDATE postings p
+SET created_at = je.created_at
+FROM journal_entries je
+WHERE p.journal_entry_id = je.id AND p.created_at <> je.created_at;
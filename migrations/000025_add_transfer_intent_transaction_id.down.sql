@@ -0,0 +1 @@
+ALTER TABLE transfer_intents DROP COLUMN IF EXISTS transaction_id;
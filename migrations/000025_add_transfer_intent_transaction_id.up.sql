@@ -0,0 +1,2 @@
+-- A confirmed intent points at the transfer it made, which is posted in the same DB transaction as the confirmation
+ALTER TABLE transfer_intents ADD COLUMN transaction_id INT REFERENCES transactions(id);
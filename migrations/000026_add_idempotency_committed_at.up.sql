@@ -0,0 +1,3 @@
+-- Set in the DB transaction that moves the money of the request holding the key.
+-- A key marked this way is never released or reclaimed, so its request cannot run twice.
+ALTER TABLE idempotency_keys ADD COLUMN committed_at TIMESTAMPTZ;
@@ -3,9 +3,11 @@ package transaction_test
 import (
 	"centralized-wallet/internal/database"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"centralized-wallet/internal/redis"
 	"centralized-wallet/internal/seed"
 	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
 	"centralized-wallet/tests/testutils"
 	"context"
 	"database/sql"
@@ -70,7 +72,7 @@ func GenerateSampleTransactions() []models.Transaction {
 			FromWalletNumber: &fromWallet1,            // From wallet
 			ToWalletNumber:   &toWallet1,              // To wallet
 			TransactionType:  "transfer",              // Transaction type: transfer
-			Amount:           50.00,                   // Transfer amount
+			Amount:           usd("50.00"),            // Transfer amount
 			CreatedAt:        now.Add(-1 * time.Hour), // Transaction created 1 hour ago
 		},
 		{
@@ -78,7 +80,7 @@ func GenerateSampleTransactions() []models.Transaction {
 			FromWalletNumber: nil,                     // Deposit to wallet, no from wallet
 			ToWalletNumber:   &toWallet1,              // To wallet
 			TransactionType:  "deposit",               // Transaction type: deposit
-			Amount:           200.00,                  // Deposit amount
+			Amount:           usd("200.00"),           // Deposit amount
 			CreatedAt:        now.Add(-2 * time.Hour), // Transaction created 2 hours ago
 		},
 		{
@@ -86,7 +88,7 @@ func GenerateSampleTransactions() []models.Transaction {
 			FromWalletNumber: nil,                        // Deposit to wallet, no from wallet
 			ToWalletNumber:   &toWallet2,                 // To wallet
 			TransactionType:  "deposit",                  // Transaction type: deposit
-			Amount:           300.00,                     // Deposit amount
+			Amount:           usd("300.00"),              // Deposit amount
 			CreatedAt:        now.Add(-30 * time.Minute), // Transaction created 30 minutes ago
 		},
 		{
@@ -94,7 +96,7 @@ func GenerateSampleTransactions() []models.Transaction {
 			FromWalletNumber: &fromWallet2,               // From wallet
 			ToWalletNumber:   &toWallet2,                 // To wallet
 			TransactionType:  "transfer",                 // Transaction type: transfer
-			Amount:           150.00,                     // Transfer amount
+			Amount:           usd("150.00"),              // Transfer amount
 			CreatedAt:        now.Add(-15 * time.Minute), // Transaction created 15 minutes ago
 		},
 		{
@@ -102,12 +104,18 @@ func GenerateSampleTransactions() []models.Transaction {
 			FromWalletNumber: &fromWallet1,               // From wallet
 			ToWalletNumber:   &toWallet2,                 // To wallet
 			TransactionType:  "transfer",                 // Transaction type: transfer
-			Amount:           75.00,                      // Transfer amount
+			Amount:           usd("75.00"),               // Transfer amount
+			Status:           transaction.StatusPending,  // Transfer still in flight
 			CreatedAt:        now.Add(-10 * time.Minute), // Transaction created 10 minutes ago
 		},
 	}
 }
 
+// usd builds a Money value in the default currency for test fixtures
+func usd(amount string) money.Money {
+	return money.MustParse(amount, money.DefaultCurrency)
+}
+
 func setupFixtures() {
 	for _, transaction := range GenerateSampleTransactions() {
 		seed.SeedTransactions(dbService.GetDB(), &transaction)
@@ -125,14 +133,14 @@ func setupFixtures() {
 		var id int
 		var fromWallet sql.NullString
 		var toWallet sql.NullString
-		var amount float64
+		var amount money.Money
 
 		err := rows.Scan(&id, &fromWallet, &toWallet, &amount)
 		if err != nil {
 			log.Fatalf("Failed to scan transaction row: %v", err)
 		}
 
-		log.Printf("ID: %d, FromWallet: %s, ToWallet: %s, Amount: %.2f\n",
+		log.Printf("ID: %d, FromWallet: %s, ToWallet: %s, Amount: %s\n",
 			id, fromWallet.String, toWallet.String, amount)
 	}
 }
@@ -144,15 +152,21 @@ func TestGetTransactionHistoryService(t *testing.T) {
 	transactionRepo := transaction.NewTransactionRepository(dbService.GetDB())
 	transactionService := transaction.NewTransactionService(transactionRepo, redisService)
 
+	// Filter bounds relative to the fixtures
+	twentyMinutesAgo := time.Now().Add(-20 * time.Minute)
+	minAmount := usd("100.00")
+	maxAmount := usd("200.00")
+
 	// Define the test cases (table-driven test)
 	testCases := []struct {
 		name            string
 		walletNumber    string
+		filter          models.TransactionFilter
 		orderBy         string
 		limit           int
 		offset          int
 		expectedLength  int
-		expectedAmounts []float64
+		expectedAmounts []money.Money
 	}{
 		{
 			name:            "First page, descending order",
@@ -161,7 +175,7 @@ func TestGetTransactionHistoryService(t *testing.T) {
 			limit:           2,
 			offset:          0,
 			expectedLength:  2,
-			expectedAmounts: []float64{75.00, 50.00},
+			expectedAmounts: []money.Money{usd("75.00"), usd("50.00")},
 		},
 		{
 			name:            "First page, ascending order",
@@ -170,7 +184,7 @@ func TestGetTransactionHistoryService(t *testing.T) {
 			limit:           2,
 			offset:          0,
 			expectedLength:  2,
-			expectedAmounts: []float64{50.00, 75.00},
+			expectedAmounts: []money.Money{usd("50.00"), usd("75.00")},
 		},
 		{
 			name:            "Second page, descending order",
@@ -179,7 +193,7 @@ func TestGetTransactionHistoryService(t *testing.T) {
 			limit:           1,
 			offset:          1,
 			expectedLength:  1,
-			expectedAmounts: []float64{50.00},
+			expectedAmounts: []money.Money{usd("50.00")},
 		},
 		{
 			name:            "Limit more than available transactions",
@@ -188,7 +202,7 @@ func TestGetTransactionHistoryService(t *testing.T) {
 			limit:           10,
 			offset:          0,
 			expectedLength:  2,
-			expectedAmounts: []float64{75.00, 50.00},
+			expectedAmounts: []money.Money{usd("75.00"), usd("50.00")},
 		},
 		{
 			name:            "Offset beyond data range",
@@ -197,7 +211,77 @@ func TestGetTransactionHistoryService(t *testing.T) {
 			limit:           2,
 			offset:          10,
 			expectedLength:  0,
-			expectedAmounts: []float64{},
+			expectedAmounts: []money.Money{},
+		},
+		{
+			name:            "Only completed transactions",
+			walletNumber:    "wallet123",
+			filter:          models.TransactionFilter{Status: transaction.StatusCompleted},
+			orderBy:         "DESC",
+			limit:           10,
+			offset:          0,
+			expectedLength:  1,
+			expectedAmounts: []money.Money{usd("50.00")},
+		},
+		{
+			name:            "Only pending transactions",
+			walletNumber:    "wallet123",
+			filter:          models.TransactionFilter{Status: transaction.StatusPending},
+			orderBy:         "DESC",
+			limit:           10,
+			offset:          0,
+			expectedLength:  1,
+			expectedAmounts: []money.Money{usd("75.00")},
+		},
+		{
+			name:            "Only deposits",
+			walletNumber:    "wallet101112",
+			filter:          models.TransactionFilter{Type: transaction.TypeDeposit},
+			orderBy:         "DESC",
+			limit:           10,
+			offset:          0,
+			expectedLength:  1,
+			expectedAmounts: []money.Money{usd("300.00")},
+		},
+		{
+			name:            "No incoming transactions",
+			walletNumber:    "wallet123",
+			filter:          models.TransactionFilter{Direction: transaction.DirectionIncoming},
+			orderBy:         "DESC",
+			limit:           10,
+			offset:          0,
+			expectedLength:  0,
+			expectedAmounts: []money.Money{},
+		},
+		{
+			name:            "Created in the last 20 minutes",
+			walletNumber:    "wallet101112",
+			filter:          models.TransactionFilter{CreatedFrom: &twentyMinutesAgo},
+			orderBy:         "DESC",
+			limit:           10,
+			offset:          0,
+			expectedLength:  2,
+			expectedAmounts: []money.Money{usd("75.00"), usd("150.00")},
+		},
+		{
+			name:            "Amount range",
+			walletNumber:    "wallet101112",
+			filter:          models.TransactionFilter{MinAmount: &minAmount, MaxAmount: &maxAmount},
+			orderBy:         "DESC",
+			limit:           10,
+			offset:          0,
+			expectedLength:  1,
+			expectedAmounts: []money.Money{usd("150.00")},
+		},
+		{
+			name:            "Counterparty wallet number",
+			walletNumber:    "wallet101112",
+			filter:          models.TransactionFilter{Counterparty: "wallet456"},
+			orderBy:         "DESC",
+			limit:           10,
+			offset:          0,
+			expectedLength:  1,
+			expectedAmounts: []money.Money{usd("150.00")},
 		},
 	}
 
@@ -207,7 +291,7 @@ func TestGetTransactionHistoryService(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the method you're testing
-			transactions, err := transactionService.GetTransactionHistory(tc.walletNumber, tc.orderBy, tc.limit, tc.offset)
+			transactions, err := transactionService.GetTransactionHistory(tc.walletNumber, tc.filter, tc.orderBy, tc.limit, tc.offset)
 
 			// Ensure no error occurred
 			assert.NoError(t, err)
@@ -222,3 +306,48 @@ func TestGetTransactionHistoryService(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTransactionHistoryPageService(t *testing.T) {
+	setupFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(dbService.GetDB()), redisService)
+	amounts := func(page *models.TransactionPage) []money.Money {
+		result := []money.Money{}
+		for _, tx := range page.Transactions {
+			result = append(result, tx.Amount)
+		}
+		return result
+	}
+
+	first, err := transactionService.GetTransactionHistoryPage("wallet101112", models.TransactionFilter{}, "DESC", 2, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []money.Money{usd("75.00"), usd("150.00")}, amounts(first))
+	assert.Empty(t, first.PrevCursor)
+	assert.NotEmpty(t, first.NextCursor)
+
+	// A transaction arriving between two reads does not shift the next page
+	toWallet := "wallet101112"
+	err = seed.SeedTransactions(dbService.GetDB(), &models.Transaction{
+		ToWalletNumber:  &toWallet,
+		TransactionType: "deposit",
+		Amount:          usd("20.00"),
+		CreatedAt:       time.Now(),
+	})
+	assert.NoError(t, err)
+
+	second, err := transactionService.GetTransactionHistoryPage("wallet101112", models.TransactionFilter{}, "DESC", 2, first.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, []money.Money{usd("300.00")}, amounts(second))
+	assert.Empty(t, second.NextCursor)
+	assert.NotEmpty(t, second.PrevCursor)
+
+	previous, err := transactionService.GetTransactionHistoryPage("wallet101112", models.TransactionFilter{}, "DESC", 2, second.PrevCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, []money.Money{usd("75.00"), usd("150.00")}, amounts(previous))
+	assert.NotEmpty(t, previous.PrevCursor, "the new deposit is now before the first page")
+	assert.NotEmpty(t, previous.NextCursor)
+
+	_, err = transactionService.GetTransactionHistoryPage("wallet101112", models.TransactionFilter{}, "ASC", 2, first.NextCursor)
+	assert.ErrorIs(t, err, utils.ServiceErrInvalidCursor)
+}
@@ -0,0 +1,100 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/user"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// currentVersionOf returns the token version carried by an access token
+func currentVersionOf(t *testing.T, accessToken string) int {
+	token, err := auth.ValidateJWT(accessToken)
+	assert.NoError(t, err)
+	return auth.TokenVersionClaim(token.Claims.(jwt.MapClaims))
+}
+
+// TestChangePasswordInvalidatesTokens changes Jack's password and checks the tokens issued before are refused,
+// while David's are not
+func TestChangePasswordInvalidatesTokens(t *testing.T) {
+	setupUserFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	sessionService := auth.NewSessionService(auth.NewSessionRepository(dbService.GetDB()), redisService)
+	accountService := user.NewAccountService(user.NewUserRepository(dbService.GetDB()), sessionService, redisService)
+	tokenService := auth.NewTokenService(auth.NewRefreshTokenRepository(dbService.GetDB()), sessionService, accountService)
+
+	jack, err := tokenService.IssueTokens(1, "Mozilla/5.0", "203.0.113.7")
+	assert.NoError(t, err)
+	david, err := tokenService.IssueTokens(2, "Mozilla/5.0", "192.0.2.10")
+	assert.NoError(t, err)
+
+	version, err := accountService.CurrentTokenVersion(1)
+	assert.NoError(t, err)
+	assert.Equal(t, version, currentVersionOf(t, jack.AccessToken))
+
+	assert.ErrorIs(t, accountService.ChangePassword(1, "wrong-password", "new-password"), utils.ServiceErrIncorrectPassword)
+	assert.NoError(t, accountService.ChangePassword(1, "password1", "new-password"))
+
+	// The cached version was dropped, so the change is seen at once
+	changed, err := accountService.CurrentTokenVersion(1)
+	assert.NoError(t, err)
+	assert.Equal(t, version+1, changed)
+	assert.NotEqual(t, changed, currentVersionOf(t, jack.AccessToken))
+	_, err = tokenService.RefreshTokens(jack.RefreshToken)
+	assert.Error(t, err)
+
+	// David is not affected
+	davidVersion, err := accountService.CurrentTokenVersion(2)
+	assert.NoError(t, err)
+	assert.Equal(t, davidVersion, currentVersionOf(t, david.AccessToken))
+
+	// Logging in again with the new password gets tokens of the new version
+	login, err := user.NewUserService(user.NewUserRepository(dbService.GetDB())).LoginUser("jack@example.com", "new-password")
+	assert.NoError(t, err)
+	relogin, err := tokenService.IssueTokens(login.ID, "Mozilla/5.0", "203.0.113.7")
+	assert.NoError(t, err)
+	assert.Equal(t, changed, currentVersionOf(t, relogin.AccessToken))
+}
+
+// TestDisableUser disables Jack and checks his tokens and logins are refused until he is enabled again
+func TestDisableUser(t *testing.T) {
+	setupUserFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	sessionService := auth.NewSessionService(auth.NewSessionRepository(dbService.GetDB()), redisService)
+	accountService := user.NewAccountService(user.NewUserRepository(dbService.GetDB()), sessionService, redisService)
+	tokenService := auth.NewTokenService(auth.NewRefreshTokenRepository(dbService.GetDB()), sessionService, accountService)
+	userService := user.NewUserService(user.NewUserRepository(dbService.GetDB()))
+
+	jack, err := tokenService.IssueTokens(1, "Mozilla/5.0", "203.0.113.7")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, accountService.SetUserStatus(1, "suspended"), utils.ServiceErrInvalidUserStatus)
+	assert.NoError(t, accountService.SetUserStatus(1, models.UserStatusDisabled))
+
+	_, err = accountService.CurrentTokenVersion(1)
+	assert.ErrorIs(t, err, utils.ServiceErrUserDisabled)
+	_, err = tokenService.RefreshTokens(jack.RefreshToken)
+	assert.Error(t, err)
+	_, err = userService.LoginUser("jack@example.com", "password1")
+	assert.ErrorIs(t, err, utils.ServiceErrUserDisabled)
+	sessions, err := sessionService.ListSessions(1)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	// Enabled again, Jack logs in with new tokens; those issued before stay refused
+	assert.NoError(t, accountService.SetUserStatus(1, models.UserStatusActive))
+	version, err := accountService.CurrentTokenVersion(1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, version, currentVersionOf(t, jack.AccessToken))
+	_, err = userService.LoginUser("jack@example.com", "password1")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, accountService.SetUserStatus(99, models.UserStatusDisabled), utils.ErrUserNotFound)
+}
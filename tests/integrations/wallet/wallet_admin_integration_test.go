@@ -0,0 +1,80 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/admin"
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/user"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdminLookups makes David support staff, then finds Jack and his wallet the way support would
+func TestAdminLookups(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	userRepo := user.NewUserRepository(dbService.GetDB())
+	sessionService := auth.NewSessionService(auth.NewSessionRepository(dbService.GetDB()), redisService)
+	accountService := user.NewAccountService(userRepo, sessionService, redisService)
+	tokenService := auth.NewTokenService(auth.NewRefreshTokenRepository(dbService.GetDB()), sessionService, accountService)
+	adminService := admin.NewAdminService(userRepo, wallet.NewWalletRepository(dbService.GetDB()), accountService)
+
+	david, err := tokenService.IssueTokens(2, "Mozilla/5.0", "192.0.2.10")
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserRoleUser, roleOf(t, david.AccessToken))
+
+	// Changing the role refuses the tokens of the previous role; refreshing gets one of the new role
+	assert.ErrorIs(t, accountService.SetUserRole(2, "root"), utils.ServiceErrInvalidUserRole)
+	assert.NoError(t, accountService.SetUserRole(2, models.UserRoleSupport))
+	version, err := accountService.CurrentTokenVersion(2)
+	assert.NoError(t, err)
+	assert.NotEqual(t, version, currentVersionOf(t, david.AccessToken))
+	refreshed, err := tokenService.RefreshTokens(david.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserRoleSupport, roleOf(t, refreshed.AccessToken))
+	assert.Equal(t, version, currentVersionOf(t, refreshed.AccessToken))
+
+	// Search by part of the email, or by ID; wildcards are matched literally
+	users, err := adminService.SearchUsers("JACK@", 20, 0)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "jack@example.com", users[0].Email)
+	assert.Equal(t, models.UserStatusActive, users[0].Status)
+
+	users, err = adminService.SearchUsers("2", 20, 0)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, models.UserRoleSupport, users[0].Role)
+
+	users, err = adminService.SearchUsers("%", 20, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	// A number too large for a user ID matches nothing instead of failing the query
+	users, err = adminService.SearchUsers("12345678901", 20, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	// Any wallet can be looked up, with its owner
+	w, owner, err := adminService.GetWallet("wallet123")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, owner.ID)
+	assert.Equal(t, "100.00", w.Balance.String())
+
+	_, _, err = adminService.GetWallet("missing")
+	assert.ErrorIs(t, err, utils.RepoErrWalletNotFound)
+}
+
+// roleOf returns the role carried by an access token
+func roleOf(t *testing.T, accessToken string) string {
+	token, err := auth.ValidateJWT(accessToken)
+	assert.NoError(t, err)
+	return auth.RoleClaim(token.Claims.(jwt.MapClaims))
+}
@@ -0,0 +1,56 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/analytics"
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWalletAnalytics aggregates a period of transfers in SQL, then checks a new transaction drops the cached result
+func TestWalletAnalytics(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	db := dbService.GetDB()
+	walletRepo := wallet.NewWalletRepository(db)
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(db), redisService)
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(db), transactionService)
+	walletService := wallet.NewWalletService(walletRepo, ledgerService, transactionService, redisService, nil)
+	analyticsService := analytics.NewAnalyticsService(analytics.NewAnalyticsRepository(db), walletRepo, redisService)
+
+	// Alice (user 1, wallet123) pays Bob twice and receives from Charlie
+	_, err := walletService.Transfer(1, "", "wallet456", usd("40.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	_, err = walletService.Transfer(1, "", "wallet456", usd("10.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	_, err = walletService.Transfer(3, "", "wallet123", usd("25.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	result, err := analyticsService.GetAnalytics(1, "", nil, nil, analytics.IntervalMonth, analytics.DefaultTop)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("25.00"), result.TotalIn)
+	assert.Equal(t, usd("50.00"), result.TotalOut)
+	assert.Equal(t, usd("-25.00"), result.Net)
+	if assert.Len(t, result.TopCounterparties, 2) {
+		assert.Equal(t, "wallet456", result.TopCounterparties[0].WalletNumber)
+		assert.Equal(t, 2, result.TopCounterparties[0].Count)
+		assert.Equal(t, usd("50.00"), result.TopCounterparties[0].Out)
+		assert.Equal(t, "wallet789", result.TopCounterparties[1].WalletNumber)
+	}
+
+	// A deposit invalidates the cached analytics, so the next read sees it
+	_, err = walletService.Deposit(1, "", usd("5.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	result, err = analyticsService.GetAnalytics(1, "", nil, nil, analytics.IntervalMonth, analytics.DefaultTop)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("30.00"), result.TotalIn)
+	assert.Equal(t, 4, result.Count)
+}
@@ -0,0 +1,68 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBalanceAfterEachTransaction posts a few transactions and checks the history shows the running balance
+// of each wallet, and that the ledger answers the balance a wallet had before and after them.
+func TestBalanceAfterEachTransaction(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	db := dbService.GetDB()
+	walletRepo := wallet.NewWalletRepository(db)
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(db), redisService)
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(db), transactionService)
+	walletService := wallet.NewWalletService(walletRepo, ledgerService, transactionService, redisService, nil)
+
+	beforeAll := time.Now()
+
+	// Alice (user 1, wallet123) deposits, pays Bob (user 2, wallet456) and withdraws
+	_, err := walletService.Deposit(1, "", usd("50.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	_, err = walletService.Transfer(1, "", "wallet456", usd("70.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	afterTransfer := time.Now()
+	_, err = walletService.Withdraw(1, "", usd("20.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	history, err := transactionService.GetTransactionHistory("wallet123", models.TransactionFilter{}, "ASC", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 3) {
+		assert.Equal(t, usd("150.00"), *history[0].BalanceAfter)
+		assert.Equal(t, usd("80.00"), *history[1].BalanceAfter)
+		assert.Equal(t, usd("60.00"), *history[2].BalanceAfter)
+	}
+
+	// The receiver sees its own balance on the same transfer
+	bobHistory, err := transactionService.GetTransactionHistory("wallet456", models.TransactionFilter{}, "ASC", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, bobHistory, 1) {
+		assert.Equal(t, transaction.DirectionIncoming, bobHistory[0].Direction)
+		assert.Equal(t, usd("270.00"), *bobHistory[0].BalanceAfter)
+	}
+
+	for _, tc := range []struct {
+		asOf     time.Time
+		expected money.Money
+	}{
+		{asOf: beforeAll, expected: usd("100.00")},
+		{asOf: afterTransfer, expected: usd("80.00")},
+		{asOf: time.Now(), expected: usd("60.00")},
+	} {
+		balance, err := ledgerService.GetBalanceAsOf("wallet123", money.DefaultCurrency, tc.asOf)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, balance)
+	}
+}
@@ -0,0 +1,139 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newConcurrencyWalletService() (*wallet.WalletService, *wallet.WalletRepository) {
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	return newLedgerBackedWalletService(walletRepo), walletRepo
+}
+
+// TestConcurrentWithdrawalsNeverOverdraw fires more withdrawals than the balance can cover at the same time
+// and checks that exactly the affordable ones succeed and the balance never drops below zero.
+func TestConcurrentWithdrawalsNeverOverdraw(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletService, walletRepo := newConcurrencyWalletService()
+
+	const attempts = 25
+	amount := usd("10.00") // user 1 starts with 100.00, so only 10 withdrawals are affordable
+
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		successes    int
+		insufficient int
+		unexpected   []error
+	)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := walletService.Withdraw(1, "", amount, models.TransactionNote{})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				successes++
+			case utils.RepoErrInsufficientFunds:
+				insufficient++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, unexpected)
+	assert.Equal(t, 10, successes)
+	assert.Equal(t, attempts-10, insufficient)
+
+	wallet, err := walletRepo.GetDefaultWallet(1)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("0.00"), wallet.Balance)
+
+	var withdrawCount int
+	err = dbService.GetDB().QueryRow(
+		"SELECT COUNT(*) FROM transactions WHERE from_wallet_number = $1 AND transaction_type = 'withdraw' AND status = 'completed'",
+		wallet.WalletNumber,
+	).Scan(&withdrawCount)
+	assert.NoError(t, err)
+	assert.Equal(t, successes, withdrawCount)
+
+	// Every refused withdrawal is kept as a failed transaction
+	var failedCount int
+	err = dbService.GetDB().QueryRow(
+		"SELECT COUNT(*) FROM transactions WHERE from_wallet_number = $1 AND transaction_type = 'withdraw' AND status = 'failed'",
+		wallet.WalletNumber,
+	).Scan(&failedCount)
+	assert.NoError(t, err)
+	assert.Equal(t, insufficient, failedCount)
+}
+
+// TestConcurrentOppositeTransfersConserveFunds runs transfers in both directions between two wallets at once.
+// Locking wallets in a consistent order means none of them deadlock and the total amount is conserved.
+func TestConcurrentOppositeTransfersConserveFunds(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletService, walletRepo := newConcurrencyWalletService()
+
+	const rounds = 20
+	amount := usd("5.00")
+
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		transferErrs []error
+	)
+
+	for i := 0; i < rounds; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := walletService.Transfer(1, "", "wallet456", amount, models.TransactionNote{}); err != nil {
+				mu.Lock()
+				transferErrs = append(transferErrs, err)
+				mu.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := walletService.Transfer(2, "", "wallet123", amount, models.TransactionNote{}); err != nil {
+				mu.Lock()
+				transferErrs = append(transferErrs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, transferErrs)
+
+	fromWallet, err := walletRepo.FindByWalletNumber("wallet123")
+	assert.NoError(t, err)
+	toWallet, err := walletRepo.FindByWalletNumber("wallet456")
+	assert.NoError(t, err)
+
+	// Each direction moved the same total, so both balances end where they started
+	assert.Equal(t, usd("100.00"), fromWallet.Balance)
+	assert.Equal(t, usd("200.00"), toWallet.Balance)
+
+	total, err := fromWallet.Balance.Add(toWallet.Balance)
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("300.00", money.DefaultCurrency), total)
+}
@@ -0,0 +1,76 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWalletsInOtherCurrencies opens yen and dinar wallets, moves money between same-currency wallets
+// and checks transfers across currencies and amounts in the wrong currency are refused.
+func TestWalletsInOtherCurrencies(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	walletService := newLedgerBackedWalletService(walletRepo)
+
+	aliceYen, err := walletService.CreateWallet(1, "Travel", "jpy")
+	assert.NoError(t, err)
+	assert.Equal(t, "JPY", aliceYen.Currency)
+	bobYen, err := walletService.CreateWallet(2, "Travel", "JPY")
+	assert.NoError(t, err)
+	aliceDinar, err := walletService.CreateWallet(1, "Bahrain", "BHD")
+	assert.NoError(t, err)
+
+	_, err = walletService.CreateWallet(1, "Unknown", "XYZ")
+	assert.ErrorIs(t, err, utils.ServiceErrUnsupportedCurrency)
+
+	updated, err := walletService.Deposit(1, aliceYen.WalletNumber, money.MustParse("1500", "JPY"), models.TransactionNote{})
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("1500", "JPY"), updated.Balance)
+
+	updated, err = walletService.Deposit(1, aliceDinar.WalletNumber, money.MustParse("1.005", "BHD"), models.TransactionNote{})
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("1.005", "BHD"), updated.Balance)
+
+	// Dollars cannot be paid into a yen wallet
+	_, err = walletService.Deposit(1, aliceYen.WalletNumber, usd("10.00"), models.TransactionNote{})
+	assert.ErrorIs(t, err, utils.ServiceErrCurrencyMismatch)
+
+	// Same-currency transfers work as before
+	_, err = walletService.Transfer(1, aliceYen.WalletNumber, bobYen.WalletNumber, money.MustParse("500", "JPY"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	// Without a conversion path yen cannot reach a dollar wallet
+	_, err = walletService.Transfer(1, aliceYen.WalletNumber, "wallet456", money.MustParse("500", "JPY"), models.TransactionNote{})
+	assert.ErrorIs(t, err, utils.ServiceErrNoConversionPath)
+
+	stored, err := walletService.GetWallet(2, bobYen.WalletNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("500", "JPY"), stored.Balance)
+
+	// The history keeps the currency, also when served from the cache
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(dbService.GetDB()), redisService)
+	for i := 0; i < 2; i++ {
+		history, err := transactionService.GetTransactionHistory(aliceDinar.WalletNumber, models.TransactionFilter{}, "DESC", 10, 0)
+		assert.NoError(t, err)
+		if assert.Len(t, history, 1) {
+			assert.Equal(t, "BHD", history[0].Currency)
+			assert.Equal(t, money.MustParse("1.005", "BHD"), history[0].Amount)
+		}
+	}
+
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(dbService.GetDB()), nil)
+	for _, walletNumber := range []string{aliceYen.WalletNumber, bobYen.WalletNumber, aliceDinar.WalletNumber} {
+		assert.NoError(t, ledgerService.VerifyWalletBalance(walletNumber), walletNumber)
+	}
+}
@@ -0,0 +1,54 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRefusedMoneyMovementsAreRecordedAsFailed checks that a withdrawal and a transfer refused for insufficient
+// funds are kept as failed transactions in the sender's history, without moving money or showing to the recipient.
+func TestRefusedMoneyMovementsAreRecordedAsFailed(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	db := dbService.GetDB()
+	walletRepo := wallet.NewWalletRepository(db)
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(db), redisService)
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(db), transactionService)
+	walletService := wallet.NewWalletService(walletRepo, ledgerService, transactionService, redisService, nil)
+
+	// Alice (user 1, wallet123) has 100.00
+	_, err := walletService.Withdraw(1, "", usd("150.00"), models.TransactionNote{})
+	assert.ErrorIs(t, err, utils.RepoErrInsufficientFunds)
+	_, err = walletService.Transfer(1, "", "wallet456", usd("120.00"), models.TransactionNote{Memo: "Rent"})
+	assert.ErrorIs(t, err, utils.RepoErrInsufficientFunds)
+
+	history, err := transactionService.GetTransactionHistory("wallet123", models.TransactionFilter{}, "ASC", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, transaction.TypeWithdraw, history[0].TransactionType)
+		assert.Equal(t, transaction.StatusFailed, history[0].Status)
+		assert.Nil(t, history[0].BalanceAfter)
+		assert.Equal(t, transaction.TypeTransfer, history[1].TransactionType)
+		assert.Equal(t, transaction.StatusFailed, history[1].Status)
+		assert.Equal(t, "Rent", history[1].Memo)
+	}
+
+	// The recipient never sees the transfer that did not reach them
+	bobHistory, err := transactionService.GetTransactionHistory("wallet456", models.TransactionFilter{}, "ASC", 10, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, bobHistory)
+
+	aliceWallet, err := walletRepo.GetDefaultWallet(1)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("100.00"), aliceWallet.Balance)
+	assert.NoError(t, ledgerService.VerifyWalletBalance("wallet123"))
+}
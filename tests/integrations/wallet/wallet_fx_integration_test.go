@@ -0,0 +1,88 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/exchange"
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTransfersAcrossCurrencies converts dollars into euros with a direct transfer and with a quote,
+// using the reference rates seeded by the migrations, and checks both legs and the spread are recorded.
+func TestTransfersAcrossCurrencies(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	db := dbService.GetDB()
+	walletRepo := wallet.NewWalletRepository(db)
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(db), redisService)
+	ledgerRepo := ledger.NewLedgerRepository(db)
+	ledgerService := ledger.NewLedgerService(ledgerRepo, transactionService)
+	converter := fx.NewConverter(fx.NewDBRateProvider(db), fx.DefaultSpreadBps)
+	walletService := wallet.NewWalletService(walletRepo, ledgerService, transactionService, redisService, converter)
+	exchangeService := exchange.NewExchangeService(exchange.NewExchangeRepository(db), walletRepo, ledgerService, converter)
+
+	bobEuro, err := walletService.CreateWallet(2, "Euro", "EUR")
+	assert.NoError(t, err)
+
+	// Alice (user 1, wallet123) sends 10.00 USD: 0.05 spread, 9.95 * 0.92 = 9.154 rounded down
+	_, err = walletService.Transfer(1, "", bobEuro.WalletNumber, usd("10.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	stored, err := walletService.GetWallet(2, bobEuro.WalletNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("9.15", "EUR"), stored.Balance)
+
+	history, err := transactionService.GetTransactionHistory("wallet123", models.TransactionFilter{}, "DESC", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, "exchange", history[0].TransactionType)
+		assert.Equal(t, usd("10.00"), history[0].Amount)
+		assert.Equal(t, "EUR", history[0].ToCurrency)
+		assert.Equal(t, money.MustParse("9.15", "EUR"), *history[0].ToAmount)
+		assert.Equal(t, "0.915", history[0].FXRate.String())
+	}
+
+	// A quote locks the price; executing it moves exactly the quoted amounts, once
+	quote, err := exchangeService.CreateQuote(1, "", bobEuro.WalletNumber, usd("20.00"))
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("18.30", "EUR"), quote.TargetAmount)
+	assert.Equal(t, usd("0.10"), quote.SpreadAmount)
+
+	executed, aliceWallet, err := exchangeService.ExecuteQuote(1, quote.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, exchange.StatusExecuted, executed.Status)
+	assert.NotNil(t, executed.TransactionID)
+	assert.Equal(t, usd("70.00"), aliceWallet.Balance)
+
+	_, _, err = exchangeService.ExecuteQuote(1, quote.ID)
+	assert.ErrorIs(t, err, utils.ServiceErrQuoteAlreadyExecuted)
+
+	// Bob cannot execute a quote made by Alice
+	other, err := exchangeService.CreateQuote(1, "", bobEuro.WalletNumber, usd("5.00"))
+	assert.NoError(t, err)
+	_, _, err = exchangeService.ExecuteQuote(2, other.ID)
+	assert.ErrorIs(t, err, utils.RepoErrQuoteNotFound)
+
+	stored, err = walletService.GetWallet(2, bobEuro.WalletNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("27.45", "EUR"), stored.Balance)
+
+	// Finance can reconcile: the spread account holds the revenue and the position nets out per currency
+	spread, err := ledgerRepo.GetPostedBalance(ledger.AccountFXSpread, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, usd("0.15"), spread)
+
+	for _, walletNumber := range []string{"wallet123", bobEuro.WalletNumber} {
+		assert.NoError(t, ledgerService.VerifyWalletBalance(walletNumber), walletNumber)
+	}
+}
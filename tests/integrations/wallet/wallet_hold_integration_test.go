@@ -0,0 +1,121 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/hold"
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newLedgerBackedHoldService wires the hold service to a real ledger and transaction service
+func newLedgerBackedHoldService(walletRepo *wallet.WalletRepository) *hold.HoldService {
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(dbService.GetDB()), redisService)
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(dbService.GetDB()), transactionService)
+	return hold.NewHoldService(hold.NewHoldRepository(dbService.GetDB()), walletRepo, ledgerService)
+}
+
+// TestHoldReducesAvailableBalanceAndCapturesPartially reserves funds, checks withdrawals cannot touch them,
+// captures part of the hold and checks the rest becomes available again.
+func TestHoldReducesAvailableBalanceAndCapturesPartially(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	walletService := newLedgerBackedWalletService(walletRepo)
+	holdService := newLedgerBackedHoldService(walletRepo)
+
+	// Alice (user 1, wallet123) reserves 80.00 for Charlie (user 3, wallet789)
+	created, err := holdService.CreateHold(1, "", "wallet789", usd("80.00"), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, hold.StatusActive, created.Status)
+
+	aliceWallet, err := walletRepo.GetDefaultWallet(1)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("100.00"), aliceWallet.Balance)
+	assert.Equal(t, usd("20.00"), aliceWallet.AvailableBalance())
+
+	// Neither a withdrawal nor a transfer can spend the held funds
+	_, err = walletService.Withdraw(1, "", usd("20.01"), models.TransactionNote{})
+	assert.ErrorIs(t, err, utils.RepoErrInsufficientFunds)
+	_, err = walletService.Transfer(1, "", "wallet456", usd("20.01"), models.TransactionNote{})
+	assert.ErrorIs(t, err, utils.RepoErrInsufficientFunds)
+
+	// Only the payee can capture
+	_, _, err = holdService.CaptureHold(1, created.ID, nil)
+	assert.ErrorIs(t, err, utils.ServiceErrHoldActionNotAllowed)
+
+	partial := usd("30.00")
+	captured, charlieWallet, err := holdService.CaptureHold(3, created.ID, &partial)
+	assert.NoError(t, err)
+	assert.Equal(t, hold.StatusCaptured, captured.Status)
+	assert.Equal(t, usd("330.00"), charlieWallet.Balance)
+
+	aliceWallet, err = walletRepo.GetDefaultWallet(1)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("70.00"), aliceWallet.Balance)
+	assert.Equal(t, usd("70.00"), aliceWallet.AvailableBalance())
+
+	// A captured hold is closed
+	_, err = holdService.ReleaseHold(3, created.ID)
+	assert.ErrorIs(t, err, utils.ServiceErrHoldNotActive)
+
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(dbService.GetDB()), nil)
+	for _, walletNumber := range []string{"wallet123", "wallet789"} {
+		assert.NoError(t, ledgerService.VerifyWalletBalance(walletNumber), walletNumber)
+	}
+}
+
+// TestHoldReleaseAndExpiry checks that released and expired holds give the funds back without moving money
+func TestHoldReleaseAndExpiry(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	holdService := newLedgerBackedHoldService(walletRepo)
+
+	released, err := holdService.CreateHold(1, "", "wallet789", usd("40.00"), 0)
+	assert.NoError(t, err)
+	expiring, err := holdService.CreateHold(1, "", "wallet789", usd("50.00"), time.Hour)
+	assert.NoError(t, err)
+
+	// 90.00 of 100.00 is reserved
+	_, err = holdService.CreateHold(1, "", "wallet789", usd("10.01"), 0)
+	assert.ErrorIs(t, err, utils.RepoErrInsufficientFunds)
+
+	released, err = holdService.ReleaseHold(3, released.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, hold.StatusReleased, released.Status)
+
+	// Nothing is due yet
+	count, err := holdService.ExpireHolds()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = dbService.GetDB().Exec("UPDATE holds SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", expiring.ID)
+	assert.NoError(t, err)
+
+	count, err = holdService.ExpireHolds()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, _, err = holdService.CaptureHold(3, expiring.ID, nil)
+	assert.ErrorIs(t, err, utils.ServiceErrHoldNotActive)
+
+	aliceWallet, err := walletRepo.GetDefaultWallet(1)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("100.00"), aliceWallet.AvailableBalance())
+
+	var transactions int
+	err = dbService.GetDB().QueryRow("SELECT COUNT(*) FROM transactions").Scan(&transactions)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, transactions)
+}
@@ -0,0 +1,54 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLedgerStaysBalancedAcrossWalletOperations runs every kind of wallet operation and checks that
+// each journal entry balances and every wallet balance equals the sum of its postings.
+func TestLedgerStaysBalancedAcrossWalletOperations(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(dbService.GetDB()), redisService)
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(dbService.GetDB()), transactionService)
+	walletService := wallet.NewWalletService(wallet.NewWalletRepository(dbService.GetDB()), ledgerService, transactionService, redisService, nil)
+
+	_, err := walletService.Deposit(1, "", usd("25.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	_, err = walletService.Withdraw(2, "", usd("40.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	_, err = walletService.Transfer(3, "", "wallet123", usd("60.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	// A failed withdrawal must leave no postings behind
+	_, err = walletService.Withdraw(1, "", usd("1000.00"), models.TransactionNote{})
+	assert.Error(t, err)
+
+	var unbalancedEntries int
+	err = dbService.GetDB().QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT journal_entry_id FROM postings
+			GROUP BY journal_entry_id, currency
+			HAVING SUM(CASE WHEN direction = 'debit' THEN amount ELSE -amount END) <> 0
+		) unbalanced`).Scan(&unbalancedEntries)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, unbalancedEntries)
+
+	for _, walletNumber := range []string{"wallet123", "wallet456", "wallet789"} {
+		assert.NoError(t, ledgerService.VerifyWalletBalance(walletNumber), walletNumber)
+	}
+
+	// Deposits debit the cash account and withdrawals credit it, so credits minus debits is 40.00 - 25.00
+	externalCash, err := ledger.NewLedgerRepository(dbService.GetDB()).GetPostedBalance(ledger.AccountExternalCash, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, usd("15.00"), externalCash)
+}
@@ -0,0 +1,75 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMultipleWalletsPerUser opens a second wallet, moves money between the user's own wallets,
+// switches the default and checks other users' wallets cannot be used as a source.
+func TestMultipleWalletsPerUser(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	walletService := newLedgerBackedWalletService(walletRepo)
+
+	// Alice (user 1) already has wallet123 as her default
+	savings, err := walletService.CreateWallet(1, "Savings", "")
+	assert.NoError(t, err)
+	assert.False(t, savings.IsDefault)
+
+	// Without a source wallet the default is debited
+	_, err = walletService.Transfer(1, "", savings.WalletNumber, usd("30.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	wallets, err := walletService.ListWallets(1)
+	assert.NoError(t, err)
+	if assert.Len(t, wallets, 2) {
+		assert.Equal(t, "wallet123", wallets[0].WalletNumber)
+		assert.True(t, wallets[0].IsDefault)
+		assert.Equal(t, usd("70.00"), wallets[0].Balance)
+		assert.Equal(t, "Savings", wallets[1].Name)
+		assert.Equal(t, usd("30.00"), wallets[1].Balance)
+	}
+
+	// A wallet cannot pay itself
+	_, err = walletService.Transfer(1, savings.WalletNumber, savings.WalletNumber, usd("1.00"), models.TransactionNote{})
+	assert.ErrorIs(t, err, utils.ServiceErrTransferToSameWallet)
+
+	// Bob's wallet cannot be used as Alice's source, nor made her default
+	_, err = walletService.Withdraw(1, "wallet456", usd("1.00"), models.TransactionNote{})
+	assert.ErrorIs(t, err, utils.RepoErrWalletNotFound)
+	_, err = walletService.SetDefaultWallet(1, "wallet456")
+	assert.ErrorIs(t, err, utils.RepoErrWalletNotFound)
+
+	_, err = walletService.SetDefaultWallet(1, savings.WalletNumber)
+	assert.NoError(t, err)
+
+	defaultWallet, err := walletService.GetWallet(1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, savings.WalletNumber, defaultWallet.WalletNumber)
+
+	// Exactly one default remains
+	var defaults int
+	err = dbService.GetDB().QueryRow("SELECT COUNT(*) FROM wallets WHERE user_id = 1 AND is_default").Scan(&defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, defaults)
+
+	// The old default can still be used by naming it
+	updated, err := walletService.Withdraw(1, "wallet123", usd("70.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	assert.Equal(t, usd("0.00"), updated.Balance)
+
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(dbService.GetDB()), nil)
+	for _, walletNumber := range []string{"wallet123", savings.WalletNumber} {
+		assert.NoError(t, ledgerService.VerifyWalletBalance(walletNumber), walletNumber)
+	}
+}
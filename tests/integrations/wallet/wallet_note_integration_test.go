@@ -0,0 +1,53 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTransferNoteIsStoredAndSearchable records a transfer with a memo, reference and metadata, then finds it
+// in both parties' history by its external reference and reads the note back from the detail.
+func TestTransferNoteIsStoredAndSearchable(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	walletService := newLedgerBackedWalletService(walletRepo)
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(dbService.GetDB()), redisService)
+
+	note := models.TransactionNote{
+		Memo:              "Concert tickets",
+		ExternalReference: "order-1042",
+		Metadata:          models.Metadata{"channel": "mobile", "seats": float64(2)},
+	}
+	_, err := walletService.Transfer(3, "", "wallet123", usd("60.00"), note)
+	assert.NoError(t, err)
+	_, err = walletService.Transfer(3, "", "wallet123", usd("5.00"), models.TransactionNote{ExternalReference: "order-1043"})
+	assert.NoError(t, err)
+
+	filter := models.TransactionFilter{ExternalReference: "order-1042"}
+	for _, walletNumber := range []string{"wallet789", "wallet123"} {
+		history, err := transactionService.GetTransactionHistory(walletNumber, filter, "DESC", 10, 0)
+		assert.NoError(t, err)
+		if assert.Len(t, history, 1) {
+			assert.Equal(t, "Concert tickets", history[0].Memo)
+			assert.Equal(t, "order-1042", history[0].ExternalReference)
+			assert.Equal(t, note.Metadata, history[0].Metadata)
+		}
+	}
+
+	var transferID int
+	err = dbService.GetDB().QueryRow("SELECT id FROM transactions WHERE external_reference = 'order-1042'").Scan(&transferID)
+	assert.NoError(t, err)
+
+	detail, err := transactionService.GetTransactionDetail(1, transferID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Concert tickets", detail.Memo)
+	assert.Equal(t, note.Metadata, detail.Metadata)
+}
@@ -0,0 +1,57 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/user"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTransferToHandle gives Jack a handle, resolves it as Charlie and pays him through it, then checks
+// Charlie's lookups are cut off once the rate limit is reached.
+func TestTransferToHandle(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+	assert.NoError(t, redisService.DeleteKeysByPattern(context.Background(), "user:*:recipient_lookups"))
+
+	userRepo := user.NewUserRepository(dbService.GetDB())
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	walletService := newLedgerBackedWalletService(walletRepo)
+	recipientService := wallet.NewRecipientService(userRepo, walletRepo, redisService)
+
+	name, handle := "Jack Sparrow", "jack"
+	_, err := userRepo.UpdateProfile(1, &name, &handle)
+	assert.NoError(t, err)
+	// Handles are unique
+	_, err = userRepo.UpdateProfile(2, nil, &handle)
+	assert.ErrorIs(t, err, utils.RepoErrHandleTaken)
+
+	recipient, err := recipientService.ResolveRecipient(3, "$Jack")
+	assert.NoError(t, err)
+	assert.Equal(t, "wallet123", recipient.WalletNumber)
+	assert.Equal(t, "J*** S***", recipient.Name)
+	assert.Equal(t, "j***@example.com", recipient.Email)
+
+	_, err = walletService.Transfer(3, "", recipient.WalletNumber, usd("25.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	jack, err := walletRepo.FindByWalletNumber("wallet123")
+	assert.NoError(t, err)
+	assert.Equal(t, usd("125.00"), jack.Balance)
+
+	_, err = recipientService.ResolveRecipient(3, "nobody@example.com")
+	assert.ErrorIs(t, err, utils.ServiceErrRecipientNotFound)
+
+	// Two lookups are used; the rest of the window's budget runs out on the limit
+	for i := 2; i < wallet.RecipientLookupLimit; i++ {
+		_, err = recipientService.ResolveRecipient(3, "$jack")
+		assert.NoError(t, err)
+	}
+	_, err = recipientService.ResolveRecipient(3, "$jack")
+	assert.ErrorIs(t, err, utils.ServiceErrRecipientLookupLimited)
+}
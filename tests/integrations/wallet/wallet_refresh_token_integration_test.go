@@ -0,0 +1,60 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/user"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRefreshTokenRotation logs Jack in, rotates his refresh token, then replays the rotated token and checks
+// the replay revokes the token issued in its place, and the session, too.
+func TestRefreshTokenRotation(t *testing.T) {
+	setupUserFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	refreshTokenRepo := auth.NewRefreshTokenRepository(dbService.GetDB())
+	sessionService := auth.NewSessionService(auth.NewSessionRepository(dbService.GetDB()), redisService)
+	accountService := user.NewAccountService(user.NewUserRepository(dbService.GetDB()), sessionService, redisService)
+	tokenService := auth.NewTokenService(refreshTokenRepo, sessionService, accountService)
+
+	login, err := tokenService.IssueTokens(1, "Mozilla/5.0", "203.0.113.7")
+	assert.NoError(t, err)
+
+	// Only the hash is stored
+	stored, err := refreshTokenRepo.GetRefreshTokenByHash(auth.HashRefreshToken(login.RefreshToken))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stored.UserID)
+	assert.Nil(t, stored.RevokedAt)
+
+	rotated, err := tokenService.RefreshTokens(login.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+
+	// The first token was rotated away; presenting it again revokes the whole session
+	_, err = tokenService.RefreshTokens(login.RefreshToken)
+	assert.ErrorIs(t, err, utils.ServiceErrRefreshTokenReused)
+	_, err = tokenService.RefreshTokens(rotated.RefreshToken)
+	assert.ErrorIs(t, err, utils.ServiceErrRefreshTokenReused)
+
+	assert.ErrorIs(t, sessionService.CheckSession(stored.FamilyID), utils.ServiceErrSessionRevoked)
+
+	// Revoking a session revokes the refresh tokens of that login, and only those
+	other, err := tokenService.IssueTokens(1, "Mozilla/5.0", "203.0.113.7")
+	assert.NoError(t, err)
+	second, err := tokenService.IssueTokens(1, "Mozilla/5.0", "203.0.113.7")
+	assert.NoError(t, err)
+	otherToken, err := refreshTokenRepo.GetRefreshTokenByHash(auth.HashRefreshToken(other.RefreshToken))
+	assert.NoError(t, err)
+	assert.NoError(t, sessionService.RevokeSession(1, otherToken.FamilyID))
+	_, err = tokenService.RefreshTokens(other.RefreshToken)
+	assert.ErrorIs(t, err, utils.ServiceErrRefreshTokenReused)
+	_, err = tokenService.RefreshTokens(second.RefreshToken)
+	assert.NoError(t, err)
+
+	_, err = tokenService.RefreshTokens("not-a-token")
+	assert.ErrorIs(t, err, utils.ServiceErrInvalidRefreshToken)
+}
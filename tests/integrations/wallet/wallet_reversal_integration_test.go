@@ -0,0 +1,95 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReverseTransferInSteps refunds a transfer partially and then in full, and checks that the original
+// ends up reversed, cannot be reversed again, and that balances and the ledger agree afterwards.
+func TestReverseTransferInSteps(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	walletService := newLedgerBackedWalletService(walletRepo)
+
+	// Charlie (user 3) pays Alice (user 1, wallet123)
+	_, err := walletService.Transfer(3, "", "wallet123", usd("60.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	var transferID int
+	err = dbService.GetDB().QueryRow("SELECT id FROM transactions WHERE transaction_type = 'transfer'").Scan(&transferID)
+	assert.NoError(t, err)
+
+	// The sender cannot pull the money back
+	_, _, err = walletService.ReverseTransaction(3, transferID, nil)
+	assert.ErrorIs(t, err, utils.ServiceErrTransactionNotReversible)
+
+	partial := usd("20.00")
+	reversal, aliceWallet, err := walletService.ReverseTransaction(1, transferID, &partial)
+	assert.NoError(t, err)
+	assert.Equal(t, transferID, *reversal.ReversesTransactionID)
+	assert.Equal(t, transaction.StatusCompleted, reversal.Status)
+	assert.Equal(t, usd("140.00"), aliceWallet.Balance)
+
+	tooMuch := usd("50.00")
+	_, _, err = walletService.ReverseTransaction(1, transferID, &tooMuch)
+	assert.ErrorIs(t, err, utils.ServiceErrReversalExceedsRemaining)
+
+	// Refund whatever is left
+	_, aliceWallet, err = walletService.ReverseTransaction(1, transferID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("100.00"), aliceWallet.Balance)
+
+	_, _, err = walletService.ReverseTransaction(1, transferID, nil)
+	assert.ErrorIs(t, err, utils.ServiceErrTransactionNotReversible)
+
+	var status string
+	err = dbService.GetDB().QueryRow("SELECT status FROM transactions WHERE id = $1", transferID).Scan(&status)
+	assert.NoError(t, err)
+	assert.Equal(t, transaction.StatusReversed, status)
+
+	charlieWallet, err := walletRepo.GetDefaultWallet(3)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("300.00"), charlieWallet.Balance)
+
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(dbService.GetDB()), nil)
+	for _, walletNumber := range []string{"wallet123", "wallet789"} {
+		assert.NoError(t, ledgerService.VerifyWalletBalance(walletNumber), walletNumber)
+	}
+}
+
+// TestReverseRefusedWhenRecipientSpentTheFunds checks that a reversal never overdraws the receiving wallet
+func TestReverseRefusedWhenRecipientSpentTheFunds(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletService := newLedgerBackedWalletService(wallet.NewWalletRepository(dbService.GetDB()))
+
+	_, err := walletService.Transfer(3, "", "wallet123", usd("60.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	_, err = walletService.Withdraw(1, "", usd("150.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	var transferID int
+	err = dbService.GetDB().QueryRow("SELECT id FROM transactions WHERE transaction_type = 'transfer'").Scan(&transferID)
+	assert.NoError(t, err)
+
+	_, _, err = walletService.ReverseTransaction(1, transferID, nil)
+	assert.ErrorIs(t, err, utils.RepoErrInsufficientFunds)
+
+	var reversals int
+	err = dbService.GetDB().QueryRow("SELECT COUNT(*) FROM transactions WHERE reverses_transaction_id = $1", transferID).Scan(&reversals)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reversals)
+}
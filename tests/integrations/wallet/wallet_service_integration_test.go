@@ -2,7 +2,9 @@ package wallet_test
 
 import (
 	"centralized-wallet/internal/database"
+	"centralized-wallet/internal/ledger"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"centralized-wallet/internal/redis"
 	"centralized-wallet/internal/seed"
 	"centralized-wallet/internal/transaction"
@@ -27,11 +29,11 @@ type testWalletService struct {
 	userId              int
 	fromUserId          int
 	toWalletNumber      string
-	amount              float64
+	amount              money.Money
 	expectedError       error
-	expectedFromBalance float64
-	expectedToBalance   float64
-	expectedBalance     float64
+	expectedFromBalance money.Money
+	expectedToBalance   money.Money
+	expectedBalance     money.Money
 	expectWallet        bool
 	shouldRecordTx      bool
 }
@@ -94,32 +96,60 @@ func setupWalletFixtures() {
 	}
 }
 
-func TestGetWalletByUserIDService(t *testing.T) {
+// newLedgerBackedWalletService wires the wallet service to a real ledger and transaction service, without currency conversion
+func newLedgerBackedWalletService(walletRepo *wallet.WalletRepository) *wallet.WalletService {
+	transactionRepo := transaction.NewTransactionRepository(dbService.GetDB())
+	transactionService := transaction.NewTransactionService(transactionRepo, redisService)
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(dbService.GetDB()), transactionService)
+	return wallet.NewWalletService(walletRepo, ledgerService, transactionService, redisService, nil)
+}
+
+func TestGetWalletService(t *testing.T) {
 	setupUserFixtures()
 	setupWalletFixtures()
 
 	// Initialize the wallet repository and service
 	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
-	walletService := wallet.NewWalletService(walletRepo, nil)
+	walletService := wallet.NewWalletService(walletRepo, nil, nil, nil, nil)
 
 	// Define the test cases
 	testCases := []struct {
 		name           string
 		userId         int
+		walletNumber   string
 		expectedError  error
 		expectedWallet *models.Wallet
 	}{
 		{
-			name:          "Successful wallet retrieval",
+			name:          "Default wallet retrieval",
 			userId:        1, // Assuming user 1 has a wallet
 			expectedError: nil,
 			expectedWallet: &models.Wallet{
 				ID:           1,
 				UserID:       1,
 				WalletNumber: "wallet123",
-				Balance:      100.0, // Expected balance for this user
+				Balance:      usd("100.00"), // Expected balance for this user
+			},
+		},
+		{
+			name:          "Wallet retrieval by number",
+			userId:        2,
+			walletNumber:  "wallet456",
+			expectedError: nil,
+			expectedWallet: &models.Wallet{
+				ID:           2,
+				UserID:       2,
+				WalletNumber: "wallet456",
+				Balance:      usd("200.00"),
 			},
 		},
+		{
+			name:           "Wallet of another user",
+			userId:         1,
+			walletNumber:   "wallet456",
+			expectedError:  utils.RepoErrWalletNotFound, // Other users' wallets look like they do not exist
+			expectedWallet: nil,
+		},
 		{
 			name:           "Wallet not found",
 			userId:         9999,                        // Non-existent user
@@ -133,8 +163,7 @@ func TestGetWalletByUserIDService(t *testing.T) {
 	// Iterate over the test cases
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Call the service to get the wallet by user ID
-			wallet, err := walletService.GetWalletByUserID(tc.userId)
+			wallet, err := walletService.GetWallet(tc.userId, tc.walletNumber)
 
 			// Check if the error matches the expected error
 			if tc.expectedError != nil {
@@ -162,30 +191,37 @@ func TestCreateWalletService(t *testing.T) {
 
 	// Initialize the wallet repository and service
 	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
-	walletService := wallet.NewWalletService(walletRepo, nil)
+	walletService := wallet.NewWalletService(walletRepo, nil, nil, nil, nil)
 
-	// Define the test cases
-	testCases := []testWalletService{
+	// Define the test cases, run in order
+	testCases := []struct {
+		name            string
+		userId          int
+		walletName      string
+		expectedError   error
+		expectedDefault bool
+	}{
 		{
-			name:            "Create wallet for new user without existing wallet",
-			userId:          3, // Assuming this user does not have a wallet
-			expectedError:   nil,
-			expectWallet:    true,
-			expectedBalance: 0.0,
+			name:            "First wallet of a user becomes the default",
+			userId:          3,
+			expectedDefault: true,
 		},
 		{
-			name:            "Fail to create wallet for user with existing wallet",
-			userId:          3,                            // Assuming this user already has a wallet (pre-seeded in setup)
-			expectedError:   utils.ErrWalletAlreadyExists, // Replace with the correct error you're handling
-			expectWallet:    false,
-			expectedBalance: 0.0,
+			name:          "Second wallet with the same name",
+			userId:        3,
+			expectedError: utils.RepoErrWalletNameTaken,
+		},
+		{
+			name:            "Second wallet with its own name",
+			userId:          3,
+			walletName:      "Savings",
+			expectedDefault: false,
 		},
 		{
-			name:            "Create wallet for another user without existing wallet",
-			userId:          2, // Assuming this user does not have a wallet
-			expectedError:   nil,
-			expectWallet:    true,
-			expectedBalance: 0.0,
+			name:            "Names only need to be unique per user",
+			userId:          2,
+			walletName:      "Savings",
+			expectedDefault: true,
 		},
 	}
 
@@ -195,24 +231,18 @@ func TestCreateWalletService(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the service to create a wallet
-			wallet, err := walletService.CreateWallet(tc.userId)
+			wallet, err := walletService.CreateWallet(tc.userId, tc.walletName, "")
 
-			// Check if the error matches the expected error
 			if tc.expectedError != nil {
 				assert.ErrorIs(t, err, tc.expectedError)
-			} else {
-				assert.NoError(t, err)
-			}
-
-			// If we expect a wallet to be created, check the wallet fields
-			if tc.expectWallet {
-				assert.NotNil(t, wallet)
-				assert.Equal(t, tc.userId, wallet.UserID)
-				assert.Equal(t, tc.expectedBalance, wallet.Balance)
-			} else {
-				// If we don't expect a wallet, ensure it is nil
 				assert.Nil(t, wallet)
+				return
 			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.userId, wallet.UserID)
+			assert.Equal(t, usd("0.00"), wallet.Balance)
+			assert.Equal(t, tc.expectedDefault, wallet.IsDefault)
 		})
 	}
 }
@@ -223,27 +253,25 @@ func TestDepositService(t *testing.T) {
 	setupWalletFixtures()
 
 	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
-	transactionRepo := transaction.NewTransactionRepository(dbService.GetDB())
-	transactionService := transaction.NewTransactionService(transactionRepo, redisService)
-	walletService := wallet.NewWalletService(walletRepo, transactionService)
+	walletService := newLedgerBackedWalletService(walletRepo)
 
 	// Define the test cases
 	testCases := []testWalletService{
 		{
 			name:            "Successful deposit for user with wallet",
-			userId:          1,     // Assuming this user has an existing wallet (from setup)
-			amount:          100.0, // Deposit amount
-			expectedError:   nil,   // No error expected
-			expectedBalance: 200.0, // Assuming initial balance is 100.00 (will increase by 100.00)
-			expectWallet:    true,  // Wallet should exist
-			shouldRecordTx:  true,  // Transaction should be recorded
+			userId:          1,             // Assuming this user has an existing wallet (from setup)
+			amount:          usd("100.00"), // Deposit amount
+			expectedError:   nil,           // No error expected
+			expectedBalance: usd("200.00"), // Assuming initial balance is 100.00 (will increase by 100.00)
+			expectWallet:    true,          // Wallet should exist
+			shouldRecordTx:  true,          // Transaction should be recorded
 		},
 		{
 			name:            "Wallet not found for user",
 			userId:          9999,                        // Non-existent user ID
-			amount:          50.0,                        // Deposit amount
+			amount:          usd("50.00"),                // Deposit amount
 			expectedError:   utils.RepoErrWalletNotFound, // Error expected for non-existent wallet
-			expectedBalance: 0.0,                         // No change in balance
+			expectedBalance: usd("0.00"),                 // No change in balance
 			expectWallet:    false,
 			shouldRecordTx:  false,
 		},
@@ -255,7 +283,7 @@ func TestDepositService(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the service to deposit into the wallet
-			wallet, err := walletService.Deposit(tc.userId, tc.amount)
+			wallet, err := walletService.Deposit(tc.userId, "", tc.amount, models.TransactionNote{})
 
 			// Check if the error matches the expected error
 			if tc.expectedError != nil {
@@ -288,36 +316,34 @@ func TestWithdrawService(t *testing.T) {
 
 	// Initialize the wallet repository and service
 	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
-	transactionRepo := transaction.NewTransactionRepository(dbService.GetDB())
-	transactionService := transaction.NewTransactionService(transactionRepo, redisService)
-	walletService := wallet.NewWalletService(walletRepo, transactionService)
+	walletService := newLedgerBackedWalletService(walletRepo)
 
 	// Define the test cases
 	testCases := []testWalletService{
 		{
 			name:            "Successful withdrawal with sufficient funds",
-			userId:          1,    // Assuming this user has an existing wallet with enough balance
-			amount:          50.0, // Withdraw amount
-			expectedError:   nil,  // No error expected
-			expectedBalance: 50.0, // Assuming initial balance is 100.00 (will decrease by 50.00)
-			expectWallet:    true, // Wallet should exist
-			shouldRecordTx:  true, // Transaction should be recorded
+			userId:          1,            // Assuming this user has an existing wallet with enough balance
+			amount:          usd("50.00"), // Withdraw amount
+			expectedError:   nil,          // No error expected
+			expectedBalance: usd("50.00"), // Assuming initial balance is 100.00 (will decrease by 50.00)
+			expectWallet:    true,         // Wallet should exist
+			shouldRecordTx:  true,         // Transaction should be recorded
 		},
 		{
 			name:            "Withdrawal with insufficient funds",
 			userId:          1,                              // Assuming this user has an existing wallet
-			amount:          200.0,                          // Withdraw amount greater than balance
+			amount:          usd("200.00"),                  // Withdraw amount greater than balance
 			expectedError:   utils.RepoErrInsufficientFunds, // Error expected for insufficient funds
-			expectedBalance: 100.0,                          // Balance should not change
+			expectedBalance: usd("100.00"),                  // Balance should not change
 			expectWallet:    false,                          // Wallet should exist
 			shouldRecordTx:  false,                          // Transaction should not be recorded
 		},
 		{
 			name:            "Wallet not found for user",
 			userId:          9999,                        // Non-existent user ID
-			amount:          50.0,                        // Withdraw amount
+			amount:          usd("50.00"),                // Withdraw amount
 			expectedError:   utils.RepoErrWalletNotFound, // Error expected for non-existent wallet
-			expectedBalance: 0.0,                         // No change in balance
+			expectedBalance: usd("0.00"),                 // No change in balance
 			expectWallet:    false,                       // Wallet should not exist
 			shouldRecordTx:  false,                       // Transaction should not be recorded
 		},
@@ -329,7 +355,7 @@ func TestWithdrawService(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the service to withdraw from the wallet
-			wallet, err := walletService.Withdraw(tc.userId, tc.amount)
+			wallet, err := walletService.Withdraw(tc.userId, "", tc.amount, models.TransactionNote{})
 
 			// Check if the error matches the expected error
 			if tc.expectedError != nil {
@@ -362,31 +388,29 @@ func TestTransferService(t *testing.T) {
 
 	// Initialize the wallet repository and service
 	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
-	transactionRepo := transaction.NewTransactionRepository(dbService.GetDB())
-	transactionService := transaction.NewTransactionService(transactionRepo, redisService)
-	walletService := wallet.NewWalletService(walletRepo, transactionService)
+	walletService := newLedgerBackedWalletService(walletRepo)
 
 	// Define the test cases
 	testCases := []testWalletService{
 		{
 			name:                "Successful transfer with sufficient funds",
-			fromUserId:          1,           // Assuming user 1 has sufficient balance
-			toWalletNumber:      "wallet456", // Assuming wallet 456 belongs to user 2
-			amount:              50.0,        // Transfer amount
-			expectedError:       nil,         // No error expected
-			expectedFromBalance: 50.0,        // Assuming initial balance is 100.00 (will decrease by 50.00)
-			expectedToBalance:   250.0,       // Assuming recipient initial balance is 200.00 (will increase by 50.00)
-			expectWallet:        true,        // Wallets should exist
-			shouldRecordTx:      true,        // Transaction should be recorded
+			fromUserId:          1,             // Assuming user 1 has sufficient balance
+			toWalletNumber:      "wallet456",   // Assuming wallet 456 belongs to user 2
+			amount:              usd("50.00"),  // Transfer amount
+			expectedError:       nil,           // No error expected
+			expectedFromBalance: usd("50.00"),  // Assuming initial balance is 100.00 (will decrease by 50.00)
+			expectedToBalance:   usd("250.00"), // Assuming recipient initial balance is 200.00 (will increase by 50.00)
+			expectWallet:        true,          // Wallets should exist
+			shouldRecordTx:      true,          // Transaction should be recorded
 		},
 		{
 			name:                "Transfer with insufficient funds",
 			fromUserId:          1,                              // Assuming user 1 has insufficient funds
 			toWalletNumber:      "wallet456",                    // Valid recipient wallet
-			amount:              200.0,                          // Transfer amount greater than balance
+			amount:              usd("200.00"),                  // Transfer amount greater than balance
 			expectedError:       utils.RepoErrInsufficientFunds, // Error expected for insufficient funds
-			expectedFromBalance: 100.0,                          // Balance should not change
-			expectedToBalance:   200.0,                          // Recipient balance should not change
+			expectedFromBalance: usd("100.00"),                  // Balance should not change
+			expectedToBalance:   usd("200.00"),                  // Recipient balance should not change
 			expectWallet:        false,                          // Wallet should exist
 			shouldRecordTx:      false,                          // Transaction should not be recorded
 		},
@@ -394,10 +418,10 @@ func TestTransferService(t *testing.T) {
 			name:                "Recipient wallet not found",
 			fromUserId:          1,                           // Assuming user 1 has sufficient balance
 			toWalletNumber:      "nonexistent_wallet",        // Non-existent wallet
-			amount:              50.0,                        // Transfer amount
+			amount:              usd("50.00"),                // Transfer amount
 			expectedError:       utils.RepoErrWalletNotFound, // Error expected for non-existent recipient wallet
-			expectedFromBalance: 100.0,                       // Sender balance should not change
-			expectedToBalance:   0.0,                         // Recipient balance should not change (invalid wallet)
+			expectedFromBalance: usd("100.00"),               // Sender balance should not change
+			expectedToBalance:   usd("0.00"),                 // Recipient balance should not change (invalid wallet)
 			expectWallet:        false,                       // Sender wallet should exist
 			shouldRecordTx:      false,                       // Transaction should not be recorded
 		},
@@ -409,7 +433,7 @@ func TestTransferService(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the service to perform the transfer
-			fromWallet, err := walletService.Transfer(tc.fromUserId, tc.toWalletNumber, tc.amount)
+			fromWallet, err := walletService.Transfer(tc.fromUserId, "", tc.toWalletNumber, tc.amount, models.TransactionNote{})
 
 			// Check if the error matches the expected error
 			if tc.expectedError != nil {
@@ -445,7 +469,12 @@ func TestTransferService(t *testing.T) {
 	}
 }
 
-func verifyTransactionRecorded(t *testing.T, walletNumber string, amount float64, txType string, isFromWallet bool) {
+// usd builds a Money value in the default currency for test fixtures
+func usd(amount string) money.Money {
+	return money.MustParse(amount, money.DefaultCurrency)
+}
+
+func verifyTransactionRecorded(t *testing.T, walletNumber string, amount money.Money, txType string, isFromWallet bool) {
 	db := dbService.GetDB()
 
 	// Query the transactions table for the matching transaction based on wallet number
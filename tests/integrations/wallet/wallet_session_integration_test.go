@@ -0,0 +1,65 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/auth"
+	"centralized-wallet/internal/user"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSessions logs Jack in on two devices and David on one, then revokes Jack's sessions one by one and all at once
+func TestSessions(t *testing.T) {
+	setupUserFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	refreshTokenRepo := auth.NewRefreshTokenRepository(dbService.GetDB())
+	sessionService := auth.NewSessionService(auth.NewSessionRepository(dbService.GetDB()), redisService)
+	accountService := user.NewAccountService(user.NewUserRepository(dbService.GetDB()), sessionService, redisService)
+	tokenService := auth.NewTokenService(refreshTokenRepo, sessionService, accountService)
+
+	laptop, err := tokenService.IssueTokens(1, "Mozilla/5.0 (Macintosh)", "203.0.113.7")
+	assert.NoError(t, err)
+	phone, err := tokenService.IssueTokens(1, "WalletApp/2.1 (iOS)", "198.51.100.4")
+	assert.NoError(t, err)
+	david, err := tokenService.IssueTokens(2, "Mozilla/5.0 (Windows)", "192.0.2.10")
+	assert.NoError(t, err)
+
+	sessions, err := sessionService.ListSessions(1)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	laptopToken, err := refreshTokenRepo.GetRefreshTokenByHash(auth.HashRefreshToken(laptop.RefreshToken))
+	assert.NoError(t, err)
+	laptopID := laptopToken.FamilyID
+	assert.NoError(t, sessionService.CheckSession(laptopID))
+
+	// David cannot end Jack's session
+	assert.ErrorIs(t, sessionService.RevokeSession(2, laptopID), utils.RepoErrSessionNotFound)
+
+	// Ending the laptop session refuses its access and refresh tokens at once, and hides it from the list
+	assert.NoError(t, sessionService.RevokeSession(1, laptopID))
+	assert.ErrorIs(t, sessionService.CheckSession(laptopID), utils.ServiceErrSessionRevoked)
+	_, err = tokenService.RefreshTokens(laptop.RefreshToken)
+	assert.Error(t, err)
+	sessions, err = sessionService.ListSessions(1)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "WalletApp/2.1 (iOS)", sessions[0].UserAgent)
+	assert.Equal(t, "198.51.100.4", sessions[0].IPAddress)
+
+	// Logging out everywhere ends the phone session too, but none of David's
+	revoked, err := sessionService.RevokeAllSessions(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	_, err = tokenService.RefreshTokens(phone.RefreshToken)
+	assert.Error(t, err)
+	sessions, err = sessionService.ListSessions(1)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	_, err = tokenService.RefreshTokens(david.RefreshToken)
+	assert.NoError(t, err)
+}
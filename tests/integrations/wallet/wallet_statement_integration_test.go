@@ -0,0 +1,110 @@
+package wallet_test
+
+import (
+	"bytes"
+	"centralized-wallet/internal/ledger"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/statement"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStatementExport exports the transactions of a period as CSV and checks the balances at both ends
+// match the ledger and the rows add up from one to the other
+func TestStatementExport(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	db := dbService.GetDB()
+	walletRepo := wallet.NewWalletRepository(db)
+	transactionRepo := transaction.NewTransactionRepository(db)
+	transactionService := transaction.NewTransactionService(transactionRepo, redisService)
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(db), transactionService)
+	walletService := wallet.NewWalletService(walletRepo, ledgerService, transactionService, redisService, nil)
+	statementService := statement.NewStatementService(transactionRepo, walletRepo, ledgerService, statement.NewStatementRepository(db))
+
+	// Alice (user 1, wallet123) deposits before the period, then pays Bob and receives from Charlie within it
+	_, err := walletService.Deposit(1, "", usd("10.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	from := time.Now()
+	_, err = walletService.Transfer(1, "", "wallet456", usd("40.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	_, err = walletService.Transfer(3, "", "wallet123", usd("25.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+	to := time.Now()
+
+	opened, err := statementService.OpenStatement(1, "", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, usd("110.00"), opened.OpeningBalance)
+	assert.Equal(t, usd("95.00"), opened.ClosingBalance)
+
+	var out bytes.Buffer
+	assert.NoError(t, statementService.WriteStatement(opened, statement.FormatCSV, &out))
+
+	records, err := csv.NewReader(&out).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 6) {
+		assert.Equal(t, []string{"opening_balance", "110.00"}, []string{records[1][0], records[1][10]})
+		assert.Equal(t, []string{"transaction", "outgoing", "wallet456", "-40.00", "70.00"}, []string{records[2][0], records[2][5], records[2][6], records[2][8], records[2][10]})
+		assert.Equal(t, []string{"transaction", "incoming", "wallet789", "25.00", "95.00"}, []string{records[3][0], records[3][5], records[3][6], records[3][8], records[3][10]})
+		assert.Equal(t, []string{"total", "transfer", "2", "25.00", "40.00"}, []string{records[4][0], records[4][3], records[4][11], records[4][12], records[4][13]})
+		assert.Equal(t, []string{"closing_balance", "95.00"}, []string{records[5][0], records[5][10]})
+	}
+}
+
+// TestMonthlyStatementGeneration issues last month's statements for wallets opened before it, then checks a rerun
+// issues nothing new and the stored documents can be listed and downloaded
+func TestMonthlyStatementGeneration(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	db := dbService.GetDB()
+	walletRepo := wallet.NewWalletRepository(db)
+	transactionRepo := transaction.NewTransactionRepository(db)
+	transactionService := transaction.NewTransactionService(transactionRepo, redisService)
+	ledgerService := ledger.NewLedgerService(ledger.NewLedgerRepository(db), transactionService)
+	statementService := statement.NewStatementService(transactionRepo, walletRepo, ledgerService, statement.NewStatementRepository(db))
+
+	// The fixture wallets are opened a year ago, their opening balances are posted now
+	_, err := db.Exec("UPDATE wallets SET created_at = NOW() - INTERVAL '1 year'")
+	assert.NoError(t, err)
+
+	now := time.Now().UTC()
+	lastMonth := now.AddDate(0, 0, -now.Day())
+	issued, err := statementService.GenerateMonthlyStatements(lastMonth)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, issued)
+
+	issued, err = statementService.GenerateMonthlyStatements(lastMonth)
+	assert.NoError(t, err)
+	assert.Zero(t, issued)
+
+	_, err = statementService.GenerateMonthlyStatements(now)
+	assert.ErrorIs(t, err, utils.ServiceErrStatementMonthNotOver)
+
+	statements, err := statementService.ListMonthlyStatements(1, "")
+	assert.NoError(t, err)
+	if assert.Len(t, statements, 1) {
+		assert.Equal(t, "wallet123", statements[0].WalletNumber)
+		assert.Equal(t, usd("0.00"), statements[0].ClosingBalance)
+		assert.Zero(t, statements[0].TransactionCount)
+		assert.Nil(t, statements[0].PDF)
+
+		downloaded, err := statementService.GetMonthlyStatement(1, statements[0].StatementNumber)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(downloaded.PDF, []byte("%PDF-")))
+		assert.Contains(t, string(downloaded.HTML), statements[0].StatementNumber)
+
+		_, err = statementService.GetMonthlyStatement(2, statements[0].StatementNumber)
+		assert.ErrorIs(t, err, utils.RepoErrStatementNotFound)
+	}
+}
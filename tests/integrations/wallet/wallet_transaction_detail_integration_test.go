@@ -0,0 +1,55 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/transaction"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTransactionDetailForEachParty reads one transfer as its sender and its receiver, checking each sees
+// their own side and resulting balance, and that a third user cannot read it.
+func TestTransactionDetailForEachParty(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	walletService := newLedgerBackedWalletService(walletRepo)
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(dbService.GetDB()), redisService)
+
+	// Charlie (user 3, wallet789) pays Alice (user 1, wallet123), then Alice spends part of it
+	_, err := walletService.Transfer(3, "", "wallet123", usd("60.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	var transferID int
+	err = dbService.GetDB().QueryRow("SELECT id FROM transactions WHERE transaction_type = 'transfer'").Scan(&transferID)
+	assert.NoError(t, err)
+
+	_, err = walletService.Withdraw(1, "", usd("30.00"), models.TransactionNote{})
+	assert.NoError(t, err)
+
+	received, err := transactionService.GetTransactionDetail(1, transferID)
+	assert.NoError(t, err)
+	assert.Equal(t, transaction.DirectionIncoming, received.Direction)
+	assert.Equal(t, "wallet123", received.WalletNumber)
+	assert.Equal(t, "c***@example.com", received.FromEmail)
+	assert.Equal(t, "j***@example.com", received.ToEmail)
+	assert.Equal(t, transaction.StatusCompleted, received.Status)
+	assert.NotNil(t, received.CompletedAt)
+	// The balance right after the transfer, not the current one
+	assert.Equal(t, usd("160.00"), *received.BalanceAfter)
+
+	sent, err := transactionService.GetTransactionDetail(3, transferID)
+	assert.NoError(t, err)
+	assert.Equal(t, transaction.DirectionOutgoing, sent.Direction)
+	assert.Equal(t, "wallet789", sent.WalletNumber)
+	assert.Equal(t, usd("240.00"), *sent.BalanceAfter)
+
+	_, err = transactionService.GetTransactionDetail(2, transferID)
+	assert.ErrorIs(t, err, utils.RepoErrTransactionNotFound)
+}
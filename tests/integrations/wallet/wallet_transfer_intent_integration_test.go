@@ -0,0 +1,76 @@
+package wallet_test
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/transfer"
+	"centralized-wallet/internal/user"
+	"centralized-wallet/internal/utils"
+	"centralized-wallet/internal/wallet"
+	"centralized-wallet/tests/testutils"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPreviewAndConfirmTransfer previews a transfer from Jack to David, checks nothing moved until it is
+// confirmed, then confirms it, checks the intent records its transfer and cannot be confirmed a second time.
+func TestPreviewAndConfirmTransfer(t *testing.T) {
+	setupUserFixtures()
+	setupWalletFixtures()
+	defer testutils.CleanDatabase(dbService.GetDB())
+	assert.NoError(t, redisService.DeleteKeysByPattern(context.Background(), "user:*:recipient_lookups"))
+
+	userRepo := user.NewUserRepository(dbService.GetDB())
+	walletRepo := wallet.NewWalletRepository(dbService.GetDB())
+	walletService := newLedgerBackedWalletService(walletRepo)
+	recipientService := wallet.NewRecipientService(userRepo, walletRepo, redisService)
+	transferRepo := transfer.NewTransferRepository(dbService.GetDB())
+	transferService := transfer.NewTransferService(transferRepo, walletRepo, walletService, recipientService, nil)
+
+	note := models.TransactionNote{Memo: "Rent", Metadata: models.Metadata{"month": "May"}}
+	intent, err := transferService.PreviewTransfer(1, "", "", "david@example.com", usd("40.00"), note)
+	assert.NoError(t, err)
+	assert.Equal(t, "d***@example.com", intent.Recipient.Email)
+	assert.Equal(t, usd("0.00"), intent.Fee)
+	assert.Equal(t, usd("60.00"), intent.BalanceAfter)
+
+	// The preview is stored as is and moves no money
+	stored, err := transferRepo.GetIntentByID(intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, transfer.StatusOpen, stored.Status)
+	assert.Equal(t, "wallet456", stored.ToWalletNumber)
+	assert.Equal(t, note, stored.Note())
+	jack, err := walletRepo.FindByWalletNumber("wallet123")
+	assert.NoError(t, err)
+	assert.Equal(t, usd("100.00"), jack.Balance)
+
+	// Only the payer can confirm
+	_, _, err = transferService.ConfirmTransfer(2, intent.ID)
+	assert.ErrorIs(t, err, utils.RepoErrTransferIntentNotFound)
+
+	confirmed, updatedWallet, err := transferService.ConfirmTransfer(1, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, transfer.StatusConfirmed, confirmed.Status)
+	assert.Equal(t, usd("60.00"), updatedWallet.Balance)
+	david, err := walletRepo.FindByWalletNumber("wallet456")
+	assert.NoError(t, err)
+	assert.Equal(t, usd("240.00"), david.Balance)
+
+	// The confirmed intent points at the transfer committed with it
+	stored, err = transferRepo.GetIntentByID(intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, transfer.StatusConfirmed, stored.Status)
+	if assert.NotNil(t, stored.TransactionID) {
+		var amount int64
+		assert.NoError(t, dbService.GetDB().QueryRow(`SELECT amount FROM transactions WHERE id = $1 AND to_wallet_number = 'wallet456'`, *stored.TransactionID).Scan(&amount))
+		assert.Equal(t, usd("40.00").Amount, amount)
+	}
+
+	_, _, err = transferService.ConfirmTransfer(1, intent.ID)
+	assert.ErrorIs(t, err, utils.ServiceErrTransferIntentConfirmed)
+
+	// Previews beyond the available balance are refused
+	_, err = transferService.PreviewTransfer(1, "", "wallet456", "", usd("60.01"), models.TransactionNote{})
+	assert.ErrorIs(t, err, utils.RepoErrInsufficientFunds)
+}
@@ -0,0 +1,42 @@
+package mock_admin
+
+import (
+	"centralized-wallet/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAdminService is a mock implementation of AdminServiceInterface
+type MockAdminService struct {
+	mock.Mock
+}
+
+// SearchUsers mocks the SearchUsers function
+func (m *MockAdminService) SearchUsers(query string, limit, offset int) ([]models.User, error) {
+	args := m.Called(query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+// GetWallet mocks the GetWallet function
+func (m *MockAdminService) GetWallet(walletNumber string) (*models.Wallet, *models.User, error) {
+	args := m.Called(walletNumber)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.Wallet), args.Get(1).(*models.User), args.Error(2)
+}
+
+// SetUserStatus mocks the SetUserStatus function
+func (m *MockAdminService) SetUserStatus(userID int, status string) error {
+	args := m.Called(userID, status)
+	return args.Error(0)
+}
+
+// SetUserRole mocks the SetUserRole function
+func (m *MockAdminService) SetUserRole(userID int, role string) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
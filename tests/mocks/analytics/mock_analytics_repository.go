@@ -0,0 +1,40 @@
+package mock_analytics
+
+import (
+	"centralized-wallet/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAnalyticsRepository is a mock implementation of AnalyticsRepositoryInterface
+type MockAnalyticsRepository struct {
+	mock.Mock
+}
+
+// GetTypeTotals mocks the GetTypeTotals function
+func (m *MockAnalyticsRepository) GetTypeTotals(walletNumber, currency string, from, to time.Time) ([]models.AnalyticsTypeTotal, error) {
+	args := m.Called(walletNumber, currency, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AnalyticsTypeTotal), args.Error(1)
+}
+
+// GetTopCounterparties mocks the GetTopCounterparties function
+func (m *MockAnalyticsRepository) GetTopCounterparties(walletNumber, currency string, from, to time.Time, limit int) ([]models.AnalyticsCounterparty, error) {
+	args := m.Called(walletNumber, currency, from, to, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AnalyticsCounterparty), args.Error(1)
+}
+
+// GetBuckets mocks the GetBuckets function
+func (m *MockAnalyticsRepository) GetBuckets(walletNumber, currency string, from, to time.Time, interval string) ([]models.AnalyticsBucket, error) {
+	args := m.Called(walletNumber, currency, from, to, interval)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AnalyticsBucket), args.Error(1)
+}
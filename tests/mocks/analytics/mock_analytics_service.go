@@ -0,0 +1,22 @@
+package mock_analytics
+
+import (
+	"centralized-wallet/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAnalyticsService is a mock implementation of AnalyticsServiceInterface
+type MockAnalyticsService struct {
+	mock.Mock
+}
+
+// GetAnalytics mocks the GetAnalytics function
+func (m *MockAnalyticsService) GetAnalytics(userID int, walletNumber string, from, to *time.Time, interval string, top int) (*models.WalletAnalytics, error) {
+	args := m.Called(userID, walletNumber, from, to, interval, top)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WalletAnalytics), args.Error(1)
+}
@@ -0,0 +1,22 @@
+package mock_auth
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAccountChecker is a mock implementation of AccountCheckerInterface
+type MockAccountChecker struct {
+	mock.Mock
+}
+
+// CurrentTokenVersion mocks the CurrentTokenVersion function
+func (m *MockAccountChecker) CurrentTokenVersion(userID int) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+// CurrentRole mocks the CurrentRole function
+func (m *MockAccountChecker) CurrentRole(userID int) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
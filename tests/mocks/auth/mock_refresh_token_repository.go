@@ -0,0 +1,37 @@
+package mock_auth
+
+import (
+	"centralized-wallet/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRefreshTokenRepository is a mock implementation of RefreshTokenRepositoryInterface
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+// CreateRefreshToken mocks the CreateRefreshToken function
+func (m *MockRefreshTokenRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+// GetRefreshTokenByHash mocks the GetRefreshTokenByHash function
+func (m *MockRefreshTokenRepository) GetRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+// RevokeRefreshToken mocks the RevokeRefreshToken function
+func (m *MockRefreshTokenRepository) RevokeRefreshToken(tokenHash string, now time.Time) (*models.RefreshToken, error) {
+	args := m.Called(tokenHash, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
@@ -0,0 +1,49 @@
+package mock_auth
+
+import (
+	"centralized-wallet/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSessionRepository is a mock implementation of SessionRepositoryInterface
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+// CreateSession mocks the CreateSession function
+func (m *MockSessionRepository) CreateSession(session *models.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+// ListActiveSessions mocks the ListActiveSessions function
+func (m *MockSessionRepository) ListActiveSessions(userID int, now time.Time) ([]*models.Session, error) {
+	args := m.Called(userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+// TouchSession mocks the TouchSession function
+func (m *MockSessionRepository) TouchSession(sessionID string, now time.Time) error {
+	args := m.Called(sessionID, now)
+	return args.Error(0)
+}
+
+// RevokeSession mocks the RevokeSession function
+func (m *MockSessionRepository) RevokeSession(userID int, sessionID string, now time.Time) error {
+	args := m.Called(userID, sessionID, now)
+	return args.Error(0)
+}
+
+// RevokeUserSessions mocks the RevokeUserSessions function
+func (m *MockSessionRepository) RevokeUserSessions(userID int, now time.Time) ([]string, error) {
+	args := m.Called(userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
@@ -0,0 +1,48 @@
+package mock_auth
+
+import (
+	"centralized-wallet/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSessionService is a mock implementation of SessionServiceInterface
+type MockSessionService struct {
+	mock.Mock
+}
+
+// StartSession mocks the StartSession function
+func (m *MockSessionService) StartSession(userID int, userAgent, ipAddress string) (*models.Session, error) {
+	args := m.Called(userID, userAgent, ipAddress)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+// ListSessions mocks the ListSessions function
+func (m *MockSessionService) ListSessions(userID int) ([]*models.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+// CheckSession mocks the CheckSession function
+func (m *MockSessionService) CheckSession(sessionID string) error {
+	args := m.Called(sessionID)
+	return args.Error(0)
+}
+
+// RevokeSession mocks the RevokeSession function
+func (m *MockSessionService) RevokeSession(userID int, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+// RevokeAllSessions mocks the RevokeAllSessions function
+func (m *MockSessionService) RevokeAllSessions(userID int) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,30 @@
+package mock_auth
+
+import (
+	"centralized-wallet/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTokenService is a mock implementation of TokenServiceInterface
+type MockTokenService struct {
+	mock.Mock
+}
+
+// IssueTokens mocks the IssueTokens function
+func (m *MockTokenService) IssueTokens(userID int, userAgent, ipAddress string) (*models.TokenPair, error) {
+	args := m.Called(userID, userAgent, ipAddress)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TokenPair), args.Error(1)
+}
+
+// RefreshTokens mocks the RefreshTokens function
+func (m *MockTokenService) RefreshTokens(refreshToken string) (*models.TokenPair, error) {
+	args := m.Called(refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TokenPair), args.Error(1)
+}
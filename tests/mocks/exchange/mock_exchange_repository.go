@@ -0,0 +1,55 @@
+package mock_exchange
+
+import (
+	"centralized-wallet/internal/models"
+	"database/sql"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockExchangeRepository is a mock implementation of ExchangeRepositoryInterface
+type MockExchangeRepository struct {
+	mock.Mock
+}
+
+// mock begin transaction
+func (m *MockExchangeRepository) Begin() (*sql.Tx, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sql.Tx), args.Error(1)
+}
+
+// mock commit transaction
+func (m *MockExchangeRepository) Commit(tx *sql.Tx) error {
+	args := m.Called(tx)
+	return args.Error(0)
+}
+
+// mock rollback transaction
+func (m *MockExchangeRepository) Rollback(tx *sql.Tx) error {
+	args := m.Called(tx)
+	return args.Error(0)
+}
+
+// CreateQuote mocks the CreateQuote function
+func (m *MockExchangeRepository) CreateQuote(quote *models.FXQuote) error {
+	args := m.Called(quote)
+	return args.Error(0)
+}
+
+// LockQuoteByID mocks the LockQuoteByID function
+func (m *MockExchangeRepository) LockQuoteByID(tx *sql.Tx, quoteID int) (*models.FXQuote, error) {
+	args := m.Called(tx, quoteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FXQuote), args.Error(1)
+}
+
+// UpdateQuote mocks the UpdateQuote function
+func (m *MockExchangeRepository) UpdateQuote(tx *sql.Tx, quote *models.FXQuote) error {
+	args := m.Called(tx, quote)
+	return args.Error(0)
+}
@@ -0,0 +1,31 @@
+package mock_exchange
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockExchangeService is a mock implementation of ExchangeServiceInterface
+type MockExchangeService struct {
+	mock.Mock
+}
+
+// CreateQuote mocks the CreateQuote function
+func (m *MockExchangeService) CreateQuote(userID int, fromWalletNumber, toWalletNumber string, amount money.Money) (*models.FXQuote, error) {
+	args := m.Called(userID, fromWalletNumber, toWalletNumber, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FXQuote), args.Error(1)
+}
+
+// ExecuteQuote mocks the ExecuteQuote function
+func (m *MockExchangeService) ExecuteQuote(userID, quoteID int) (*models.FXQuote, *models.Wallet, error) {
+	args := m.Called(userID, quoteID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.FXQuote), args.Get(1).(*models.Wallet), args.Error(2)
+}
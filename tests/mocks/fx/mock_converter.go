@@ -0,0 +1,22 @@
+package mock_fx
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/money"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockConverter is a mock implementation of ConverterInterface
+type MockConverter struct {
+	mock.Mock
+}
+
+// Convert mocks the Convert function
+func (m *MockConverter) Convert(source money.Money, toCurrency string) (*fx.Conversion, error) {
+	args := m.Called(source, toCurrency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*fx.Conversion), args.Error(1)
+}
@@ -0,0 +1,72 @@
+package mock_hold
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHoldRepository is a mock implementation of HoldRepositoryInterface
+type MockHoldRepository struct {
+	mock.Mock
+}
+
+// mock begin transaction
+func (m *MockHoldRepository) Begin() (*sql.Tx, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sql.Tx), args.Error(1)
+}
+
+// mock commit transaction
+func (m *MockHoldRepository) Commit(tx *sql.Tx) error {
+	args := m.Called(tx)
+	return args.Error(0)
+}
+
+// mock rollback transaction
+func (m *MockHoldRepository) Rollback(tx *sql.Tx) error {
+	args := m.Called(tx)
+	return args.Error(0)
+}
+
+// CreateHold mocks the CreateHold function
+func (m *MockHoldRepository) CreateHold(tx *sql.Tx, hold *models.Hold) error {
+	args := m.Called(tx, hold)
+	return args.Error(0)
+}
+
+// LockHoldByID mocks the LockHoldByID function
+func (m *MockHoldRepository) LockHoldByID(tx *sql.Tx, holdID int) (*models.Hold, error) {
+	args := m.Called(tx, holdID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+// UpdateHold mocks the UpdateHold function
+func (m *MockHoldRepository) UpdateHold(tx *sql.Tx, hold *models.Hold) error {
+	args := m.Called(tx, hold)
+	return args.Error(0)
+}
+
+// AdjustHeldBalance mocks the AdjustHeldBalance function
+func (m *MockHoldRepository) AdjustHeldBalance(tx *sql.Tx, walletNumber string, delta money.Money) error {
+	args := m.Called(tx, walletNumber, delta)
+	return args.Error(0)
+}
+
+// FindExpiredHoldIDs mocks the FindExpiredHoldIDs function
+func (m *MockHoldRepository) FindExpiredHoldIDs(before time.Time) ([]int, error) {
+	args := m.Called(before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
@@ -0,0 +1,47 @@
+package mock_hold
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHoldService is a mock implementation of HoldServiceInterface
+type MockHoldService struct {
+	mock.Mock
+}
+
+// CreateHold mocks the CreateHold function
+func (m *MockHoldService) CreateHold(userID int, walletNumber, toWalletNumber string, amount money.Money, ttl time.Duration) (*models.Hold, error) {
+	args := m.Called(userID, walletNumber, toWalletNumber, amount, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+// CaptureHold mocks the CaptureHold function
+func (m *MockHoldService) CaptureHold(userID, holdID int, amount *money.Money) (*models.Hold, *models.Wallet, error) {
+	args := m.Called(userID, holdID, amount)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.Hold), args.Get(1).(*models.Wallet), args.Error(2)
+}
+
+// ReleaseHold mocks the ReleaseHold function
+func (m *MockHoldService) ReleaseHold(userID, holdID int) (*models.Hold, error) {
+	args := m.Called(userID, holdID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+// ExpireHolds mocks the ExpireHolds function
+func (m *MockHoldService) ExpireHolds() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,61 @@
+package mock_idempotency
+
+import (
+	"centralized-wallet/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyRepository is a mock implementation of IdempotencyRepositoryInterface
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+// Reserve mocks the Reserve function
+func (m *MockIdempotencyRepository) Reserve(record *models.IdempotencyKey) (bool, error) {
+	args := m.Called(record)
+	return args.Bool(0), args.Error(1)
+}
+
+// FindByKey mocks the FindByKey function
+func (m *MockIdempotencyRepository) FindByKey(userID int, key string) (*models.IdempotencyKey, error) {
+	args := m.Called(userID, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyKey), args.Error(1)
+}
+
+// Reclaim mocks the Reclaim function
+func (m *MockIdempotencyRepository) Reclaim(claim models.IdempotencyClaim, newLockedUntil time.Time) (*models.IdempotencyClaim, error) {
+	args := m.Called(claim, newLockedUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyClaim), args.Error(1)
+}
+
+// SaveResponse mocks the SaveResponse function
+func (m *MockIdempotencyRepository) SaveResponse(claim models.IdempotencyClaim, statusCode int, body []byte) error {
+	args := m.Called(claim, statusCode, body)
+	return args.Error(0)
+}
+
+// Release mocks the Release function
+func (m *MockIdempotencyRepository) Release(claim models.IdempotencyClaim) error {
+	args := m.Called(claim)
+	return args.Error(0)
+}
+
+// Delete mocks the Delete function
+func (m *MockIdempotencyRepository) Delete(userID int, key string) error {
+	args := m.Called(userID, key)
+	return args.Error(0)
+}
+
+// DeleteExpired mocks the DeleteExpired function
+func (m *MockIdempotencyRepository) DeleteExpired() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
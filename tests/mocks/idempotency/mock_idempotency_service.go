@@ -0,0 +1,39 @@
+package mock_idempotency
+
+import (
+	"centralized-wallet/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyService is a mock implementation of IdempotencyServiceInterface
+type MockIdempotencyService struct {
+	mock.Mock
+}
+
+// Begin mocks the Begin function
+func (m *MockIdempotencyService) Begin(userID int, key, requestHash string, reclaimable bool) (*models.IdempotencyKey, error) {
+	args := m.Called(userID, key, requestHash, reclaimable)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyKey), args.Error(1)
+}
+
+// Complete mocks the Complete function
+func (m *MockIdempotencyService) Complete(claim models.IdempotencyClaim, requestHash string, statusCode int, body []byte) error {
+	args := m.Called(claim, requestHash, statusCode, body)
+	return args.Error(0)
+}
+
+// Release mocks the Release function
+func (m *MockIdempotencyService) Release(claim models.IdempotencyClaim) error {
+	args := m.Called(claim)
+	return args.Error(0)
+}
+
+// PurgeExpired mocks the PurgeExpired function
+func (m *MockIdempotencyService) PurgeExpired() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,60 @@
+package mock_ledger
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLedgerRepository is a mock implementation of LedgerRepositoryInterface
+type MockLedgerRepository struct {
+	mock.Mock
+}
+
+// EnsureAccount mocks the EnsureAccount function
+func (m *MockLedgerRepository) EnsureAccount(tx *sql.Tx, account *models.LedgerAccount) error {
+	args := m.Called(tx, account)
+	return args.Error(0)
+}
+
+// CreateJournalEntry mocks the CreateJournalEntry function
+func (m *MockLedgerRepository) CreateJournalEntry(tx *sql.Tx, entry *models.JournalEntry) error {
+	args := m.Called(tx, entry)
+	return args.Error(0)
+}
+
+// CreatePosting mocks the CreatePosting function
+func (m *MockLedgerRepository) CreatePosting(tx *sql.Tx, posting *models.Posting) error {
+	args := m.Called(tx, posting)
+	return args.Error(0)
+}
+
+// ApplyWalletDelta mocks the ApplyWalletDelta function
+func (m *MockLedgerRepository) ApplyWalletDelta(tx *sql.Tx, walletNumber string, delta money.Money) (*models.Wallet, error) {
+	args := m.Called(tx, walletNumber, delta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Wallet), args.Error(1)
+}
+
+// GetPostedBalance mocks the GetPostedBalance function
+func (m *MockLedgerRepository) GetPostedBalance(code string, currency string) (money.Money, error) {
+	args := m.Called(code, currency)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+
+// GetPostedBalanceAsOf mocks the GetPostedBalanceAsOf function
+func (m *MockLedgerRepository) GetPostedBalanceAsOf(code string, currency string, asOf time.Time) (money.Money, error) {
+	args := m.Called(code, currency, asOf)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+
+// GetWalletBalance mocks the GetWalletBalance function
+func (m *MockLedgerRepository) GetWalletBalance(walletNumber string) (money.Money, error) {
+	args := m.Called(walletNumber)
+	return args.Get(0).(money.Money), args.Error(1)
+}
@@ -0,0 +1,94 @@
+package mock_ledger
+
+import (
+	"centralized-wallet/internal/fx"
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLedgerService is a mock implementation of LedgerServiceInterface
+type MockLedgerService struct {
+	mock.Mock
+}
+
+// Deposit mocks the Deposit function
+func (m *MockLedgerService) Deposit(tx *sql.Tx, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	args := m.Called(tx, walletNumber, amount, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Wallet), args.Error(1)
+}
+
+// Withdraw mocks the Withdraw function
+func (m *MockLedgerService) Withdraw(tx *sql.Tx, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	args := m.Called(tx, walletNumber, amount, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Wallet), args.Error(1)
+}
+
+// Transfer mocks the Transfer function
+func (m *MockLedgerService) Transfer(tx *sql.Tx, fromWalletNumber, toWalletNumber string, amount money.Money, note models.TransactionNote) (*models.Transaction, *models.Wallet, *models.Wallet, error) {
+	args := m.Called(tx, fromWalletNumber, toWalletNumber, amount, note)
+	var txn *models.Transaction
+	var fromWallet, toWallet *models.Wallet
+	if args.Get(0) != nil {
+		txn = args.Get(0).(*models.Transaction)
+	}
+	if args.Get(1) != nil {
+		fromWallet = args.Get(1).(*models.Wallet)
+	}
+	if args.Get(2) != nil {
+		toWallet = args.Get(2).(*models.Wallet)
+	}
+	return txn, fromWallet, toWallet, args.Error(3)
+}
+
+// Reverse mocks the Reverse function
+func (m *MockLedgerService) Reverse(tx *sql.Tx, original *models.Transaction, amount money.Money) (*models.Transaction, *models.Wallet, error) {
+	args := m.Called(tx, original, amount)
+	var reversal *models.Transaction
+	var wallet *models.Wallet
+	if args.Get(0) != nil {
+		reversal = args.Get(0).(*models.Transaction)
+	}
+	if args.Get(1) != nil {
+		wallet = args.Get(1).(*models.Wallet)
+	}
+	return reversal, wallet, args.Error(2)
+}
+
+// Exchange mocks the Exchange function
+func (m *MockLedgerService) Exchange(tx *sql.Tx, fromWalletNumber, toWalletNumber string, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, *models.Wallet, *models.Wallet, error) {
+	args := m.Called(tx, fromWalletNumber, toWalletNumber, conversion, note)
+	var txn *models.Transaction
+	var fromWallet, toWallet *models.Wallet
+	if args.Get(0) != nil {
+		txn = args.Get(0).(*models.Transaction)
+	}
+	if args.Get(1) != nil {
+		fromWallet = args.Get(1).(*models.Wallet)
+	}
+	if args.Get(2) != nil {
+		toWallet = args.Get(2).(*models.Wallet)
+	}
+	return txn, fromWallet, toWallet, args.Error(3)
+}
+
+// VerifyWalletBalance mocks the VerifyWalletBalance function
+func (m *MockLedgerService) VerifyWalletBalance(walletNumber string) error {
+	args := m.Called(walletNumber)
+	return args.Error(0)
+}
+
+// GetBalanceAsOf mocks the GetBalanceAsOf function
+func (m *MockLedgerService) GetBalanceAsOf(walletNumber, currency string, asOf time.Time) (money.Money, error) {
+	args := m.Called(walletNumber, currency, asOf)
+	return args.Get(0).(money.Money), args.Error(1)
+}
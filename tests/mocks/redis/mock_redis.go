@@ -29,3 +29,9 @@ func (m *MockRedisClient) DeleteKeysByPattern(ctx context.Context, pattern strin
 	args := m.Called(ctx, pattern)
 	return args.Error(0)
 }
+
+// Increment mocks the Redis INCR command with an expiry on the first increment
+func (m *MockRedisClient) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	args := m.Called(ctx, key, expiration)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,46 @@
+package mock_statement
+
+import (
+	"centralized-wallet/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStatementRepository is a mock implementation of StatementRepositoryInterface
+type MockStatementRepository struct {
+	mock.Mock
+}
+
+// FindWalletsWithoutStatement mocks the FindWalletsWithoutStatement function
+func (m *MockStatementRepository) FindWalletsWithoutStatement(periodStart, periodEnd time.Time) ([]string, error) {
+	args := m.Called(periodStart, periodEnd)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// CreateStatement mocks the CreateStatement function
+func (m *MockStatementRepository) CreateStatement(statement *models.MonthlyStatement) (bool, error) {
+	args := m.Called(statement)
+	return args.Bool(0), args.Error(1)
+}
+
+// ListStatements mocks the ListStatements function
+func (m *MockStatementRepository) ListStatements(walletNumber string) ([]models.MonthlyStatement, error) {
+	args := m.Called(walletNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MonthlyStatement), args.Error(1)
+}
+
+// GetStatement mocks the GetStatement function
+func (m *MockStatementRepository) GetStatement(statementNumber string) (*models.MonthlyStatement, error) {
+	args := m.Called(statementNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MonthlyStatement), args.Error(1)
+}
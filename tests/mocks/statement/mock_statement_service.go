@@ -0,0 +1,56 @@
+package mock_statement
+
+import (
+	"centralized-wallet/internal/models"
+	"io"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStatementService is a mock implementation of StatementServiceInterface
+type MockStatementService struct {
+	mock.Mock
+}
+
+// OpenStatement mocks the OpenStatement function
+func (m *MockStatementService) OpenStatement(userID int, walletNumber string, from, to time.Time) (*models.Statement, error) {
+	args := m.Called(userID, walletNumber, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Statement), args.Error(1)
+}
+
+// WriteStatement mocks the WriteStatement function, writing the returned content to w
+func (m *MockStatementService) WriteStatement(statement *models.Statement, format string, w io.Writer) error {
+	args := m.Called(statement, format)
+	if content, ok := args.Get(0).(string); ok {
+		io.WriteString(w, content)
+	}
+	return args.Error(1)
+}
+
+// GenerateMonthlyStatements mocks the GenerateMonthlyStatements function
+func (m *MockStatementService) GenerateMonthlyStatements(month time.Time) (int, error) {
+	args := m.Called(month)
+	return args.Int(0), args.Error(1)
+}
+
+// ListMonthlyStatements mocks the ListMonthlyStatements function
+func (m *MockStatementService) ListMonthlyStatements(userID int, walletNumber string) ([]models.MonthlyStatement, error) {
+	args := m.Called(userID, walletNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MonthlyStatement), args.Error(1)
+}
+
+// GetMonthlyStatement mocks the GetMonthlyStatement function
+func (m *MockStatementService) GetMonthlyStatement(userID int, statementNumber string) (*models.MonthlyStatement, error) {
+	args := m.Called(userID, statementNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MonthlyStatement), args.Error(1)
+}
@@ -2,7 +2,10 @@ package mock_transaction
 
 import (
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"centralized-wallet/internal/transaction"
 	"database/sql"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -17,8 +20,72 @@ func (m *MockTransactionRepository) CreateTransaction(tx *sql.Tx, transaction *m
 	return args.Error(0)
 }
 
+// Mock UpdateStatus method
+func (m *MockTransactionRepository) UpdateStatus(tx *sql.Tx, transactionID int, fromStatus, toStatus string, at time.Time) error {
+	args := m.Called(transactionID, fromStatus, toStatus, at)
+	return args.Error(0)
+}
+
+// Mock LockTransactionByID method
+func (m *MockTransactionRepository) LockTransactionByID(tx *sql.Tx, transactionID int) (*models.Transaction, error) {
+	args := m.Called(transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+// Mock GetReversedAmount method
+func (m *MockTransactionRepository) GetReversedAmount(tx *sql.Tx, transactionID int, currency string) (money.Money, error) {
+	args := m.Called(transactionID, currency)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+
 // Mock GetTransactionHistory method
-func (m *MockTransactionRepository) GetTransactionHistory(walletNumber string, orderBy string, limit, offset int) ([]models.TransactionWithEmails, error) {
-	args := m.Called(walletNumber, orderBy, limit)
+func (m *MockTransactionRepository) GetTransactionHistory(walletNumber string, filter models.TransactionFilter, orderBy string, limit, offset int) ([]models.TransactionWithEmails, error) {
+	args := m.Called(walletNumber, filter, orderBy, limit)
+	return args.Get(0).([]models.TransactionWithEmails), args.Error(1)
+}
+
+// Mock GetTransactionHistoryByCursor method
+func (m *MockTransactionRepository) GetTransactionHistoryByCursor(walletNumber string, filter models.TransactionFilter, orderBy string, cursor *transaction.Cursor, limit int) ([]models.TransactionWithEmails, error) {
+	args := m.Called(walletNumber, filter, orderBy, cursor, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).([]models.TransactionWithEmails), args.Error(1)
 }
+
+// Mock GetTransactionWithParties method
+func (m *MockTransactionRepository) GetTransactionWithParties(transactionID int) (*models.TransactionWithEmails, error) {
+	args := m.Called(transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TransactionWithEmails), args.Error(1)
+}
+
+// Mock SetBalancesAfter method
+func (m *MockTransactionRepository) SetBalancesAfter(tx *sql.Tx, transactionID int, fromBalance, toBalance *money.Money) error {
+	args := m.Called(transactionID, fromBalance, toBalance)
+	return args.Error(0)
+}
+
+// Mock StreamTransactionHistory method, feeding the returned transactions to fn one by one
+func (m *MockTransactionRepository) StreamTransactionHistory(walletNumber string, filter models.TransactionFilter, fn func(transaction *models.TransactionWithEmails) error) error {
+	args := m.Called(walletNumber, filter)
+	if transactions, ok := args.Get(0).([]models.TransactionWithEmails); ok {
+		for i := range transactions {
+			if err := fn(&transactions[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// Mock MarkIdempotencyKeyCommitted method
+func (m *MockTransactionRepository) MarkIdempotencyKeyCommitted(tx *sql.Tx, claim *models.IdempotencyClaim) error {
+	args := m.Called(claim)
+	return args.Error(0)
+}
@@ -1,7 +1,9 @@
 package mock_transaction
 
 import (
+	"centralized-wallet/internal/fx"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
 	"database/sql"
 
 	"github.com/stretchr/testify/mock"
@@ -12,20 +14,86 @@ type MockTransactionService struct {
 }
 
 // RecordTransaction mocks the RecordTransaction function
-func (m *MockTransactionService) RecordTransaction(tx *sql.Tx, fromWalletNumber, toWalletNumber *string, transactionType string, amount float64) error {
-	args := m.Called(tx, fromWalletNumber, toWalletNumber, transactionType, amount)
+func (m *MockTransactionService) RecordTransaction(tx *sql.Tx, fromWalletNumber, toWalletNumber *string, transactionType string, amount money.Money, note models.TransactionNote) (*models.Transaction, error) {
+	args := m.Called(tx, fromWalletNumber, toWalletNumber, transactionType, amount, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+// RecordReversal mocks the RecordReversal function
+func (m *MockTransactionService) RecordReversal(tx *sql.Tx, original *models.Transaction, amount money.Money) (*models.Transaction, error) {
+	args := m.Called(tx, original, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+// RecordExchange mocks the RecordExchange function
+func (m *MockTransactionService) RecordExchange(tx *sql.Tx, fromWalletNumber, toWalletNumber string, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, error) {
+	args := m.Called(tx, fromWalletNumber, toWalletNumber, conversion, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+// LockTransactionByID mocks the LockTransactionByID function
+func (m *MockTransactionService) LockTransactionByID(tx *sql.Tx, transactionID int) (*models.Transaction, error) {
+	args := m.Called(tx, transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Transaction), args.Error(1)
+}
+
+// GetReversedAmount mocks the GetReversedAmount function
+func (m *MockTransactionService) GetReversedAmount(tx *sql.Tx, original *models.Transaction) (money.Money, error) {
+	args := m.Called(tx, original)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+
+// UpdateStatus mocks the UpdateStatus function
+func (m *MockTransactionService) UpdateStatus(tx *sql.Tx, transaction *models.Transaction, status string) error {
+	args := m.Called(tx, transaction, status)
+	return args.Error(0)
+}
+
+// SetBalancesAfter mocks the SetBalancesAfter function
+func (m *MockTransactionService) SetBalancesAfter(tx *sql.Tx, transaction *models.Transaction, fromBalance, toBalance *money.Money) error {
+	args := m.Called(tx, transaction, fromBalance, toBalance)
 	return args.Error(0)
 }
 
 // GetTransactionHistory mocks the GetTransactionHistory function
-func (m *MockTransactionService) GetTransactionHistory(walletNumber string, orderBy string, limit, offset int) ([]models.FormattedTransaction, error) {
-	args := m.Called(walletNumber, orderBy, limit, offset)
+func (m *MockTransactionService) GetTransactionHistory(walletNumber string, filter models.TransactionFilter, orderBy string, limit, offset int) ([]models.FormattedTransaction, error) {
+	args := m.Called(walletNumber, filter, orderBy, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]models.FormattedTransaction), args.Error(1)
 }
 
+// GetTransactionHistoryPage mocks the GetTransactionHistoryPage function
+func (m *MockTransactionService) GetTransactionHistoryPage(walletNumber string, filter models.TransactionFilter, orderBy string, limit int, cursor string) (*models.TransactionPage, error) {
+	args := m.Called(walletNumber, filter, orderBy, limit, cursor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TransactionPage), args.Error(1)
+}
+
+// GetTransactionDetail mocks the GetTransactionDetail function
+func (m *MockTransactionService) GetTransactionDetail(userID, transactionID int) (*models.TransactionDetail, error) {
+	args := m.Called(userID, transactionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TransactionDetail), args.Error(1)
+}
+
 // formatTransactionResponse mocks the formatTransactionResponse function
 func (m *MockTransactionService) FormatTransactionResponse(walletNumber string, transactions []models.TransactionWithEmails) []models.FormattedTransaction {
 	args := m.Called(walletNumber, transactions)
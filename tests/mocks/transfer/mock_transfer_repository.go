@@ -0,0 +1,64 @@
+package mock_transfer
+
+import (
+	"centralized-wallet/internal/models"
+	"database/sql"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTransferRepository is a mock implementation of TransferRepositoryInterface
+type MockTransferRepository struct {
+	mock.Mock
+}
+
+// mock begin transaction
+func (m *MockTransferRepository) Begin() (*sql.Tx, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sql.Tx), args.Error(1)
+}
+
+// mock commit transaction
+func (m *MockTransferRepository) Commit(tx *sql.Tx) error {
+	args := m.Called(tx)
+	return args.Error(0)
+}
+
+// mock rollback transaction
+func (m *MockTransferRepository) Rollback(tx *sql.Tx) error {
+	args := m.Called(tx)
+	return args.Error(0)
+}
+
+// CreateIntent mocks the CreateIntent function
+func (m *MockTransferRepository) CreateIntent(intent *models.TransferIntent) error {
+	args := m.Called(intent)
+	return args.Error(0)
+}
+
+// GetIntentByID mocks the GetIntentByID function
+func (m *MockTransferRepository) GetIntentByID(intentID int) (*models.TransferIntent, error) {
+	args := m.Called(intentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TransferIntent), args.Error(1)
+}
+
+// LockIntentByID mocks the LockIntentByID function
+func (m *MockTransferRepository) LockIntentByID(tx *sql.Tx, intentID int) (*models.TransferIntent, error) {
+	args := m.Called(tx, intentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TransferIntent), args.Error(1)
+}
+
+// UpdateIntent mocks the UpdateIntent function
+func (m *MockTransferRepository) UpdateIntent(tx *sql.Tx, intent *models.TransferIntent) error {
+	args := m.Called(tx, intent)
+	return args.Error(0)
+}
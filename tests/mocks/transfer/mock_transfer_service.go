@@ -0,0 +1,31 @@
+package mock_transfer
+
+import (
+	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTransferService is a mock implementation of TransferServiceInterface
+type MockTransferService struct {
+	mock.Mock
+}
+
+// PreviewTransfer mocks the PreviewTransfer function
+func (m *MockTransferService) PreviewTransfer(userID int, fromWalletNumber, toWalletNumber, to string, amount money.Money, note models.TransactionNote) (*models.TransferIntent, error) {
+	args := m.Called(userID, fromWalletNumber, toWalletNumber, to, amount, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TransferIntent), args.Error(1)
+}
+
+// ConfirmTransfer mocks the ConfirmTransfer function
+func (m *MockTransferService) ConfirmTransfer(userID, intentID int) (*models.TransferIntent, *models.Wallet, error) {
+	args := m.Called(userID, intentID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.TransferIntent), args.Get(1).(*models.Wallet), args.Error(2)
+}
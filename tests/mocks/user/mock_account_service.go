@@ -0,0 +1,40 @@
+package mock_user
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAccountService is a mock implementation of AccountServiceInterface
+type MockAccountService struct {
+	mock.Mock
+}
+
+// CurrentTokenVersion mocks the CurrentTokenVersion function
+func (m *MockAccountService) CurrentTokenVersion(userID int) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+// CurrentRole mocks the CurrentRole function
+func (m *MockAccountService) CurrentRole(userID int) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+// ChangePassword mocks the ChangePassword function
+func (m *MockAccountService) ChangePassword(userID int, currentPassword, newPassword string) error {
+	args := m.Called(userID, currentPassword, newPassword)
+	return args.Error(0)
+}
+
+// SetUserStatus mocks the SetUserStatus function
+func (m *MockAccountService) SetUserStatus(userID int, status string) error {
+	args := m.Called(userID, status)
+	return args.Error(0)
+}
+
+// SetUserRole mocks the SetUserRole function
+func (m *MockAccountService) SetUserRole(userID int, role string) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
@@ -36,3 +36,57 @@ func (m *MockUserRepository) GetUserByEmail(email string) (*models.User, error)
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
+
+// GetUserByID mocks the GetUserByID function
+func (m *MockUserRepository) GetUserByID(userID int) (*models.User, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// GetUserByHandle mocks the GetUserByHandle function
+func (m *MockUserRepository) GetUserByHandle(handle string) (*models.User, error) {
+	args := m.Called(handle)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// UpdateProfile mocks the UpdateProfile function
+func (m *MockUserRepository) UpdateProfile(userID int, name, handle *string) (*models.User, error) {
+	args := m.Called(userID, name, handle)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// UpdatePassword mocks the UpdatePassword function
+func (m *MockUserRepository) UpdatePassword(userID int, hashedPassword string) error {
+	args := m.Called(userID, hashedPassword)
+	return args.Error(0)
+}
+
+// UpdateStatus mocks the UpdateStatus function
+func (m *MockUserRepository) UpdateStatus(userID int, status string) error {
+	args := m.Called(userID, status)
+	return args.Error(0)
+}
+
+// UpdateRole mocks the UpdateRole function
+func (m *MockUserRepository) UpdateRole(userID int, role string) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
+
+// SearchUsers mocks the SearchUsers function
+func (m *MockUserRepository) SearchUsers(query string, limit, offset int) ([]models.User, error) {
+	args := m.Called(query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
@@ -27,3 +27,11 @@ func (m *MockUserService) LoginUser(email, password string) (*models.User, error
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
+
+func (m *MockUserService) UpdateProfile(userID int, name, handle string) (*models.User, error) {
+	args := m.Called(userID, name, handle)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
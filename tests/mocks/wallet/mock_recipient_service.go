@@ -0,0 +1,28 @@
+package mock_wallet
+
+import (
+	"centralized-wallet/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRecipientService is a mock of RecipientServiceInterface
+type MockRecipientService struct {
+	mock.Mock
+}
+
+func (m *MockRecipientService) ResolveRecipient(userID int, identifier string) (*models.Recipient, error) {
+	args := m.Called(userID, identifier)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Recipient), args.Error(1)
+}
+
+func (m *MockRecipientService) ResolveRecipientWallet(userID int, walletNumber string) (*models.Recipient, error) {
+	args := m.Called(userID, walletNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Recipient), args.Error(1)
+}
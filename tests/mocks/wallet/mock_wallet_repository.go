@@ -41,8 +41,8 @@ func (m *MockWalletRepository) Rollback(tx *sql.Tx) error {
 	return args.Error(0)
 }
 
-// GetWalletByUserID mocks the GetWalletByUserID function
-func (m *MockWalletRepository) GetWalletByUserID(userID int) (*models.Wallet, error) {
+// GetDefaultWallet mocks the GetDefaultWallet function
+func (m *MockWalletRepository) GetDefaultWallet(userID int) (*models.Wallet, error) {
 	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -50,30 +50,30 @@ func (m *MockWalletRepository) GetWalletByUserID(userID int) (*models.Wallet, er
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-// Deposit mocks the Deposit function
-func (m *MockWalletRepository) Deposit(tx *sql.Tx, userID int, amount float64) (*models.Wallet, error) {
-	args := m.Called(tx, userID, amount)
+// ListWalletsByUserID mocks the ListWalletsByUserID function
+func (m *MockWalletRepository) ListWalletsByUserID(userID int) ([]models.Wallet, error) {
+	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Wallet), args.Error(1)
+	return args.Get(0).([]models.Wallet), args.Error(1)
+}
+
+// SetDefaultWallet mocks the SetDefaultWallet function
+func (m *MockWalletRepository) SetDefaultWallet(tx *sql.Tx, userID int, walletNumber string) error {
+	args := m.Called(tx, userID, walletNumber)
+	return args.Error(0)
 }
 
-// Withdraw mocks the Withdraw function
-func (m *MockWalletRepository) Withdraw(tx *sql.Tx, userID int, amount float64) (*models.Wallet, error) {
-	args := m.Called(tx, userID, amount)
+// LockWalletByID mocks the LockWalletByID function
+func (m *MockWalletRepository) LockWalletByID(tx *sql.Tx, walletID int) (*models.Wallet, error) {
+	args := m.Called(tx, walletID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-// UserExists mocks the UserExists function
-func (m *MockWalletRepository) UserExists(userID int) (bool, error) {
-	args := m.Called(userID)
-	return args.Bool(0), args.Error(1)
-}
-
 // FindByWalletNumber mocks the FindByWalletNumber function
 func (m *MockWalletRepository) FindByWalletNumber(walletNumber string) (*models.Wallet, error) {
 	args := m.Called(walletNumber)
@@ -1,7 +1,11 @@
 package mock_wallet
 
 import (
+	"centralized-wallet/internal/fx"
 	"centralized-wallet/internal/models"
+	"centralized-wallet/internal/money"
+	"database/sql"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -11,57 +15,105 @@ type MockWalletService struct {
 	mock.Mock
 }
 
-// GetBalance mocks the GetBalance function
-func (m *MockWalletService) GetBalance(userID int) (float64, error) {
+// ListWallets mocks the ListWallets function
+func (m *MockWalletService) ListWallets(userID int) ([]models.Wallet, error) {
 	args := m.Called(userID)
-	return args.Get(0).(float64), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Wallet), args.Error(1)
 }
 
-// UserExists mocks the UserExists function
-func (m *MockWalletService) UserExists(userID int) (bool, error) {
-	args := m.Called(userID)
-	return args.Bool(0), args.Error(1)
+// GetWallet mocks the GetWallet function
+func (m *MockWalletService) GetWallet(userID int, walletNumber string) (*models.Wallet, error) {
+	args := m.Called(userID, walletNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-// Deposit mocks the Deposit function and returns a wallet struct
-func (m *MockWalletService) Deposit(userID int, amount float64) (*models.Wallet, error) {
-	args := m.Called(userID, amount)
+// GetBalanceAsOf mocks the GetBalanceAsOf function
+func (m *MockWalletService) GetBalanceAsOf(userID int, walletNumber string, asOf time.Time) (*models.Wallet, money.Money, error) {
+	args := m.Called(userID, walletNumber, asOf)
+	if args.Get(0) == nil {
+		return nil, money.Money{}, args.Error(2)
+	}
+	return args.Get(0).(*models.Wallet), args.Get(1).(money.Money), args.Error(2)
+}
+
+// SetDefaultWallet mocks the SetDefaultWallet function
+func (m *MockWalletService) SetDefaultWallet(userID int, walletNumber string) (*models.Wallet, error) {
+	args := m.Called(userID, walletNumber)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-// Withdraw mocks the Withdraw function and returns a wallet struct
-func (m *MockWalletService) Withdraw(userID int, amount float64) (*models.Wallet, error) {
-	args := m.Called(userID, amount)
+// Deposit mocks the Deposit function and returns a wallet struct
+func (m *MockWalletService) Deposit(userID int, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	args := m.Called(userID, walletNumber, amount, note)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-// Transfer mocks the Transfer function and returns a wallet struct
-func (m *MockWalletService) Transfer(fromUserID int, toWalletNumber string, amount float64) (*models.Wallet, error) {
-	args := m.Called(fromUserID, toWalletNumber, amount)
+// Withdraw mocks the Withdraw function and returns a wallet struct
+func (m *MockWalletService) Withdraw(userID int, walletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	args := m.Called(userID, walletNumber, amount, note)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-// GetWalletByUserID mocks the GetWalletByUserID function
-func (m *MockWalletService) GetWalletByUserID(userID int) (*models.Wallet, error) {
-	args := m.Called(userID)
+// Transfer mocks the Transfer function and returns a wallet struct
+func (m *MockWalletService) Transfer(fromUserID int, fromWalletNumber, toWalletNumber string, amount money.Money, note models.TransactionNote) (*models.Wallet, error) {
+	args := m.Called(fromUserID, fromWalletNumber, toWalletNumber, amount, note)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-// CreateWalletWithTx mocks the CreateWalletWithTx function
-func (m *MockWalletService) CreateWallet(userID int) (*models.Wallet, error) {
-	args := m.Called(userID)
+// TransferTx mocks the TransferTx function and returns the transfer and the updated source wallet
+func (m *MockWalletService) TransferTx(tx *sql.Tx, userID int, fromWalletNumber, toWalletNumber string, amount money.Money, conversion *fx.Conversion, note models.TransactionNote) (*models.Transaction, *models.Wallet, error) {
+	args := m.Called(tx, userID, fromWalletNumber, toWalletNumber, amount, conversion, note)
+	var txn *models.Transaction
+	var wallet *models.Wallet
+	if args.Get(0) != nil {
+		txn = args.Get(0).(*models.Transaction)
+	}
+	if args.Get(1) != nil {
+		wallet = args.Get(1).(*models.Wallet)
+	}
+	return txn, wallet, args.Error(2)
+}
+
+// RecordFailedTransfer mocks the RecordFailedTransfer function
+func (m *MockWalletService) RecordFailedTransfer(fromWalletNumber, toWalletNumber string, amount money.Money, conversion *fx.Conversion, note models.TransactionNote) {
+	m.Called(fromWalletNumber, toWalletNumber, amount, conversion, note)
+}
+
+// ReverseTransaction mocks the ReverseTransaction function and returns the reversal and the updated wallet
+func (m *MockWalletService) ReverseTransaction(userID, transactionID int, amount *money.Money) (*models.Transaction, *models.Wallet, error) {
+	args := m.Called(userID, transactionID, amount)
+	var reversal *models.Transaction
+	var wallet *models.Wallet
+	if args.Get(0) != nil {
+		reversal = args.Get(0).(*models.Transaction)
+	}
+	if args.Get(1) != nil {
+		wallet = args.Get(1).(*models.Wallet)
+	}
+	return reversal, wallet, args.Error(2)
+}
+
+// CreateWallet mocks the CreateWallet function
+func (m *MockWalletService) CreateWallet(userID int, name, currency string) (*models.Wallet, error) {
+	args := m.Called(userID, name, currency)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -125,7 +125,7 @@ func applyMigrations(migrationPath string) error {
 
 func CleanDatabase(db *sql.DB) error {
 	// List all the tables to truncate
-	tables := []string{"transactions", "wallets", "users"} // Add your tables here
+	tables := []string{"refresh_tokens", "sessions", "transfer_intents", "statements", "fx_quotes", "holds", "postings", "journal_entries", "ledger_accounts", "idempotency_keys", "transactions", "wallets", "users"} // Add your tables here
 
 	// Disable constraints to allow truncation in the right order
 	if _, err := db.Exec("SET session_replication_role = 'replica';"); err != nil {